@@ -1014,22 +1014,28 @@ func (m *manager) finalize(bn *bnode, updatePCM bool) error {
 		m.bntr.TraceRef(bn)
 	}
 
-	err = block.(base.BlockVersionSpec).FinalizeHeader(m.chain.Database())
+	// The header, its index and the last height are written in a batch, so
+	// the last height always refers to the block with its header.
+	ldb := db.NewLayerDB(m.db())
+	err = block.(base.BlockVersionSpec).FinalizeHeader(ldb)
 	if err != nil {
 		return err
 	}
-	nextVer := m.sm.GetNextBlockVersion(m.finalized.in.mtransition().Result())
-	if m.activeHandlers.last().Version() != nextVer {
-		m.activeHandlers = m.handlers.upTo(nextVer)
-	}
-
-	chainProp, err := m.bucketFor(db.ChainProperty)
+	chainProp, err := db.NewCodedBucket(ldb, db.ChainProperty, nil)
 	if err != nil {
 		return err
 	}
 	if err = chainProp.Set(db.Raw(keyLastBlockHeight), block.Height()); err != nil {
 		return err
 	}
+	if err = ldb.Flush(true); err != nil {
+		return err
+	}
+
+	nextVer := m.sm.GetNextBlockVersion(m.finalized.in.mtransition().Result())
+	if m.activeHandlers.last().Version() != nextVer {
+		m.activeHandlers = m.handlers.upTo(nextVer)
+	}
 
 	if updatePCM {
 		nextPCM, err := m.nextPCM.Update(m.finalized.block)
@@ -104,6 +104,8 @@ type singleChain struct {
 	logger log.Logger
 
 	regulator *regulator
+	pruner    *statePruner
+	archiver  *stateArchiver
 
 	state      State
 	lastErr    error
@@ -112,7 +114,8 @@ type singleChain struct {
 	termWaiter *sync.Cond
 
 	// monitor
-	metricCtx context.Context
+	metricCtx   context.Context
+	cacheMetric *metric.NodeCacheMetric
 }
 
 const (
@@ -121,6 +124,7 @@ const (
 	DefaultContractDir = "contract"
 	DefaultCacheDir    = "cache"
 	DefaultTmpDBDir    = "tmp"
+	DefaultBanListFile = "bans.json"
 )
 
 func (c *singleChain) Database() db.Database {
@@ -207,6 +211,10 @@ func (c *singleChain) ConcurrencyLevel() int {
 	}
 }
 
+func (c *singleChain) OptimisticExecution() bool {
+	return c.cfg.OptimisticExec
+}
+
 func (c *singleChain) NormalTxPoolSize() int {
 	if c.cfg.NormalTxPoolSize > 0 {
 		return c.cfg.NormalTxPoolSize
@@ -420,6 +428,18 @@ func (c *singleChain) prepareDatabase(chainDir string) error {
 	if err != nil {
 		return err
 	}
+	if c.cfg.Archive && c.cfg.PruneRetention > 0 {
+		c.logger.Warnf("Pruning is disabled for archive (retention=%d)",
+			c.cfg.PruneRetention)
+	}
+	if c.cfg.PruneRetention > 0 && !c.cfg.Archive {
+		pdb := newPruningDB(cdb)
+		c.pruner = newStatePruner(c, pdb, c.logger)
+		cdb = pdb
+	} else {
+		c.pruner = nil
+	}
+	registerPruner(c.cid, c.pruner)
 	if len(c.cfg.NodeCache) == 0 {
 		c.cfg.NodeCache = NodeCacheDefault
 	}
@@ -429,13 +449,30 @@ func (c *singleChain) prepareDatabase(chainDir string) error {
 		return errors.Wrapf(err, "UnknownCacheStrategy(%s)", c.cfg.NodeCache)
 	}
 	cacheDir := path.Join(chainDir, DefaultCacheDir)
-	cdb = cache.AttachManager(cdb, cacheDir, mLevel, fLevel, stores)
+	budget := cache.NewBudget(int64(c.cfg.NodeCacheMemory) * 1024 * 1024)
+	cdb = cache.AttachManager(cdb, cacheDir, mLevel, fLevel, stores, budget)
 	cdb, err = state.AttachAPIInfoCache(cdb, ConfigDefaultAPIInfoCacheSize)
 	if err != nil {
 		_ = cdb.Close()
 		return errors.Wrap(err, "FailToAttachAPIInfoCache")
 	}
 	c.database = cdb
+	c.cacheMetric = metric.NewNodeCacheMetric(c.metricCtx, func() metric.NodeCacheStat {
+		s := budget.Stat()
+		return metric.NodeCacheStat{
+			Limit:  s.Limit,
+			Bytes:  s.Bytes,
+			Hits:   s.Hits,
+			Misses: s.Misses,
+			Evicts: s.Evicts,
+		}
+	})
+	if c.cfg.Archive {
+		c.archiver = newStateArchiver(c, cdb, c.logger)
+	} else {
+		c.archiver = nil
+	}
+	registerArchiver(c.cid, c.archiver)
 	return nil
 }
 
@@ -446,6 +483,10 @@ func (c *singleChain) releaseDatabase() {
 		c.database.Close()
 		c.database = nil
 	}
+	if c.cacheMetric != nil {
+		c.cacheMetric.Close()
+		c.cacheMetric = nil
+	}
 }
 
 func (c *singleChain) _init() error {
@@ -485,10 +526,25 @@ func (c *singleChain) _init() error {
 }
 
 func (c *singleChain) prepareManagers() error {
+	c.regulator.SetEmptyBlockPolicy(c.cfg.SkipEmptyBlock,
+		time.Duration(c.cfg.MaxIdleInterval)*time.Millisecond)
+	c.regulator.SetAdaptiveInterval(
+		time.Duration(c.cfg.MinBlockInterval)*time.Millisecond,
+		time.Duration(c.cfg.MaxBlockInterval)*time.Millisecond)
+
 	pr := network.PeerRoleFlag(c.cfg.Role)
 	c.nm = network.NewManager(c, c.nt, c.cfg.SeedAddr, pr.ToRoles()...)
 
 	chainDir := c.cfg.AbsBaseDir()
+	banFile := path.Join(chainDir, DefaultBanListFile)
+	if err := network.LoadBanList(c.nm, banFile); err != nil {
+		c.logger.Warnf("fail to load ban list err=%+v", err)
+	}
+	if c.cfg.NetworkCapture {
+		if err := network.StartCapture(c.nm, c.cfg.CaptureWriterConfig()); err != nil {
+			c.logger.Warnf("fail to start network capture err=%+v", err)
+		}
+	}
 	ContractDir := path.Join(chainDir, DefaultContractDir)
 	var err error
 	c.sm, err = service.NewManager(c, c.nm, c.pm, c.plt, ContractDir)
@@ -509,6 +565,12 @@ func (c *singleChain) prepareManagers() error {
 }
 
 func (c *singleChain) releaseManagers() {
+	if c.pruner != nil {
+		c.pruner.Stop()
+	}
+	if c.archiver != nil {
+		c.archiver.Stop()
+	}
 	if c.cs != nil {
 		c.cs.Term()
 		c.cs = nil
@@ -616,6 +678,9 @@ func (c *singleChain) Import(src string, height int64) error {
 }
 
 func (c *singleChain) Prune(gsfile string, dbtype string, height int64) error {
+	if c.cfg.Archive {
+		return errors.InvalidStateError.New("PruningNotAllowedForArchive")
+	}
 	if dbtype == "" {
 		dbtype = c.cfg.DBType
 	}
@@ -623,8 +688,8 @@ func (c *singleChain) Prune(gsfile string, dbtype string, height int64) error {
 	return c._runTask(task, false)
 }
 
-func (c *singleChain) Backup(file string, extra []string) error {
-	task := newTaskBackup(c, file, extra)
+func (c *singleChain) Backup(file, base string, extra []string) error {
+	task := newTaskBackup(c, file, base, extra)
 	return c._runTask(task, false)
 }
 
@@ -662,6 +727,8 @@ func (c *singleChain) _handleTerminateInLock() {
 }
 
 func (c *singleChain) _terminate() {
+	registerPruner(c.cid, nil)
+	registerArchiver(c.cid, nil)
 	c.releaseDatabase()
 	c.plt.Term()
 }
@@ -756,6 +823,7 @@ func NewChain(
 	chainLogger := logger.WithFields(log.Fields{
 		log.FieldKeyCID: strconv.FormatInt(int64(cid), 16),
 	})
+	metricCtx := metric.GetMetricContextByCID(cid)
 	c := &singleChain{
 		wallet:    wallet,
 		nt:        transport,
@@ -764,8 +832,8 @@ func NewChain(
 		cfg:       *cfg,
 		pm:        pm,
 		logger:    chainLogger,
-		regulator: NewRegulator(chainLogger),
-		metricCtx: metric.GetMetricContextByCID(cid),
+		regulator: NewRegulator(metricCtx, chainLogger),
+		metricCtx: metricCtx,
 	}
 	return c
 }
@@ -10,7 +10,9 @@ import (
 
 	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/network"
 )
 
 const (
@@ -41,14 +43,24 @@ type Config struct {
 	SeedAddr         string `json:"seed_addr"`
 	Role             uint   `json:"role"`
 	ConcurrencyLevel int    `json:"concurrency_level,omitempty"`
+	OptimisticExec   bool   `json:"optimistic_execution,omitempty"`
 	NormalTxPoolSize int    `json:"normal_tx_pool,omitempty"`
 	PatchTxPoolSize  int    `json:"patch_tx_pool,omitempty"`
 	MaxBlockTxBytes  int    `json:"max_block_tx_bytes,omitempty"`
 	NodeCache        string `json:"node_cache,omitempty"`
+	NodeCacheMemory  int    `json:"node_cache_memory,omitempty"`
 	AutoStart        bool   `json:"auto_start,omitempty"`
 	ChildrenLimit    *int   `json:"children_limit,omitempty"`
 	NephewsLimit     *int   `json:"nephews_limit,omitempty"`
 	ValidateTxOnSend bool   `json:"validate_tx_on_send,omitempty"`
+	SkipEmptyBlock   bool   `json:"skip_empty_block,omitempty"`
+	MaxIdleInterval  int64  `json:"max_idle_interval,omitempty"`
+	MinBlockInterval int64  `json:"min_block_interval,omitempty"`
+	MaxBlockInterval int64  `json:"max_block_interval,omitempty"`
+	NetworkCapture   bool   `json:"network_capture,omitempty"`
+	PruneRetention   int64  `json:"prune_retention,omitempty"`
+	PruneCheckpoint  int64  `json:"prune_checkpoint,omitempty"`
+	Archive          bool   `json:"archive,omitempty"`
 
 	// runtime
 	Channel        string `json:"channel"`
@@ -98,6 +110,16 @@ func (c *Config) AbsBaseDir() string {
 	return c.ResolveAbsolute(c.BaseDir)
 }
 
+// CaptureWriterConfig returns configuration for the rotating file of the
+// network capture in the chain directory.
+func (c *Config) CaptureWriterConfig() *log.WriterConfig {
+	return &log.WriterConfig{
+		Filename:   path.Join(c.AbsBaseDir(), network.DefaultCaptureFile),
+		MaxSize:    network.DefaultCaptureMaxSize,
+		MaxBackups: network.DefaultCaptureMaxBackups,
+	}
+}
+
 func (c *Config) NetID() int {
 	if c.NIDForP2P {
 		return c.NID
@@ -0,0 +1,26 @@
+package chain
+
+import (
+	"github.com/icon-project/goloop/common/trie/cache"
+	"github.com/icon-project/goloop/module"
+)
+
+func Inspect(c module.Chain, informal bool) map[string]interface{} {
+	m := make(map[string]interface{})
+	if r, ok := c.Regulator().(*regulator); ok {
+		m["regulator"] = r.Inspect()
+	}
+	if p := prunerByCID(c.CID()); p != nil {
+		m["pruner"] = p.Inspect()
+	}
+	if a := archiverByCID(c.CID()); a != nil {
+		m["archiver"] = a.Inspect()
+	}
+	if b := cache.BudgetOf(c.Database()); b != nil {
+		m["nodeCache"] = b.Stat()
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
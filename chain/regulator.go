@@ -1,10 +1,12 @@
 package chain
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/server/metric"
 )
 
 const (
@@ -13,6 +15,20 @@ const (
 	ConfigDefaultCommitTimeout    = time.Second
 	ConfigDefaultBlockInterval    = time.Second
 	ConfigDefaultMinCommitTimeout = 200 * time.Millisecond
+	ConfigDefaultMaxIdleInterval  = time.Minute
+)
+
+const (
+	configHighLoadRatio   = 0.8
+	configLowLoadRatio    = 0.2
+	configShortenPercent  = 90
+	configLengthenPercent = 110
+)
+
+const (
+	AdjustKeep     = "keep"
+	AdjustShorten  = "shorten"
+	AdjustLengthen = "lengthen"
 )
 
 type txExecutionEntry struct {
@@ -46,16 +62,27 @@ type regulator struct {
 	lock sync.Mutex
 
 	proposeTime      time.Time
+	targetInterval   time.Duration
 	blockInterval    time.Duration
 	minCommitTimeout time.Duration
 
+	// adaptive block interval control, enabled if maxInterval > 0
+	minInterval  time.Duration
+	maxInterval  time.Duration
+	lastLoad     float64
+	lastDecision string
+
 	history      [30]txExecutionEntry
 	sum          txExecutionSum
 	currentIndex int
 
 	currentTxCount int
 
-	log log.Logger
+	skipEmptyBlock  bool
+	maxIdleInterval time.Duration
+
+	log    log.Logger
+	metric *metric.RegulatorMetric
 }
 
 func (r *regulator) SetBlockInterval(blockInterval time.Duration, commitTimeout time.Duration) {
@@ -71,18 +98,170 @@ func (r *regulator) SetBlockInterval(blockInterval time.Duration, commitTimeout
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	if r.minCommitTimeout == commitTimeout && r.blockInterval == blockInterval {
+	if r.minCommitTimeout == commitTimeout && r.targetInterval == blockInterval {
 		return
 	}
 
 	r.log.Printf("Regulator.SetCommitTimeout(interval=%s,timeout=%s)", blockInterval, commitTimeout)
 
+	r.targetInterval = blockInterval
+	r.minCommitTimeout = commitTimeout
+	if r.isAdaptiveInLock() {
+		blockInterval = r.clampIntervalInLock(r.blockInterval)
+	}
+	r.setIntervalInLock(blockInterval)
+}
+
+func (r *regulator) setIntervalInLock(blockInterval time.Duration) {
+	if blockInterval == r.blockInterval {
+		return
+	}
 	txCount := int(blockInterval * time.Duration(r.currentTxCount) / r.blockInterval)
+	if txCount < configMinimumTransactions {
+		txCount = configMinimumTransactions
+	}
 	r.blockInterval = blockInterval
-	r.minCommitTimeout = commitTimeout
 	r.currentTxCount = txCount
 }
 
+func (r *regulator) isAdaptiveInLock() bool {
+	return r.maxInterval > 0
+}
+
+func (r *regulator) clampIntervalInLock(interval time.Duration) time.Duration {
+	if interval > r.maxInterval {
+		interval = r.maxInterval
+	}
+	if interval < r.minInterval {
+		interval = r.minInterval
+	}
+	if interval < r.minCommitTimeout {
+		interval = r.minCommitTimeout
+	}
+	return interval
+}
+
+// SetAdaptiveInterval configures bounds of the block interval adjusted by
+// the load of recent blocks. Zero maxInterval disables adaptive control, and
+// then the block interval configured by SetBlockInterval is used as it is.
+func (r *regulator) SetAdaptiveInterval(minInterval, maxInterval time.Duration) {
+	if maxInterval > 0 && minInterval > maxInterval {
+		minInterval = maxInterval
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.minInterval == minInterval && r.maxInterval == maxInterval {
+		return
+	}
+
+	r.log.Printf("Regulator.SetAdaptiveInterval(min=%s,max=%s)", minInterval, maxInterval)
+
+	r.minInterval = minInterval
+	r.maxInterval = maxInterval
+	if r.isAdaptiveInLock() {
+		r.setIntervalInLock(r.clampIntervalInLock(r.targetInterval))
+	} else {
+		r.setIntervalInLock(r.targetInterval)
+		r.lastDecision = ""
+	}
+	r.recordInLock()
+}
+
+// capacityInLock returns the number of transactions which can be executed
+// within the target interval. Unlike currentTxCount, it doesn't depend on
+// the block interval adjusted by adaptive control.
+func (r *regulator) capacityInLock() int {
+	if r.sum.execution <= 0 {
+		return r.currentTxCount
+	}
+	iv := r.targetInterval - (r.sum.finalize / time.Duration(r.sum.entries))
+	capacity := int(time.Duration(r.sum.count) * iv / r.sum.execution)
+	if capacity < configMinimumTransactions {
+		capacity = configMinimumTransactions
+	}
+	return capacity
+}
+
+// adaptInLock adjusts the block interval with the number of transactions
+// executed in the last block. It shortens the interval if the block has
+// nearly as many transactions as the node can execute within the target
+// interval, and lengthens it if the block is nearly empty.
+func (r *regulator) adaptInLock(count int) {
+	r.lastLoad = float64(count) / float64(r.capacityInLock())
+	if !r.isAdaptiveInLock() {
+		return
+	}
+	interval := r.blockInterval
+	switch {
+	case r.lastLoad >= configHighLoadRatio:
+		interval = interval * configShortenPercent / 100
+	case r.lastLoad <= configLowLoadRatio:
+		interval = interval * configLengthenPercent / 100
+	}
+	interval = r.clampIntervalInLock(interval)
+	switch {
+	case interval < r.blockInterval:
+		r.lastDecision = AdjustShorten
+	case interval > r.blockInterval:
+		r.lastDecision = AdjustLengthen
+	default:
+		r.lastDecision = AdjustKeep
+	}
+	if interval != r.blockInterval {
+		r.log.Debugf("Regulator.Adapt(load=%.2f): interval %s -> %s",
+			r.lastLoad, r.blockInterval, interval)
+		r.setIntervalInLock(interval)
+	}
+}
+
+func (r *regulator) recordInLock() {
+	if r.metric == nil {
+		return
+	}
+	r.metric.OnAdjust(r.blockInterval, r.currentTxCount, r.lastLoad, r.lastDecision)
+}
+
+// SetEmptyBlockPolicy configures whether consensus may skip empty blocks.
+// If skip is true, consensus waits for transactions up to maxIdle since the
+// last block before it proposes an empty block. Zero maxIdle uses the
+// default value.
+func (r *regulator) SetEmptyBlockPolicy(skip bool, maxIdle time.Duration) {
+	if maxIdle <= 0 {
+		maxIdle = ConfigDefaultMaxIdleInterval
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.skipEmptyBlock == skip && r.maxIdleInterval == maxIdle {
+		return
+	}
+
+	r.log.Printf("Regulator.SetEmptyBlockPolicy(skip=%v,maxIdle=%s)", skip, maxIdle)
+
+	r.skipEmptyBlock = skip
+	r.maxIdleInterval = maxIdle
+}
+
+// IdleTimeout returns how long consensus may wait for transactions before
+// it proposes an empty block following the block made at lastBlockTime.
+// It returns zero if it shall not wait.
+func (r *regulator) IdleTimeout(lastBlockTime time.Time) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.skipEmptyBlock {
+		return 0
+	}
+	timeout := r.maxIdleInterval - time.Now().Sub(lastBlockTime)
+	if timeout < r.minCommitTimeout {
+		return 0
+	}
+	return timeout
+}
+
 func (r *regulator) OnPropose(now time.Time) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -126,13 +305,16 @@ func (r *regulator) addEntryInLock(count int, ed time.Duration, fd time.Duration
 }
 
 func (r *regulator) OnTxExecution(count int, ed time.Duration, fd time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.adaptInLock(count)
+	defer r.recordInLock()
+
 	if count <= configMinimumTransactions {
 		return
 	}
 
-	r.lock.Lock()
-	defer r.lock.Unlock()
-
 	r.addEntryInLock(count, ed, fd)
 
 	// For target duration
@@ -145,12 +327,44 @@ func (r *regulator) OnTxExecution(count int, ed time.Duration, fd time.Duration)
 		count, ed, fd, r.currentTxCount)
 }
 
-func NewRegulator(logger log.Logger) *regulator {
+// Inspect returns the current state of the regulator.
+func (r *regulator) Inspect() map[string]interface{} {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	m := map[string]interface{}{
+		"targetInterval":   r.targetInterval.String(),
+		"blockInterval":    r.blockInterval.String(),
+		"minCommitTimeout": r.minCommitTimeout.String(),
+		"maxTxCount":       r.currentTxCount,
+		"load":             r.lastLoad,
+	}
+	if r.isAdaptiveInLock() {
+		m["adaptive"] = map[string]interface{}{
+			"minInterval":  r.minInterval.String(),
+			"maxInterval":  r.maxInterval.String(),
+			"lastDecision": r.lastDecision,
+		}
+	}
+	if r.skipEmptyBlock {
+		m["maxIdleInterval"] = r.maxIdleInterval.String()
+	}
+	return m
+}
+
+func NewRegulator(ctx context.Context, logger log.Logger) *regulator {
+	var mt *metric.RegulatorMetric
+	if ctx != nil {
+		mt = metric.NewRegulatorMetric(ctx)
+	}
 	r := &regulator{
+		targetInterval:   ConfigDefaultBlockInterval,
 		blockInterval:    ConfigDefaultBlockInterval,
 		minCommitTimeout: ConfigDefaultMinCommitTimeout,
 		currentTxCount:   ConfigDefaultTransactions,
+		maxIdleInterval:  ConfigDefaultMaxIdleInterval,
 		log:              logger,
+		metric:           mt,
 	}
 	r.addEntryInLock(ConfigDefaultTransactions, time.Second, 0)
 	return r
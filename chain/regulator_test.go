@@ -0,0 +1,93 @@
+/*
+ * Copyright 2026 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/log"
+)
+
+func TestRegulator_IdleTimeout(t *testing.T) {
+	r := NewRegulator(nil, log.New())
+	now := time.Now()
+
+	// disabled by default
+	assert.Zero(t, r.IdleTimeout(now))
+
+	r.SetEmptyBlockPolicy(true, 0)
+	assert.Equal(t, ConfigDefaultMaxIdleInterval, r.maxIdleInterval)
+
+	r.SetEmptyBlockPolicy(true, 10*time.Second)
+	to := r.IdleTimeout(now)
+	assert.True(t, to > 9*time.Second && to <= 10*time.Second)
+
+	to = r.IdleTimeout(now.Add(-5 * time.Second))
+	assert.True(t, to > 4*time.Second && to <= 5*time.Second)
+
+	// expired or too short to wait
+	assert.Zero(t, r.IdleTimeout(now.Add(-10*time.Second)))
+	assert.Zero(t, r.IdleTimeout(now.Add(-10*time.Second+r.minCommitTimeout/2)))
+
+	r.SetEmptyBlockPolicy(false, 10*time.Second)
+	assert.Zero(t, r.IdleTimeout(now))
+}
+
+func TestRegulator_AdaptiveInterval(t *testing.T) {
+	r := NewRegulator(nil, log.New())
+	r.SetBlockInterval(2*time.Second, 200*time.Millisecond)
+
+	r.SetAdaptiveInterval(time.Second, 4*time.Second)
+	assert.Equal(t, 2*time.Second, r.blockInterval)
+
+	// full blocks shorten the interval, but the load is measured against
+	// the capacity of the target interval, so it stops before the lower
+	// bound as blocks get smaller.
+	for i := 0; i < 20; i++ {
+		count := r.MaxTxCount()
+		r.OnTxExecution(count, time.Duration(count)*time.Millisecond, 0)
+	}
+	assert.Less(t, r.blockInterval, 2*time.Second)
+	assert.Greater(t, r.blockInterval, time.Second)
+	assert.Equal(t, AdjustKeep, r.lastDecision)
+
+	// empty blocks lengthen the interval up to the upper bound
+	r.OnTxExecution(0, 0, 0)
+	assert.Equal(t, AdjustLengthen, r.lastDecision)
+	for i := 0; i < 20; i++ {
+		r.OnTxExecution(0, 0, 0)
+	}
+	assert.Equal(t, 4*time.Second, r.blockInterval)
+
+	// moderate load keeps the interval
+	r.OnTxExecution(1000, time.Second, 0)
+	assert.Equal(t, AdjustKeep, r.lastDecision)
+	assert.Equal(t, 4*time.Second, r.blockInterval)
+
+	m := r.Inspect()
+	assert.Contains(t, m, "adaptive")
+
+	// disabling restores the configured interval
+	r.SetAdaptiveInterval(0, 0)
+	assert.Equal(t, 2*time.Second, r.blockInterval)
+	r.OnTxExecution(0, 0, 0)
+	assert.Equal(t, 2*time.Second, r.blockInterval)
+	assert.NotContains(t, r.Inspect(), "adaptive")
+}
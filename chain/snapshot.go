@@ -0,0 +1,334 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/icon-project/goloop/block"
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+)
+
+// A snapshot is a directory having the manifest and chunks named by their
+// hashes, so it can be served by any file server. The genesis storage of the
+// snapshot is a pruned genesis at the height, and other chunks have entries
+// of the database required to start the chain from the height, which are
+// the world state, the extension state, receipts and headers of the blocks.
+const (
+	SnapshotManifestFile = "manifest.json"
+	SnapshotChunkDir     = "chunks"
+	SnapshotVersion      = 1
+
+	snapshotChunkSize   = 4 * 1024 * 1024
+	snapshotHTTPTimeout = 2 * time.Minute
+
+	// snapshotPrevBlocks is the number of the previous blocks in the
+	// snapshot for the validators and the voters of the block.
+	snapshotPrevBlocks = 2
+)
+
+type SnapshotChunk struct {
+	Hash common.HexBytes `json:"hash"`
+	Size int64           `json:"size"`
+}
+
+func (c *SnapshotChunk) name() string {
+	return path.Join(SnapshotChunkDir, hex.EncodeToString(c.Hash))
+}
+
+type SnapshotManifest struct {
+	Version int             `json:"version"`
+	CID     common.HexInt32 `json:"cid"`
+	NID     common.HexInt32 `json:"nid"`
+	Height  common.HexInt64 `json:"height"`
+	BlockID common.HexBytes `json:"blockId"`
+	Genesis SnapshotChunk   `json:"genesis"`
+	Chunks  []SnapshotChunk `json:"chunks"`
+}
+
+type snapshotEntry struct {
+	ID    string
+	Key   []byte
+	Value []byte
+}
+
+type snapshotWriter struct {
+	dir     string
+	entries []snapshotEntry
+	size    int
+	chunks  []SnapshotChunk
+}
+
+func newSnapshotWriter(dir string) (*snapshotWriter, error) {
+	if err := os.MkdirAll(path.Join(dir, SnapshotChunkDir), 0755); err != nil {
+		return nil, errors.Wrapf(err, "fail to make directory dir=%s", dir)
+	}
+	return &snapshotWriter{dir: dir}, nil
+}
+
+func (w *snapshotWriter) writeChunk(bs []byte) (SnapshotChunk, error) {
+	chunk := SnapshotChunk{
+		Hash: crypto.SHA3Sum256(bs),
+		Size: int64(len(bs)),
+	}
+	if err := os.WriteFile(path.Join(w.dir, chunk.name()), bs, 0644); err != nil {
+		return chunk, err
+	}
+	return chunk, nil
+}
+
+func (w *snapshotWriter) add(id db.BucketID, key, value []byte) error {
+	w.entries = append(w.entries, snapshotEntry{
+		ID:    string(id),
+		Key:   append([]byte{}, key...),
+		Value: append([]byte{}, value...),
+	})
+	w.size += len(id) + len(key) + len(value)
+	if w.size >= snapshotChunkSize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *snapshotWriter) flush() error {
+	if len(w.entries) == 0 {
+		return nil
+	}
+	chunk, err := w.writeChunk(codec.BC.MustMarshalToBytes(w.entries))
+	if err != nil {
+		return err
+	}
+	w.chunks = append(w.chunks, chunk)
+	w.entries, w.size = nil, 0
+	return nil
+}
+
+func (w *snapshotWriter) writeManifest(m *SnapshotManifest) error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	m.Chunks = w.chunks
+	bs, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(w.dir, SnapshotManifestFile), bs, 0644)
+}
+
+// snapshotDB records entries exported to it with the writer. Entries already
+// exported are skipped with their descendants as markDB does.
+type snapshotDB struct {
+	*markDB
+	w *snapshotWriter
+}
+
+func (d *snapshotDB) GetBucket(id db.BucketID) (db.Bucket, error) {
+	bk, err := d.markDB.GetBucket(id)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotBucket{Bucket: bk, id: id, w: d.w}, nil
+}
+
+type snapshotBucket struct {
+	db.Bucket
+	id db.BucketID
+	w  *snapshotWriter
+}
+
+func (bk *snapshotBucket) Set(key []byte, value []byte) error {
+	if err := bk.w.add(bk.id, key, value); err != nil {
+		return err
+	}
+	return bk.Bucket.Set(key, value)
+}
+
+// snapshotSource reads files of the snapshot from the directory or from the
+// base URL of the file server.
+type snapshotSource interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+type dirSnapshotSource string
+
+func (s dirSnapshotSource) Open(name string) (io.ReadCloser, error) {
+	return os.Open(path.Join(string(s), name))
+}
+
+type httpSnapshotSource string
+
+// snapshotHTTPClient limits the time for each file, so the import doesn't
+// hang on a stalled server.
+var snapshotHTTPClient = &http.Client{Timeout: snapshotHTTPTimeout}
+
+func (s httpSnapshotSource) Open(name string) (io.ReadCloser, error) {
+	url := strings.TrimSuffix(string(s), "/") + "/" + name
+	resp, err := snapshotHTTPClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.NotFoundError.Errorf(
+			"FailToGet(url=%s,status=%s)", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func newSnapshotSource(src string) snapshotSource {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		return httpSnapshotSource(src)
+	}
+	return dirSnapshotSource(src)
+}
+
+func readSnapshotManifest(s snapshotSource) (*SnapshotManifest, error) {
+	r, err := s.Open(SnapshotManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	m := new(SnapshotManifest)
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, errors.IllegalArgumentError.Wrap(err, "InvalidManifest")
+	}
+	if m.Version != SnapshotVersion {
+		return nil, errors.UnsupportedError.Errorf(
+			"UnsupportedSnapshotVersion(version=%d)", m.Version)
+	}
+	return m, nil
+}
+
+// readSnapshotChunk reads the chunk and verifies it with the size and the
+// hash in the manifest.
+func readSnapshotChunk(s snapshotSource, c SnapshotChunk) ([]byte, error) {
+	r, err := s.Open(c.name())
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	bs, err := io.ReadAll(io.LimitReader(r, c.Size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(bs)) != c.Size || !bytes.Equal(crypto.SHA3Sum256(bs), c.Hash) {
+		return nil, errors.InvalidStateError.Errorf(
+			"InvalidChunk(hash=%s,size=%d)", c.Hash, len(bs))
+	}
+	return bs, nil
+}
+
+func decodeSnapshotChunk(bs []byte) ([]snapshotEntry, error) {
+	var entries []snapshotEntry
+	if _, err := codec.BC.UnmarshalFromBytes(bs, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// checkSnapshotEntry checks the entry of the snapshot at the height before
+// importing it. Keys of the buckets having the hasher must be the hashes of
+// their values. Indexes are allowed only for the blocks of the snapshot, and
+// they are verified with the blocks after import. It returns false for the
+// entry to be skipped, such as chain properties written by the importer.
+func checkSnapshotEntry(e *snapshotEntry, height int64) (bool, error) {
+	id := db.BucketID(e.ID)
+	if hasher := id.Hasher(); hasher != nil {
+		if !bytes.Equal(e.Key, hasher.Hash(e.Value)) {
+			return false, errors.InvalidStateError.Errorf(
+				"InvalidEntry(id=%q,key=%x)", e.ID, e.Key)
+		}
+		return true, nil
+	}
+	switch id {
+	case db.TransactionLocatorByHash:
+		if len(e.Key) != crypto.HashLen {
+			return false, errors.InvalidStateError.Errorf(
+				"InvalidTransactionHash(hash=%x)", e.Key)
+		}
+		return true, nil
+	case db.BlockHeaderHashByHeight:
+		var h int64
+		if _, err := codec.BC.UnmarshalFromBytes(e.Key, &h); err != nil {
+			return false, errors.InvalidStateError.Wrapf(err,
+				"InvalidHeight(key=%x)", e.Key)
+		}
+		if h > height || h < height-snapshotPrevBlocks {
+			return false, errors.InvalidStateError.Errorf(
+				"InvalidHeight(height=%d,snapshot=%d)", h, height)
+		}
+		return true, nil
+	case db.ChainProperty:
+		return false, nil
+	default:
+		return false, errors.InvalidStateError.Errorf(
+			"UnknownBucket(id=%q)", e.ID)
+	}
+}
+
+// snapshotBlock is the block of the header in the snapshot. It has only the
+// ID and the height for verifying votes of the block.
+type snapshotBlock struct {
+	module.BlockData
+	id     []byte
+	header *block.V2HeaderFormat
+}
+
+func (b *snapshotBlock) ID() []byte {
+	return b.id
+}
+
+func (b *snapshotBlock) Height() int64 {
+	return b.header.Height
+}
+
+// readSnapshotBlock reads the header of the block with the hash, which is
+// verified on import, and checks the index of the block for the height.
+func readSnapshotBlock(dbase db.Database, id []byte, height int64) (*snapshotBlock, error) {
+	bs, err := db.DoGetWithBucketID(dbase, db.BytesByHash, id)
+	if err != nil {
+		return nil, errors.InvalidStateError.Wrapf(err, "NoBlockHeader(id=%x)", id)
+	}
+	header := new(block.V2HeaderFormat)
+	if _, err := codec.BC.UnmarshalFromBytes(bs, header); err != nil {
+		return nil, errors.InvalidStateError.Wrapf(err, "InvalidBlockHeader(id=%x)", id)
+	}
+	if header.Version != module.BlockVersion2 || header.Height != height {
+		return nil, errors.InvalidStateError.Errorf(
+			"InvalidBlockHeader(id=%x,version=%d,height=%d)",
+			id, header.Version, header.Height)
+	}
+	hash, err := block.GetBlockHeaderHashByHeight(dbase, nil, height)
+	if err != nil || !bytes.Equal(hash, id) {
+		return nil, errors.InvalidStateError.Errorf(
+			"InvalidBlockIndex(height=%d,id=%x,index=%x)", height, id, hash)
+	}
+	return &snapshotBlock{id: id, header: header}, nil
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/db"
+)
+
+func TestSnapshot_WriteRead(t *testing.T) {
+	dir := t.TempDir()
+	w, err := newSnapshotWriter(dir)
+	assert.NoError(t, err)
+
+	genesis, err := w.writeChunk([]byte("genesis"))
+	assert.NoError(t, err)
+
+	src := db.NewMapDB()
+	sdb := &snapshotDB{
+		markDB: &markDB{src: src, marks: db.NewMapDB()},
+		w:      w,
+	}
+	const count = 1000
+	value := make([]byte, snapshotChunkSize/count*3)
+	for i := 0; i < count; i++ {
+		bk, err := sdb.GetBucket(db.BytesByHash)
+		assert.NoError(t, err)
+		assert.NoError(t, bk.Set([]byte(fmt.Sprintf("key%04d", i)), value))
+	}
+	m := &SnapshotManifest{
+		Version: SnapshotVersion,
+		Height:  common.HexInt64{Value: 10},
+		Genesis: genesis,
+	}
+	assert.NoError(t, w.writeManifest(m))
+	assert.True(t, len(m.Chunks) > 1)
+
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+
+	for _, s := range []snapshotSource{
+		newSnapshotSource(dir),
+		newSnapshotSource(server.URL),
+	} {
+		m2, err := readSnapshotManifest(s)
+		assert.NoError(t, err)
+		assert.Equal(t, m, m2)
+
+		bs, err := readSnapshotChunk(s, m2.Genesis)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("genesis"), bs)
+
+		var keys int
+		for _, c := range m2.Chunks {
+			bs, err := readSnapshotChunk(s, c)
+			assert.NoError(t, err)
+			entries, err := decodeSnapshotChunk(bs)
+			assert.NoError(t, err)
+			for _, e := range entries {
+				assert.Equal(t, string(db.BytesByHash), e.ID)
+				assert.Equal(t, fmt.Sprintf("key%04d", keys), string(e.Key))
+				keys += 1
+			}
+		}
+		assert.Equal(t, count, keys)
+	}
+
+	// corrupted chunk
+	c := m.Chunks[0]
+	assert.NoError(t, os.WriteFile(path.Join(dir, c.name()), []byte("corrupted"), 0644))
+	_, err = readSnapshotChunk(newSnapshotSource(dir), c)
+	assert.Error(t, err)
+}
+
+func TestSnapshot_checkSnapshotEntry(t *testing.T) {
+	value := []byte("value")
+	hash := crypto.SHA3Sum256(value)
+	heightKey := func(h int64) []byte {
+		return codec.BC.MustMarshalToBytes(h)
+	}
+	cases := []struct {
+		name  string
+		entry snapshotEntry
+		ok    bool
+		err   bool
+	}{
+		{"TrieNode", snapshotEntry{string(db.MerkleTrie), hash, value}, true, false},
+		{"BadTrieNode", snapshotEntry{string(db.MerkleTrie), []byte("key"), value}, false, true},
+		{"Bytes", snapshotEntry{string(db.BytesByHash), hash, value}, true, false},
+		{"BadBytes", snapshotEntry{string(db.BytesByHash), hash[1:], value}, false, true},
+		{"Locator", snapshotEntry{string(db.TransactionLocatorByHash), hash, value}, true, false},
+		{"BadLocator", snapshotEntry{string(db.TransactionLocatorByHash), hash[1:], value}, false, true},
+		{"Header", snapshotEntry{string(db.BlockHeaderHashByHeight), heightKey(8), hash}, true, false},
+		{"OldHeader", snapshotEntry{string(db.BlockHeaderHashByHeight), heightKey(7), hash}, false, true},
+		{"NewHeader", snapshotEntry{string(db.BlockHeaderHashByHeight), heightKey(11), hash}, false, true},
+		{"Property", snapshotEntry{string(db.ChainProperty), []byte("key"), value}, false, false},
+		{"ChangeSet", snapshotEntry{string(db.ChangeSetByHeight), []byte("key"), value}, false, true},
+		{"Unknown", snapshotEntry{"X", []byte("key"), value}, false, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := checkSnapshotEntry(&tc.entry, 10)
+			assert.Equal(t, tc.ok, ok)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSnapshot_ImportParams(t *testing.T) {
+	c := &singleChain{}
+	_, err := taskImportSnapshotFactory(c, []byte(`{"source":"snapshot"}`))
+	assert.Error(t, err)
+	_, err = taskImportSnapshotFactory(c, []byte(`{"source":"snapshot","blockHash":"0x1234"}`))
+	assert.Error(t, err)
+	task, err := taskImportSnapshotFactory(c, []byte(fmt.Sprintf(
+		`{"source":"snapshot","blockHash":"%#x"}`, crypto.SHA3Sum256([]byte("block")))))
+	assert.NoError(t, err)
+	assert.NotNil(t, task)
+}
@@ -0,0 +1,277 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/service"
+	"github.com/icon-project/goloop/service/state"
+)
+
+const keyArchivedHeight = "archiver.height"
+
+var (
+	archiverLock sync.Mutex
+	archivers    = map[int]*stateArchiver{}
+)
+
+func registerArchiver(cid int, a *stateArchiver) {
+	archiverLock.Lock()
+	defer archiverLock.Unlock()
+	if a == nil {
+		delete(archivers, cid)
+	} else {
+		archivers[cid] = a
+	}
+}
+
+func archiverByCID(cid int) *stateArchiver {
+	archiverLock.Lock()
+	defer archiverLock.Unlock()
+	return archivers[cid]
+}
+
+// StateIndex is the index of the state of the block kept by archive nodes.
+// Result has the world state and the extension state, such as IISS, of the
+// block, and NextValidatorsHash is for the validators of them.
+type StateIndex struct {
+	BlockID            []byte
+	Result             []byte
+	NextValidatorsHash []byte
+}
+
+// heightKey returns the key for the height. Unlike the codec, it keeps the
+// order of heights, so the keys can be iterated in the order of heights.
+func heightKey(height int64) []byte {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], uint64(height))
+	return key[:]
+}
+
+// GetStateIndex returns the state index of the height stored by the archive
+// node.
+func GetStateIndex(dbase db.Database, height int64) (*StateIndex, error) {
+	bs, err := db.DoGetWithBucketID(dbase, db.StateIndexByHeight, heightKey(height))
+	if err != nil {
+		return nil, err
+	}
+	si := new(StateIndex)
+	if _, err := codec.BC.UnmarshalFromBytes(bs, si); err != nil {
+		return nil, err
+	}
+	return si, nil
+}
+
+// GetChangeSet returns changes of the world state from the state of the
+// previous height to the state of the height, stored by the archive node.
+func GetChangeSet(dbase db.Database, height int64) (*state.ChangeSet, error) {
+	bs, err := db.DoGetWithBucketID(dbase, db.ChangeSetByHeight, heightKey(height))
+	if err != nil {
+		return nil, err
+	}
+	return state.ChangeSetFromBytes(bs)
+}
+
+// GetAccountHistory returns heights in [from, to) where the account has
+// been changed, using changesets stored by the archive node. Zero to means
+// no limit.
+func GetAccountHistory(dbase db.Database, id []byte, from, to int64) ([]int64, error) {
+	bk, err := dbase.GetBucket(db.ChangeSetByHeight)
+	if err != nil {
+		return nil, err
+	}
+	r := &db.Range{Start: heightKey(from)}
+	if to > 0 {
+		r.Limit = heightKey(to)
+	}
+	itr, err := db.NewIterator(bk, r)
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Release()
+
+	var heights []int64
+	for itr.Next() {
+		cs, err := state.ChangeSetFromBytes(itr.Value())
+		if err != nil {
+			return nil, err
+		}
+		if cs.Account(id) != nil {
+			heights = append(heights, int64(binary.BigEndian.Uint64(itr.Key())))
+		}
+	}
+	return heights, itr.Error()
+}
+
+// stateArchiver indexes states of blocks by height along with changesets of
+// them while the chain is running. States themselves are kept because
+// pruning is not allowed for archive nodes.
+type stateArchiver struct {
+	chain *singleChain
+	dbase db.Database
+	log   log.Logger
+
+	mtx       sync.Mutex
+	height    int64
+	indexed   int
+	lastError error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (a *stateArchiver) loadHeight() int64 {
+	height := a.chain.GenesisStorage().Height()
+	if bs, err := db.DoGetWithBucketID(a.dbase, db.ChainProperty, []byte(keyArchivedHeight)); err == nil {
+		var next int64
+		if _, err := codec.BC.UnmarshalFromBytes(bs, &next); err == nil && next > height {
+			height = next
+		}
+	}
+	return height
+}
+
+func (a *stateArchiver) getHeight() int64 {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.height
+}
+
+func (a *stateArchiver) Start() {
+	height := a.loadHeight()
+	a.mtx.Lock()
+	a.height = height
+	a.mtx.Unlock()
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+	go a.routine()
+}
+
+func (a *stateArchiver) Stop() {
+	if a.done == nil {
+		return
+	}
+	close(a.stop)
+	<-a.done
+	a.done = nil
+}
+
+func (a *stateArchiver) routine() {
+	defer close(a.done)
+	bm := a.chain.BlockManager()
+	next := a.getHeight()
+	for {
+		bch, err := bm.WaitForBlock(next)
+		if err != nil {
+			a.log.Warnf("Archiver: fail to wait for block height=%d err=%+v", next, err)
+			return
+		}
+		select {
+		case <-a.stop:
+			return
+		case _, ok := <-bch:
+			if !ok {
+				return
+			}
+		}
+		for height := a.getHeight(); height <= next; height++ {
+			if err := a.index(height); err != nil {
+				a.log.Warnf("Archiver: fail to index state height=%d err=%+v", height, err)
+				a.mtx.Lock()
+				a.lastError = err
+				a.mtx.Unlock()
+				break
+			}
+		}
+		// on failure, it tries again with the next block.
+		next += 1
+	}
+}
+
+func (a *stateArchiver) index(height int64) error {
+	bm := a.chain.BlockManager()
+	blk, err := bm.GetBlockByHeight(height)
+	if err != nil {
+		return err
+	}
+	var parent []byte
+	if height > a.chain.GenesisStorage().Height() {
+		pblk, err := bm.GetBlockByHeight(height - 1)
+		if err != nil {
+			return err
+		}
+		parent = pblk.Result()
+	}
+	cs, err := service.NewChangeSet(a.dbase, parent, blk.Result())
+	if err != nil {
+		return err
+	}
+	si := &StateIndex{
+		BlockID:            blk.ID(),
+		Result:             blk.Result(),
+		NextValidatorsHash: blk.NextValidatorsHash(),
+	}
+
+	batch := db.NewBatch(a.dbase)
+	key := heightKey(height)
+	if err := batch.Set(db.StateIndexByHeight, key, codec.BC.MustMarshalToBytes(si)); err != nil {
+		return err
+	}
+	if err := batch.Set(db.ChangeSetByHeight, key, cs.Bytes()); err != nil {
+		return err
+	}
+	if err := batch.Set(db.ChainProperty, []byte(keyArchivedHeight), codec.BC.MustMarshalToBytes(height+1)); err != nil {
+		return err
+	}
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.height = height + 1
+	a.indexed += 1
+	a.lastError = nil
+	return nil
+}
+
+func (a *stateArchiver) Inspect() map[string]interface{} {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	m := map[string]interface{}{
+		"height":  a.height,
+		"indexed": a.indexed,
+	}
+	if a.lastError != nil {
+		m["error"] = a.lastError.Error()
+	}
+	return m
+}
+
+func newStateArchiver(c *singleChain, dbase db.Database, logger log.Logger) *stateArchiver {
+	return &stateArchiver{
+		chain: c,
+		dbase: dbase,
+		log:   logger,
+	}
+}
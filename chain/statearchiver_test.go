@@ -0,0 +1,104 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/service/state"
+)
+
+func testGetAccountHistory(t *testing.T, dbase db.Database) {
+	// nodes of MerkleTrie may share the prefix with other buckets
+	mbk, err := dbase.GetBucket(db.MerkleTrie)
+	assert.NoError(t, err)
+	for i, nodes := 0, 0; nodes < 3; i++ {
+		node := []byte(fmt.Sprintf("node%d", i))
+		if hash := crypto.SHA3Sum256(node); hash[0] == db.ChangeSetByHeight[0] {
+			assert.NoError(t, mbk.Set(hash, node))
+			nodes++
+		}
+	}
+
+	ibk, err := dbase.GetBucket(db.StateIndexByHeight)
+	assert.NoError(t, err)
+	cbk, err := dbase.GetBucket(db.ChangeSetByHeight)
+	assert.NoError(t, err)
+
+	id1, id2 := []byte("account1"), []byte("account2")
+	for height := int64(0); height < 300; height++ {
+		cs := new(state.ChangeSet)
+		if height%3 == 0 {
+			cs.Accounts = append(cs.Accounts, &state.AccountChange{
+				Key: crypto.SHA3Sum256(id1),
+			})
+		}
+		if height%100 == 0 {
+			cs.Accounts = append(cs.Accounts, &state.AccountChange{
+				Key:     crypto.SHA3Sum256(id2),
+				Storage: [][]byte{[]byte("key")},
+			})
+		}
+		assert.NoError(t, cbk.Set(heightKey(height), cs.Bytes()))
+		si := &StateIndex{Result: codec.BC.MustMarshalToBytes(height)}
+		assert.NoError(t, ibk.Set(heightKey(height), codec.BC.MustMarshalToBytes(si)))
+	}
+
+	heights, err := GetAccountHistory(dbase, id2, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{0, 100, 200}, heights)
+
+	heights, err = GetAccountHistory(dbase, id2, 1, 200)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{100}, heights)
+
+	heights, err = GetAccountHistory(dbase, id1, 250, 262)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{252, 255, 258, 261}, heights)
+
+	cs, err := GetChangeSet(dbase, 200)
+	assert.NoError(t, err)
+	assert.Len(t, cs.Accounts, 1)
+	assert.Equal(t, [][]byte{[]byte("key")}, cs.Account(id2).Storage)
+
+	si, err := GetStateIndex(dbase, 256)
+	assert.NoError(t, err)
+	assert.Equal(t, codec.BC.MustMarshalToBytes(int64(256)), si.Result)
+
+	_, err = GetStateIndex(dbase, 300)
+	assert.Error(t, err)
+}
+
+func TestGetAccountHistory(t *testing.T) {
+	t.Run("mapdb", func(t *testing.T) {
+		testGetAccountHistory(t, db.NewMapDB())
+	})
+	for _, backend := range []db.BackendType{db.GoLevelDBBackend, db.PebbleDBBackend} {
+		t.Run(string(backend), func(t *testing.T) {
+			dbase, err := db.Open(t.TempDir(), string(backend), "test")
+			assert.NoError(t, err)
+			defer dbase.Close()
+			testGetAccountHistory(t, dbase)
+		})
+	}
+}
@@ -0,0 +1,600 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/common/merkle"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	// PruneRetentionMin is the minimum number of recent blocks whose
+	// states are kept by online pruning.
+	PruneRetentionMin = 10
+
+	keyPrunedBase      = "pruner.base"
+	prunerTmpDir       = "pruner"
+	prunerSweepChunk   = 1024
+	prunerMarkBucket   = db.BucketID("M")
+	prunerOtherBucket  = db.BucketID("m")
+	prunerStateIdle    = "idle"
+	prunerStateCollect = "collecting"
+	prunerStateMark    = "marking"
+	prunerStateSweep   = "sweeping"
+)
+
+var (
+	prunerLock sync.Mutex
+	pruners    = map[int]*statePruner{}
+)
+
+func registerPruner(cid int, p *statePruner) {
+	prunerLock.Lock()
+	defer prunerLock.Unlock()
+	if p == nil {
+		delete(pruners, cid)
+	} else {
+		pruners[cid] = p
+	}
+}
+
+func prunerByCID(cid int) *statePruner {
+	prunerLock.Lock()
+	defer prunerLock.Unlock()
+	return pruners[cid]
+}
+
+// stateExporter is implemented by the service manager supporting export of
+// states without receipts.
+type stateExporter interface {
+	ExportState(result []byte, vh []byte, dst db.Database) error
+}
+
+// pruningDB records keys of merkle trie nodes written while the pruner is
+// sweeping, so nodes written again by the running chain are not deleted.
+type pruningDB struct {
+	db.Database
+	lock    sync.Mutex
+	written map[string]struct{}
+}
+
+func (d *pruningDB) record(key []byte) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.written != nil {
+		d.written[string(key)] = struct{}{}
+	}
+}
+
+func (d *pruningDB) startRecord() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.written = make(map[string]struct{})
+}
+
+func (d *pruningDB) stopRecord() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.written = nil
+}
+
+// deleteNodes deletes merkle trie nodes except ones written after
+// startRecord. It returns the number of deleted nodes.
+func (d *pruningDB) deleteNodes(keys [][]byte) (int, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	batch := db.NewBatch(d.Database)
+	for _, key := range keys {
+		if _, ok := d.written[string(key)]; ok {
+			continue
+		}
+		if err := batch.Delete(db.MerkleTrie, key); err != nil {
+			return 0, err
+		}
+	}
+	cnt := batch.Len()
+	if err := batch.Commit(); err != nil {
+		return 0, err
+	}
+	return cnt, nil
+}
+
+func (d *pruningDB) GetBucket(id db.BucketID) (db.Bucket, error) {
+	bk, err := d.Database.GetBucket(id)
+	if err != nil || id != db.MerkleTrie {
+		return bk, err
+	}
+	return &pruningBucket{Bucket: bk, dbase: d}, nil
+}
+
+func (d *pruningDB) NewRawIterator() (db.Iterator, error) {
+	return db.NewRawIterator(d.Database)
+}
+
+func (d *pruningDB) NewBatch() db.Batch {
+	return &pruningBatch{Batch: db.NewBatch(d.Database), dbase: d}
+}
+
+func (d *pruningDB) Compact() error {
+	return db.Compact(d.Database)
+}
+
+type pruningBucket struct {
+	db.Bucket
+	dbase *pruningDB
+}
+
+func (bk *pruningBucket) Set(key []byte, value []byte) error {
+	bk.dbase.record(key)
+	return bk.Bucket.Set(key, value)
+}
+
+func (bk *pruningBucket) NewIterator(r *db.Range) (db.Iterator, error) {
+	return db.NewIterator(bk.Bucket, r)
+}
+
+type pruningBatch struct {
+	db.Batch
+	dbase *pruningDB
+}
+
+func (b *pruningBatch) Set(id db.BucketID, key []byte, value []byte) error {
+	if id == db.MerkleTrie {
+		b.dbase.record(key)
+	}
+	return b.Batch.Set(id, key, value)
+}
+
+func newPruningDB(dbase db.Database) *pruningDB {
+	return &pruningDB{Database: dbase}
+}
+
+// markDB keeps only keys of the entries written by merkle.Builder, and
+// returns the value of the source database for the marked key. Marks of
+// merkle trie nodes are kept in a separate bucket for iteration.
+type markDB struct {
+	src   db.Database
+	marks db.Database
+}
+
+func (d *markDB) GetBucket(id db.BucketID) (db.Bucket, error) {
+	src, err := d.src.GetBucket(id)
+	if err != nil {
+		return nil, err
+	}
+	mid := prunerMarkBucket
+	if id != db.MerkleTrie {
+		mid = prunerOtherBucket + id
+	}
+	marks, err := d.marks.GetBucket(mid)
+	if err != nil {
+		return nil, err
+	}
+	return &markBucket{src: src, marks: marks}, nil
+}
+
+func (d *markDB) Close() error {
+	return d.marks.Close()
+}
+
+// Nodes returns the iterator for keys of marked merkle trie nodes.
+func (d *markDB) Nodes() (db.Iterator, error) {
+	bk, err := d.marks.GetBucket(prunerMarkBucket)
+	if err != nil {
+		return nil, err
+	}
+	return db.NewIterator(bk, nil)
+}
+
+func (d *markDB) HasNode(key []byte) (bool, error) {
+	bk, err := d.marks.GetBucket(prunerMarkBucket)
+	if err != nil {
+		return false, err
+	}
+	return bk.Has(key)
+}
+
+type markBucket struct {
+	src   db.Bucket
+	marks db.Bucket
+}
+
+func (bk *markBucket) Get(key []byte) ([]byte, error) {
+	if ok, err := bk.marks.Has(key); err != nil || !ok {
+		return nil, err
+	}
+	return bk.src.Get(key)
+}
+
+func (bk *markBucket) Has(key []byte) (bool, error) {
+	return bk.marks.Has(key)
+}
+
+func (bk *markBucket) Set(key []byte, value []byte) error {
+	return bk.marks.Set(key, []byte{})
+}
+
+func (bk *markBucket) Delete(key []byte) error {
+	return bk.marks.Delete(key)
+}
+
+// statePruner removes merkle trie nodes only reachable from the states out
+// of the retention window while the chain is running. States of the last
+// retention blocks and the checkpoints are kept.
+//
+// On each run, it collects nodes of the states being pruned as candidates,
+// marks nodes of the states to keep, then deletes candidates not marked.
+// Nodes written by the chain during the run are never deleted, because new
+// states refer only to the nodes of the kept states or to the written ones.
+type statePruner struct {
+	chain      *singleChain
+	dbase      *pruningDB
+	retention  int64
+	checkpoint int64
+	log        log.Logger
+
+	mtx        sync.Mutex
+	state      string
+	base       int64
+	target     int64
+	height     int64
+	resolved   int
+	unresolved int
+	candidates int
+	deleted    int
+	runs       int
+	lastError  error
+	lastRun    time.Time
+	duration   time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (p *statePruner) isCheckpoint(height int64) bool {
+	return p.checkpoint > 0 && height%p.checkpoint == 0
+}
+
+func (p *statePruner) setState(s string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.state = s
+	p.height, p.resolved, p.unresolved = 0, 0, 0
+}
+
+func (p *statePruner) stopped() bool {
+	select {
+	case <-p.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *statePruner) onProgress(height int64, resolved, unresolved int) error {
+	if p.stopped() {
+		return errors.ErrInterrupted
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.height, p.resolved, p.unresolved = height, resolved, unresolved
+	return nil
+}
+
+func (p *statePruner) loadBase() int64 {
+	base := p.chain.GenesisStorage().Height()
+	if bs, err := db.DoGetWithBucketID(p.dbase, db.ChainProperty, []byte(keyPrunedBase)); err == nil {
+		var height int64
+		if _, err := codec.BC.UnmarshalFromBytes(bs, &height); err == nil && height > base {
+			base = height
+		}
+	}
+	return base
+}
+
+func (p *statePruner) storeBase(base int64) error {
+	bk, err := p.dbase.GetBucket(db.ChainProperty)
+	if err != nil {
+		return err
+	}
+	return bk.Set([]byte(keyPrunedBase), codec.BC.MustMarshalToBytes(base))
+}
+
+func (p *statePruner) getBase() int64 {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.base
+}
+
+func (p *statePruner) Start() {
+	base := p.loadBase()
+	p.mtx.Lock()
+	p.state = prunerStateIdle
+	p.base = base
+	p.mtx.Unlock()
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.routine()
+}
+
+func (p *statePruner) Stop() {
+	if p.done == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.done = nil
+}
+
+func (p *statePruner) routine() {
+	defer close(p.done)
+	bm := p.chain.BlockManager()
+	// it starts pruning when the states to prune are as many as the states
+	// to keep, so each run handles retention blocks at least.
+	next := p.getBase() + 2*p.retention - 1
+	for {
+		bch, err := bm.WaitForBlock(next)
+		if err != nil {
+			p.log.Warnf("Pruner: fail to wait for block height=%d err=%+v", next, err)
+			return
+		}
+		var blk module.Block
+		select {
+		case <-p.stop:
+			return
+		case b, ok := <-bch:
+			if !ok {
+				return
+			}
+			blk = b
+		}
+		base, target := p.getBase(), blk.Height()-p.retention+1
+		if err := p.run(base, target); err != nil {
+			if errors.InterruptedError.Equals(err) {
+				return
+			}
+			p.log.Warnf("Pruner: fail to prune states base=%d target=%d err=%+v", base, target, err)
+			next = blk.Height() + p.retention
+		} else {
+			next = target + 2*p.retention - 1
+		}
+	}
+}
+
+func (p *statePruner) openMarkDB(dir, name string) (*markDB, error) {
+	marks, err := p.chain.openDatabase(path.Join(dir, name), p.chain.cfg.DBType)
+	if err != nil {
+		return nil, err
+	}
+	return &markDB{src: p.dbase, marks: marks}, nil
+}
+
+// export marks entries of the state of the block in the database. Entries
+// already marked are skipped with their descendants.
+func (p *statePruner) export(dst *markDB, height int64) error {
+	blk, err := p.chain.BlockManager().GetBlockByHeight(height)
+	if err != nil {
+		return err
+	}
+	ex, ok := p.chain.ServiceManager().(stateExporter)
+	if !ok {
+		return errors.UnsupportedError.New("StateExportNotSupported")
+	}
+	ctx := merkle.NewCopyContext(p.chain.Database(), dst)
+	ctx.SetProgressCallback(p.onProgress)
+	ctx.SetHeight(height)
+	return ex.ExportState(blk.Result(), blk.NextValidatorsHash(), ctx.TargetDB())
+}
+
+func (p *statePruner) run(base, target int64) (rerr error) {
+	start := time.Now()
+	p.mtx.Lock()
+	p.target = target
+	p.candidates, p.deleted = 0, 0
+	p.mtx.Unlock()
+	defer func() {
+		p.mtx.Lock()
+		defer p.mtx.Unlock()
+		p.state = prunerStateIdle
+		p.lastError = rerr
+		p.lastRun = start
+		p.duration = time.Since(start)
+		p.runs += 1
+	}()
+
+	p.dbase.startRecord()
+	defer p.dbase.stopRecord()
+
+	dir := path.Join(p.chain.cfg.AbsBaseDir(), prunerTmpDir)
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	p.log.Infof("Pruner: collect nodes of states from=%d to=%d", base, target-1)
+	p.setState(prunerStateCollect)
+	cands, err := p.openMarkDB(dir, "candidates")
+	if err != nil {
+		return err
+	}
+	defer cands.Close()
+	for h := base; h < target; h++ {
+		if p.isCheckpoint(h) {
+			continue
+		}
+		if err := p.export(cands, h); err != nil {
+			return err
+		}
+	}
+
+	p.log.Infof("Pruner: mark nodes of states from=%d", target)
+	p.setState(prunerStateMark)
+	marks, err := p.openMarkDB(dir, "marks")
+	if err != nil {
+		return err
+	}
+	defer marks.Close()
+	if p.checkpoint > 0 {
+		gh := p.chain.GenesisStorage().Height()
+		for h := (gh + p.checkpoint - 1) / p.checkpoint * p.checkpoint; h < target; h += p.checkpoint {
+			if err := p.export(marks, h); err != nil {
+				// the checkpoint may be pruned before the checkpoint
+				// interval is configured.
+				if !errors.NotFoundError.Equals(err) {
+					return err
+				}
+				p.log.Warnf("Pruner: checkpoint is broken height=%d err=%v", h, err)
+			}
+		}
+	}
+	// mark till the last block, including blocks finalized during marking.
+	for h := target; ; h++ {
+		blk, err := p.chain.BlockManager().GetLastBlock()
+		if err != nil {
+			return err
+		}
+		if h > blk.Height() {
+			break
+		}
+		if err := p.export(marks, h); err != nil {
+			return err
+		}
+	}
+
+	// nodes of states below the target won't be referenced anymore, so
+	// store it before deletion. Interrupted sweep leaves some garbage only.
+	if err := p.storeBase(target); err != nil {
+		return err
+	}
+	p.mtx.Lock()
+	p.base = target
+	p.mtx.Unlock()
+
+	p.log.Infof("Pruner: sweep nodes")
+	p.setState(prunerStateSweep)
+	return p.sweep(cands, marks)
+}
+
+func (p *statePruner) sweep(cands, marks *markDB) error {
+	itr, err := cands.Nodes()
+	if err != nil {
+		return err
+	}
+	defer itr.Release()
+
+	var keys [][]byte
+	flush := func() error {
+		cnt, err := p.dbase.deleteNodes(keys)
+		if err != nil {
+			return err
+		}
+		p.mtx.Lock()
+		p.deleted += cnt
+		p.mtx.Unlock()
+		keys = keys[:0]
+		return nil
+	}
+	for itr.Next() {
+		if p.stopped() {
+			return errors.ErrInterrupted
+		}
+		key := itr.Key()
+		p.mtx.Lock()
+		p.candidates += 1
+		p.mtx.Unlock()
+		if ok, err := marks.HasNode(key); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+		keys = append(keys, key)
+		if len(keys) >= prunerSweepChunk {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+func (p *statePruner) Inspect() map[string]interface{} {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	m := map[string]interface{}{
+		"state":      p.state,
+		"retention":  p.retention,
+		"checkpoint": p.checkpoint,
+		"base":       p.base,
+		"runs":       p.runs,
+	}
+	switch p.state {
+	case prunerStateCollect, prunerStateMark:
+		m["target"] = p.target
+		m["height"] = p.height
+		m["resolved"] = p.resolved
+		m["unresolved"] = p.unresolved
+	case prunerStateSweep:
+		m["target"] = p.target
+		m["candidates"] = p.candidates
+		m["deleted"] = p.deleted
+	}
+	if p.runs > 0 {
+		last := map[string]interface{}{
+			"start":      p.lastRun.Format(time.RFC3339),
+			"duration":   p.duration.String(),
+			"candidates": p.candidates,
+			"deleted":    p.deleted,
+		}
+		if p.lastError != nil {
+			last["error"] = p.lastError.Error()
+		}
+		m["last"] = last
+	}
+	return m
+}
+
+func newStatePruner(c *singleChain, dbase *pruningDB, logger log.Logger) *statePruner {
+	retention := c.cfg.PruneRetention
+	if retention < PruneRetentionMin {
+		logger.Warnf("Pruner: retention=%d is too small, use %d",
+			retention, PruneRetentionMin)
+		retention = PruneRetentionMin
+	}
+	return &statePruner{
+		chain:      c,
+		dbase:      dbase,
+		retention:  retention,
+		checkpoint: c.cfg.PruneCheckpoint,
+		log:        logger,
+		state:      prunerStateIdle,
+	}
+}
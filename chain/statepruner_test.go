@@ -0,0 +1,133 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/log"
+)
+
+func TestPruningDB_DeleteNodes(t *testing.T) {
+	pdb := newPruningDB(db.NewMapDB())
+	bk, err := pdb.GetBucket(db.MerkleTrie)
+	assert.NoError(t, err)
+	for _, k := range []string{"k1", "k2", "k3", "k4"} {
+		assert.NoError(t, bk.Set([]byte(k), []byte(k)))
+	}
+
+	pdb.startRecord()
+	// written again through the bucket and the batch during the sweep
+	assert.NoError(t, bk.Set([]byte("k2"), []byte("k2")))
+	batch := db.NewBatch(db.WithFlags(pdb, db.Flags{}))
+	assert.NoError(t, batch.Set(db.MerkleTrie, []byte("k3"), []byte("k3")))
+	assert.NoError(t, batch.Commit())
+
+	cnt, err := pdb.deleteNodes([][]byte{[]byte("k1"), []byte("k2"), []byte("k3")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cnt)
+	pdb.stopRecord()
+
+	for k, exp := range map[string]bool{"k1": false, "k2": true, "k3": true, "k4": true} {
+		has, err := bk.Has([]byte(k))
+		assert.NoError(t, err)
+		assert.Equal(t, exp, has, k)
+	}
+
+	cnt, err = pdb.deleteNodes([][]byte{[]byte("k2")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cnt)
+}
+
+func TestMarkDB_Basic(t *testing.T) {
+	src := db.NewMapDB()
+	sbk, err := src.GetBucket(db.MerkleTrie)
+	assert.NoError(t, err)
+	assert.NoError(t, sbk.Set([]byte("k1"), []byte("v1")))
+	assert.NoError(t, sbk.Set([]byte("k2"), []byte("v2")))
+
+	mdb := &markDB{src: src, marks: db.NewMapDB()}
+	bk, err := mdb.GetBucket(db.MerkleTrie)
+	assert.NoError(t, err)
+
+	// values of unmarked entries are hidden
+	v, err := bk.Get([]byte("k1"))
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	assert.NoError(t, bk.Set([]byte("k1"), []byte("v1")))
+	v, err = bk.Get([]byte("k1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), v)
+
+	// marks of other buckets are not merkle trie nodes
+	obk, err := mdb.GetBucket(db.BytesByHash)
+	assert.NoError(t, err)
+	assert.NoError(t, obk.Set([]byte("k2"), []byte("v2")))
+
+	has, err := mdb.HasNode([]byte("k2"))
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	itr, err := mdb.Nodes()
+	assert.NoError(t, err)
+	var keys []string
+	for itr.Next() {
+		keys = append(keys, string(itr.Key()))
+	}
+	itr.Release()
+	assert.Equal(t, []string{"k1"}, keys)
+}
+
+func TestStatePruner_Sweep(t *testing.T) {
+	pdb := newPruningDB(db.NewMapDB())
+	bk, err := pdb.GetBucket(db.MerkleTrie)
+	assert.NoError(t, err)
+	for _, k := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, bk.Set([]byte(k), []byte(k)))
+	}
+
+	cands := &markDB{src: pdb, marks: db.NewMapDB()}
+	marks := &markDB{src: pdb, marks: db.NewMapDB()}
+	cbk, _ := cands.GetBucket(db.MerkleTrie)
+	mbk, _ := marks.GetBucket(db.MerkleTrie)
+	for _, k := range []string{"a", "b", "c"} {
+		assert.NoError(t, cbk.Set([]byte(k), nil))
+	}
+	assert.NoError(t, mbk.Set([]byte("b"), nil))
+
+	p := &statePruner{
+		dbase: pdb,
+		log:   log.New(),
+		stop:  make(chan struct{}),
+	}
+	pdb.startRecord()
+	assert.NoError(t, bk.Set([]byte("c"), []byte("c")))
+	assert.NoError(t, p.sweep(cands, marks))
+	pdb.stopRecord()
+
+	assert.Equal(t, 3, p.candidates)
+	assert.Equal(t, 1, p.deleted)
+	for k, exp := range map[string]bool{"a": false, "b": true, "c": true, "d": true} {
+		has, err := bk.Has([]byte(k))
+		assert.NoError(t, err)
+		assert.Equal(t, exp, has, k)
+	}
+}
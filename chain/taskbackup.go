@@ -18,6 +18,7 @@ package chain
 
 import (
 	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -29,10 +30,19 @@ import (
 
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/common/errors"
 )
 
-const TemporalBackupFile = ".backup"
+const (
+	TemporalBackupFile = ".backup"
+
+	// BackupFileList is the entry of the backup having the list of all
+	// files of the chain at the time of the backup. Incremental backups
+	// have only files changed since the base, so restore applies the base
+	// and increments in order, then removes files not in the list.
+	BackupFileList = ".backup_files"
+)
 
 type BackupInfo struct {
 	NID     common.HexInt32 `json:"nid"`
@@ -40,6 +50,27 @@ type BackupInfo struct {
 	Channel string          `json:"channel"`
 	Height  int64           `json:"height"`
 	Codec   string          `json:"codec"`
+	Base    string          `json:"base,omitempty"`
+
+	// ID is the hash of the file list of the backup, and BaseID is the ID
+	// of the base. So the base is identified by its content, not by the
+	// name of the file.
+	ID     common.HexBytes `json:"id,omitempty"`
+	BaseID common.HexBytes `json:"baseId,omitempty"`
+}
+
+type BackupFile struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"`
+}
+
+func backupFileOf(name string, st fs.FileInfo) BackupFile {
+	return BackupFile{
+		Name:    name,
+		Size:    st.Size(),
+		ModTime: st.ModTime().UnixNano(),
+	}
 }
 
 var backupStates = map[State]string{
@@ -52,7 +83,11 @@ var backupStates = map[State]string{
 type taskBackup struct {
 	chain   *singleChain
 	file    string
+	base    string
+	baseID  []byte
 	extra   []string
+	info    *BackupInfo
+	files   map[string]BackupFile
 	fd      io.WriteCloser
 	zw      *zip.Writer
 	current int32
@@ -62,6 +97,10 @@ type taskBackup struct {
 }
 
 func (t *taskBackup) String() string {
+	if t.base != "" {
+		return fmt.Sprintf("Backup(file=%s,base=%s)",
+			path.Base(t.file), path.Base(t.base))
+	}
 	return fmt.Sprintf("Backup(file=%s)", path.Base(t.file))
 }
 
@@ -93,6 +132,13 @@ func (t *taskBackup) Start() (ret error) {
 		t.chain.releaseDatabase()
 		return nil
 	}
+	var base string
+	if t.base != "" {
+		if err := t._loadBase(); err != nil {
+			return err
+		}
+		base = path.Base(t.base)
+	}
 	tmp, err := os.CreateTemp(path.Dir(t.file), TemporalBackupFile)
 	if err != nil {
 		return errors.Wrap(err, "Fail to make temporal file")
@@ -110,13 +156,16 @@ func (t *taskBackup) Start() (ret error) {
 	t.fd = tmp
 	t.zw = zip.NewWriter(tmp)
 
-	if err := writeBackupInfo(t.zw, &BackupInfo{
+	t.info = &BackupInfo{
 		NID:     common.HexInt32{Value: int32(t.chain.NID())},
 		CID:     common.HexInt32{Value: int32(t.chain.CID())},
 		Channel: t.chain.Channel(),
 		Height:  t.chain.lastBlockHeight(),
 		Codec:   codec.BC.Name(),
-	}); err != nil {
+		Base:    base,
+		BaseID:  t.baseID,
+	}
+	if err := writeBackupInfo(t.zw, t.info); err != nil {
 		return err
 	}
 
@@ -135,7 +184,57 @@ func (t *taskBackup) Start() (ret error) {
 	return nil
 }
 
-func zipWrite(writer *zip.Writer, p, n string, on func(int64) error) error {
+// _loadBase loads the list of files of the base backup for incremental
+// backup.
+func (t *taskBackup) _loadBase() error {
+	zr, err := zip.OpenReader(t.base)
+	if err != nil {
+		return errors.IllegalArgumentError.Wrapf(err,
+			"ZipOpenFailure(base=%s)", t.base)
+	}
+	defer zr.Close()
+	info, err := ReadBackupInfo(&zr.Reader)
+	if err != nil {
+		return errors.IllegalArgumentError.Wrap(err, "InvalidBackupInfo")
+	}
+	if int(info.CID.Value) != t.chain.CID() || int(info.NID.Value) != t.chain.NID() {
+		return errors.IllegalArgumentError.Errorf(
+			"InvalidBase(cid=%#x,nid=%#x)", info.CID.Value, info.NID.Value)
+	}
+	files, err := ReadBackupFiles(&zr.Reader)
+	if err != nil {
+		return err
+	}
+	if files == nil {
+		return errors.IllegalArgumentError.Errorf(
+			"NoFileListInBase(base=%s)", path.Base(t.base))
+	}
+	if id, err := BackupIDOf(&zr.Reader); err != nil {
+		return err
+	} else if !bytes.Equal(id, info.ID) {
+		return errors.IllegalArgumentError.Errorf(
+			"InvalidBaseID(base=%s,id=%s)", path.Base(t.base), info.ID)
+	} else {
+		t.baseID = id
+	}
+	t.files = make(map[string]BackupFile, len(files))
+	for _, f := range files {
+		t.files[f.Name] = f
+	}
+	return nil
+}
+
+// _changed returns whether the file is changed since the base. All files
+// are changed for full backup.
+func (t *taskBackup) _changed(f BackupFile) bool {
+	if t.files == nil {
+		return true
+	}
+	bf, ok := t.files[f.Name]
+	return !ok || bf != f
+}
+
+func zipWrite(writer *zip.Writer, p, n string, filter func(BackupFile) bool, on func(int64) error) error {
 	p2 := path.Join(p, n)
 	st, err := os.Stat(p2)
 	if errors.Is(err, fs.ErrNotExist) {
@@ -144,6 +243,9 @@ func zipWrite(writer *zip.Writer, p, n string, on func(int64) error) error {
 		return errors.Wrap(err, "writeToZip: FAIL on os.State")
 	}
 	if st.Mode().IsRegular() {
+		if filter != nil && !filter(backupFileOf(n, st)) {
+			return nil
+		}
 		fd, err := os.Open(p2)
 		defer fd.Close()
 		if err != nil {
@@ -183,52 +285,39 @@ func zipWrite(writer *zip.Writer, p, n string, on func(int64) error) error {
 		return fis[i].Name() < fis[j].Name()
 	})
 	for _, fi := range fis {
-		if err := zipWrite(writer, p, path.Join(n, fi.Name()), on); err != nil {
+		if err := zipWrite(writer, p, path.Join(n, fi.Name()), filter, on); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func countFiles(p string) (int, error) {
-	st, err := os.Stat(p)
+func listFiles(p, n string, files []BackupFile) ([]BackupFile, error) {
+	p2 := path.Join(p, n)
+	st, err := os.Stat(p2)
 	if errors.Is(err, fs.ErrNotExist) {
-		return 0, nil
+		return files, nil
 	} else if err != nil {
-		return 0, err
+		return nil, err
 	}
-	if !st.IsDir() {
-		return 1, nil
+	if st.Mode().IsRegular() {
+		return append(files, backupFileOf(n, st)), nil
+	} else if !st.IsDir() {
+		return files, nil
 	}
-	fis, err := os.ReadDir(p)
+	fis, err := os.ReadDir(p2)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	cnt := 0
+	sort.SliceStable(fis, func(i, j int) bool {
+		return fis[i].Name() < fis[j].Name()
+	})
 	for _, fi := range fis {
-		if fi.IsDir() {
-			if c, err := countFiles(path.Join(p, fi.Name())); err != nil {
-				return 0, err
-			} else {
-				cnt += c
-			}
-		} else if fi.Type().IsRegular() {
-			cnt += 1
+		if files, err = listFiles(p, path.Join(n, fi.Name()), files); err != nil {
+			return nil, err
 		}
 	}
-	return cnt, nil
-}
-
-func (t *taskBackup) _countFiles(chainDir string, names []string) (int, error) {
-	count := 0
-	for _, name := range names {
-		if cnt, err := countFiles(path.Join(chainDir, name)); err != nil {
-			return 0, err
-		} else {
-			count += cnt
-		}
-	}
-	return count, nil
+	return files, nil
 }
 
 func (t *taskBackup) _isInterrupted() bool {
@@ -253,19 +342,33 @@ func (t *taskBackup) _backup() error {
 	}, t.extra...)
 
 	chainDir := t.chain.cfg.AbsBaseDir()
-	if cnt, err := t._countFiles(chainDir, names); err != nil {
-		return err
-	} else {
-		t.total = int32(cnt)
+	var files []BackupFile
+	for _, name := range names {
+		var err error
+		if files, err = listFiles(chainDir, name, files); err != nil {
+			return err
+		}
 	}
+	var total int32
+	for _, f := range files {
+		if t._changed(f) {
+			total += 1
+		}
+	}
+	atomic.StoreInt32(&t.total, total)
 
 	for _, name := range names {
-		if err := zipWrite(t.zw, chainDir, name, t.OnWrite); err != nil {
+		if err := zipWrite(t.zw, chainDir, name, t._changed, t.OnWrite); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	id, err := writeBackupFiles(t.zw, files)
+	if err != nil {
+		return err
+	}
+	t.info.ID = id
+	return writeBackupInfo(t.zw, t.info)
 }
 
 func (t *taskBackup) Stop() {
@@ -282,10 +385,11 @@ func (t *taskBackup) Wait() error {
 	return t.result.Wait()
 }
 
-func newTaskBackup(chain *singleChain, file string, extra []string) chainTask {
+func newTaskBackup(chain *singleChain, file, base string, extra []string) chainTask {
 	return &taskBackup{
 		chain: chain,
 		file:  file,
+		base:  base,
 		extra: extra,
 	}
 }
@@ -323,3 +427,62 @@ func ReadBackupInfo(zr *zip.Reader) (*BackupInfo, error) {
 	}
 	return info, nil
 }
+
+// writeBackupFiles writes the list of files, then it returns the ID of the
+// backup.
+func writeBackupFiles(zw *zip.Writer, files []BackupFile) ([]byte, error) {
+	bs, err := json.Marshal(files)
+	if err != nil {
+		return nil, err
+	}
+	w, err := zw.Create(BackupFileList)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(bs); err != nil {
+		return nil, err
+	}
+	return crypto.SHA3Sum256(bs), nil
+}
+
+// BackupIDOf returns the ID of the backup calculated from the list of files.
+// It returns nil if the backup doesn't have the list.
+func BackupIDOf(zr *zip.Reader) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != BackupFileList {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		bs, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.SHA3Sum256(bs), nil
+	}
+	return nil, nil
+}
+
+// ReadBackupFiles returns the list of files of the chain at the time of the
+// backup. It returns nil if the backup doesn't have the list.
+func ReadBackupFiles(zr *zip.Reader) ([]BackupFile, error) {
+	for _, f := range zr.File {
+		if f.Name != BackupFileList {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		files := make([]BackupFile, 0)
+		if err := json.NewDecoder(r).Decode(&files); err != nil {
+			return nil, errors.IllegalArgumentError.Wrap(err, "InvalidBackupFileList")
+		}
+		return files, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestBackup(t *testing.T, dir string, task *taskBackup) *zip.Reader {
+	files, err := listFiles(dir, DefaultDBDir, nil)
+	assert.NoError(t, err)
+
+	buf := bytes.NewBuffer(nil)
+	zw := zip.NewWriter(buf)
+	assert.NoError(t, zipWrite(zw, dir, DefaultDBDir, task._changed, func(int64) error {
+		return nil
+	}))
+	id, err := writeBackupFiles(zw, files)
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	bid, err := BackupIDOf(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, id, bid)
+	return zr
+}
+
+func namesOf(zr *zip.Reader) []string {
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestTaskBackup_Incremental(t *testing.T) {
+	dir := t.TempDir()
+	dbDir := path.Join(dir, DefaultDBDir)
+	assert.NoError(t, os.MkdirAll(dbDir, 0700))
+	for _, name := range []string{"000001.ldb", "000002.ldb", "MANIFEST"} {
+		assert.NoError(t, os.WriteFile(path.Join(dbDir, name), []byte(name), 0600))
+	}
+
+	full := &taskBackup{}
+	zr := writeTestBackup(t, dir, full)
+	assert.Equal(t, []string{
+		"db/000001.ldb", "db/000002.ldb", "db/MANIFEST", BackupFileList,
+	}, namesOf(zr))
+	files, err := ReadBackupFiles(zr)
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	// compaction removes and adds files, and updates the manifest
+	assert.NoError(t, os.Remove(path.Join(dbDir, "000001.ldb")))
+	assert.NoError(t, os.WriteFile(path.Join(dbDir, "000003.ldb"), []byte("3"), 0600))
+	mtime := time.Now().Add(time.Second)
+	assert.NoError(t, os.WriteFile(path.Join(dbDir, "MANIFEST"), []byte("MANIFEST2"), 0600))
+	assert.NoError(t, os.Chtimes(path.Join(dbDir, "MANIFEST"), mtime, mtime))
+
+	inc := &taskBackup{files: make(map[string]BackupFile)}
+	for _, f := range files {
+		inc.files[f.Name] = f
+	}
+	zr = writeTestBackup(t, dir, inc)
+	assert.Equal(t, []string{
+		"db/000003.ldb", "db/MANIFEST", BackupFileList,
+	}, namesOf(zr))
+	files, err = ReadBackupFiles(zr)
+	assert.NoError(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"db/000002.ldb", "db/000003.ldb", "db/MANIFEST"}, names)
+}
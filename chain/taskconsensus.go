@@ -66,6 +66,12 @@ func (t *taskConsensus) _start(c *singleChain) error {
 	if err := c.nm.Start(); err != nil {
 		return err
 	}
+	if c.pruner != nil {
+		c.pruner.Start()
+	}
+	if c.archiver != nil {
+		c.archiver.Start()
+	}
 	return nil
 }
 
@@ -0,0 +1,463 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/merkle"
+)
+
+const (
+	DefaultDBStatFile  = "dbstat.json"
+	DefaultDBCheckFile = "dbcheck.json"
+	dbCheckTmpDir      = "dbcheck"
+
+	dbCheckMaxMissingKeys = 100
+)
+
+// dbBuckets has names of known buckets for statistics. Merkle trie nodes
+// don't have a bucket prefix.
+var dbBuckets = []struct {
+	name string
+	id   db.BucketID
+}{
+	{"merkle", db.MerkleTrie},
+	{"bytes", db.BytesByHash},
+	{"txLocator", db.TransactionLocatorByHash},
+	{"headerByHeight", db.BlockHeaderHashByHeight},
+	{"chainProperty", db.ChainProperty},
+	{"stateIndex", db.StateIndexByHeight},
+	{"changeSet", db.ChangeSetByHeight},
+	{"ethList", "e" + db.ListByMerkleRootBase},
+	{"iconList", "i" + db.ListByMerkleRootBase},
+}
+
+const dbBucketOthers = "others"
+
+type BucketStat struct {
+	Keys  int64 `json:"keys"`
+	Bytes int64 `json:"bytes"`
+}
+
+func (s *BucketStat) add(key, value []byte) {
+	s.Keys += 1
+	s.Bytes += int64(len(key) + len(value))
+}
+
+type DBStat struct {
+	DBType  string                 `json:"dbType"`
+	Compact bool                   `json:"compact"`
+	Keys    int64                  `json:"keys"`
+	Bytes   int64                  `json:"bytes"`
+	Buckets map[string]*BucketStat `json:"buckets"`
+}
+
+func (s *DBStat) add(name string, key, value []byte) {
+	bs, ok := s.Buckets[name]
+	if !ok {
+		bs = new(BucketStat)
+		s.Buckets[name] = bs
+	}
+	bs.add(key, value)
+	s.Keys += 1
+	s.Bytes += int64(len(key) + len(value))
+}
+
+func (s *DBStat) String() string {
+	names := make([]string, 0, len(s.Buckets))
+	for name := range s.Buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	str := fmt.Sprintf("keys=%d bytes=%d", s.Keys, s.Bytes)
+	for _, name := range names {
+		bs := s.Buckets[name]
+		str += fmt.Sprintf(" %s=%d/%d", name, bs.Keys, bs.Bytes)
+	}
+	return str
+}
+
+// bucketOfKey returns the name of the bucket for the key of the database
+// sharing one key space for all buckets. Keys of merkle trie nodes are
+// hashes without any prefix.
+func bucketOfKey(key []byte) string {
+	if len(key) == 32 {
+		return dbBuckets[0].name
+	}
+	for _, b := range dbBuckets[1:] {
+		if len(key) > len(b.id) && string(key[:len(b.id)]) == string(b.id) {
+			return b.name
+		}
+	}
+	return dbBucketOthers
+}
+
+// statIterator returns the iterator for entries of the bucket. For the
+// database sharing one key space, it iterates entries of all buckets.
+func statIterator(dbase db.Database, id db.BucketID, shared bool) (db.Iterator, error) {
+	if shared {
+		return db.NewRawIterator(dbase)
+	}
+	bk, err := dbase.GetBucket(id)
+	if err != nil {
+		return nil, err
+	}
+	return db.NewIterator(bk, nil)
+}
+
+func collectDBStat(dbase db.Database, shared bool, stopped func() bool) (*DBStat, error) {
+	stat := &DBStat{Buckets: make(map[string]*BucketStat)}
+	buckets := dbBuckets
+	if shared {
+		buckets = dbBuckets[:1]
+	}
+	for _, b := range buckets {
+		itr, err := statIterator(dbase, b.id, shared)
+		if err != nil {
+			return nil, err
+		}
+		for itr.Next() {
+			if stopped() {
+				itr.Release()
+				return nil, errors.ErrInterrupted
+			}
+			name := b.name
+			if shared {
+				name = bucketOfKey(itr.Key())
+			}
+			stat.add(name, itr.Key(), itr.Value())
+		}
+		err = itr.Error()
+		itr.Release()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stat, nil
+}
+
+func writeReport(file string, report interface{}) error {
+	bs, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, bs, 0600)
+}
+
+type dbStatParams struct {
+	Compact bool `json:"compact"`
+}
+
+type taskDBStat struct {
+	chain   *singleChain
+	result  resultStore
+	compact bool
+	stop    chan struct{}
+
+	lock       sync.Mutex
+	compacting bool
+	stat       *DBStat
+}
+
+func (t *taskDBStat) String() string {
+	return fmt.Sprintf("DBStat(compact=%v)", t.compact)
+}
+
+func (t *taskDBStat) DetailOf(s State) string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	switch s {
+	case Started:
+		if t.compacting {
+			return "dbstat compacting"
+		}
+		return "dbstat collecting"
+	case Finished:
+		if t.stat != nil {
+			return "dbstat done " + t.stat.String()
+		}
+		return "dbstat done"
+	default:
+		return "dbstat " + s.String()
+	}
+}
+
+func (t *taskDBStat) stopped() bool {
+	select {
+	case <-t.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *taskDBStat) Start() error {
+	t.stop = make(chan struct{})
+	if t.compact {
+		t.compacting = true
+	}
+	go func() {
+		t.result.SetValue(t.doStat())
+	}()
+	return nil
+}
+
+func (t *taskDBStat) doStat() error {
+	c := t.chain
+	dbase := c.Database()
+	if t.compact {
+		c.logger.Infof("DBStat: compact database type=%s", c.cfg.DBType)
+		if err := db.Compact(dbase); err != nil {
+			return err
+		}
+		t.lock.Lock()
+		t.compacting = false
+		t.lock.Unlock()
+	}
+	stat, err := collectDBStat(dbase, sharedKeySpace(c.cfg.DBType), t.stopped)
+	if err != nil {
+		return err
+	}
+	stat.DBType = c.cfg.DBType
+	stat.Compact = t.compact
+
+	file := path.Join(c.cfg.AbsBaseDir(), DefaultDBStatFile)
+	if err := writeReport(file, stat); err != nil {
+		return err
+	}
+	c.logger.Infof("DBStat: %s (report=%s)", stat, file)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.stat = stat
+	return nil
+}
+
+func (t *taskDBStat) Stop() {
+	close(t.stop)
+}
+
+func (t *taskDBStat) Wait() error {
+	return t.result.Wait()
+}
+
+func taskDBStatFactory(c *singleChain, params json.RawMessage) (chainTask, error) {
+	var p dbStatParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+	}
+	return &taskDBStat{
+		chain:   c,
+		compact: p.Compact,
+	}, nil
+}
+
+type dbCheckParams struct {
+	Heights int64 `json:"heights"`
+}
+
+// DBCheck is the report of the consistency check of states. Missing has
+// keys of missing entries up to dbCheckMaxMissingKeys.
+type DBCheck struct {
+	From    int64    `json:"from"`
+	To      int64    `json:"to"`
+	Count   int      `json:"count"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+type taskDBCheck struct {
+	chain   *singleChain
+	result  resultStore
+	heights int64
+	stop    chan struct{}
+
+	lock       sync.Mutex
+	height     int64
+	resolved   int
+	unresolved int
+	missing    map[string]struct{}
+	report     DBCheck
+}
+
+func (t *taskDBCheck) String() string {
+	return fmt.Sprintf("DBCheck(heights=%d)", t.heights)
+}
+
+func (t *taskDBCheck) DetailOf(s State) string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	switch s {
+	case Started:
+		return fmt.Sprintf("dbcheck height=%d resolved=%d unresolved=%d missing=%d",
+			t.height, t.resolved, t.unresolved, t.report.Count)
+	case Finished, Failed:
+		return fmt.Sprintf("dbcheck %s from=%d to=%d missing=%d",
+			s.String(), t.report.From, t.report.To, t.report.Count)
+	default:
+		return "dbcheck " + s.String()
+	}
+}
+
+func (t *taskDBCheck) onProgress(height int64, resolved, unresolved int) error {
+	select {
+	case <-t.stop:
+		return errors.ErrInterrupted
+	default:
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.height, t.resolved, t.unresolved = height, resolved, unresolved
+	return nil
+}
+
+func (t *taskDBCheck) onMissing(key []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	// missing entries are not marked, so they can be reported again for
+	// other blocks.
+	if _, ok := t.missing[string(key)]; ok {
+		return nil
+	}
+	t.missing[string(key)] = struct{}{}
+	t.report.Count += 1
+	if len(t.report.Missing) < dbCheckMaxMissingKeys {
+		t.report.Missing = append(t.report.Missing, hex.EncodeToString(key))
+	}
+	return nil
+}
+
+func (t *taskDBCheck) Start() error {
+	if err := t.chain.prepareManagers(); err != nil {
+		return err
+	}
+	if _, ok := t.chain.sm.(stateExporter); !ok {
+		t.chain.releaseManagers()
+		return errors.UnsupportedError.New("StateExportNotSupported")
+	}
+	t.stop = make(chan struct{})
+	go func() {
+		t.result.SetValue(t.doCheck())
+	}()
+	return nil
+}
+
+// check walks the state of the block and reports missing entries. Entries
+// already visited for other blocks are skipped with their descendants.
+func (t *taskDBCheck) check(marks *markDB, height int64) error {
+	c := t.chain
+	blk, err := c.bm.GetBlockByHeight(height)
+	if err != nil {
+		return err
+	}
+	ctx := merkle.NewCopyContext(c.Database(), marks)
+	ctx.SetProgressCallback(t.onProgress)
+	ctx.SetMissingCallback(t.onMissing)
+	ctx.SetHeight(height)
+	ex := c.sm.(stateExporter)
+	return ex.ExportState(blk.Result(), blk.NextValidatorsHash(), ctx.TargetDB())
+}
+
+func (t *taskDBCheck) doCheck() (rerr error) {
+	c := t.chain
+	defer c.releaseManagers()
+
+	last, err := c.bm.GetLastBlock()
+	if err != nil {
+		return err
+	}
+	from := last.Height() - t.heights + 1
+	if gh := c.GenesisStorage().Height(); from < gh {
+		from = gh
+	}
+	t.lock.Lock()
+	t.report.From, t.report.To = from, last.Height()
+	t.lock.Unlock()
+
+	dir := path.Join(c.cfg.AbsBaseDir(), dbCheckTmpDir)
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+	marks, err := c.openDatabase(dir, c.cfg.DBType)
+	if err != nil {
+		return err
+	}
+	mdb := &markDB{src: c.Database(), marks: marks}
+	defer mdb.Close()
+
+	c.logger.Infof("DBCheck: check states from=%d to=%d", from, last.Height())
+	for h := from; h <= last.Height(); h++ {
+		if err := t.check(mdb, h); err != nil {
+			return err
+		}
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	file := path.Join(c.cfg.AbsBaseDir(), DefaultDBCheckFile)
+	if err := writeReport(file, &t.report); err != nil {
+		return err
+	}
+	c.logger.Infof("DBCheck: missing=%d (report=%s)", t.report.Count, file)
+	if t.report.Count > 0 {
+		return errors.NotFoundError.Errorf("MissingEntries(count=%d)", t.report.Count)
+	}
+	return nil
+}
+
+func (t *taskDBCheck) Stop() {
+	close(t.stop)
+}
+
+func (t *taskDBCheck) Wait() error {
+	return t.result.Wait()
+}
+
+func taskDBCheckFactory(c *singleChain, params json.RawMessage) (chainTask, error) {
+	var p dbCheckParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+	}
+	if p.Heights < 0 {
+		return nil, errors.IllegalArgumentError.Errorf(
+			"InvalidParameter(heights=%d)", p.Heights)
+	}
+	if p.Heights == 0 {
+		p.Heights = 1
+	}
+	return &taskDBCheck{
+		chain:   c,
+		heights: p.Heights,
+		missing: make(map[string]struct{}),
+	}, nil
+}
+
+func init() {
+	registerTaskFactory("dbstat", taskDBStatFactory)
+	registerTaskFactory("dbcheck", taskDBCheckFactory)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/db"
+)
+
+func TestCollectDBStat(t *testing.T) {
+	dir := t.TempDir()
+	for _, dbtype := range []db.BackendType{db.MapDBBackend, db.GoLevelDBBackend, db.PebbleDBBackend} {
+		t.Run(string(dbtype), func(t *testing.T) {
+			dbase, err := db.Open(dir, string(dbtype), "stat")
+			assert.NoError(t, err)
+			defer dbase.Close()
+
+			node := []byte("node")
+			batch := db.NewBatch(dbase)
+			assert.NoError(t, batch.Set(db.MerkleTrie, crypto.SHA3Sum256(node), node))
+			assert.NoError(t, batch.Set(db.BytesByHash, []byte("k1"), []byte("v1")))
+			assert.NoError(t, batch.Set(db.BytesByHash, []byte("k2"), []byte("v2")))
+			assert.NoError(t, batch.Set(db.ChainProperty, []byte("k3"), []byte("v3")))
+			assert.NoError(t, batch.Commit())
+
+			stat, err := collectDBStat(dbase, sharedKeySpace(string(dbtype)), func() bool { return false })
+			assert.NoError(t, err)
+			assert.EqualValues(t, 4, stat.Keys)
+			assert.EqualValues(t, 1, stat.Buckets["merkle"].Keys)
+			assert.EqualValues(t, 2, stat.Buckets["bytes"].Keys)
+			assert.EqualValues(t, 1, stat.Buckets["chainProperty"].Keys)
+			assert.Nil(t, stat.Buckets[dbBucketOthers])
+		})
+	}
+}
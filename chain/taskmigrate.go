@@ -0,0 +1,228 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync/atomic"
+
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
+)
+
+const (
+	migrateBatchEntries = 4096
+	migrateBatchBytes   = 16 * 1024 * 1024
+)
+
+// sharedKeySpace returns whether the backend keeps all buckets in one key
+// space with bucket prefixes. Entries of all buckets in such database can
+// be iterated with db.NewRawIterator.
+func sharedKeySpace(dbtype string) bool {
+	switch db.BackendType(dbtype) {
+	case db.GoLevelDBBackend, db.PebbleDBBackend:
+		return true
+	default:
+		return false
+	}
+}
+
+// copyDatabase copies all entries of src to dst. Both should share one key
+// space for all buckets, so entries are copied as they are.
+func copyDatabase(dst, src db.Database, onProgress func(keys, bytes int64) error) error {
+	itr, err := db.NewRawIterator(src)
+	if err != nil {
+		return err
+	}
+	defer itr.Release()
+
+	batch := db.NewBatch(dst)
+	var keys, bytes, pending int64
+	for itr.Next() {
+		key, value := itr.Key(), itr.Value()
+		if err := batch.Set(db.MerkleTrie, key, value); err != nil {
+			return err
+		}
+		keys += 1
+		bytes += int64(len(key) + len(value))
+		pending += int64(len(key) + len(value))
+		if batch.Len() >= migrateBatchEntries || pending >= migrateBatchBytes {
+			if err := batch.Commit(); err != nil {
+				return err
+			}
+			pending = 0
+			if err := onProgress(keys, bytes); err != nil {
+				return err
+			}
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+	return onProgress(keys, bytes)
+}
+
+type migrateParams struct {
+	DBType string `json:"dbType"`
+}
+
+type taskMigrate struct {
+	chain  *singleChain
+	result resultStore
+	dbtype string
+
+	stopped int32
+	keys    int64
+	bytes   int64
+}
+
+func (t *taskMigrate) String() string {
+	return fmt.Sprintf("Migrate(dbType=%s)", t.dbtype)
+}
+
+func (t *taskMigrate) DetailOf(s State) string {
+	switch s {
+	case Started:
+		return fmt.Sprintf("migrate keys=%d bytes=%d",
+			atomic.LoadInt64(&t.keys), atomic.LoadInt64(&t.bytes))
+	case Finished:
+		return fmt.Sprintf("migrate done keys=%d bytes=%d",
+			atomic.LoadInt64(&t.keys), atomic.LoadInt64(&t.bytes))
+	default:
+		return "migrate " + s.String()
+	}
+}
+
+func (t *taskMigrate) onProgress(keys, bytes int64) error {
+	if atomic.LoadInt32(&t.stopped) != 0 {
+		return errors.ErrInterrupted
+	}
+	atomic.StoreInt64(&t.keys, keys)
+	atomic.StoreInt64(&t.bytes, bytes)
+	return nil
+}
+
+func (t *taskMigrate) Start() error {
+	go func() {
+		t.result.SetValue(t.doMigrate())
+	}()
+	return nil
+}
+
+func (t *taskMigrate) _copyDatabase(dbpath string) (rerr error) {
+	os.RemoveAll(dbpath)
+	dbase, err := t.chain.openDatabase(dbpath, t.dbtype)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		dbase.Close()
+		if rerr != nil {
+			os.RemoveAll(dbpath)
+		}
+	}()
+	return copyDatabase(dbase, t.chain.Database(), t.onProgress)
+}
+
+func (t *taskMigrate) doMigrate() (rerr error) {
+	c := t.chain
+	chainDir := c.cfg.AbsBaseDir()
+	dbpath := path.Join(chainDir, DefaultTmpDBDir)
+
+	c.logger.Infof("Migrate Database path=%s type=%s->%s",
+		dbpath, c.cfg.DBType, t.dbtype)
+	if err := t._copyDatabase(dbpath); err != nil {
+		return err
+	}
+	defer func() {
+		if rerr != nil {
+			os.RemoveAll(dbpath)
+		}
+	}()
+
+	c.releaseDatabase()
+	defer c.ensureDatabase()
+
+	target := path.Join(chainDir, DefaultDBDir)
+	dbbk := target + ".bk"
+
+	c.logger.Infof("Replace DB %s -> %s", dbpath, target)
+	os.RemoveAll(dbbk)
+	if err := os.Rename(target, dbbk); err != nil {
+		return errors.UnknownError.Errorf("fail on backup %s to %s",
+			target, dbbk)
+	}
+	defer func() {
+		if rerr != nil {
+			os.RemoveAll(target)
+			os.Rename(dbbk, target)
+		} else {
+			os.RemoveAll(dbbk)
+		}
+	}()
+	if err := os.Rename(dbpath, target); err != nil {
+		return errors.UnknownError.Errorf("fail on rename %s to %s",
+			dbpath, target)
+	}
+
+	c.logger.Infof("Reopen DB %s type=%s", chainDir, t.dbtype)
+	dbtype := c.cfg.DBType
+	c.cfg.DBType = t.dbtype
+	if err := c.cfg.Save(); err != nil {
+		c.cfg.DBType = dbtype
+		return errors.UnknownError.Wrap(err, "fail to store configuration")
+	}
+	return nil
+}
+
+func (t *taskMigrate) Stop() {
+	atomic.StoreInt32(&t.stopped, 1)
+}
+
+func (t *taskMigrate) Wait() error {
+	return t.result.Wait()
+}
+
+func taskMigrateFactory(c *singleChain, params json.RawMessage) (chainTask, error) {
+	var p migrateParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+	}
+	if len(p.DBType) == 0 {
+		p.DBType = string(db.PebbleDBBackend)
+	}
+	if !sharedKeySpace(c.cfg.DBType) || !sharedKeySpace(p.DBType) || c.cfg.DBType == p.DBType {
+		return nil, errors.IllegalArgumentError.Errorf(
+			"InvalidDBType(from=%s,to=%s)", c.cfg.DBType, p.DBType)
+	}
+	return &taskMigrate{
+		chain:  c,
+		dbtype: p.DBType,
+	}, nil
+}
+
+func init() {
+	registerTaskFactory("migrate", taskMigrateFactory)
+}
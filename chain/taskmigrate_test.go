@@ -0,0 +1,62 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/db"
+)
+
+func TestCopyDatabase(t *testing.T) {
+	dir := t.TempDir()
+	src, err := db.Open(dir, string(db.GoLevelDBBackend), "src")
+	assert.NoError(t, err)
+	defer src.Close()
+	dst, err := db.Open(dir, string(db.PebbleDBBackend), "dst")
+	assert.NoError(t, err)
+	defer dst.Close()
+
+	buckets := []db.BucketID{db.MerkleTrie, db.BytesByHash, db.ChainProperty}
+	const count = migrateBatchEntries + 10
+	batch := db.NewBatch(src)
+	for i := 0; i < count; i++ {
+		id := buckets[i%len(buckets)]
+		key := []byte(fmt.Sprintf("key%06d", i))
+		assert.NoError(t, batch.Set(id, key, key))
+	}
+	assert.NoError(t, batch.Commit())
+
+	var keys int64
+	err = copyDatabase(dst, src, func(k, b int64) error {
+		keys = k
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, count, keys)
+
+	for i := 0; i < count; i++ {
+		id := buckets[i%len(buckets)]
+		key := []byte(fmt.Sprintf("key%06d", i))
+		value, err := db.DoGetWithBucketID(dst, id, key)
+		assert.NoError(t, err)
+		assert.Equal(t, key, value)
+	}
+}
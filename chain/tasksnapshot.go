@@ -0,0 +1,585 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/icon-project/goloop/block"
+	"github.com/icon-project/goloop/chain/gs"
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/merkle"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
+)
+
+const (
+	ExportSnapshotTask = "export_snapshot"
+	ImportSnapshotTask = "import_snapshot"
+
+	DefaultSnapshotDir = "snapshot"
+	snapshotTmpDir     = "tmp-snapshot"
+	chainGenesisFile   = "genesis.zip"
+)
+
+type exportSnapshotParams struct {
+	Height int64  `json:"height"`
+	Dir    string `json:"dir"`
+}
+
+type taskExportSnapshot struct {
+	chain  *singleChain
+	result resultStore
+	height int64
+	dir    string
+
+	stopped    int32
+	current    int64
+	resolved   int64
+	unresolved int64
+}
+
+func (t *taskExportSnapshot) String() string {
+	return fmt.Sprintf("ExportSnapshot(height=%d,dir=%s)", t.height, t.dir)
+}
+
+func (t *taskExportSnapshot) DetailOf(s State) string {
+	switch s {
+	case Started:
+		return fmt.Sprintf("export snapshot height=%d resolved=%d unresolved=%d",
+			atomic.LoadInt64(&t.current), atomic.LoadInt64(&t.resolved),
+			atomic.LoadInt64(&t.unresolved))
+	default:
+		return "export snapshot " + s.String()
+	}
+}
+
+func (t *taskExportSnapshot) onProgress(height int64, r, u int) error {
+	if atomic.LoadInt32(&t.stopped) != 0 {
+		return errors.ErrInterrupted
+	}
+	atomic.StoreInt64(&t.current, height)
+	atomic.StoreInt64(&t.resolved, int64(r))
+	atomic.StoreInt64(&t.unresolved, int64(u))
+	return nil
+}
+
+func (t *taskExportSnapshot) Start() error {
+	if err := t.chain.prepareManagers(); err != nil {
+		return err
+	}
+	blk, err := t.chain.bm.GetLastBlock()
+	if err != nil {
+		t.chain.releaseManagers()
+		return err
+	}
+	// votes for the block are in the next block.
+	if t.height == 0 {
+		t.height = blk.Height() - 1
+	}
+	if t.height < t.chain.GenesisStorage().Height() || t.height >= blk.Height() {
+		t.chain.releaseManagers()
+		return errors.IllegalArgumentError.Errorf(
+			"InvalidHeight(height=%d,last=%d)", t.height, blk.Height())
+	}
+	go func() {
+		t.result.SetValue(t.doExport())
+	}()
+	return nil
+}
+
+func (t *taskExportSnapshot) doExport() (rerr error) {
+	c := t.chain
+	defer c.releaseManagers()
+
+	blk, err := c.bm.GetBlockByHeight(t.height)
+	if err != nil {
+		return err
+	}
+	w, err := newSnapshotWriter(t.dir)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Infof("ExportSnapshot: export genesis height=%d", t.height)
+	buf := bytes.NewBuffer(nil)
+	gsw := gs.NewGenesisStorageWriter(buf)
+	if err := c.bm.ExportGenesis(blk, nil, gsw); err != nil {
+		return errors.Wrap(err, "fail on exporting genesis storage")
+	}
+	if err := gsw.Close(); err != nil {
+		return err
+	}
+	genesis, err := w.writeChunk(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	tmp := path.Join(c.cfg.AbsBaseDir(), snapshotTmpDir)
+	os.RemoveAll(tmp)
+	defer os.RemoveAll(tmp)
+	marks, err := c.openDatabase(tmp, c.cfg.DBType)
+	if err != nil {
+		return err
+	}
+	sdb := &snapshotDB{
+		markDB: &markDB{src: c.Database(), marks: marks},
+		w:      w,
+	}
+	defer sdb.Close()
+
+	c.logger.Infof("ExportSnapshot: export blocks dir=%s", t.dir)
+	if err := c.bm.ExportBlocks(t.height, t.height, sdb, t.onProgress); err != nil {
+		return err
+	}
+	m := &SnapshotManifest{
+		Version: SnapshotVersion,
+		CID:     common.HexInt32{Value: int32(c.CID())},
+		NID:     common.HexInt32{Value: int32(c.NID())},
+		Height:  common.HexInt64{Value: t.height},
+		BlockID: blk.ID(),
+		Genesis: genesis,
+	}
+	if err := w.writeManifest(m); err != nil {
+		return err
+	}
+	c.logger.Infof("ExportSnapshot: done height=%d chunks=%d", t.height, len(m.Chunks))
+	return nil
+}
+
+func (t *taskExportSnapshot) Stop() {
+	atomic.StoreInt32(&t.stopped, 1)
+}
+
+func (t *taskExportSnapshot) Wait() error {
+	return t.result.Wait()
+}
+
+func taskExportSnapshotFactory(c *singleChain, params json.RawMessage) (chainTask, error) {
+	var p exportSnapshotParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+	}
+	if p.Height < 0 {
+		return nil, errors.IllegalArgumentError.Errorf(
+			"InvalidParameter(height=%d)", p.Height)
+	}
+	if len(p.Dir) == 0 {
+		p.Dir = DefaultSnapshotDir
+	}
+	if !filepath.IsAbs(p.Dir) {
+		p.Dir = path.Join(c.cfg.AbsBaseDir(), p.Dir)
+	}
+	return &taskExportSnapshot{
+		chain:  c,
+		height: p.Height,
+		dir:    p.Dir,
+	}, nil
+}
+
+type importSnapshotParams struct {
+	Source    string          `json:"source"`
+	BlockHash common.HexBytes `json:"blockHash"`
+}
+
+type taskImportSnapshot struct {
+	chain     *singleChain
+	result    resultStore
+	source    string
+	blockHash []byte
+
+	stopped int32
+	current int64
+	chunks  int64
+}
+
+func (t *taskImportSnapshot) String() string {
+	return fmt.Sprintf("ImportSnapshot(source=%s,blockHash=%#x)", t.source, t.blockHash)
+}
+
+func (t *taskImportSnapshot) DetailOf(s State) string {
+	switch s {
+	case Started:
+		return fmt.Sprintf("import snapshot %d/%d",
+			atomic.LoadInt64(&t.current), atomic.LoadInt64(&t.chunks))
+	default:
+		return "import snapshot " + s.String()
+	}
+}
+
+func (t *taskImportSnapshot) Start() error {
+	go func() {
+		t.result.SetValue(t.doImport())
+	}()
+	return nil
+}
+
+// _importChunks writes entries of chunks to the database at dbpath after
+// checking them, then verifies the database with the trusted block.
+func (t *taskImportSnapshot) _importChunks(src snapshotSource, m *SnapshotManifest, votes module.CommitVoteSet, dbpath string) (rerr error) {
+	c := t.chain
+	os.RemoveAll(dbpath)
+	dbase, err := c.openDatabase(dbpath, c.cfg.DBType)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		dbase.Close()
+		if rerr != nil {
+			os.RemoveAll(dbpath)
+		}
+	}()
+
+	atomic.StoreInt64(&t.chunks, int64(len(m.Chunks)))
+	for i, chunk := range m.Chunks {
+		if atomic.LoadInt32(&t.stopped) != 0 {
+			return errors.ErrInterrupted
+		}
+		bs, err := readSnapshotChunk(src, chunk)
+		if err != nil {
+			return err
+		}
+		entries, err := decodeSnapshotChunk(bs)
+		if err != nil {
+			return err
+		}
+		batch := db.NewBatch(dbase)
+		for j := range entries {
+			e := &entries[j]
+			if ok, err := checkSnapshotEntry(e, m.Height.Value); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			if err := batch.Set(db.BucketID(e.ID), e.Key, e.Value); err != nil {
+				return err
+			}
+		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+		atomic.StoreInt64(&t.current, int64(i+1))
+	}
+
+	blks, err := t._verifyBlocks(dbase, m, votes)
+	if err != nil {
+		return err
+	}
+	if err := t._verifyLocators(dbase, blks); err != nil {
+		return err
+	}
+	if err := t._verifyStates(dbase, blks); err != nil {
+		return err
+	}
+	return block.SetLastHeight(dbase, nil, m.Height.Value)
+}
+
+// _verifyBlocks verifies the block of the snapshot with the trusted block
+// hash and its previous blocks linked by their headers. Votes for the block
+// and the previous block are verified with their validators. It returns
+// blocks from the block of the snapshot.
+func (t *taskImportSnapshot) _verifyBlocks(dbase db.Database, m *SnapshotManifest, votes module.CommitVoteSet) ([]*snapshotBlock, error) {
+	height := m.Height.Value
+	blk, err := readSnapshotBlock(dbase, t.blockHash, height)
+	if err != nil {
+		return nil, err
+	}
+	blks := []*snapshotBlock{blk}
+	for i := 0; i < snapshotPrevBlocks && len(blk.header.PrevID) > 0; i++ {
+		blk, err = readSnapshotBlock(dbase, blk.header.PrevID, blk.Height()-1)
+		if err != nil {
+			return nil, err
+		}
+		blks = append(blks, blk)
+	}
+
+	// votes of the block are in the genesis, and votes of the previous
+	// block are in the block.
+	for i := 0; i < len(blks) && i < snapshotPrevBlocks; i++ {
+		if i > 0 {
+			bs, err := db.DoGetWithBucketID(dbase, db.BytesByHash, blks[i-1].header.VotesHash)
+			if err != nil {
+				return nil, errors.InvalidStateError.Wrapf(err,
+					"NoVotes(height=%d)", blks[i].Height())
+			}
+			votes = t.chain.CommitVoteSetDecoder()(bs)
+		}
+		var validators module.ValidatorList
+		if i+1 < len(blks) {
+			validators, err = state.ValidatorSnapshotFromHash(dbase, blks[i+1].header.NextValidatorsHash)
+			if err != nil {
+				return nil, errors.InvalidStateError.Wrapf(err,
+					"NoValidators(height=%d)", blks[i+1].Height())
+			}
+		}
+		if votes == nil {
+			return nil, errors.InvalidStateError.Errorf(
+				"InvalidVotes(height=%d)", blks[i].Height())
+		}
+		if _, err := votes.VerifyBlock(blks[i], validators); err != nil {
+			return nil, errors.InvalidStateError.Wrapf(err,
+				"InvalidVotes(height=%d)", blks[i].Height())
+		}
+	}
+	return blks, nil
+}
+
+// _verifyLocators verifies transaction locators with transactions of the
+// blocks.
+func (t *taskImportSnapshot) _verifyLocators(dbase db.Database, blks []*snapshotBlock) error {
+	bk, err := dbase.GetBucket(db.TransactionLocatorByHash)
+	if err != nil {
+		return err
+	}
+	itr, err := db.NewIterator(bk, nil)
+	if err != nil {
+		return err
+	}
+	defer itr.Release()
+	for itr.Next() {
+		var loc module.TransactionLocator
+		if _, err := codec.BC.UnmarshalFromBytes(itr.Value(), &loc); err != nil {
+			return errors.InvalidStateError.Wrapf(err,
+				"InvalidLocator(tx=%x)", itr.Key())
+		}
+		idx := blks[0].Height() - loc.BlockHeight
+		if idx < 0 || idx >= int64(len(blks)) {
+			return errors.InvalidStateError.Errorf(
+				"InvalidLocator(tx=%x,height=%d)", itr.Key(), loc.BlockHeight)
+		}
+		hash := blks[idx].header.NormalTransactionsHash
+		if loc.TransactionGroup == module.TransactionGroupPatch {
+			hash = blks[idx].header.PatchTransactionsHash
+		}
+		tx, err := transaction.NewTransactionListFromHash(dbase, hash).Get(loc.IndexInGroup)
+		if err != nil || !bytes.Equal(tx.ID(), itr.Key()) {
+			return errors.InvalidStateError.Errorf(
+				"InvalidLocator(tx=%x,height=%d)", itr.Key(), loc.BlockHeight)
+		}
+	}
+	return itr.Error()
+}
+
+// _verifyStates walks results and transactions of the blocks to confirm
+// that the database has all the entries for them. Keys of visited entries
+// are marked in a temporary database, so shared entries are visited once.
+func (t *taskImportSnapshot) _verifyStates(dbase db.Database, blks []*snapshotBlock) error {
+	c := t.chain
+	tmp := path.Join(c.cfg.AbsBaseDir(), snapshotTmpDir)
+	os.RemoveAll(tmp)
+	defer os.RemoveAll(tmp)
+	marks, err := c.openDatabase(tmp, c.cfg.DBType)
+	if err != nil {
+		return err
+	}
+	mdb := &markDB{src: dbase, marks: marks}
+	defer mdb.Close()
+
+	for _, blk := range blks {
+		c.logger.Infof("ImportSnapshot: verify states height=%d", blk.Height())
+		ctx := merkle.NewCopyContext(dbase, mdb)
+		ctx.SetProgressCallback(t.onProgress)
+		ctx.SetHeight(blk.Height())
+		transaction.NewTransactionListWithBuilder(ctx.Builder(), blk.header.PatchTransactionsHash)
+		transaction.NewTransactionListWithBuilder(ctx.Builder(), blk.header.NormalTransactionsHash)
+		if err := ctx.Run(); err != nil {
+			return err
+		}
+		err := service.ExportResult(dbase, c.plt, blk.header.Result,
+			blk.header.NextValidatorsHash, ctx.TargetDB())
+		if err != nil {
+			return errors.InvalidStateError.Wrapf(err,
+				"IncompleteState(height=%d)", blk.Height())
+		}
+	}
+	return nil
+}
+
+func (t *taskImportSnapshot) onProgress(height int64, r, u int) error {
+	if atomic.LoadInt32(&t.stopped) != 0 {
+		return errors.ErrInterrupted
+	}
+	return nil
+}
+
+// _votesOf returns votes for the trusted block in the pruned genesis.
+func (t *taskImportSnapshot) _votesOf(g module.GenesisStorage) (module.CommitVoteSet, error) {
+	if gt, err := g.Type(); err != nil || gt != module.GenesisPruned {
+		return nil, errors.IllegalArgumentError.Errorf(
+			"InvalidGenesisType(type=%v,err=%v)", gt, err)
+	}
+	pg := new(gs.PrunedGenesis)
+	if err := json.Unmarshal(g.Genesis(), pg); err != nil {
+		return nil, errors.IllegalArgumentError.Wrap(err, "InvalidGenesis")
+	}
+	if !bytes.Equal(pg.Block, t.blockHash) || pg.Height.Value != g.Height() {
+		return nil, errors.InvalidStateError.Errorf(
+			"InvalidGenesis(block=%s,height=%d)", pg.Block, pg.Height.Value)
+	}
+	bs, err := g.Get(pg.Votes)
+	if err != nil {
+		return nil, errors.IllegalArgumentError.Wrapf(err,
+			"NoVotes(hash=%s)", pg.Votes)
+	}
+	return t.chain.CommitVoteSetDecoder()(bs), nil
+}
+
+func (t *taskImportSnapshot) doImport() (rerr error) {
+	c := t.chain
+	chainDir := c.cfg.AbsBaseDir()
+	src := newSnapshotSource(t.source)
+
+	m, err := readSnapshotManifest(src)
+	if err != nil {
+		return err
+	}
+	if int(m.CID.Value) != c.CID() || int(m.NID.Value) != c.NID() {
+		return errors.InvalidStateError.Errorf(
+			"InvalidSnapshot(cid=%#x,nid=%#x)", m.CID.Value, m.NID.Value)
+	}
+	if !bytes.Equal(m.BlockID, t.blockHash) {
+		return errors.InvalidStateError.Errorf(
+			"InvalidSnapshot(blockId=%s,trusted=%#x)", m.BlockID, t.blockHash)
+	}
+	gsbs, err := readSnapshotChunk(src, m.Genesis)
+	if err != nil {
+		return err
+	}
+	g, err := gs.New(gsbs)
+	if err != nil {
+		return errors.IllegalArgumentError.Wrap(err, "InvalidGenesisStorage")
+	}
+	if g.Height() != m.Height.Value {
+		return errors.InvalidStateError.Errorf(
+			"InvalidGenesisHeight(exp=%d,real=%d)", m.Height.Value, g.Height())
+	}
+	votes, err := t._votesOf(g)
+	if err != nil {
+		return err
+	}
+
+	dbpath := path.Join(chainDir, DefaultTmpDBDir)
+	c.logger.Infof("ImportSnapshot: import chunks source=%s height=%d chunks=%d",
+		t.source, m.Height.Value, len(m.Chunks))
+	if err := t._importChunks(src, m, votes, dbpath); err != nil {
+		return err
+	}
+	defer func() {
+		if rerr != nil {
+			os.RemoveAll(dbpath)
+		}
+	}()
+
+	c.releaseDatabase()
+	defer c.ensureDatabase()
+
+	target := path.Join(chainDir, DefaultDBDir)
+	dbbk := target + ".bk"
+	gsfile := path.Join(chainDir, chainGenesisFile)
+	gsbk := gsfile + ".bk"
+
+	c.logger.Infof("Replace DB %s -> %s", dbpath, target)
+	os.RemoveAll(dbbk)
+	if err := os.Rename(target, dbbk); err != nil {
+		return errors.UnknownError.Errorf("fail on backup %s to %s",
+			target, dbbk)
+	}
+	defer func() {
+		if rerr != nil {
+			os.RemoveAll(target)
+			os.Rename(dbbk, target)
+		} else {
+			os.RemoveAll(dbbk)
+		}
+	}()
+	if err := os.Rename(dbpath, target); err != nil {
+		return errors.UnknownError.Errorf("fail on rename %s to %s",
+			dbpath, target)
+	}
+
+	c.logger.Infof("Replace GS %s", gsfile)
+	os.RemoveAll(gsbk)
+	if err := os.Rename(gsfile, gsbk); err != nil && !os.IsNotExist(err) {
+		return errors.UnknownError.Errorf("fail on backup %s to %s",
+			gsfile, gsbk)
+	}
+	defer func() {
+		if rerr != nil {
+			os.RemoveAll(gsfile)
+			os.Rename(gsbk, gsfile)
+		} else {
+			os.RemoveAll(gsbk)
+		}
+	}()
+	if err := os.WriteFile(gsfile, gsbs, 0644); err != nil {
+		return errors.UnknownError.Wrapf(err, "fail to write %s", gsfile)
+	}
+
+	c.logger.Infof("Reopen DB %s", chainDir)
+	c.cfg.GenesisStorage = g
+	c.cfg.Genesis = g.Genesis()
+	if err := c.cfg.Save(); err != nil {
+		return errors.UnknownError.Wrap(err, "fail to store configuration")
+	}
+	return nil
+}
+
+func (t *taskImportSnapshot) Stop() {
+	atomic.StoreInt32(&t.stopped, 1)
+}
+
+func (t *taskImportSnapshot) Wait() error {
+	return t.result.Wait()
+}
+
+func taskImportSnapshotFactory(c *singleChain, params json.RawMessage) (chainTask, error) {
+	var p importSnapshotParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Source) == 0 {
+		return nil, errors.IllegalArgumentError.New("NoSource")
+	}
+	if len(p.BlockHash) != crypto.HashLen {
+		return nil, errors.IllegalArgumentError.Errorf(
+			"InvalidBlockHash(hash=%#x)", p.BlockHash)
+	}
+	return &taskImportSnapshot{
+		chain:     c,
+		source:    p.Source,
+		blockHash: p.BlockHash,
+	}, nil
+}
+
+func init() {
+	registerTaskFactory(ExportSnapshotTask, taskExportSnapshotFactory)
+	registerTaskFactory(ImportSnapshotTask, taskImportSnapshotFactory)
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/wallet"
 	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/server"
 	"github.com/icon-project/goloop/server/jsonrpc"
@@ -240,6 +242,15 @@ func (c *ClientV3) GetBalance(param *v3.AddressParam) (*jsonrpc.HexInt, error) {
 	return &result, nil
 }
 
+func (c *ClientV3) GetTokenBalance(param *v3.TokenBalanceParam) (*jsonrpc.HexInt, error) {
+	var result jsonrpc.HexInt
+	_, err := c.Do("icx_getTokenBalance", param, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 //refer servicce/scoreapi/info.go Info.ToJSON
 func (c *ClientV3) GetScoreApi(param *v3.ScoreAddressParam) ([]interface{}, error) {
 	var result []interface{}
@@ -292,6 +303,10 @@ func (c *ClientV3) GetTransactionByHash(param *v3.TransactionHashParam) (*Transa
 var txSerializeExcludes = map[string]bool{"signature": true}
 
 func SignTransaction(w module.Wallet, param *v3.TransactionParam) error {
+	if kt := wallet.KeyTypeOf(w); kt != crypto.KeyTypeSecp256k1 {
+		param.KeyType = kt
+		param.PublicKey = jsonrpc.HexBytes("0x" + hex.EncodeToString(w.PublicKey()))
+	}
 	js, err := json.Marshal(param)
 	if err != nil {
 		return err
@@ -328,6 +343,10 @@ func (c *ClientV3) SendTransaction(w module.Wallet, param *v3.TransactionParam)
 
 func (c *ClientV3) SendRawTransaction(w module.Wallet, param map[string]interface{}) (*jsonrpc.HexBytes, error) {
 	param["timestamp"] = TimestampNow()
+	if kt := wallet.KeyTypeOf(w); kt != crypto.KeyTypeSecp256k1 {
+		param["keyType"] = kt
+		param["publicKey"] = "0x" + hex.EncodeToString(w.PublicKey())
+	}
 	bs, err := transaction.SerializeMap(param, nil, txSerializeExcludes)
 	if err != nil {
 		return nil, err
@@ -10,6 +10,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gosuri/uitable"
 	"github.com/jroimartin/gocui"
@@ -21,6 +22,7 @@ import (
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/db"
 	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/network"
 	"github.com/icon-project/goloop/node"
 )
 
@@ -125,10 +127,12 @@ func NewChainCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comman
 			param.DBType, _ = fs.GetString("db_type")
 			param.Platform, _ = fs.GetString("platform")
 			param.ConcurrencyLevel, _ = fs.GetInt("concurrency")
+			param.OptimisticExec, _ = fs.GetBool("optimistic_execution")
 			param.NormalTxPoolSize, _ = fs.GetInt("normal_tx_pool")
 			param.PatchTxPoolSize, _ = fs.GetInt("patch_tx_pool")
 			param.MaxBlockTxBytes, _ = fs.GetInt("max_block_tx_bytes")
 			param.NodeCache, _ = fs.GetString("node_cache")
+			param.NodeCacheMemory, _ = fs.GetInt("node_cache_memory")
 			param.Channel, _ = fs.GetString("channel")
 			param.SecureSuites, _ = fs.GetString("secure_suites")
 			param.SecureAeads, _ = fs.GetString("secure_aeads")
@@ -145,6 +149,14 @@ func NewChainCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comman
 				param.NephewsLimit = &nephewsLimit
 			}
 			param.ValidateTxOnSend, _ = fs.GetBool("validate_tx_on_send")
+			param.SkipEmptyBlock, _ = fs.GetBool("skip_empty_block")
+			param.MaxIdleInterval, _ = fs.GetInt64("max_idle_interval")
+			param.MinBlockInterval, _ = fs.GetInt64("min_block_interval")
+			param.MaxBlockInterval, _ = fs.GetInt64("max_block_interval")
+			param.NetworkCapture, _ = fs.GetBool("network_capture")
+			param.PruneRetention, _ = fs.GetInt64("prune_retention")
+			param.PruneCheckpoint, _ = fs.GetInt64("prune_checkpoint")
+			param.Archive, _ = fs.GetBool("archive")
 
 			var buf *bytes.Buffer
 			if len(genesisZip) > 0 {
@@ -186,10 +198,12 @@ func NewChainCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comman
 	joinFlags.String("db_type", "goleveldb", "Name of database system("+strings.Join(db.RegisteredBackendTypes(), ", ")+")")
 	joinFlags.String("platform", "", "Name of service platform")
 	joinFlags.Int("concurrency", 1, "Maximum number of executors to be used for concurrency")
+	joinFlags.Bool("optimistic_execution", false, "Execute transactions optimistically with concurrency, then re-execute conflicting ones")
 	joinFlags.Int("normal_tx_pool", 0, "Size of normal transaction pool")
 	joinFlags.Int("patch_tx_pool", 0, "Size of patch transaction pool")
 	joinFlags.Int("max_block_tx_bytes", 0, "Max size of transactions in a block")
 	joinFlags.String("node_cache", chain.NodeCacheDefault, "Node cache (none,small,large)")
+	joinFlags.Int("node_cache_memory", 0, "Memory budget of trie node and API info caches in MB (0: unlimited)")
 	joinFlags.String("channel", "", "Channel")
 	joinFlags.String("secure_suites", "none,tls,ecdhe",
 		"Supported Secure suites with order (none,tls,ecdhe) - Comma separated string")
@@ -202,6 +216,14 @@ func NewChainCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comman
 	joinFlags.Int("children_limit", -1, "Maximum number of child connections (-1: uses system default value)")
 	joinFlags.Int("nephews_limit", -1, "Maximum number of nephew connections (-1: uses system default value)")
 	joinFlags.Bool("validate_tx_on_send", false, "Validate transaction on send")
+	joinFlags.Bool("skip_empty_block", false, "Skip empty blocks while there is no transaction")
+	joinFlags.Int64("max_idle_interval", 0, "Max interval between blocks in milli-second while skipping empty blocks (0: uses system default value)")
+	joinFlags.Int64("min_block_interval", 0, "Lower bound of adaptive block interval in milli-second")
+	joinFlags.Int64("max_block_interval", 0, "Upper bound of adaptive block interval in milli-second (0: disable)")
+	joinFlags.Bool("network_capture", false, "Record packets of the chain to capture files in the chain directory")
+	joinFlags.Int64("prune_retention", 0, "Number of recent blocks whose states are kept by online pruning (0: disable)")
+	joinFlags.Int64("prune_checkpoint", 0, "Interval of heights whose states are kept as checkpoints by online pruning (0: no checkpoint)")
+	joinFlags.Bool("archive", false, "Keep states of all blocks and index them with changesets by height")
 
 	leaveCmd := &cobra.Command{
 		Use:   "leave CID",
@@ -384,6 +406,124 @@ func NewChainCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comman
 	pruneFlags.Int64("height", 0, "Block Height")
 	MarkAnnotationRequired(pruneFlags, "height")
 
+	dbstatCmd := &cobra.Command{
+		Use:   "dbstat CID",
+		Short: "Start to collect statistics of the database",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := cmd.Flags()
+			param := &node.ChainDBStatParam{}
+			param.Compact, _ = fs.GetBool("compact")
+
+			var v string
+			reqUrl := node.UrlChain + "/" + args[0] + "/dbstat"
+			_, err := adminClient.PostWithJson(reqUrl, param, &v)
+			if err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+	rootCmd.AddCommand(dbstatCmd)
+	dbstatFlags := dbstatCmd.Flags()
+	dbstatFlags.Bool("compact", false, "Compact the database before collecting statistics")
+
+	dbcheckCmd := &cobra.Command{
+		Use:   "dbcheck CID",
+		Short: "Start to check missing entries of states in the database",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := cmd.Flags()
+			param := &node.ChainDBCheckParam{}
+			param.Heights, _ = fs.GetInt64("heights")
+
+			var v string
+			reqUrl := node.UrlChain + "/" + args[0] + "/dbcheck"
+			_, err := adminClient.PostWithJson(reqUrl, param, &v)
+			if err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+	rootCmd.AddCommand(dbcheckCmd)
+	dbcheckFlags := dbcheckCmd.Flags()
+	dbcheckFlags.Int64("heights", 1, "Number of recent blocks whose states are checked")
+
+	migrateCmd := &cobra.Command{
+		Use:   "migrate CID",
+		Short: "Start to migrate the database to another database type",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := cmd.Flags()
+			param := &node.ChainMigrateParam{}
+			param.DBType, _ = fs.GetString("db_type")
+
+			var v string
+			reqUrl := node.UrlChain + "/" + args[0] + "/migrate"
+			_, err := adminClient.PostWithJson(reqUrl, param, &v)
+			if err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+	rootCmd.AddCommand(migrateCmd)
+	migrateFlags := migrateCmd.Flags()
+	migrateFlags.String("db_type", string(db.PebbleDBBackend), "Database type to migrate to")
+
+	exportSnapshotCmd := &cobra.Command{
+		Use:   "export_snapshot CID",
+		Short: "Start to export the snapshot of the chain at the height",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := cmd.Flags()
+			param := &node.ChainExportSnapshotParam{}
+			param.Height, _ = fs.GetInt64("height")
+			param.Dir, _ = fs.GetString("dir")
+
+			var v string
+			reqUrl := node.UrlChain + "/" + args[0] + "/" + chain.ExportSnapshotTask
+			_, err := adminClient.PostWithJson(reqUrl, param, &v)
+			if err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+	rootCmd.AddCommand(exportSnapshotCmd)
+	exportSnapshotFlags := exportSnapshotCmd.Flags()
+	exportSnapshotFlags.Int64("height", 0, "Block Height (0: the previous block of the last block)")
+	exportSnapshotFlags.String("dir", "", "Directory for the snapshot on the node (default:[chain directory]/snapshot)")
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "import_snapshot CID SOURCE BLOCK_HASH",
+		Short: "Start to import the snapshot from the directory or the URL",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(3)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			blockHash, err := hex.DecodeString(strings.TrimPrefix(args[2], "0x"))
+			if err != nil {
+				return err
+			}
+			param := &node.ChainImportSnapshotParam{
+				Source:    args[1],
+				BlockHash: blockHash,
+			}
+			var v string
+			reqUrl := node.UrlChain + "/" + args[0] + "/" + chain.ImportSnapshotTask
+			_, err = adminClient.PostWithJson(reqUrl, param, &v)
+			if err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	})
+
 	backupCmd := &cobra.Command{
 		Use:   "backup CID",
 		Short: "Start to backup the channel",
@@ -391,8 +531,10 @@ func NewChainCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comman
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fs := cmd.Flags()
 			manual, _ := fs.GetBool("manual")
+			base, _ := fs.GetString("base")
 			param := &node.ChainBackupParam{
 				Manual: manual,
+				Base:   base,
 			}
 			var v string
 			reqUrl := node.UrlChain + "/" + args[0] + "/backup"
@@ -407,6 +549,102 @@ func NewChainCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comman
 	rootCmd.AddCommand(backupCmd)
 	backupFlags := backupCmd.Flags()
 	backupFlags.Bool("manual", false, "Manual backup mode (just release database)")
+	backupFlags.String("base", "", "Name of the previous backup for incremental backup")
+
+	bansCmd := &cobra.Command{
+		Use:   "bans CID",
+		Short: "List banned peers and IPs",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := adminClient.Get(node.UrlChain+"/"+args[0]+"/ban", nil)
+			if err != nil {
+				return err
+			}
+			return JsonPrettyCopyAndClose(os.Stdout, resp.Body)
+		},
+	}
+	rootCmd.AddCommand(bansCmd)
+
+	banCmd := &cobra.Command{
+		Use:   "ban CID TARGET",
+		Short: "Ban the peer ID or the IP",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := cmd.Flags()
+			param := &node.ChainBanParam{Target: args[1]}
+			d, _ := fs.GetDuration("duration")
+			param.Duration = int64(d / time.Millisecond)
+			param.Reason, _ = fs.GetString("reason")
+			var v string
+			reqUrl := node.UrlChain + "/" + args[0] + "/ban"
+			if _, err := adminClient.PostWithJson(reqUrl, param, &v); err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+	rootCmd.AddCommand(banCmd)
+	banFlags := banCmd.Flags()
+	banFlags.Duration("duration", network.DefaultBanDuration, "Duration of the ban")
+	banFlags.String("reason", "", "Reason of the ban")
+
+	unbanCmd := &cobra.Command{
+		Use:   "unban CID TARGET",
+		Short: "Remove the peer ID or the IP from the ban list",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var v string
+			reqUrl := node.UrlChain + "/" + args[0] + "/ban/" + url.PathEscape(args[1])
+			if _, err := adminClient.Delete(reqUrl, &v); err != nil {
+				return err
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+	rootCmd.AddCommand(unbanCmd)
+
+	archiveCmd := &cobra.Command{
+		Use:   "archive CID HEIGHT",
+		Short: "Get the state index and the changeset of the height from the archive node",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := new(node.ChainArchiveView)
+			reqUrl := node.UrlChain + "/" + args[0] + "/archive/" + args[1]
+			if _, err := adminClient.Get(reqUrl, v); err != nil {
+				return err
+			}
+			return JsonPrettyPrintln(os.Stdout, v)
+		},
+	}
+	rootCmd.AddCommand(archiveCmd)
+
+	historyCmd := &cobra.Command{
+		Use:   "history CID ADDRESS",
+		Short: "Get heights where the account has been changed from the archive node",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := cmd.Flags()
+			params := &url.Values{}
+			if from, _ := fs.GetInt64("from"); from > 0 {
+				params.Add("from", strconv.FormatInt(from, 10))
+			}
+			if to, _ := fs.GetInt64("to"); to > 0 {
+				params.Add("to", strconv.FormatInt(to, 10))
+			}
+			v := new(node.AccountHistoryView)
+			reqUrl := node.UrlChain + "/" + args[0] + "/history/" + url.PathEscape(args[1])
+			if _, err := adminClient.Get(reqUrl, v, params); err != nil {
+				return err
+			}
+			return JsonPrettyPrintln(os.Stdout, v)
+		},
+	}
+	rootCmd.AddCommand(historyCmd)
+	historyFlags := historyCmd.Flags()
+	historyFlags.Int64("from", 0, "Height to start (inclusive)")
+	historyFlags.Int64("to", 0, "Height to end (exclusive, 0: no limit)")
 
 	genesisCmd := &cobra.Command{
 		Use:   "genesis CID FILE",
@@ -575,6 +813,24 @@ func NewSystemCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Comma
 	}
 	rootCmd.AddCommand(configCmd)
 
+	executorsCmd := &cobra.Command{
+		Use:   "executors",
+		Short: "List executors of execution engines",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var v []*node.EngineView
+			resp, err := adminClient.Get(node.UrlSystem+"/executors", &v)
+			if err != nil {
+				return err
+			}
+			if err = JsonPrettyPrintln(os.Stdout, v); err != nil {
+				return errors.Errorf("failed JsonIntend resp=%+v, err=%+v", resp, err)
+			}
+			return nil
+		},
+	}
+	rootCmd.AddCommand(executorsCmd)
+
 	NewBackupCmd(rootCmd, &adminClient)
 	NewRestoreCmd(rootCmd, &adminClient)
 
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/network"
+)
+
+type captureRecordJSON struct {
+	Time        string          `json:"time"`
+	Direction   string          `json:"direction"`
+	Protocol    string          `json:"protocol"`
+	SubProtocol string          `json:"subProtocol"`
+	Peer        string          `json:"peer,omitempty"`
+	Src         string          `json:"src,omitempty"`
+	Dest        byte            `json:"dest"`
+	TTL         byte            `json:"ttl"`
+	Payload     common.HexBytes `json:"payload,omitempty"`
+	Size        int             `json:"size"`
+}
+
+func newCaptureRecordJSON(rec *network.CaptureRecord, withPayload bool) *captureRecordJSON {
+	v := &captureRecordJSON{
+		Time:        rec.Time().UTC().Format("2006-01-02T15:04:05.000000Z"),
+		Direction:   rec.Direction.String(),
+		Protocol:    rec.Protocol.String(),
+		SubProtocol: rec.SubProtocol.String(),
+		Dest:        rec.Dest,
+		TTL:         rec.TTL,
+		Size:        len(rec.Payload),
+	}
+	if id := rec.PeerID(); id != nil {
+		v.Peer = id.String()
+	}
+	if id := rec.SrcID(); id != nil {
+		v.Src = id.String()
+	}
+	if withPayload {
+		v.Payload = rec.Payload
+	}
+	return v
+}
+
+func newCaptureDumpCmd(c string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("%s FILE...", c),
+		Short: "Dump packets in the capture files as JSON lines",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	flags := cmd.Flags()
+	protocol := flags.String("protocol", "", "Protocol to dump in hex (ex: 0x0100, default: all)")
+	direction := flags.String("direction", "", "Direction to dump (in,out, default: all)")
+	withPayload := flags.Bool("payload", false, "Dump payload of packets")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var pi *module.ProtocolInfo
+		if len(*protocol) > 0 {
+			v, err := strconv.ParseUint(*protocol, 0, 16)
+			if err != nil {
+				return fmt.Errorf("invalid protocol %s err=%+v", *protocol, err)
+			}
+			p := module.ProtocolInfo(v)
+			pi = &p
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, arg := range args {
+			f, err := os.Open(arg)
+			if err != nil {
+				return fmt.Errorf("fail to open file=%s err=%+v", arg, err)
+			}
+			cr := network.NewCaptureReader(f)
+			for {
+				rec, err := cr.Next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					_ = f.Close()
+					return fmt.Errorf("fail to read capture file=%s err=%+v", arg, err)
+				}
+				if pi != nil && rec.Protocol != *pi {
+					continue
+				}
+				if len(*direction) > 0 && rec.Direction.String() != *direction {
+					continue
+				}
+				if err = enc.Encode(newCaptureRecordJSON(rec, *withPayload)); err != nil {
+					_ = f.Close()
+					return err
+				}
+			}
+			_ = f.Close()
+		}
+		return nil
+	}
+	return cmd
+}
+
+func NewCaptureCmd(c string) *cobra.Command {
+	cmd := &cobra.Command{Use: c, Short: "Network capture manipulation"}
+	cmd.AddCommand(newCaptureDumpCmd("dump"))
+	return cmd
+}
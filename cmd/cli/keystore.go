@@ -11,7 +11,9 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/common/wallet"
+	"github.com/icon-project/goloop/module"
 )
 
 func readPassword(prompt string) ([]byte, error) {
@@ -50,10 +52,19 @@ func newKeystoreGenCmd(c string) *cobra.Command {
 	interactive := flags.BoolP("interactive", "i", false, "Interactive mode for password input")
 	secret := flags.StringP("secret", "s", "", "KeySecret file path")
 	pass := flags.StringP("password", "p", "gochain", "Password for the keystore")
+	keyType := flags.StringP("type", "t", crypto.KeyTypeSecp256k1, "Key type (secp256k1, ed25519)")
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
 		pb := getPasswordFromFlags("Password: ", interactive, secret, pass)
-		w := wallet.New()
+		var w module.Wallet
+		switch *keyType {
+		case crypto.KeyTypeSecp256k1:
+			w = wallet.New()
+		case crypto.KeyTypeEd25519:
+			w = wallet.NewEd25519()
+		default:
+			log.Panicf("Unknown key type %s", *keyType)
+		}
 		ks, err := wallet.KeyStoreFromWallet(w, pb)
 		if err != nil {
 			log.Panicf("Fail to generate keystore err=%+v", err)
@@ -148,6 +159,7 @@ func NewKeystoreCmd(c string) *cobra.Command {
 	cmd.AddCommand(newVerifyCmd("verify"))
 	cmd.AddCommand(publickeyFromKeyStore("pubkey"))
 	cmd.AddCommand(newReEncryptCmd("encrypt"))
+	cmd.AddCommand(newSignTypedCmd("sign-typed"))
 	return cmd
 }
 
@@ -175,3 +187,41 @@ func publickeyFromKeyStore(c string) *cobra.Command {
 	}
 	return cmd
 }
+
+func newSignTypedCmd(c string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   c + " TYPED_DATA_FILE",
+		Short: "Sign typed structured data with keystore",
+		Args:  cobra.ExactArgs(1),
+	}
+	flags := cmd.PersistentFlags()
+	keystorePath := flags.StringP("keystore", "k", "keystore.json", "Keystore file path")
+	interactive := flags.BoolP("interactive", "i", false, "Interactive mode for password input")
+	secret := flags.StringP("secret", "s", "", "KeySecret file path")
+	pass := flags.StringP("password", "p", "gochain", "Password for the keystore")
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		bs, err := os.ReadFile(args[0])
+		if err != nil {
+			log.Panicf("fail to open typed data file err=%+v", err)
+		}
+		td, err := crypto.ParseTypedData(bs)
+		if err != nil {
+			log.Panicf("Fail to parse typed data err=%+v", err)
+		}
+		kb, err := os.ReadFile(*keystorePath)
+		if err != nil {
+			log.Panicf("fail to open keystore file err=%+v", err)
+		}
+		pb := getPasswordFromFlags("Password: ", interactive, secret, pass)
+		w, err := wallet.NewFromKeyStore(kb, pb)
+		if err != nil {
+			log.Panicf("Fail to decrypt KeyStore err=%+v", err)
+		}
+		sig, err := wallet.SignTypedData(w, td)
+		if err != nil {
+			log.Panicf("Fail to sign typed data err=%+v", err)
+		}
+		fmt.Println("0x" + hex.EncodeToString(sig))
+	}
+	return cmd
+}
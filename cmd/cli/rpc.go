@@ -220,6 +220,36 @@ func NewRpcCmd(parentCmd *cobra.Command, parentVc *viper.Viper) (*cobra.Command,
 	flags := balanceCmd.Flags()
 	flags.Int("height", -1, "BlockHeight")
 
+	tokenBalanceCmd := &cobra.Command{
+		Use:   "tokenbalance ID ADDRESS",
+		Short: "GetTokenBalance",
+		Args:  ArgsWithDefaultErrorFunc(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := intconv.ParseInt(args[0], 64)
+			if err != nil {
+				return err
+			}
+			param := &v3.TokenBalanceParam{
+				ID:      jsonrpc.HexInt(intconv.FormatInt(id)),
+				Address: jsonrpc.Address(args[1]),
+			}
+			height, err := intconv.ParseInt(cmd.Flag("height").Value.String(), 64)
+			if err != nil {
+				return err
+			}
+			if height != -1 {
+				param.Height = jsonrpc.HexInt(intconv.FormatInt(height))
+			}
+			balance, err := rpcClient.GetTokenBalance(param)
+			if err != nil {
+				return err
+			}
+			return JsonPrettyPrintln(os.Stdout, balance)
+		},
+	}
+	rootCmd.AddCommand(tokenBalanceCmd)
+	tokenBalanceCmd.Flags().Int("height", -1, "BlockHeight")
+
 	scoreAPICmd := &cobra.Command{
 		Use:   "scoreapi ADDRESS",
 		Short: "GetScoreApi",
@@ -1059,6 +1089,8 @@ func NewSendTxCmd(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Comman
 				if contentType == "" {
 					if strings.HasSuffix(strings.ToLower(args[0]), ".jar") {
 						contentType = "application/java"
+					} else if strings.HasSuffix(strings.ToLower(args[0]), ".wasm") {
+						contentType = "application/wasm"
 					} else {
 						contentType = "application/zip"
 					}
@@ -14,6 +14,7 @@ import (
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/common/wallet"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/network"
 	"github.com/icon-project/goloop/node"
 )
 
@@ -135,8 +136,10 @@ func NewServerCmd(parentCmd *cobra.Command, parentVc *viper.Viper, version, buil
 		return nil
 	}
 	rootPFlags := rootCmd.PersistentFlags()
-	rootPFlags.String("p2p", "127.0.0.1:8080", "Advertise ip-port of P2P")
+	rootPFlags.String("p2p", "127.0.0.1:8080", "Advertise ip-port of P2P (ip is detected if it's empty)")
 	rootPFlags.String("p2p_listen", "", "Listen ip-port of P2P")
+	rootPFlags.String("p2p_transport", "tcp", "Transport protocol of P2P (tcp,quic)")
+	rootPFlags.String("p2p_nat", "", "Port mapping method of P2P (none,upnp,pmp,any,local[:IP])")
 	rootPFlags.String("rpc_addr", ":9080", "Listen ip-port of JSON-RPC")
 	rootPFlags.Bool("rpc_dump", false, "JSON-RPC Request, Response Dump flag")
 	rootPFlags.String("ee_socket", "", "Execution engine socket path")
@@ -161,7 +164,7 @@ func NewServerCmd(parentCmd *cobra.Command, parentVc *viper.Viper, version, buil
 	rootPFlags.String("log_forwarder_level", "info", "LogForwarder level")
 	rootPFlags.String("log_forwarder_name", "", "LogForwarder name")
 	rootPFlags.StringToString("log_forwarder_options", nil, "LogForwarder options, comma-separated 'key=value'")
-	rootPFlags.String("engines", "python", "Execution engines, comma-separated (python,java)")
+	rootPFlags.String("engines", "python", "Execution engines, comma-separated (python,java,wasm)")
 
 	rootPFlags.String("log_writer_filename", "", "Log filename (rotated files resides in same directory)")
 	rootPFlags.Int("log_writer_maxsize", 100, "Maximum log file size in MiB")
@@ -344,6 +347,9 @@ func MergeWithViper(vc *viper.Viper, cfg *ServerConfig) error {
 	if err := vc.Unmarshal(&cfg.StaticConfig, ViperDecodeOptJson); err != nil {
 		return errors.Errorf("fail to unmarshall node config from env err=%+v", err)
 	}
+	if !network.IsValidTransport(cfg.P2PTransport) {
+		return errors.Errorf("invalid p2p_transport=%s", cfg.P2PTransport)
+	}
 
 	var lfOpts map[string]interface{}
 	switch v := vc.Get("log_forwarder_options").(type) {
@@ -35,6 +35,8 @@ type GoChainConfig struct {
 	chain.Config
 	P2PAddr       string `json:"p2p"`
 	P2PListenAddr string `json:"p2p_listen"`
+	P2PTransport  string `json:"p2p_transport,omitempty"`
+	P2PNAT        string `json:"p2p_nat,omitempty"`
 	EESocket      string `json:"ee_socket"`
 	RPCAddr       string `json:"rpc_addr"`
 	RPCDump       bool   `json:"rpc_dump"`
@@ -110,8 +112,10 @@ func main() {
 	flag.StringVar(&saveFile, "save", "", "File path for storing current configuration (it exits after save)")
 	flag.StringVar(&saveKeyStore, "save_key_store", "", "File path for storing current KeyStore")
 	flag.StringVar(&cfg.Channel, "channel", "default", "Channel name for the chain")
-	flag.StringVar(&cfg.P2PAddr, "p2p", "127.0.0.1:8080", "Advertise ip-port of P2P")
+	flag.StringVar(&cfg.P2PAddr, "p2p", "127.0.0.1:8080", "Advertise ip-port of P2P (ip is detected if it's empty)")
 	flag.StringVar(&cfg.P2PListenAddr, "p2p_listen", "", "Listen ip-port of P2P")
+	flag.StringVar(&cfg.P2PTransport, "p2p_transport", "tcp", "Transport protocol of P2P (tcp,quic)")
+	flag.StringVar(&cfg.P2PNAT, "p2p_nat", "", "Port mapping method of P2P (none,upnp,pmp,any,local[:IP])")
 	flag.IntVar(&cfg.NID, "nid", 0, "Chain Network ID")
 	flag.StringVar(&cfg.RPCAddr, "rpc", ":9080", "Listen ip-port of JSON-RPC")
 	flag.BoolVar(&cfg.RPCDump, "rpc_dump", false, "JSON-RPC Request, Response Dump flag")
@@ -135,11 +139,21 @@ func main() {
 	flag.StringVar(&chainDir, "chain_dir", "", "Chain data directory (default: .chain/<address>/<nid>)")
 	flag.IntVar(&cfg.EEInstances, "ee_instances", 1, "Number of execution engines")
 	flag.IntVar(&cfg.ConcurrencyLevel, "concurrency", 1, "Maximum number of executors to be used for concurrency")
+	flag.BoolVar(&cfg.OptimisticExec, "optimistic_execution", false, "Execute transactions optimistically with concurrency, then re-execute conflicting ones")
 	flag.IntVar(&cfg.NormalTxPoolSize, "normal_tx_pool", 0, "Normal transaction pool size")
 	flag.IntVar(&cfg.PatchTxPoolSize, "patch_tx_pool", 0, "Patch transaction pool size")
 	flag.IntVar(&cfg.MaxBlockTxBytes, "max_block_tx_bytes", 0, "Maximum size of transactions in a block")
 	flag.StringVar(&cfg.NodeCache, "node_cache", chain.NodeCacheDefault, "Node cache (none,small,large)")
+	flag.IntVar(&cfg.NodeCacheMemory, "node_cache_memory", 0, "Memory budget of trie node and API info caches in MB (0: unlimited)")
 	flag.BoolVar(&cfg.ValidateTxOnSend, "validate_tx_on_send", false, "Validate transaction on send")
+	flag.BoolVar(&cfg.SkipEmptyBlock, "skip_empty_block", false, "Skip empty blocks while there is no transaction")
+	flag.Int64Var(&cfg.MaxIdleInterval, "max_idle_interval", 0, "Max interval between blocks in milli-second while skipping empty blocks (0: uses system default value)")
+	flag.Int64Var(&cfg.MinBlockInterval, "min_block_interval", 0, "Lower bound of adaptive block interval in milli-second")
+	flag.Int64Var(&cfg.MaxBlockInterval, "max_block_interval", 0, "Upper bound of adaptive block interval in milli-second (0: disable)")
+	flag.BoolVar(&cfg.NetworkCapture, "network_capture", false, "Record packets of the chain to capture files in the chain directory")
+	flag.Int64Var(&cfg.PruneRetention, "prune_retention", 0, "Number of recent blocks whose states are kept by online pruning (0: disable)")
+	flag.Int64Var(&cfg.PruneCheckpoint, "prune_checkpoint", 0, "Interval of heights whose states are kept as checkpoints by online pruning (0: no checkpoint)")
+	flag.BoolVar(&cfg.Archive, "archive", false, "Keep states of all blocks and index them with changesets by height")
 	cfg.ChildrenLimit = flag.Int("children_limit", -1, "Maximum number of child connections (-1: uses system default value)")
 	cfg.NephewsLimit = flag.Int("nephews_limit", -1, "Maximum number of nephew connections (-1: uses system default value)")
 	flag.StringVar(&cfg.LogLevel, "log_level", "debug", "Main log level")
@@ -153,7 +167,7 @@ func main() {
 	flag.Int64Var(&cfg.DefWaitTimeout, "default_wait_timeout", 0, "Default wait timeout in milli-second (0: disable)")
 	flag.Int64Var(&cfg.MaxWaitTimeout, "max_wait_timeout", 0, "Max wait timeout in milli-second (0: uses same value of default_wait_timeout)")
 	flag.Int64Var(&cfg.TxTimeout, "tx_timeout", 0, "Transaction timeout in milli-second (0: uses system default value)")
-	flag.StringVar(&cfg.Engines, "engines", "python", "Execution engines, comma-separated (python,java)")
+	flag.StringVar(&cfg.Engines, "engines", "python", "Execution engines, comma-separated (python,java,wasm)")
 	flag.IntVar(&cfg.WSMaxSession, "ws_max_session", server.DefaultWSMaxSession, "Websocket session limit (use -1 to disable)")
 	flag.StringVar(&lwCfg.Filename, "log_writer_filename", "", "Log filename")
 	flag.IntVar(&lwCfg.MaxSize, "log_writer_maxsize", 100, "Log file max size")
@@ -403,6 +417,10 @@ func Execute(cmd *cobra.Command, args []string) {
 		logger.SetConsoleLevel(lv)
 	}
 
+	if !network.IsValidTransport(cfg.P2PTransport) {
+		log.Fatalf("Invalid p2p_transport=%s", cfg.P2PTransport)
+	}
+
 	if len(modLevels) > 0 {
 		for mod, lvString := range modLevels {
 			if lv, err := log.ParseLevel(lvString); err != nil {
@@ -469,10 +487,15 @@ func Execute(cmd *cobra.Command, args []string) {
 	log.Infof("Build   : %s", build)
 
 	metric.Initialize(wallet)
-	nt := network.NewTransport(cfg.P2PAddr, wallet, logger)
+	nt := network.NewTransportOf(cfg.P2PTransport, cfg.P2PAddr, wallet, logger)
 	if cfg.P2PListenAddr != "" {
 		_ = nt.SetListenAddress(cfg.P2PListenAddr)
 	}
+	if pm, err := network.NewPortMapper(cfg.P2PNAT); err != nil {
+		log.Panicf("fail to create port mapper err=%+v", err)
+	} else if pm != nil {
+		network.SetPortMapper(nt, pm)
+	}
 	err := nt.Listen()
 	if err != nil {
 		log.Panicf("FAIL to listen P2P err=%+v", err)
@@ -49,7 +49,8 @@ func main() {
 	rootCmd.AddCommand(
 		cli.NewGStorageCmd("gs"),
 		cli.NewGenesisCmd("gn"),
-		cli.NewKeystoreCmd("ks"))
+		cli.NewKeystoreCmd("ks"),
+		cli.NewCaptureCmd("capture"))
 
 	genMdCmd := cli.NewGenerateMarkdownCommand(rootCmd, nil)
 	genMdCmd.Hidden = true
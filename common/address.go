@@ -223,6 +223,28 @@ func NewAccountAddressFromPublicKey(pubKey *crypto.PublicKey) *Address {
 	return NewAccountAddress(digest[len(digest)-AddressIDBytes:])
 }
 
+// NewAccountAddressFromKey returns the address for the public key of the
+// key type. Like secp256k1, it's the last 20 bytes of SHA3-256 of the public
+// key for ed25519.
+func NewAccountAddressFromKey(keyType string, pubKey []byte) (*Address, error) {
+	switch keyType {
+	case crypto.KeyTypeSecp256k1:
+		pk, err := crypto.ParsePublicKey(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		return NewAccountAddressFromPublicKey(pk), nil
+	case crypto.KeyTypeEd25519:
+		if len(pubKey) != crypto.Ed25519PublicKeyLen {
+			return nil, ErrIllegalArgument
+		}
+		digest := crypto.SHA3Sum256(pubKey)
+		return NewAccountAddress(digest[len(digest)-AddressIDBytes:]), nil
+	default:
+		return nil, ErrIllegalArgument
+	}
+}
+
 func (a *Address) Equal(a2 module.Address) bool {
 	a2IsNil := a2 == nil || reflect.ValueOf(a2).IsNil()
 	if a2IsNil && a == nil {
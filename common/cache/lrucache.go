@@ -9,6 +9,17 @@ import (
 
 type Create func([]byte) (interface{}, error)
 
+// SizeOf returns bytes of the value in the memory.
+type SizeOf func(value interface{}) int64
+
+// MemoryUsage charges bytes of entries of the cache to a memory budget
+// shared with other caches.
+type MemoryUsage interface {
+	Add(n int64)
+	Evict(n int64)
+	Exceeded() bool
+}
+
 type LRUCache struct {
 	lock  sync.Mutex
 	size  int
@@ -16,21 +27,43 @@ type LRUCache struct {
 	items map[string]*list.Element
 
 	create Create
+	sizeOf SizeOf
+	usage  MemoryUsage
 }
 
 type item struct {
 	key   string
 	value interface{}
+	bytes int64
+}
+
+func (c *LRUCache) newItem(key string, value interface{}) item {
+	i := item{key: key, value: value}
+	if c.usage != nil {
+		i.bytes = c.sizeOf(value)
+		c.usage.Add(i.bytes)
+	}
+	return i
+}
+
+func (c *LRUCache) evictInLock() {
+	e := c.lru.Front()
+	c.lru.Remove(e)
+	i := e.Value.(item)
+	delete(c.items, i.key)
+	if c.usage != nil {
+		c.usage.Evict(i.bytes)
+	}
 }
 
 func (c *LRUCache) putInLock(key string, value interface{}) {
-	e := c.lru.PushBack(item{key: key, value: value})
+	e := c.lru.PushBack(c.newItem(key, value))
 	c.items[key] = e
 	if c.lru.Len() > c.size {
-		e := c.lru.Front()
-		c.lru.Remove(e)
-		i := e.Value.(item)
-		delete(c.items, i.key)
+		c.evictInLock()
+	}
+	for c.usage != nil && c.lru.Len() > 0 && c.usage.Exceeded() {
+		c.evictInLock()
 	}
 }
 
@@ -61,7 +94,13 @@ func (c *LRUCache) Put(key string, value interface{}) {
 
 	e, ok := c.items[key]
 	if ok {
-		e.Value = item{key: key, value: value}
+		if c.usage != nil {
+			c.usage.Add(-e.Value.(item).bytes)
+		}
+		e.Value = c.newItem(key, value)
+		for c.usage != nil && c.lru.Len() > 0 && c.usage.Exceeded() {
+			c.evictInLock()
+		}
 		return
 	}
 	c.putInLock(key, value)
@@ -86,3 +125,15 @@ func NewLRUCache(size int, create Create) *LRUCache {
 	}
 	return c
 }
+
+// NewLRUCacheWithUsage returns a new LRUCache charging bytes of values
+// to the memory usage. Least recently used values are evicted while the
+// usage is exceeded.
+func NewLRUCacheWithUsage(size int, create Create, sizeOf SizeOf, usage MemoryUsage) *LRUCache {
+	c := NewLRUCache(size, create)
+	if usage != nil {
+		c.sizeOf = sizeOf
+		c.usage = usage
+	}
+	return c
+}
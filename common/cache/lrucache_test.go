@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testUsage struct {
+	limit  int64
+	bytes  int64
+	evicts int
+}
+
+func (u *testUsage) Add(n int64) {
+	u.bytes += n
+}
+
+func (u *testUsage) Evict(n int64) {
+	u.bytes -= n
+	u.evicts += 1
+}
+
+func (u *testUsage) Exceeded() bool {
+	return u.bytes > u.limit
+}
+
+func TestLRUCache_WithUsage(t *testing.T) {
+	usage := &testUsage{limit: 10}
+	c := NewLRUCacheWithUsage(10, nil, func(v interface{}) int64 {
+		return int64(v.(int))
+	}, usage)
+
+	c.Put("a", 4)
+	c.Put("b", 4)
+	assert.EqualValues(t, 8, usage.bytes)
+	assert.Equal(t, 2, c.Len())
+
+	// the least recently used one is evicted for the budget
+	_, err := c.Get([]byte("a"))
+	assert.NoError(t, err)
+	c.Put("c", 4)
+	assert.EqualValues(t, 8, usage.bytes)
+	assert.Equal(t, 1, usage.evicts)
+	_, err = c.Get([]byte("b"))
+	assert.Error(t, err)
+
+	// replacing the value charges the difference
+	c.Put("c", 2)
+	assert.EqualValues(t, 6, usage.bytes)
+
+	// too large value isn't kept
+	c.Put("d", 11)
+	assert.EqualValues(t, 0, usage.bytes)
+	assert.Equal(t, 0, c.Len())
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+)
+
+// Key types of accounts. Keys of secp256k1 are used by default.
+const (
+	KeyTypeSecp256k1 = "secp256k1"
+	KeyTypeEd25519   = "ed25519"
+)
+
+const (
+	// Ed25519PublicKeyLen is the byte length of an ed25519 public key
+	Ed25519PublicKeyLen = ed25519.PublicKeySize
+	// Ed25519SeedLen is the byte length of an ed25519 private key seed
+	Ed25519SeedLen = ed25519.SeedSize
+	// Ed25519SignatureLen is the byte length of an ed25519 signature
+	Ed25519SignatureLen = ed25519.SignatureSize
+)
+
+// GenerateEd25519Key generates a seed of an ed25519 private key.
+func GenerateEd25519Key() []byte {
+	_, sk, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return sk.Seed()
+}
+
+// Ed25519PublicKeyFromSeed returns the public key for the seed.
+func Ed25519PublicKeyFromSeed(seed []byte) ([]byte, error) {
+	if len(seed) != Ed25519SeedLen {
+		return nil, errors.New("invalid ed25519 seed length")
+	}
+	sk := ed25519.NewKeyFromSeed(seed)
+	return sk.Public().(ed25519.PublicKey), nil
+}
+
+// SignEd25519 signs the hash with the private key for the seed.
+func SignEd25519(seed, hash []byte) ([]byte, error) {
+	if len(seed) != Ed25519SeedLen || len(hash) == 0 {
+		return nil, errors.New("Invalid arguments")
+	}
+	return ed25519.Sign(ed25519.NewKeyFromSeed(seed), hash), nil
+}
+
+// VerifyEd25519 checks whether the signature for the hash is made by
+// the public key.
+func VerifyEd25519(pubKey, hash, sig []byte) bool {
+	if len(pubKey) != Ed25519PublicKeyLen || len(sig) != Ed25519SignatureLen {
+		return false
+	}
+	return ed25519.Verify(pubKey, hash, sig)
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEd25519_SignVerify(t *testing.T) {
+	seed := GenerateEd25519Key()
+	assert.Len(t, seed, Ed25519SeedLen)
+	pk, err := Ed25519PublicKeyFromSeed(seed)
+	assert.NoError(t, err)
+	assert.Len(t, pk, Ed25519PublicKeyLen)
+
+	hash := SHA3Sum256([]byte("test message"))
+	sig, err := SignEd25519(seed, hash)
+	assert.NoError(t, err)
+	assert.True(t, VerifyEd25519(pk, hash, sig))
+
+	assert.False(t, VerifyEd25519(pk, SHA3Sum256([]byte("other")), sig))
+	pk2, _ := Ed25519PublicKeyFromSeed(GenerateEd25519Key())
+	assert.False(t, VerifyEd25519(pk2, hash, sig))
+	assert.False(t, VerifyEd25519(pk[1:], hash, sig))
+
+	_, err = SignEd25519(seed[1:], hash)
+	assert.Error(t, err)
+}
@@ -0,0 +1,417 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// TypedDataDomainType is the name of the type of the domain. The fields of
+// the domain are name(str), version(str), nid(int) and
+// verifyingContract(Address) in order, and only nid is required.
+const TypedDataDomainType = "Domain"
+
+const (
+	typedDataWordLen  = 32
+	typedDataListType = "[]"
+)
+
+var typedDataPrefix = []byte{0x19, 0x01}
+
+var typedDataDomainFields = []TypedDataField{
+	{"name", "str"},
+	{"version", "str"},
+	{"nid", "int"},
+	{"verifyingContract", "Address"},
+}
+
+// TypedDataField is a member of the struct type. Type is one of the names
+// of the data types of the contract API (int, str, bytes, bool and Address),
+// a name of the struct type, or a list of them with "[]" prefix.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is the structured data for signing. The digest for the
+// signature is SHA3-256 of 0x19 0x01, the hash of the domain and the hash of
+// the message.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+
+	// hashes of the encoded types by the name of the type
+	typeHashes map[string][]byte
+	// number of the struct values hashed
+	structs int
+}
+
+type typedDataJSON TypedData
+
+func (td *TypedData) UnmarshalJSON(bs []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.UseNumber()
+	return dec.Decode((*typedDataJSON)(td))
+}
+
+// ParseTypedData parses the typed data from JSON, and checks the types.
+func ParseTypedData(bs []byte) (*TypedData, error) {
+	td := new(TypedData)
+	if err := json.Unmarshal(bs, td); err != nil {
+		return nil, err
+	}
+	if err := td.Validate(); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+func isTypedDataBaseType(t string) bool {
+	switch t {
+	case "int", "str", "bytes", "bool", "Address":
+		return true
+	}
+	return false
+}
+
+// Validate checks the types, and whether the primary type is defined.
+func (td *TypedData) Validate() error {
+	if _, ok := td.Types[TypedDataDomainType]; ok {
+		return fmt.Errorf("reserved type name %s", TypedDataDomainType)
+	}
+	for name, fields := range td.Types {
+		if len(name) == 0 || isTypedDataBaseType(name) ||
+			strings.ContainsAny(name, "()[], ") {
+			return fmt.Errorf("invalid type name %q", name)
+		}
+		seen := make(map[string]bool)
+		for _, f := range fields {
+			if len(f.Name) == 0 || seen[f.Name] ||
+				strings.ContainsAny(f.Name, "()[], ") {
+				return fmt.Errorf("invalid field name %q of %s", f.Name, name)
+			}
+			seen[f.Name] = true
+			if t := trimListType(f.Type); !isTypedDataBaseType(t) {
+				if _, ok := td.Types[t]; !ok {
+					return fmt.Errorf("unknown type %q of %s.%s", f.Type, name, f.Name)
+				}
+			}
+		}
+	}
+	if _, ok := td.Types[td.PrimaryType]; !ok {
+		return fmt.Errorf("unknown primary type %q", td.PrimaryType)
+	}
+	if _, ok := td.Domain["nid"]; !ok {
+		return fmt.Errorf("no nid in domain")
+	}
+	return nil
+}
+
+func trimListType(t string) string {
+	for strings.HasPrefix(t, typedDataListType) {
+		t = t[len(typedDataListType):]
+	}
+	return t
+}
+
+func (td *TypedData) fieldsOf(name string) []TypedDataField {
+	if name == TypedDataDomainType {
+		fields := make([]TypedDataField, 0, len(typedDataDomainFields))
+		for _, f := range typedDataDomainFields {
+			if _, ok := td.Domain[f.Name]; ok {
+				fields = append(fields, f)
+			}
+		}
+		return fields
+	}
+	return td.Types[name]
+}
+
+func (td *TypedData) collectDeps(name string, deps map[string]bool) {
+	if deps[name] {
+		return
+	}
+	deps[name] = true
+	for _, f := range td.fieldsOf(name) {
+		if t := trimListType(f.Type); !isTypedDataBaseType(t) {
+			td.collectDeps(t, deps)
+		}
+	}
+}
+
+// EncodeType returns the encoded type of the struct type, which is the
+// type followed by the referenced struct types sorted by the name, like
+// "Mail(Person from,str contents)Person(str name,Address wallet)".
+func (td *TypedData) EncodeType(name string) string {
+	deps := make(map[string]bool)
+	td.collectDeps(name, deps)
+	delete(deps, name)
+	names := make([]string, 0, len(deps))
+	for dep := range deps {
+		names = append(names, dep)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, n := range append([]string{name}, names...) {
+		sb.WriteString(n)
+		sb.WriteByte('(')
+		for i, f := range td.fieldsOf(n) {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(f.Type)
+			sb.WriteByte(' ')
+			sb.WriteString(f.Name)
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
+func (td *TypedData) typeHash(name string) []byte {
+	if h, ok := td.typeHashes[name]; ok {
+		return h
+	}
+	if td.typeHashes == nil {
+		td.typeHashes = make(map[string][]byte)
+	}
+	h := SHA3Sum256([]byte(td.EncodeType(name)))
+	td.typeHashes[name] = h
+	return h
+}
+
+// HashStruct returns the hash of the value of the struct type.
+func (td *TypedData) HashStruct(name string, value map[string]interface{}) ([]byte, error) {
+	fields := td.fieldsOf(name)
+	if len(value) != len(fields) {
+		return nil, fmt.Errorf("invalid number of fields for %s", name)
+	}
+	td.structs += 1
+	buf := bytes.NewBuffer(nil)
+	buf.Write(td.typeHash(name))
+	for _, f := range fields {
+		v, ok := value[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("no field %s.%s", name, f.Name)
+		}
+		enc, err := td.encodeValue(f.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s.%s: %w", name, f.Name, err)
+		}
+		buf.Write(enc)
+	}
+	return SHA3Sum256(buf.Bytes()), nil
+}
+
+func (td *TypedData) encodeValue(t string, v interface{}) ([]byte, error) {
+	if strings.HasPrefix(t, typedDataListType) {
+		items, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("not a list")
+		}
+		buf := bytes.NewBuffer(nil)
+		for _, item := range items {
+			enc, err := td.encodeValue(t[len(typedDataListType):], item)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(enc)
+		}
+		return SHA3Sum256(buf.Bytes()), nil
+	}
+	switch t {
+	case "int":
+		i, err := typedDataInt(v)
+		if err != nil {
+			return nil, err
+		}
+		return typedDataWord(i)
+	case "bool":
+		var b bool
+		switch obj := v.(type) {
+		case bool:
+			b = obj
+		case string:
+			if obj != "0x0" && obj != "0x1" {
+				return nil, fmt.Errorf("invalid bool %q", obj)
+			}
+			b = obj == "0x1"
+		default:
+			return nil, fmt.Errorf("not a bool")
+		}
+		word := make([]byte, typedDataWordLen)
+		if b {
+			word[typedDataWordLen-1] = 1
+		}
+		return word, nil
+	case "str":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("not a string")
+		}
+		return SHA3Sum256([]byte(s)), nil
+	case "bytes":
+		s, ok := v.(string)
+		if !ok || !strings.HasPrefix(s, "0x") {
+			return nil, fmt.Errorf("not a bytes")
+		}
+		bs, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return nil, err
+		}
+		return SHA3Sum256(bs), nil
+	case "Address":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("not an address")
+		}
+		bs, err := typedDataAddress(s)
+		if err != nil {
+			return nil, err
+		}
+		word := make([]byte, typedDataWordLen)
+		copy(word[typedDataWordLen-len(bs):], bs)
+		return word, nil
+	default:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("not a struct")
+		}
+		return td.HashStruct(t, obj)
+	}
+}
+
+func typedDataInt(v interface{}) (*big.Int, error) {
+	var s string
+	switch obj := v.(type) {
+	case json.Number:
+		s = obj.String()
+	case string:
+		s = obj
+	default:
+		return nil, fmt.Errorf("not an integer")
+	}
+	i := new(big.Int)
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	ok := false
+	if strings.HasPrefix(s, "0x") {
+		_, ok = i.SetString(s[2:], 16)
+	} else {
+		_, ok = i.SetString(s, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", v)
+	}
+	if neg {
+		i.Neg(i)
+	}
+	return i, nil
+}
+
+var (
+	typedDataIntMax = new(big.Int).Lsh(big.NewInt(1), 8*typedDataWordLen-1)
+	typedDataIntMin = new(big.Int).Neg(typedDataIntMax)
+	typedDataIntMod = new(big.Int).Lsh(big.NewInt(1), 8*typedDataWordLen)
+)
+
+// typedDataWord returns 256 bits two's complement of the integer.
+func typedDataWord(i *big.Int) ([]byte, error) {
+	if i.Cmp(typedDataIntMax) >= 0 || i.Cmp(typedDataIntMin) < 0 {
+		return nil, fmt.Errorf("integer out of range")
+	}
+	if i.Sign() < 0 {
+		i = new(big.Int).Add(i, typedDataIntMod)
+	}
+	word := make([]byte, typedDataWordLen)
+	return i.FillBytes(word), nil
+}
+
+// typedDataAddress returns the bytes of the address, which is the type
+// (0 for hx, 1 for cx) followed by 20 bytes of the ID.
+func typedDataAddress(s string) ([]byte, error) {
+	if len(s) != 42 || strings.ToLower(s) != s {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	var prefix byte
+	switch s[0:2] {
+	case "hx":
+	case "cx":
+		prefix = 1
+	default:
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	id, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{prefix}, id...), nil
+}
+
+// DomainSeparator returns the hash of the domain.
+func (td *TypedData) DomainSeparator() ([]byte, error) {
+	return td.HashStruct(TypedDataDomainType, td.Domain)
+}
+
+// NID returns the network ID in the domain.
+func (td *TypedData) NID() (int64, error) {
+	i, err := typedDataInt(td.Domain["nid"])
+	if err != nil {
+		return 0, err
+	}
+	if !i.IsInt64() {
+		return 0, fmt.Errorf("invalid nid %s", i)
+	}
+	return i.Int64(), nil
+}
+
+// HashedStructs returns the number of the struct values hashed so far,
+// including the domain and the values in the lists.
+func (td *TypedData) HashedStructs() int {
+	return td.structs
+}
+
+// Digest returns the hash of the typed data for the signature.
+func (td *TypedData) Digest() ([]byte, error) {
+	if err := td.Validate(); err != nil {
+		return nil, err
+	}
+	ds, err := td.DomainSeparator()
+	if err != nil {
+		return nil, err
+	}
+	mh, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, len(typedDataPrefix)+len(ds)+len(mh))
+	buf = append(buf, typedDataPrefix...)
+	buf = append(buf, ds...)
+	buf = append(buf, mh...)
+	return SHA3Sum256(buf), nil
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crypto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTypedData = `{
+	"types": {
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "[]Person"},
+			{"name": "contents", "type": "str"},
+			{"name": "amount", "type": "int"}
+		],
+		"Person": [
+			{"name": "name", "type": "str"},
+			{"name": "wallet", "type": "Address"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Mailer",
+		"version": "1",
+		"nid": "0x3"
+	},
+	"message": {
+		"from": {"name": "Alice", "wallet": "hx0000000000000000000000000000000000000001"},
+		"to": [
+			{"name": "Bob", "wallet": "hx0000000000000000000000000000000000000002"}
+		],
+		"contents": "Hello, Bob!",
+		"amount": "-0x10"
+	}
+}`
+
+func TestTypedData_EncodeType(t *testing.T) {
+	td, err := ParseTypedData([]byte(testTypedData))
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"Mail(Person from,[]Person to,str contents,int amount)Person(str name,Address wallet)",
+		td.EncodeType("Mail"))
+	assert.Equal(t, "Domain(str name,str version,int nid)",
+		td.EncodeType(TypedDataDomainType))
+
+	nid, err := td.NID()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, nid)
+}
+
+func TestTypedData_Digest(t *testing.T) {
+	td, err := ParseTypedData([]byte(testTypedData))
+	assert.NoError(t, err)
+	d1, err := td.Digest()
+	assert.NoError(t, err)
+	assert.Len(t, d1, HashLen)
+	// Domain, Mail and two of Person
+	assert.Equal(t, 4, td.HashedStructs())
+	assert.Len(t, td.typeHashes, 3)
+
+	// numbers and hex strings are same
+	td2, err := ParseTypedData([]byte(strings.Replace(testTypedData, `"-0x10"`, `-16`, 1)))
+	assert.NoError(t, err)
+	d2, err := td2.Digest()
+	assert.NoError(t, err)
+	assert.Equal(t, d1, d2)
+
+	// domain is a part of the digest
+	td3, err := ParseTypedData([]byte(strings.Replace(testTypedData, `"0x3"`, `"0x4"`, 1)))
+	assert.NoError(t, err)
+	d3, err := td3.Digest()
+	assert.NoError(t, err)
+	assert.NotEqual(t, d1, d3)
+
+	sk, pk := GenerateKeyPair()
+	sig, err := NewSignature(d1, sk)
+	assert.NoError(t, err)
+	rpk, err := sig.RecoverPublicKey(d1)
+	assert.NoError(t, err)
+	assert.True(t, pk.Equal(rpk))
+}
+
+func TestTypedData_Invalid(t *testing.T) {
+	cases := map[string][2]string{
+		"UnknownType":   {`"type": "Person"}`, `"type": "Human"}`},
+		"ReservedType":  {`"Person": [`, `"Domain": [`},
+		"NoNID":         {`"nid": "0x3"`, `"salt": "0x3"`},
+		"NoPrimaryType": {`"primaryType": "Mail"`, `"primaryType": "Letter"`},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTypedData([]byte(strings.Replace(testTypedData, c[0], c[1], 1)))
+			assert.Error(t, err)
+		})
+	}
+
+	values := map[string][2]string{
+		"IntOverflow":  {`"-0x10"`, `"0x8000000000000000000000000000000000000000000000000000000000000000"`},
+		"InvalidAddr":  {`hx0000000000000000000000000000000000000002`, `hx02`},
+		"MissingField": {`"contents": "Hello, Bob!",`, `"content": "Hello, Bob!",`},
+		"ExtraDomain":  {`"version": "1",`, `"version": "1", "chain": "0x1",`},
+	}
+	for name, c := range values {
+		t.Run(name, func(t *testing.T) {
+			td, err := ParseTypedData([]byte(strings.Replace(testTypedData, c[0], c[1], 1)))
+			assert.NoError(t, err)
+			_, err = td.Digest()
+			assert.Error(t, err)
+		})
+	}
+}
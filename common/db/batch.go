@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package db
+
+// Batch collects writes on buckets of a database, and applies them on
+// Commit. All writes of a batch are applied atomically if the database
+// supports it (Batcher). Writes are applied in the order they are added.
+// After Commit, the batch is empty and can be used again.
+type Batch interface {
+	Set(id BucketID, key []byte, value []byte) error
+	Delete(id BucketID, key []byte) error
+	Len() int
+	Reset()
+	Commit() error
+}
+
+// Batcher is implemented by the database supporting atomic batches.
+type Batcher interface {
+	NewBatch() Batch
+}
+
+// NewBatch begins a batch for the database. If the database doesn't
+// support atomic batches, the returned batch applies writes one by one
+// on Commit.
+func NewBatch(database Database) Batch {
+	for {
+		switch d := database.(type) {
+		case Batcher:
+			return d.NewBatch()
+		case *databaseContext:
+			database = d.Database
+		case *layerDBContext:
+			database = d.LayerDB
+		default:
+			return &simpleBatch{database: database}
+		}
+	}
+}
+
+type batchOp struct {
+	id    BucketID
+	key   []byte
+	value []byte
+}
+
+func (op *batchOp) isDelete() bool {
+	return op.value == nil
+}
+
+// batchOps keeps copies of keys and values of writes.
+type batchOps []batchOp
+
+func (ops *batchOps) set(id BucketID, key []byte, value []byte) {
+	v := make([]byte, len(value))
+	copy(v, value)
+	*ops = append(*ops, batchOp{id, append([]byte{}, key...), v})
+}
+
+func (ops *batchOps) delete(id BucketID, key []byte) {
+	*ops = append(*ops, batchOp{id, append([]byte{}, key...), nil})
+}
+
+// simpleBatch applies writes one by one through buckets.
+type simpleBatch struct {
+	database Database
+	ops      batchOps
+}
+
+func (b *simpleBatch) Set(id BucketID, key []byte, value []byte) error {
+	b.ops.set(id, key, value)
+	return nil
+}
+
+func (b *simpleBatch) Delete(id BucketID, key []byte) error {
+	b.ops.delete(id, key)
+	return nil
+}
+
+func (b *simpleBatch) Len() int {
+	return len(b.ops)
+}
+
+func (b *simpleBatch) Reset() {
+	b.ops = nil
+}
+
+func (b *simpleBatch) Commit() error {
+	buckets := make(map[BucketID]Bucket)
+	for _, op := range b.ops {
+		bk, ok := buckets[op.id]
+		if !ok {
+			var err error
+			if bk, err = b.database.GetBucket(op.id); err != nil {
+				return err
+			}
+			buckets[op.id] = bk
+		}
+		if op.isDelete() {
+			if err := bk.Delete(op.key); err != nil {
+				return err
+			}
+		} else {
+			if err := bk.Set(op.key, op.value); err != nil {
+				return err
+			}
+		}
+	}
+	b.Reset()
+	return nil
+}
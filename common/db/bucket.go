@@ -66,6 +66,14 @@ const (
 	// ListByMerkleRootBase is the base for the bucket that maps list
 	// from network type dependent merkle root(list)
 	ListByMerkleRootBase BucketID = "L"
+
+	// StateIndexByHeight maps state index of the block from height in
+	// 8 bytes big-endian. It's written only by archive nodes.
+	StateIndexByHeight BucketID = "A"
+
+	// ChangeSetByHeight maps changes of the world state of the block from
+	// height in 8 bytes big-endian. It's written only by archive nodes.
+	ChangeSetByHeight BucketID = "D"
 )
 
 // internalKey returns key prefixed with the bucket's id.
@@ -0,0 +1,44 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package db
+
+import "github.com/icon-project/goloop/common/errors"
+
+// Compactor is implemented by the database supporting compaction of its
+// storage, which discards deleted or overwritten entries.
+type Compactor interface {
+	Compact() error
+}
+
+// Compact compacts whole storage of the database. It returns UnsupportedError
+// if the database doesn't support it.
+func Compact(database Database) error {
+	for {
+		switch d := database.(type) {
+		case Compactor:
+			return d.Compact()
+		case *databaseContext:
+			database = d.Database
+		case *layerDBContext:
+			database = d.LayerDB
+		case LayerDB:
+			database = d.Unwrap()
+		default:
+			return errors.UnsupportedError.Errorf("CompactionNotSupported(db=%T)", database)
+		}
+	}
+}
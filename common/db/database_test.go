@@ -17,9 +17,13 @@
 package db
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/errors"
 )
 
 func testDatabase_GetSetDelete(t *testing.T, creator dbCreator) {
@@ -258,3 +262,224 @@ func TestDatabase_OperationAfterClose(t *testing.T) {
 		})
 	}
 }
+
+func testDatabase_Batch(t *testing.T, creator dbCreator) {
+	dir := t.TempDir()
+	testDB, err := creator("test", dir)
+	assert.NoError(t, err)
+	defer testDB.Close()
+
+	bk1, err := testDB.GetBucket("b1")
+	assert.NoError(t, err)
+	bk2, err := testDB.GetBucket("b2")
+	assert.NoError(t, err)
+
+	assert.NoError(t, bk1.Set([]byte("k1"), []byte("v1")))
+	assert.NoError(t, bk2.Set([]byte("k2"), []byte("v2")))
+
+	batch := NewBatch(testDB)
+	assert.NoError(t, batch.Set("b1", []byte("k3"), []byte("v3")))
+	assert.NoError(t, batch.Delete("b1", []byte("k1")))
+	assert.NoError(t, batch.Set("b2", []byte("k2"), []byte("v4")))
+	assert.NoError(t, batch.Set("b2", []byte("k2"), []byte("v5")))
+	assert.NoError(t, batch.Set("b2", []byte("k6"), []byte{}))
+	assert.Equal(t, 5, batch.Len())
+
+	// nothing is written before commit
+	v, err := bk1.Get([]byte("k3"))
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	v, err = bk1.Get([]byte("k1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), v)
+
+	assert.NoError(t, batch.Commit())
+	assert.Equal(t, 0, batch.Len())
+
+	v, err = bk1.Get([]byte("k3"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v3"), v)
+	has, err := bk1.Has([]byte("k1"))
+	assert.NoError(t, err)
+	assert.False(t, has)
+	v, err = bk2.Get([]byte("k2"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v5"), v)
+	has, err = bk2.Has([]byte("k6"))
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	// reset drops writes
+	assert.NoError(t, batch.Set("b1", []byte("k7"), []byte("v7")))
+	batch.Reset()
+	assert.NoError(t, batch.Commit())
+	has, err = bk1.Has([]byte("k7"))
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestDatabase_Batch(t *testing.T) {
+	for name, be := range backends {
+		t.Run(string(name), func(t *testing.T) {
+			testDatabase_Batch(t, be)
+		})
+	}
+	t.Run("layerdb", func(t *testing.T) {
+		var creator dbCreator = func(name string, dir string) (Database, error) {
+			origin := NewMapDB()
+			return NewLayerDB(origin), nil
+		}
+		testDatabase_Batch(t, creator)
+	})
+}
+
+func collectIterator(t *testing.T, it Iterator) []string {
+	var kvs []string
+	for it.Next() {
+		kvs = append(kvs, string(it.Key())+"="+string(it.Value()))
+	}
+	assert.NoError(t, it.Error())
+	it.Release()
+	return kvs
+}
+
+func testDatabase_Iterator(t *testing.T, creator dbCreator) {
+	dir := t.TempDir()
+	testDB, err := creator("test", dir)
+	assert.NoError(t, err)
+	defer testDB.Close()
+
+	bk1, err := testDB.GetBucket("b1")
+	assert.NoError(t, err)
+	bk2, err := testDB.GetBucket("b2")
+	assert.NoError(t, err)
+
+	for _, k := range []string{"c", "a2", "b", "a1", "a", "d"} {
+		assert.NoError(t, bk1.Set([]byte(k), []byte("v"+k)))
+	}
+	assert.NoError(t, bk2.Set([]byte("a3"), []byte("va3")))
+
+	it, err := NewIterator(bk1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a=va", "a1=va1", "a2=va2", "b=vb", "c=vc", "d=vd"},
+		collectIterator(t, it))
+
+	it, err = NewPrefixIterator(bk1, []byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a=va", "a1=va1", "a2=va2"}, collectIterator(t, it))
+
+	it, err = NewIterator(bk1, &Range{Start: []byte("a1"), Limit: []byte("c")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1=va1", "a2=va2", "b=vb"}, collectIterator(t, it))
+
+	it, err = NewIterator(bk1, &Range{Start: []byte("b1")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c=vc", "d=vd"}, collectIterator(t, it))
+
+	it, err = NewPrefixIterator(bk1, []byte("x"))
+	assert.NoError(t, err)
+	assert.Empty(t, collectIterator(t, it))
+}
+
+func TestDatabase_Iterator(t *testing.T) {
+	for name, be := range backends {
+		t.Run(string(name), func(t *testing.T) {
+			testDatabase_Iterator(t, be)
+		})
+	}
+	t.Run("layerdb", func(t *testing.T) {
+		var creator dbCreator = func(name string, dir string) (Database, error) {
+			origin := NewMapDB()
+			return NewLayerDB(origin), nil
+		}
+		testDatabase_Iterator(t, creator)
+	})
+}
+
+func testDatabase_IteratorWithMerkleTrie(t *testing.T, creator dbCreator) {
+	dir := t.TempDir()
+	testDB, err := creator("test", dir)
+	assert.NoError(t, err)
+	defer testDB.Close()
+
+	// a node of MerkleTrie whose key starts with the id of the bucket
+	var node, hash []byte
+	for i := 0; ; i++ {
+		node = []byte(fmt.Sprintf("node%d", i))
+		hash = crypto.SHA3Sum256(node)
+		if hash[0] == 'D' {
+			break
+		}
+	}
+	mt, err := testDB.GetBucket(MerkleTrie)
+	assert.NoError(t, err)
+	assert.NoError(t, mt.Set(hash, node))
+
+	bk, err := testDB.GetBucket("D")
+	assert.NoError(t, err)
+	assert.NoError(t, bk.Set([]byte("a"), []byte("va")))
+	// 32 bytes with the prefix as the key of MerkleTrie
+	key := []byte("0123456789012345678901234567890")
+	assert.NoError(t, bk.Set(key, []byte("vb")))
+
+	it, err := NewIterator(bk, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{string(key) + "=vb", "a=va"}, collectIterator(t, it))
+
+	it, err = NewIterator(mt, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{string(hash) + "=" + string(node)}, collectIterator(t, it))
+
+	// raw iterator returns entries of all buckets
+	it, err = NewRawIterator(testDB)
+	assert.NoError(t, err)
+	assert.Len(t, collectIterator(t, it), 3)
+}
+
+func TestDatabase_IteratorWithMerkleTrie(t *testing.T) {
+	for _, name := range []BackendType{GoLevelDBBackend, PebbleDBBackend} {
+		t.Run(string(name), func(t *testing.T) {
+			testDatabase_IteratorWithMerkleTrie(t, backends[name])
+		})
+	}
+}
+
+func TestPrefixRange(t *testing.T) {
+	assert.Equal(t, &Range{Start: []byte{0x01, 0x02}, Limit: []byte{0x01, 0x03}},
+		PrefixRange([]byte{0x01, 0x02}))
+	assert.Equal(t, &Range{Start: []byte{0x01, 0xff}, Limit: []byte{0x02}},
+		PrefixRange([]byte{0x01, 0xff}))
+	assert.Equal(t, &Range{Start: []byte{0xff}}, PrefixRange([]byte{0xff}))
+	assert.Equal(t, &Range{}, PrefixRange(nil))
+}
+
+func testDatabase_Compact(t *testing.T, creator dbCreator) {
+	dir := t.TempDir()
+	testDB, err := creator("test", dir)
+	assert.NoError(t, err)
+
+	bk, err := testDB.GetBucket(BytesByHash)
+	assert.NoError(t, err)
+	assert.NoError(t, bk.Set([]byte("key1"), []byte("value1")))
+	assert.NoError(t, bk.Set([]byte("key2"), []byte("value2")))
+	assert.NoError(t, bk.Delete([]byte("key2")))
+
+	assert.NoError(t, Compact(WithFlags(NewLayerDB(testDB), Flags{})))
+	v, err := bk.Get([]byte("key1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), v)
+
+	assert.NoError(t, testDB.Close())
+	assert.Error(t, Compact(testDB))
+}
+
+func TestDatabase_Compact(t *testing.T) {
+	for _, name := range []BackendType{GoLevelDBBackend, PebbleDBBackend} {
+		t.Run(string(name), func(t *testing.T) {
+			testDatabase_Compact(t, backends[name])
+		})
+	}
+
+	err := Compact(NewMapDB())
+	assert.True(t, errors.UnsupportedError.Equals(err))
+}
@@ -5,7 +5,9 @@ import (
 	"sync"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const GoLevelDBBackend BackendType = "goleveldb"
@@ -38,6 +40,8 @@ func NewGoLevelDBWithOpts(name string, dir string, o *opt.Options) (*GoLevelDB,
 // Database
 
 var _ Database = (*GoLevelDB)(nil)
+var _ Batcher = (*GoLevelDB)(nil)
+var _ Compactor = (*GoLevelDB)(nil)
 
 type GoLevelDB struct {
 	lock    sync.Mutex
@@ -79,10 +83,75 @@ func (db *GoLevelDB) Close() error {
 	return nil
 }
 
+func (db *GoLevelDB) Compact() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return leveldb.ErrClosed
+	}
+	return db.db.CompactRange(util.Range{})
+}
+
+func (db *GoLevelDB) NewRawIterator() (Iterator, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return nil, leveldb.ErrClosed
+	}
+	return &goLevelIterator{it: db.db.NewIterator(nil, nil), raw: true}, nil
+}
+
+func (db *GoLevelDB) NewBatch() Batch {
+	return &goLevelBatch{database: db}
+}
+
+//----------------------------------------
+// Batch
+
+type goLevelBatch struct {
+	database *GoLevelDB
+	batch    leveldb.Batch
+}
+
+func (b *goLevelBatch) Set(id BucketID, key []byte, value []byte) error {
+	b.batch.Put(internalKey(id, key), value)
+	return nil
+}
+
+func (b *goLevelBatch) Delete(id BucketID, key []byte) error {
+	b.batch.Delete(internalKey(id, key))
+	return nil
+}
+
+func (b *goLevelBatch) Len() int {
+	return b.batch.Len()
+}
+
+func (b *goLevelBatch) Reset() {
+	b.batch.Reset()
+}
+
+func (b *goLevelBatch) Commit() error {
+	b.database.lock.Lock()
+	defer b.database.lock.Unlock()
+
+	if b.database.db == nil {
+		return leveldb.ErrClosed
+	}
+	if err := b.database.db.Write(&b.batch, nil); err != nil {
+		return err
+	}
+	b.batch.Reset()
+	return nil
+}
+
 //----------------------------------------
 // GetBucket
 
 var _ Bucket = (*goLevelBucket)(nil)
+var _ IterableBucket = (*goLevelBucket)(nil)
 
 type goLevelBucket struct {
 	id BucketID
@@ -109,3 +178,62 @@ func (bucket *goLevelBucket) Set(key []byte, value []byte) error {
 func (bucket *goLevelBucket) Delete(key []byte) error {
 	return bucket.db.Delete(internalKey(bucket.id, key), nil)
 }
+
+// NewIterator returns the iterator for keys in the range. All buckets share
+// one key space, so entries of other buckets are filtered out by the key
+// and the value.
+func (bucket *goLevelBucket) NewIterator(r *Range) (Iterator, error) {
+	prefix := []byte(bucket.id)
+	rg := util.BytesPrefix(prefix)
+	if r != nil {
+		if r.Start != nil {
+			rg.Start = internalKey(bucket.id, r.Start)
+		}
+		if r.Limit != nil {
+			rg.Limit = internalKey(bucket.id, r.Limit)
+		}
+	}
+	return &goLevelIterator{
+		it:     bucket.db.NewIterator(rg, nil),
+		id:     bucket.id,
+		prefix: len(prefix),
+	}, nil
+}
+
+type goLevelIterator struct {
+	it     iterator.Iterator
+	id     BucketID
+	prefix int
+	raw    bool
+}
+
+func (it *goLevelIterator) Next() bool {
+	for it.it.Next() {
+		if it.raw || acceptSharedEntry(it.id, it.it.Key(), it.it.Value()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *goLevelIterator) Key() []byte {
+	if key := it.it.Key(); key != nil {
+		return append([]byte{}, key[it.prefix:]...)
+	}
+	return nil
+}
+
+func (it *goLevelIterator) Value() []byte {
+	if value := it.it.Value(); value != nil {
+		return append([]byte{}, value...)
+	}
+	return nil
+}
+
+func (it *goLevelIterator) Error() error {
+	return it.it.Error()
+}
+
+func (it *goLevelIterator) Release() {
+	it.it.Release()
+}
@@ -0,0 +1,267 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package db
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/errors"
+)
+
+// Iterator iterates key-value pairs of a bucket in ascending order of keys.
+// It starts before the first pair, so Next should be called first.
+// Returned key and value are valid until the next call of Next.
+// Release should be called after use.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// Range is the range of keys [Start, Limit). Nil Start means the first key,
+// and nil Limit means after the last key.
+type Range struct {
+	Start []byte
+	Limit []byte
+}
+
+// PrefixRange returns the range of keys starting with the prefix.
+func PrefixRange(prefix []byte) *Range {
+	var limit []byte
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if c := prefix[i]; c < 0xff {
+			limit = make([]byte, i+1)
+			copy(limit, prefix)
+			limit[i] = c + 1
+			break
+		}
+	}
+	return &Range{Start: prefix, Limit: limit}
+}
+
+func (r *Range) Contains(key []byte) bool {
+	if r == nil {
+		return true
+	}
+	if r.Start != nil && bytes.Compare(key, r.Start) < 0 {
+		return false
+	}
+	if r.Limit != nil && bytes.Compare(key, r.Limit) >= 0 {
+		return false
+	}
+	return true
+}
+
+// isMerkleTrieEntry returns whether the entry of the shared key space is a
+// node of MerkleTrie, whose key is sha3 of the value without bucket id.
+func isMerkleTrieEntry(key, value []byte) bool {
+	return len(key) == crypto.HashLen && bytes.Equal(crypto.SHA3Sum256(value), key)
+}
+
+// acceptSharedEntry returns whether the entry of the key space shared by all
+// buckets belongs to the bucket. Keys of MerkleTrie are not prefixed, so they
+// may start with the id of other buckets.
+func acceptSharedEntry(id BucketID, key, value []byte) bool {
+	if id == MerkleTrie {
+		return isMerkleTrieEntry(key, value)
+	}
+	return !isMerkleTrieEntry(key, value)
+}
+
+// IterableBucket is implemented by the bucket supporting iteration.
+type IterableBucket interface {
+	Bucket
+	NewIterator(r *Range) (Iterator, error)
+}
+
+// NewIterator returns the iterator for keys of the bucket in the range.
+// If r is nil, it iterates all keys.
+func NewIterator(bk Bucket, r *Range) (Iterator, error) {
+	if ib, ok := bk.(IterableBucket); ok {
+		return ib.NewIterator(r)
+	}
+	return nil, errors.UnsupportedError.Errorf("IterationNotSupported(bucket=%T)", bk)
+}
+
+// RawIterable is implemented by the database keeping all buckets in one key
+// space.
+type RawIterable interface {
+	NewRawIterator() (Iterator, error)
+}
+
+// NewRawIterator returns the iterator for all entries of the database
+// keeping all buckets in one key space. Keys have bucket ids as prefixes
+// except ones of MerkleTrie. It returns UnsupportedError if the database
+// doesn't support it.
+func NewRawIterator(database Database) (Iterator, error) {
+	for {
+		switch d := database.(type) {
+		case RawIterable:
+			return d.NewRawIterator()
+		case *databaseContext:
+			database = d.Database
+		case *layerDBContext:
+			database = d.LayerDB
+		case LayerDB:
+			database = d.Unwrap()
+		default:
+			return nil, errors.UnsupportedError.Errorf("RawIterationNotSupported(db=%T)", database)
+		}
+	}
+}
+
+// NewPrefixIterator returns the iterator for keys of the bucket starting
+// with the prefix.
+func NewPrefixIterator(bk Bucket, prefix []byte) (Iterator, error) {
+	return NewIterator(bk, PrefixRange(prefix))
+}
+
+type keyValue struct {
+	key   []byte
+	value []byte
+}
+
+// sliceIterator iterates sorted key-value pairs.
+type sliceIterator struct {
+	kvs []keyValue
+	idx int
+}
+
+func newSliceIterator(kvs []keyValue) *sliceIterator {
+	sort.Slice(kvs, func(i, j int) bool {
+		return bytes.Compare(kvs[i].key, kvs[j].key) < 0
+	})
+	return &sliceIterator{kvs: kvs, idx: -1}
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.idx < len(it.kvs) {
+		it.idx++
+	}
+	return it.idx < len(it.kvs)
+}
+
+func (it *sliceIterator) valid() bool {
+	return it.idx >= 0 && it.idx < len(it.kvs)
+}
+
+func (it *sliceIterator) Key() []byte {
+	if !it.valid() {
+		return nil
+	}
+	return it.kvs[it.idx].key
+}
+
+func (it *sliceIterator) Value() []byte {
+	if !it.valid() {
+		return nil
+	}
+	return it.kvs[it.idx].value
+}
+
+func (it *sliceIterator) Error() error {
+	return nil
+}
+
+func (it *sliceIterator) Release() {
+	it.kvs = nil
+	it.idx = 0
+}
+
+// overlayIterator merges pairs over the lower iterator. Pairs of upper
+// override ones of lower with the same key, and nil value of upper means
+// the key is deleted.
+type overlayIterator struct {
+	upper *sliceIterator
+	lower Iterator
+
+	upperOK bool
+	lowerOK bool
+	started bool
+	fromUp  bool
+}
+
+func newOverlayIterator(upper []keyValue, lower Iterator) *overlayIterator {
+	return &overlayIterator{
+		upper: newSliceIterator(upper),
+		lower: lower,
+	}
+}
+
+func (it *overlayIterator) Next() bool {
+	if !it.started {
+		it.started = true
+		it.upperOK = it.upper.Next()
+		it.lowerOK = it.lower.Next()
+	} else if it.fromUp {
+		if it.lowerOK && bytes.Equal(it.upper.Key(), it.lower.Key()) {
+			it.lowerOK = it.lower.Next()
+		}
+		it.upperOK = it.upper.Next()
+	} else {
+		it.lowerOK = it.lower.Next()
+	}
+	for {
+		if !it.upperOK && !it.lowerOK {
+			return false
+		}
+		if !it.upperOK {
+			it.fromUp = false
+			return true
+		}
+		if it.lowerOK && bytes.Compare(it.lower.Key(), it.upper.Key()) < 0 {
+			it.fromUp = false
+			return true
+		}
+		if it.upper.Value() != nil {
+			it.fromUp = true
+			return true
+		}
+		// deleted in upper
+		if it.lowerOK && bytes.Equal(it.upper.Key(), it.lower.Key()) {
+			it.lowerOK = it.lower.Next()
+		}
+		it.upperOK = it.upper.Next()
+	}
+}
+
+func (it *overlayIterator) Key() []byte {
+	if it.fromUp {
+		return it.upper.Key()
+	}
+	return it.lower.Key()
+}
+
+func (it *overlayIterator) Value() []byte {
+	if it.fromUp {
+		return it.upper.Value()
+	}
+	return it.lower.Value()
+}
+
+func (it *overlayIterator) Error() error {
+	return it.lower.Error()
+}
+
+func (it *overlayIterator) Release() {
+	it.upper.Release()
+	it.lower.Release()
+}
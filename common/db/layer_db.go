@@ -2,6 +2,7 @@ package db
 
 import (
 	"container/list"
+	"sort"
 	"sync"
 
 	"github.com/icon-project/goloop/common/errors"
@@ -34,6 +35,7 @@ func (l *layerBucketItems) MoveToBack(element *list.Element) {
 
 type layerBucket struct {
 	lock sync.Mutex
+	id   BucketID
 	data map[string]*list.Element
 	list *layerBucketItems
 	real Bucket
@@ -67,6 +69,10 @@ func (bk *layerBucket) Set(key []byte, value []byte) error {
 	bk.lock.Lock()
 	defer bk.lock.Unlock()
 
+	return bk._set(key, value)
+}
+
+func (bk *layerBucket) _set(key []byte, value []byte) error {
 	if bk.data != nil {
 		v2 := make([]byte, len(value))
 		copy(v2, value)
@@ -89,6 +95,10 @@ func (bk *layerBucket) Delete(key []byte) error {
 	bk.lock.Lock()
 	defer bk.lock.Unlock()
 
+	return bk._delete(key)
+}
+
+func (bk *layerBucket) _delete(key []byte) error {
 	if bk.data != nil {
 		if element, ok := bk.data[string(key)] ; ok {
 			bk.list.MoveToBack(element)
@@ -103,6 +113,26 @@ func (bk *layerBucket) Delete(key []byte) error {
 	}
 }
 
+// NewIterator returns the iterator merging written items of the layer over
+// the real bucket. Items of the layer are taken at the time of the call.
+func (bk *layerBucket) NewIterator(r *Range) (Iterator, error) {
+	bk.lock.Lock()
+	defer bk.lock.Unlock()
+
+	it, err := NewIterator(bk.real, r)
+	if err != nil || bk.data == nil {
+		return it, err
+	}
+	var kvs []keyValue
+	for k, element := range bk.data {
+		key := []byte(k)
+		if r.Contains(key) {
+			kvs = append(kvs, keyValue{key, element.Value.(*layerBucketItem).value})
+		}
+	}
+	return newOverlayIterator(kvs, it), nil
+}
+
 type layerDB struct {
 	lock sync.Mutex
 
@@ -116,6 +146,10 @@ func (ldb *layerDB) GetBucket(id BucketID) (Bucket, error) {
 	ldb.lock.Lock()
 	defer ldb.lock.Unlock()
 
+	return ldb.getBucket(id)
+}
+
+func (ldb *layerDB) getBucket(id BucketID) (Bucket, error) {
 	if bk, ok := ldb.buckets[string(id)]; ok {
 		return bk, nil
 	}
@@ -128,6 +162,7 @@ func (ldb *layerDB) GetBucket(id BucketID) (Bucket, error) {
 		return realbk, nil
 	}
 	bk := &layerBucket{
+		id:   id,
 		data: make(map[string]*list.Element),
 		list: &ldb.list,
 		real: realbk,
@@ -159,19 +194,23 @@ func (ldb *layerDB) Flush(write bool) error {
 	}()
 
 	if write {
+		batch := NewBatch(ldb.real)
 		for element := ldb.list.Front() ; element != nil ; element = element.Next() {
 			item := element.Value.(*layerBucketItem)
 
 			if item.value != nil {
-				if err := item.bk.real.Set([]byte(item.key), item.value ); err != nil {
+				if err := batch.Set(item.bk.id, []byte(item.key), item.value ); err != nil {
 					return err
 				}
 			} else {
-				if err := item.bk.real.Delete([]byte(item.key)); err != nil {
+				if err := batch.Delete(item.bk.id, []byte(item.key)); err != nil {
 					return err
 				}
 			}
 		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
 		for _, bk := range ldb.buckets {
 			bk.data = nil
 		}
@@ -189,6 +228,91 @@ func (ldb *layerDB) Close() error {
 	return nil
 }
 
+func (ldb *layerDB) NewBatch() Batch {
+	return &layerBatch{ldb: ldb}
+}
+
+type layerBatch struct {
+	ldb *layerDB
+	ops batchOps
+}
+
+func (b *layerBatch) Set(id BucketID, key []byte, value []byte) error {
+	b.ops.set(id, key, value)
+	return nil
+}
+
+func (b *layerBatch) Delete(id BucketID, key []byte) error {
+	b.ops.delete(id, key)
+	return nil
+}
+
+func (b *layerBatch) Len() int {
+	return len(b.ops)
+}
+
+func (b *layerBatch) Reset() {
+	b.ops = nil
+}
+
+func (b *layerBatch) Commit() error {
+	ldb := b.ldb
+	ldb.lock.Lock()
+	defer ldb.lock.Unlock()
+
+	if ldb.flushed {
+		batch := NewBatch(ldb.real)
+		for _, op := range b.ops {
+			var err error
+			if op.isDelete() {
+				err = batch.Delete(op.id, op.key)
+			} else {
+				err = batch.Set(op.id, op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+		b.ops = nil
+		return nil
+	}
+
+	buckets := make(map[BucketID]*layerBucket)
+	for _, op := range b.ops {
+		if _, ok := buckets[op.id]; !ok {
+			bk, err := ldb.getBucket(op.id)
+			if err != nil {
+				return err
+			}
+			buckets[op.id] = bk.(*layerBucket)
+		}
+	}
+	ids := make([]string, 0, len(buckets))
+	for id := range buckets {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		bk := buckets[BucketID(id)]
+		bk.lock.Lock()
+		defer bk.lock.Unlock()
+	}
+
+	for _, op := range b.ops {
+		bk := buckets[op.id]
+		if op.isDelete() {
+			bk._delete(op.key)
+		} else {
+			bk._set(op.key, op.value)
+		}
+	}
+	b.ops = nil
+	return nil
+}
+
 type layerDBContext struct {
 	LayerDB
 	flags Flags
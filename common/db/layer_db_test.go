@@ -147,3 +147,38 @@ func TestLayerDB_FlushInOrder(t *testing.T) {
 
 	assert.Equal(t, Unwrap(ldb), dbase)
 }
+
+func TestLayerDB_Iterator(t *testing.T) {
+	dbase := NewMapDB()
+	real, err := dbase.GetBucket(BytesByHash)
+	assert.NoError(t, err)
+	for _, k := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, real.Set([]byte(k), []byte("r"+k)))
+	}
+
+	ldb := NewLayerDB(dbase)
+	bk, err := ldb.GetBucket(BytesByHash)
+	assert.NoError(t, err)
+	assert.NoError(t, bk.Set([]byte("b"), []byte("lb")))
+	assert.NoError(t, bk.Delete([]byte("c")))
+	assert.NoError(t, bk.Set([]byte("e"), []byte("le")))
+	assert.NoError(t, bk.Delete([]byte("f")))
+	assert.NoError(t, bk.Set([]byte("0"), []byte("l0")))
+
+	it, err := NewIterator(bk, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0=l0", "a=ra", "b=lb", "d=rd", "e=le"}, collectIterator(t, it))
+
+	it, err = NewIterator(bk, &Range{Start: []byte("b"), Limit: []byte("e")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b=lb", "d=rd"}, collectIterator(t, it))
+
+	it, err = NewIterator(real, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a=ra", "b=rb", "c=rc", "d=rd"}, collectIterator(t, it))
+
+	assert.NoError(t, ldb.Flush(true))
+	it, err = NewIterator(real, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0=l0", "a=ra", "b=lb", "d=rd", "e=le"}, collectIterator(t, it))
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/icon-project/goloop/common/log"
@@ -32,6 +33,7 @@ func NewMapDB() Database {
 // DB
 
 var _ Database = (*mapDatabase)(nil)
+var _ Batcher = (*mapDatabase)(nil)
 
 type mapDatabase struct {
 	lock sync.Mutex
@@ -58,10 +60,74 @@ func (t *mapDatabase) Close() error {
 	return nil
 }
 
+func (t *mapDatabase) NewBatch() Batch {
+	return &mapBatch{database: t}
+}
+
+//----------------------------------------
+// Batch
+
+type mapBatch struct {
+	database *mapDatabase
+	ops      batchOps
+}
+
+func (b *mapBatch) Set(id BucketID, key []byte, value []byte) error {
+	b.ops.set(id, key, value)
+	return nil
+}
+
+func (b *mapBatch) Delete(id BucketID, key []byte) error {
+	b.ops.delete(id, key)
+	return nil
+}
+
+func (b *mapBatch) Len() int {
+	return len(b.ops)
+}
+
+func (b *mapBatch) Reset() {
+	b.ops = nil
+}
+
+func (b *mapBatch) Commit() error {
+	buckets := make(map[BucketID]*mapBucket)
+	for _, op := range b.ops {
+		if _, ok := buckets[op.id]; !ok {
+			bk, _ := b.database.GetBucket(op.id)
+			buckets[op.id] = bk.(*mapBucket)
+		}
+	}
+
+	// lock buckets in order of ids to avoid deadlock with other batches
+	ids := make([]string, 0, len(buckets))
+	for id := range buckets {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		bk := buckets[BucketID(id)]
+		bk.mutex.Lock()
+		defer bk.mutex.Unlock()
+	}
+
+	for _, op := range b.ops {
+		bk := buckets[op.id]
+		if op.isDelete() {
+			delete(bk.real, string(op.key))
+		} else {
+			bk.real[string(op.key)] = string(op.value)
+		}
+	}
+	b.ops = nil
+	return nil
+}
+
 //----------------------------------------
 // Bucket
 
 var _ Bucket = (*mapBucket)(nil)
+var _ IterableBucket = (*mapBucket)(nil)
 
 type mapBucket struct {
 	id    string
@@ -115,3 +181,18 @@ func (t *mapBucket) Delete(k []byte) error {
 	delete(t.real, string(k))
 	return nil
 }
+
+// NewIterator returns the iterator on the snapshot of the bucket, so
+// following writes are not visible to the iterator.
+func (t *mapBucket) NewIterator(r *Range) (Iterator, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var kvs []keyValue
+	for k, v := range t.real {
+		key := []byte(k)
+		if r.Contains(key) {
+			kvs = append(kvs, keyValue{key, []byte(v)})
+		}
+	}
+	return newSliceIterator(kvs), nil
+}
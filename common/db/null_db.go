@@ -30,6 +30,10 @@ func (*nullBucket) Delete(key []byte) error {
 	panic("NullBucket.Delete() Unsupported")
 }
 
+func (*nullBucket) NewIterator(r *Range) (Iterator, error) {
+	return newSliceIterator(nil), nil
+}
+
 func NewNullDB() *nullDB {
 	return &nullDB{}
 }
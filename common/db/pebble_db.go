@@ -0,0 +1,350 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package db
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/cockroachdb/pebble"
+)
+
+const PebbleDBBackend BackendType = "pebble"
+
+func init() {
+	dbCreator := func(name string, dir string) (Database, error) {
+		return NewPebbleDB(name, dir)
+	}
+	registerDBCreator(PebbleDBBackend, dbCreator, false)
+}
+
+func NewPebbleDB(name string, dir string) (*PebbleDB, error) {
+	return NewPebbleDBWithOpts(name, dir, nil)
+}
+
+func NewPebbleDBWithOpts(name string, dir string, o *pebble.Options) (*PebbleDB, error) {
+	dbPath := filepath.Join(dir, name)
+	db, err := pebble.Open(dbPath, o)
+	if err != nil {
+		return nil, err
+	}
+	database := &PebbleDB{
+		db:      db,
+		buckets: make(map[BucketID]Bucket),
+	}
+	return database, nil
+}
+
+//----------------------------------------
+// Database
+
+var _ Database = (*PebbleDB)(nil)
+var _ Batcher = (*PebbleDB)(nil)
+var _ Compactor = (*PebbleDB)(nil)
+
+// PebbleDB keeps all buckets in one key space with bucket prefixes like
+// GoLevelDB. Pebble panics on use after close, so every access is guarded
+// by the lock.
+type PebbleDB struct {
+	lock    sync.RWMutex
+	db      *pebble.DB
+	buckets map[BucketID]Bucket
+}
+
+func (db *PebbleDB) GetBucket(id BucketID) (Bucket, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return nil, pebble.ErrClosed
+	}
+
+	if bk, ok := db.buckets[id]; ok {
+		return bk, nil
+	} else {
+		bk = &pebbleBucket{
+			id:       id,
+			database: db,
+		}
+		db.buckets[id] = bk
+		return bk, nil
+	}
+}
+
+func (db *PebbleDB) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return pebble.ErrClosed
+	}
+	if err := db.db.Close(); err != nil {
+		return err
+	}
+	db.db = nil
+	return nil
+}
+
+func (db *PebbleDB) Compact() error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return pebble.ErrClosed
+	}
+	it, err := db.db.NewIter(nil)
+	if err != nil {
+		return err
+	}
+	var first, last []byte
+	if it.First() {
+		first = append([]byte{}, it.Key()...)
+	}
+	if it.Last() {
+		last = append([]byte{}, it.Key()...)
+	}
+	if err := it.Close(); err != nil {
+		return err
+	}
+	if first == nil || last == nil {
+		return nil
+	}
+	// end of the range is exclusive
+	return db.db.Compact(first, append(last, 0), true)
+}
+
+func (db *PebbleDB) NewRawIterator() (Iterator, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return nil, pebble.ErrClosed
+	}
+	it, err := db.db.NewIter(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &pebbleIterator{it: it, raw: true}, nil
+}
+
+func (db *PebbleDB) NewBatch() Batch {
+	return &pebbleBatch{database: db}
+}
+
+//----------------------------------------
+// Batch
+
+type pebbleBatch struct {
+	database *PebbleDB
+	ops      batchOps
+}
+
+func (b *pebbleBatch) Set(id BucketID, key []byte, value []byte) error {
+	b.ops.set(id, key, value)
+	return nil
+}
+
+func (b *pebbleBatch) Delete(id BucketID, key []byte) error {
+	b.ops.delete(id, key)
+	return nil
+}
+
+func (b *pebbleBatch) Len() int {
+	return len(b.ops)
+}
+
+func (b *pebbleBatch) Reset() {
+	b.ops = nil
+}
+
+func (b *pebbleBatch) Commit() error {
+	b.database.lock.RLock()
+	defer b.database.lock.RUnlock()
+
+	if b.database.db == nil {
+		return pebble.ErrClosed
+	}
+	batch := b.database.db.NewBatch()
+	defer batch.Close()
+	for _, op := range b.ops {
+		var err error
+		if op.isDelete() {
+			err = batch.Delete(internalKey(op.id, op.key), nil)
+		} else {
+			err = batch.Set(internalKey(op.id, op.key), op.value, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := batch.Commit(pebble.NoSync); err != nil {
+		return err
+	}
+	b.Reset()
+	return nil
+}
+
+//----------------------------------------
+// Bucket
+
+var _ Bucket = (*pebbleBucket)(nil)
+var _ IterableBucket = (*pebbleBucket)(nil)
+
+type pebbleBucket struct {
+	id       BucketID
+	database *PebbleDB
+}
+
+func (bucket *pebbleBucket) Get(key []byte) ([]byte, error) {
+	bucket.database.lock.RLock()
+	defer bucket.database.lock.RUnlock()
+
+	if bucket.database.db == nil {
+		return nil, pebble.ErrClosed
+	}
+	value, closer, err := bucket.database.db.Get(internalKey(bucket.id, key))
+	if err == pebble.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	return append([]byte{}, value...), nil
+}
+
+func (bucket *pebbleBucket) Has(key []byte) (bool, error) {
+	bucket.database.lock.RLock()
+	defer bucket.database.lock.RUnlock()
+
+	if bucket.database.db == nil {
+		return false, pebble.ErrClosed
+	}
+	_, closer, err := bucket.database.db.Get(internalKey(bucket.id, key))
+	if err == pebble.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, closer.Close()
+}
+
+func (bucket *pebbleBucket) Set(key []byte, value []byte) error {
+	bucket.database.lock.RLock()
+	defer bucket.database.lock.RUnlock()
+
+	if bucket.database.db == nil {
+		return pebble.ErrClosed
+	}
+	return bucket.database.db.Set(internalKey(bucket.id, key), value, pebble.NoSync)
+}
+
+func (bucket *pebbleBucket) Delete(key []byte) error {
+	bucket.database.lock.RLock()
+	defer bucket.database.lock.RUnlock()
+
+	if bucket.database.db == nil {
+		return pebble.ErrClosed
+	}
+	return bucket.database.db.Delete(internalKey(bucket.id, key), pebble.NoSync)
+}
+
+// NewIterator returns the iterator for keys in the range. All buckets share
+// one key space, so entries of other buckets are filtered out by the key
+// and the value.
+func (bucket *pebbleBucket) NewIterator(r *Range) (Iterator, error) {
+	bucket.database.lock.RLock()
+	defer bucket.database.lock.RUnlock()
+
+	if bucket.database.db == nil {
+		return nil, pebble.ErrClosed
+	}
+	prefix := []byte(bucket.id)
+	rg := PrefixRange(prefix)
+	if r != nil {
+		if r.Start != nil {
+			rg.Start = internalKey(bucket.id, r.Start)
+		}
+		if r.Limit != nil {
+			rg.Limit = internalKey(bucket.id, r.Limit)
+		}
+	}
+	it, err := bucket.database.db.NewIter(&pebble.IterOptions{
+		LowerBound: rg.Start,
+		UpperBound: rg.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pebbleIterator{
+		it:     it,
+		id:     bucket.id,
+		prefix: len(prefix),
+	}, nil
+}
+
+type pebbleIterator struct {
+	it      *pebble.Iterator
+	id      BucketID
+	prefix  int
+	raw     bool
+	started bool
+	err     error
+}
+
+func (it *pebbleIterator) Next() bool {
+	var ok bool
+	if !it.started {
+		it.started = true
+		ok = it.it.First()
+	} else {
+		ok = it.it.Next()
+	}
+	for ; ok; ok = it.it.Next() {
+		if it.raw || acceptSharedEntry(it.id, it.it.Key(), it.it.Value()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *pebbleIterator) Key() []byte {
+	if !it.it.Valid() {
+		return nil
+	}
+	return append([]byte{}, it.it.Key()[it.prefix:]...)
+}
+
+func (it *pebbleIterator) Value() []byte {
+	if !it.it.Valid() {
+		return nil
+	}
+	return append([]byte{}, it.it.Value()...)
+}
+
+func (it *pebbleIterator) Error() error {
+	if it.it == nil {
+		return it.err
+	}
+	return it.it.Error()
+}
+
+func (it *pebbleIterator) Release() {
+	if it.it != nil {
+		it.err = it.it.Close()
+		it.it = nil
+	}
+}
@@ -36,6 +36,13 @@ func (bk *proxyBucket) Delete(key []byte) error {
 	return errors.New("ProxyIsNotRealized")
 }
 
+func (bk *proxyBucket) NewIterator(r *Range) (Iterator, error) {
+	if bk.real != nil {
+		return NewIterator(bk.real, r)
+	}
+	return nil, errors.New("ProxyIsNotRealized")
+}
+
 type proxyDB struct {
 	real    Database
 	buckets map[string]*proxyBucket
@@ -20,6 +20,7 @@
 package db
 
 import (
+	"bytes"
 	"errors"
 	"os"
 	"path"
@@ -316,3 +317,185 @@ func (b *RocksBucket) Set(key []byte, value []byte) error {
 func (b *RocksBucket) Delete(key []byte) error {
 	return b.db.deleteValue(b.cf, key)
 }
+
+// NewIterator returns the iterator for keys in the range. Each bucket has
+// its own column family, so keys of other buckets are not returned.
+func (b *RocksBucket) NewIterator(r *Range) (Iterator, error) {
+	return b.db.newIterator(b.cf, r)
+}
+
+func (db *RocksDB) Compact() error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return ErrAlreadyClosed
+	}
+
+	db.bkLock.Lock()
+	defer db.bkLock.Unlock()
+
+	for _, bk := range db.buckets {
+		C.rocksdb_compact_range_cf(db.db, bk.cf, nil, 0, nil, 0)
+	}
+	return nil
+}
+
+func (db *RocksDB) NewBatch() Batch {
+	return &rocksBatch{db: db}
+}
+
+type rocksBatch struct {
+	db  *RocksDB
+	ops batchOps
+}
+
+func (b *rocksBatch) Set(id BucketID, key []byte, value []byte) error {
+	b.ops.set(id, key, value)
+	return nil
+}
+
+func (b *rocksBatch) Delete(id BucketID, key []byte) error {
+	b.ops.delete(id, key)
+	return nil
+}
+
+func (b *rocksBatch) Len() int {
+	return len(b.ops)
+}
+
+func (b *rocksBatch) Reset() {
+	b.ops = nil
+}
+
+func (b *rocksBatch) Commit() error {
+	cfs := make([]*C.rocksdb_column_family_handle_t, len(b.ops))
+	for i, op := range b.ops {
+		bk, err := b.db.GetBucket(op.id)
+		if err != nil {
+			return err
+		}
+		cfs[i] = bk.(*RocksBucket).cf
+	}
+
+	b.db.lock.RLock()
+	defer b.db.lock.RUnlock()
+
+	if b.db.db == nil {
+		return ErrAlreadyClosed
+	}
+	wb := C.rocksdb_writebatch_create()
+	defer C.rocksdb_writebatch_destroy(wb)
+	for i, op := range b.ops {
+		cKey := (*C.char)(unsafePointerOf(op.key))
+		if op.isDelete() {
+			C.rocksdb_writebatch_delete_cf(wb, cfs[i], cKey, C.size_t(len(op.key)))
+		} else {
+			cValue := (*C.char)(unsafePointerOf(op.value))
+			C.rocksdb_writebatch_put_cf(wb, cfs[i], cKey, C.size_t(len(op.key)), cValue, C.size_t(len(op.value)))
+		}
+	}
+	var cErr *C.char
+	C.rocksdb_write(b.db.db, b.db.wo, wb, &cErr)
+	if cErr != nil {
+		defer C.rocksdb_free(unsafe.Pointer(cErr))
+		return errors.New(C.GoString(cErr))
+	}
+	b.ops = nil
+	return nil
+}
+
+// newIterator returns the iterator of the column family. The iterator
+// should be released before the database is closed.
+func (db *RocksDB) newIterator(cf *C.rocksdb_column_family_handle_t, r *Range) (Iterator, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return nil, ErrAlreadyClosed
+	}
+	it := &rocksIterator{
+		db: db,
+		it: C.rocksdb_create_iterator_cf(db.db, db.ro, cf),
+	}
+	if r != nil {
+		it.start = r.Start
+		it.limit = r.Limit
+	}
+	return it, nil
+}
+
+type rocksIterator struct {
+	db      *RocksDB
+	it      *C.rocksdb_iterator_t
+	start   []byte
+	limit   []byte
+	started bool
+
+	key   []byte
+	value []byte
+	err   error
+}
+
+func (it *rocksIterator) Next() bool {
+	it.key, it.value = nil, nil
+	if it.it == nil {
+		return false
+	}
+	it.db.lock.RLock()
+	defer it.db.lock.RUnlock()
+
+	if it.db.db == nil {
+		it.err = ErrAlreadyClosed
+		return false
+	}
+	if !it.started {
+		it.started = true
+		if it.start != nil {
+			C.rocksdb_iter_seek(it.it, (*C.char)(unsafePointerOf(it.start)), C.size_t(len(it.start)))
+		} else {
+			C.rocksdb_iter_seek_to_first(it.it)
+		}
+	} else {
+		C.rocksdb_iter_next(it.it)
+	}
+	if C.rocksdb_iter_valid(it.it) == 0 {
+		var cErr *C.char
+		C.rocksdb_iter_get_error(it.it, &cErr)
+		if cErr != nil {
+			defer C.rocksdb_free(unsafe.Pointer(cErr))
+			it.err = errors.New(C.GoString(cErr))
+		}
+		return false
+	}
+	var cLen C.size_t
+	cKey := C.rocksdb_iter_key(it.it, &cLen)
+	key := C.GoBytes(unsafe.Pointer(cKey), C.int(cLen))
+	if it.limit != nil && bytes.Compare(key, it.limit) >= 0 {
+		return false
+	}
+	cValue := C.rocksdb_iter_value(it.it, &cLen)
+	it.key = key
+	it.value = C.GoBytes(unsafe.Pointer(cValue), C.int(cLen))
+	return true
+}
+
+func (it *rocksIterator) Key() []byte {
+	return it.key
+}
+
+func (it *rocksIterator) Value() []byte {
+	return it.value
+}
+
+func (it *rocksIterator) Error() error {
+	return it.err
+}
+
+func (it *rocksIterator) Release() {
+	if it.it != nil {
+		C.rocksdb_iter_destroy(it.it)
+		it.it = nil
+	}
+	it.key, it.value = nil, nil
+}
@@ -160,6 +160,17 @@ func (b *merkleBuilder) RequestData(bid db.BucketID, key []byte, requester DataR
 	}
 }
 
+// dropRequest removes the request for the key without resolving it.
+func (b *merkleBuilder) dropRequest(key []byte) {
+	reqID := string(key)
+	for _, reqMap := range b.hasherMap {
+		if e, ok := reqMap[reqID]; ok {
+			b.requests.Remove(e)
+			delete(reqMap, reqID)
+		}
+	}
+}
+
 func (b *merkleBuilder) UnresolvedCount() int {
 	return b.requests.Len()
 }
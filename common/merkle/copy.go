@@ -21,6 +21,11 @@ type CopyContext struct {
 
 	height     int64
 	progressCB module.ProgressCallback
+	missingCB  func(key []byte) error
+}
+
+type requestDropper interface {
+	dropRequest(key []byte)
 }
 
 func (e *CopyContext) Builder() Builder {
@@ -31,6 +36,25 @@ func (e *CopyContext) SetProgressCallback(cb module.ProgressCallback) {
 	e.progressCB = cb
 }
 
+// SetMissingCallback sets the callback for the entries missing in the
+// source or having invalid values. If it's set, Run calls it and continues
+// without them instead of returning an error.
+func (e *CopyContext) SetMissingCallback(cb func(key []byte) error) {
+	e.missingCB = cb
+}
+
+func (e *CopyContext) onMissing(key []byte) error {
+	dropper, ok := e.builder.(requestDropper)
+	if e.missingCB == nil || !ok {
+		return errors.NotFoundError.Errorf("FailToFindValue(key=%x)", key)
+	}
+	if err := e.missingCB(key); err != nil {
+		return err
+	}
+	dropper.dropRequest(key)
+	return nil
+}
+
 func (e *CopyContext) SetHeight(height int64) {
 	e.height = height
 }
@@ -62,6 +86,9 @@ func (e *CopyContext) Run() error {
 				}
 				if v1 != nil {
 					err := e.builder.OnData(id, v1)
+					if err == ErrNoRequester && e.missingCB != nil {
+						break
+					}
 					if err != nil {
 						return err
 					}
@@ -70,8 +97,10 @@ func (e *CopyContext) Run() error {
 				}
 			}
 			if !found {
-				_ = e.reportProgress()
-				return errors.NotFoundError.Errorf("FailToFindValue(key=%x)", itr.Key())
+				if err := e.onMissing(itr.Key()); err != nil {
+					_ = e.reportProgress()
+					return err
+				}
 			}
 
 			// Prevent massive memory usage by cumulated requests.
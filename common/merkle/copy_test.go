@@ -0,0 +1,55 @@
+package merkle_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/merkle"
+	"github.com/icon-project/goloop/common/trie/trie_manager"
+)
+
+func TestCopyContext_Missing(t *testing.T) {
+	src := db.NewMapDB()
+	mutable := trie_manager.NewMutable(src, nil)
+	for i := 0; i < 100; i++ {
+		_, err := mutable.Set([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		assert.NoError(t, err)
+	}
+	ss := mutable.GetSnapshot()
+	assert.NoError(t, ss.Flush())
+
+	// remove a node other than the root
+	bk, err := src.GetBucket(db.MerkleTrie)
+	assert.NoError(t, err)
+	var removed []byte
+	itr, err := db.NewIterator(bk, nil)
+	assert.NoError(t, err)
+	for itr.Next() {
+		if string(itr.Key()) != string(ss.Hash()) {
+			removed = append([]byte{}, itr.Key()...)
+			break
+		}
+	}
+	itr.Release()
+	assert.NoError(t, bk.Delete(removed))
+
+	ctx := merkle.NewCopyContext(src, db.NewMapDB())
+	trie_manager.NewImmutable(ctx.TargetDB(), ss.Hash()).Resolve(ctx.Builder())
+	err = ctx.Run()
+	assert.True(t, errors.NotFoundError.Equals(err))
+
+	var missing [][]byte
+	ctx = merkle.NewCopyContext(src, db.NewMapDB())
+	ctx.SetMissingCallback(func(key []byte) error {
+		missing = append(missing, key)
+		return nil
+	})
+	trie_manager.NewImmutable(ctx.TargetDB(), ss.Hash()).Resolve(ctx.Builder())
+	assert.NoError(t, ctx.Run())
+	assert.Equal(t, [][]byte{removed}, missing)
+	assert.Equal(t, 0, ctx.Builder().UnresolvedCount())
+}
@@ -40,6 +40,7 @@ type BranchCache struct {
 	size   int
 	f      *os.File
 	missed int
+	usage  *usage
 }
 
 func (c *BranchCache) Get(nibs []byte, h []byte) ([]byte, bool) {
@@ -76,12 +77,28 @@ func (c *BranchCache) Put(nibs []byte, h []byte, serialized []byte) {
 	idx := indexByNibs(nibs)
 
 	if idx < c.offset {
-		c.nodes[idx] = [2][]byte{h, serialized}
+		c.setNode(idx, h, serialized)
 	} else {
 		c.write(idx, h, serialized)
 	}
 }
 
+// setNode replaces the node in the memory. The node is dropped instead if
+// the cache exceeds its share of the budget.
+func (c *BranchCache) setNode(idx int, h []byte, serialized []byte) {
+	if old := c.nodes[idx]; old[0] != nil {
+		c.usage.add(-nodeSize(old[0], old[1]))
+	}
+	size := nodeSize(h, serialized)
+	c.usage.add(size)
+	if c.usage.exceeded() {
+		c.usage.evict(size)
+		c.nodes[idx] = [2][]byte{}
+		return
+	}
+	c.nodes[idx] = [2][]byte{h, serialized}
+}
+
 func (c *BranchCache) read(idx int) [][]byte {
 	at, err := c.f.Seek(int64((idx-c.offset)*fileCacheItemSize), 0)
 	if err != nil {
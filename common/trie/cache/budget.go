@@ -0,0 +1,208 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// nodeOverhead is approximated memory for keeping a node in the cache
+// except its hash and serialized bytes (slice headers, list element and
+// map entry).
+const nodeOverhead = 96
+
+func nodeSize(h, v []byte) int64 {
+	return int64(len(h) + len(v) + nodeOverhead)
+}
+
+// Budget is the memory budget shared by trie node caches of the world and
+// accounts of a chain, and other caches using Usage like the API info cache.
+// Caches charge bytes of entries in memory to the budget. While the budget is
+// exceeded, caches keeping more than their share (limit divided by the
+// number of caches) evict old entries or don't grow, so a busy cache doesn't
+// empty others. Caches under their share may still grow, but they stop at
+// their share. It also counts lookups of node caches for metrics.
+// Nil Budget means no limit and no statistics.
+type Budget struct {
+	limit  int64
+	bytes  int64
+	caches int64
+	hits   int64
+	misses int64
+	evicts int64
+}
+
+type BudgetStat struct {
+	Limit  int64 `json:"limit"`
+	Bytes  int64 `json:"bytes"`
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Evicts int64 `json:"evicts"`
+}
+
+func (s BudgetStat) String() string {
+	return fmt.Sprintf("BudgetStat{limit=%d bytes=%d hits=%d misses=%d evicts=%d}",
+		s.Limit, s.Bytes, s.Hits, s.Misses, s.Evicts)
+}
+
+func (b *Budget) charge(n int64) {
+	if b != nil {
+		atomic.AddInt64(&b.bytes, n)
+	}
+}
+
+func (b *Budget) exceeded() bool {
+	return b != nil && b.limit > 0 && atomic.LoadInt64(&b.bytes) > b.limit
+}
+
+// join registers a cache sharing the budget.
+func (b *Budget) join() {
+	if b != nil {
+		atomic.AddInt64(&b.caches, 1)
+	}
+}
+
+// leave unregisters a cache sharing the budget.
+func (b *Budget) leave() {
+	if b != nil {
+		atomic.AddInt64(&b.caches, -1)
+	}
+}
+
+// share returns bytes which a cache may keep while the budget is exceeded.
+func (b *Budget) share() int64 {
+	n := atomic.LoadInt64(&b.caches)
+	if n < 1 {
+		n = 1
+	}
+	return b.limit / n
+}
+
+func (b *Budget) onLookup(hit bool) {
+	if b == nil {
+		return
+	}
+	if hit {
+		atomic.AddInt64(&b.hits, 1)
+	} else {
+		atomic.AddInt64(&b.misses, 1)
+	}
+}
+
+func (b *Budget) onEvict() {
+	if b != nil {
+		atomic.AddInt64(&b.evicts, 1)
+	}
+}
+
+func (b *Budget) Stat() BudgetStat {
+	if b == nil {
+		return BudgetStat{}
+	}
+	return BudgetStat{
+		Limit:  b.limit,
+		Bytes:  atomic.LoadInt64(&b.bytes),
+		Hits:   atomic.LoadInt64(&b.hits),
+		Misses: atomic.LoadInt64(&b.misses),
+		Evicts: atomic.LoadInt64(&b.evicts),
+	}
+}
+
+// NewBudget returns a new budget with limit in bytes. Zero limit means
+// unlimited, but the budget still counts bytes and lookups.
+func NewBudget(limit int64) *Budget {
+	return &Budget{limit: limit}
+}
+
+// usage tracks bytes charged by a NodeCache, so that they can be returned
+// to the budget when the cache is dropped.
+type usage struct {
+	budget *Budget
+	bytes  int64
+}
+
+func newUsage(budget *Budget) usage {
+	budget.join()
+	return usage{budget: budget}
+}
+
+func (u *usage) add(n int64) {
+	if u == nil {
+		return
+	}
+	u.bytes += n
+	u.budget.charge(n)
+}
+
+func (u *usage) evict(n int64) {
+	if u == nil {
+		return
+	}
+	u.add(-n)
+	u.budget.onEvict()
+}
+
+// exceeded returns whether the cache should evict old nodes or stop
+// growing. It's when the budget is exceeded and the cache keeps more than
+// its share.
+func (u *usage) exceeded() bool {
+	return u != nil && u.budget.exceeded() && u.bytes > u.budget.share()
+}
+
+func (u *usage) release() {
+	if u == nil {
+		return
+	}
+	u.budget.charge(-u.bytes)
+	u.budget.leave()
+	u.bytes = 0
+	u.budget = nil
+}
+
+// Usage is a part of the budget used by a cache other than node caches,
+// like the API info cache. It's not safe for concurrent use, so the cache
+// should call it in its lock.
+type Usage struct {
+	usage
+}
+
+// NewUsage returns a new usage of the budget for a cache. It returns nil
+// for nil budget.
+func (b *Budget) NewUsage() *Usage {
+	if b == nil {
+		return nil
+	}
+	return &Usage{newUsage(b)}
+}
+
+func (u *Usage) Add(n int64) {
+	u.add(n)
+}
+
+func (u *Usage) Evict(n int64) {
+	u.evict(n)
+}
+
+func (u *Usage) Exceeded() bool {
+	return u.exceeded()
+}
+
+// Release returns bytes of the cache to the budget.
+func (u *Usage) Release() {
+	u.release()
+}
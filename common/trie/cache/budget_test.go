@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/crypto"
+)
+
+func TestNodeCache_Budget(t *testing.T) {
+	d1 := []byte("data1")
+	h1 := crypto.SHA3Sum256(d1)
+	n1 := bytesToNibs(h1)
+	size := nodeSize(h1, d1)
+
+	budget := NewBudget(size)
+	cache := NewNodeCacheWithBudget(3, 0, "", budget)
+
+	cache.Put(n1[0:1], h1, d1)
+	assert.Equal(t, size, budget.Stat().Bytes)
+
+	data, ok := cache.Get(n1[0:1], h1)
+	assert.True(t, ok)
+	assert.Equal(t, d1, data)
+
+	// putting the same node again shouldn't be charged twice
+	cache.Put(n1[0:1], h1, d1)
+	assert.Equal(t, size, budget.Stat().Bytes)
+
+	// no room for another node
+	d2 := []byte("data2")
+	h2 := crypto.SHA3Sum256(d2)
+	n2 := bytesToNibs(h2)
+	cache.Put(n2[0:2], h2, d2)
+	data, _ = cache.Get(n2[0:2], h2)
+	assert.Nil(t, data)
+
+	stat := budget.Stat()
+	assert.Equal(t, size, stat.Bytes)
+	assert.EqualValues(t, 1, stat.Hits)
+	assert.EqualValues(t, 1, stat.Misses)
+	assert.EqualValues(t, 1, stat.Evicts)
+
+	cache.release()
+	assert.EqualValues(t, 0, budget.Stat().Bytes)
+}
+
+func TestFullCache_Budget(t *testing.T) {
+	var nodes [][]byte
+	for i := 0; i < 8; i++ {
+		nodes = append(nodes, []byte(fmt.Sprintf("node%d", i)))
+	}
+	size := nodeSize(crypto.SHA3Sum256(nodes[0]), nodes[0])
+
+	budget := NewBudget(size * 4)
+	cache := NewNodeCacheWithBudget(fullCacheBranchDepth, 0, "", budget)
+	fc := NewFullCacheFromBranch(cache.impl.(*BranchCache))
+	cache.impl = fc
+
+	// put nodes in the LRU (deeper than the branch)
+	nibs := make([]byte, fullCacheBranchDepth+1)
+	for _, d := range nodes {
+		cache.Put(nibs, crypto.SHA3Sum256(d), d)
+	}
+	assert.Equal(t, 4, fc.lru.Len())
+	assert.Equal(t, size*4, budget.Stat().Bytes)
+	assert.EqualValues(t, 4, budget.Stat().Evicts)
+
+	// least recently used nodes are evicted
+	for i, d := range nodes {
+		data, ok := cache.Get(nibs, crypto.SHA3Sum256(d))
+		assert.True(t, ok)
+		if i < 4 {
+			assert.Nil(t, data)
+		} else {
+			assert.Equal(t, d, data)
+		}
+	}
+}
+
+func TestNodeCache_BudgetShare(t *testing.T) {
+	var nodes [][]byte
+	for i := 0; i < 8; i++ {
+		nodes = append(nodes, []byte(fmt.Sprintf("node%d", i)))
+	}
+	size := nodeSize(crypto.SHA3Sum256(nodes[0]), nodes[0])
+
+	budget := NewBudget(size * 4)
+	nibs := make([]byte, fullCacheBranchDepth+1)
+	newCache := func() (*NodeCache, *FullCache) {
+		cache := NewNodeCacheWithBudget(fullCacheBranchDepth, 0, "", budget)
+		fc := NewFullCacheFromBranch(cache.impl.(*BranchCache))
+		cache.impl = fc
+		return cache, fc
+	}
+	c1, fc1 := newCache()
+	c2, fc2 := newCache()
+
+	// c1 may use more than its share while the budget isn't exceeded
+	for _, d := range nodes[:4] {
+		c1.Put(nibs, crypto.SHA3Sum256(d), d)
+	}
+	assert.Equal(t, 4, fc1.lru.Len())
+
+	// then c2 evicts its own nodes over its share, not nodes of c1
+	for _, d := range nodes[4:] {
+		c2.Put(nibs, crypto.SHA3Sum256(d), d)
+	}
+	assert.Equal(t, 4, fc1.lru.Len())
+	assert.Equal(t, 2, fc2.lru.Len())
+
+	// c1 shrinks to its share on the next put
+	c1.Put(nibs, crypto.SHA3Sum256(nodes[4]), nodes[4])
+	assert.Equal(t, 2, fc1.lru.Len())
+	assert.Equal(t, size*4, budget.Stat().Bytes)
+
+	c1.release()
+	c2.release()
+	assert.EqualValues(t, 0, budget.Stat().Bytes)
+}
@@ -76,6 +76,8 @@ func (l *nodeCacheList) updateSorted(removed, added *nodeCacheItem) {
 					}
 					delete(l.idToItem, item.id)
 				}
+				item.cache.release()
+				item.cache = nil
 				l.sorted = l.sorted[:idx]
 				continue
 			}
@@ -95,6 +97,7 @@ func (l *nodeCacheList) updateSorted(removed, added *nodeCacheItem) {
 					if logCacheEvents {
 						log.Warnf("RemoveCacheFor(%#x)", item.id)
 					}
+					item.cache.release()
 					item.cache = nil
 				}
 			}
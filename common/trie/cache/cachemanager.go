@@ -19,10 +19,11 @@ const (
 )
 
 type cacheManager struct {
-	path  string
-	depth [2]int
-	world *NodeCache
-	store *nodeCacheList
+	path   string
+	depth  [2]int
+	budget *Budget
+	world  *NodeCache
+	store  *nodeCacheList
 }
 
 func (m *cacheManager) getWorldNodeCache() *NodeCache {
@@ -36,7 +37,7 @@ func (m *cacheManager) getAccountNodeCache(id []byte) *NodeCache {
 
 func (m *cacheManager) newAccountNodeCache(id []byte, mem, file int) *NodeCache {
 	path := path.Join(m.path, hex.EncodeToString(id))
-	return NewNodeCache(mem, file, path)
+	return NewNodeCacheWithBudget(mem, file, path, m.budget)
 }
 
 func (m *cacheManager) newNodeCache(id string) *NodeCache {
@@ -66,6 +67,15 @@ func WorldNodeCacheOf(database db.Database) *NodeCache {
 	return nil
 }
 
+// BudgetOf returns the memory budget shared by node caches of the database.
+// If node cache manager is not attached, it returns nil.
+func BudgetOf(database db.Database) *Budget {
+	if cm := cacheManagerOf(database); cm != nil {
+		return cm.budget
+	}
+	return nil
+}
+
 // AccountNodeCacheOf get node cache of the account specified by *id*.
 // If node cache for the account is not enabled, it returns nil.
 func AccountNodeCacheOf(database db.Database, id []byte) *NodeCache {
@@ -92,11 +102,13 @@ func EnableAccountNodeCacheByForce(database db.Database, id []byte) bool {
 // mem is number of levels of tree items to store in the memory.
 // file is number of levels of tree items to store in files.
 // stores is number of stores to cache.
-func AttachManager(database db.Database, dir string, mem, file, stores int) db.Database {
+// budget is the memory budget shared by all node caches, nil for no limit.
+func AttachManager(database db.Database, dir string, mem, file, stores int, budget *Budget) db.Database {
 	cm := &cacheManager{
-		path:  dir,
-		depth: [2]int{mem, file},
-		world: NewNodeCache(defaultAccountDepth, 0, ""),
+		path:   dir,
+		depth:  [2]int{mem, file},
+		budget: budget,
+		world:  NewNodeCacheWithBudget(defaultAccountDepth, 0, "", budget),
 	}
 	if mem+file > 0 {
 		if stores < 1 {
@@ -42,6 +42,7 @@ type FullCache struct {
 	branch int32
 	hits   int32
 	out    int32
+	usage  *usage
 }
 
 type nodeItem struct {
@@ -59,15 +60,24 @@ func (c *FullCache) getNode(h []byte) []byte {
 	}
 }
 
+func (c *FullCache) evictNode() {
+	e := c.lru.Front()
+	c.lru.Remove(e)
+	c.out += 1
+	item := e.Value.(*nodeItem)
+	delete(c.hash2e, item.key)
+	c.usage.evict(nodeSize([]byte(item.key), item.value))
+}
+
+// putNode puts the node to the LRU. Least recently used nodes are evicted
+// if the LRU is full or the cache exceeds its share of the budget, so a
+// node may not be kept if it's too large for the share.
 func (c *FullCache) putNode(h, v []byte) {
 	if e, ok := c.hash2e[string(h)]; ok {
 		c.lru.MoveToBack(e)
 	} else {
 		if c.lru.Len() >= c.size {
-			e = c.lru.Front()
-			c.lru.Remove(e)
-			c.out += 1
-			delete(c.hash2e, e.Value.(*nodeItem).key)
+			c.evictNode()
 		}
 		key := string(h)
 		item := &nodeItem{
@@ -75,6 +85,10 @@ func (c *FullCache) putNode(h, v []byte) {
 			value: v,
 		}
 		c.hash2e[key] = c.lru.PushBack(item)
+		c.usage.add(nodeSize(h, v))
+		for c.lru.Len() > 0 && c.usage.exceeded() {
+			c.evictNode()
+		}
 	}
 }
 
@@ -108,12 +122,32 @@ func (c *FullCache) Put(nibs []byte, h, v []byte) {
 	defer c.lock.Unlock()
 
 	if idx < fullCacheBranchSize {
-		c.nodes[idx] = [2][]byte{h, v}
+		c.setNode(idx, h, v)
 	} else {
 		c.putNode(h, v)
 	}
 }
 
+// setNode replaces the node in the branch. The node is dropped instead if
+// the cache exceeds its share of the budget even after evicting all nodes in
+// the LRU.
+func (c *FullCache) setNode(idx int, h, v []byte) {
+	if old := c.nodes[idx]; old[0] != nil {
+		c.usage.add(-nodeSize(old[0], old[1]))
+	}
+	size := nodeSize(h, v)
+	c.usage.add(size)
+	for c.lru.Len() > 0 && c.usage.exceeded() {
+		c.evictNode()
+	}
+	if c.usage.exceeded() {
+		c.usage.evict(size)
+		c.nodes[idx] = [2][]byte{}
+		return
+	}
+	c.nodes[idx] = [2][]byte{h, v}
+}
+
 func (c *FullCache) String() string {
 	return fmt.Sprintf("FullCache{%p}", c)
 }
@@ -164,7 +198,12 @@ func NewFullCacheFromBranch(bc *BranchCache) *FullCache {
 		nodes = bc.nodes
 	} else {
 		nodes = make([][2][]byte, fullCacheBranchSize)
-		copy(nodes, bc.nodes)
+		n := copy(nodes, bc.nodes)
+		for _, node := range bc.nodes[n:] {
+			if node[0] != nil {
+				bc.usage.add(-nodeSize(node[0], node[1]))
+			}
+		}
 	}
 	if bc.f != nil {
 		bc.f.Close()
@@ -173,6 +212,7 @@ func NewFullCacheFromBranch(bc *BranchCache) *FullCache {
 		nodes:  nodes,
 		hash2e: make(map[string]*list.Element),
 		size:   fullCacheLRUInitial,
+		usage:  bc.usage,
 	}
 	return fc
 }
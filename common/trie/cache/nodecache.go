@@ -28,8 +28,9 @@ type cacheImpl interface {
 }
 
 type NodeCache struct {
-	lock     sync.Mutex
-	impl     cacheImpl
+	lock  sync.Mutex
+	impl  cacheImpl
+	usage usage
 }
 
 func (c *NodeCache) Get(nibs []byte, h []byte) ([]byte, bool) {
@@ -39,7 +40,9 @@ func (c *NodeCache) Get(nibs []byte, h []byte) ([]byte, bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	return c.impl.Get(nibs, h)
+	v, ok := c.impl.Get(nibs, h)
+	c.usage.budget.onLookup(v != nil)
+	return v, ok
 }
 
 func (c *NodeCache) String() string {
@@ -67,9 +70,30 @@ func (c *NodeCache) OnAttach(id []byte) *NodeCache {
 	return c
 }
 
+// release returns bytes of the cache to the budget. It's called when the
+// cache is dropped, so it would not be charged to the budget any more.
+func (c *NodeCache) release() {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.usage.release()
+}
+
 func NewNodeCache(depth int, fdepth int, path string) *NodeCache {
-	bc := NewBranchCache(depth, fdepth, path)
-	return &NodeCache{
-		impl: bc,
+	return NewNodeCacheWithBudget(depth, fdepth, path, nil)
+}
+
+// NewNodeCacheWithBudget returns a new node cache charging bytes of nodes
+// in memory to the budget.
+func NewNodeCacheWithBudget(depth int, fdepth int, path string, budget *Budget) *NodeCache {
+	c := &NodeCache{
+		usage: newUsage(budget),
 	}
+	bc := NewBranchCache(depth, fdepth, path)
+	bc.usage = &c.usage
+	c.impl = bc
+	return c
 }
@@ -0,0 +1,225 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ompt
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/icon-project/goloop/common/trie"
+)
+
+// DiffHandler is called for each key having different values in two tries.
+// op is -1 for the key only in the first, 0 for the key with different
+// values and 1 for the key only in the second.
+type DiffHandler func(op int, key []byte, o1, o2 trie.Object) error
+
+type diffEntry struct {
+	key   string
+	value trie.Object
+}
+
+type differ struct {
+	m1, m2  *mpt
+	handler DiffHandler
+}
+
+func sameNode(n1, n2 node) bool {
+	if n1 == n2 {
+		return true
+	}
+	if n1 == nil || n2 == nil {
+		return false
+	}
+	return bytes.Equal(n1.getLink(true), n2.getLink(true))
+}
+
+func (d *differ) report(op int, k string, o1, o2 trie.Object) error {
+	var err error
+	if o1, _, err = d.m1.getObject(o1); err != nil {
+		return err
+	}
+	if o2, _, err = d.m2.getObject(o2); err != nil {
+		return err
+	}
+	return d.handler(op, keysToBytes(k), o1, o2)
+}
+
+func (d *differ) compareValues(k string, o1, o2 trie.Object) error {
+	switch {
+	case o1 == nil && o2 == nil:
+		return nil
+	case o2 == nil:
+		return d.report(-1, k, o1, nil)
+	case o1 == nil:
+		return d.report(1, k, nil, o2)
+	case !bytes.Equal(o1.Bytes(), o2.Bytes()):
+		return d.report(0, k, o1, o2)
+	default:
+		return nil
+	}
+}
+
+func collectEntries(m *mpt, k string, n node, entries []diffEntry) ([]diffEntry, error) {
+	if n == nil {
+		return entries, nil
+	}
+	n, err := n.realize(m)
+	if err != nil {
+		return nil, err
+	}
+	switch tn := n.(type) {
+	case *branch:
+		lock := tn.rlock()
+		children, value := tn.children, tn.value
+		lock.Unlock()
+		if value != nil {
+			entries = append(entries, diffEntry{k, value})
+		}
+		for i, child := range children {
+			entries, err = collectEntries(m, k+string([]byte{byte(i)}), child, entries)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case *extension:
+		entries, err = collectEntries(m, k+string(tn.keys), tn.next, entries)
+		if err != nil {
+			return nil, err
+		}
+	case *leaf:
+		lock := tn.rlock()
+		value := tn.value
+		lock.Unlock()
+		entries = append(entries, diffEntry{k + string(tn.keys), value})
+	}
+	return entries, nil
+}
+
+func (d *differ) diffEntries(e1, e2 []diffEntry) error {
+	sort.Slice(e1, func(i, j int) bool { return e1[i].key < e1[j].key })
+	sort.Slice(e2, func(i, j int) bool { return e2[i].key < e2[j].key })
+	for len(e1) > 0 || len(e2) > 0 {
+		var err error
+		switch {
+		case len(e2) == 0 || (len(e1) > 0 && e1[0].key < e2[0].key):
+			err = d.compareValues(e1[0].key, e1[0].value, nil)
+			e1 = e1[1:]
+		case len(e1) == 0 || e2[0].key < e1[0].key:
+			err = d.compareValues(e2[0].key, nil, e2[0].value)
+			e2 = e2[1:]
+		default:
+			err = d.compareValues(e1[0].key, e1[0].value, e2[0].value)
+			e1, e2 = e1[1:], e2[1:]
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffNodes compares sub-trees under the same key prefix. Sub-trees with the
+// same hash are skipped, so the cost is proportional to the changes.
+func (d *differ) diffNodes(k string, n1, n2 node) error {
+	if sameNode(n1, n2) {
+		return nil
+	}
+	var err error
+	if n1 != nil {
+		if n1, err = n1.realize(d.m1); err != nil {
+			return err
+		}
+	}
+	if n2 != nil {
+		if n2, err = n2.realize(d.m2); err != nil {
+			return err
+		}
+	}
+	switch t1 := n1.(type) {
+	case *branch:
+		if t2, ok := n2.(*branch); ok {
+			l1, l2 := t1.rlock(), t2.rlock()
+			c1, v1 := t1.children, t1.value
+			c2, v2 := t2.children, t2.value
+			l2.Unlock()
+			l1.Unlock()
+			if err := d.compareValues(k, v1, v2); err != nil {
+				return err
+			}
+			for i := 0; i < 16; i++ {
+				err := d.diffNodes(k+string([]byte{byte(i)}), c1[i], c2[i])
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case *extension:
+		if t2, ok := n2.(*extension); ok && bytes.Equal(t1.keys, t2.keys) {
+			return d.diffNodes(k+string(t1.keys), t1.next, t2.next)
+		}
+	case *leaf:
+		if t2, ok := n2.(*leaf); ok && bytes.Equal(t1.keys, t2.keys) {
+			l1, l2 := t1.rlock(), t2.rlock()
+			v1, v2 := t1.value, t2.value
+			l2.Unlock()
+			l1.Unlock()
+			return d.compareValues(k+string(t1.keys), v1, v2)
+		}
+	}
+
+	// different shapes, so compare all entries of them.
+	e1, err := collectEntries(d.m1, k, n1, nil)
+	if err != nil {
+		return err
+	}
+	e2, err := collectEntries(d.m2, k, n2, nil)
+	if err != nil {
+		return err
+	}
+	return d.diffEntries(e1, e2)
+}
+
+func (m *mpt) getRoot() node {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.root
+}
+
+func mptOf(t interface{}) (*mpt, bool) {
+	switch m := t.(type) {
+	case *mpt:
+		return m, true
+	case *mptForBytes:
+		return m.mpt, true
+	default:
+		return nil, false
+	}
+}
+
+// Diff calls the handler for each key having different values in two
+// tries. It returns false if any of them is not a trie of this package.
+func Diff(t1, t2 interface{}, handler DiffHandler) (bool, error) {
+	m1, ok1 := mptOf(t1)
+	m2, ok2 := mptOf(t2)
+	if !ok1 || !ok2 {
+		return false, nil
+	}
+	d := &differ{m1: m1, m2: m2, handler: handler}
+	return true, d.diffNodes("", m1.getRoot(), m2.getRoot())
+}
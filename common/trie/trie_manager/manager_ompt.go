@@ -40,3 +40,36 @@ func SetCacheOfMutable(mutable trie.Mutable, cache *cache.NodeCache) {
 func SetCacheOfMutableForObject(mutable trie.MutableForObject, cache *cache.NodeCache) {
 	ompt.SetCacheOfMutableForObject(mutable, cache)
 }
+
+// DiffImmutable calls the handler for each key having different values in
+// two tries. Unlike CompareImmutable, sub-trees with the same hash are not
+// visited if the tries support it.
+func DiffImmutable(t1, t2 trie.Immutable, handler BytesDifferenceHandler) error {
+	ok, err := ompt.Diff(t1, t2, func(op int, key []byte, o1, o2 trie.Object) error {
+		var v1, v2 []byte
+		if o1 != nil {
+			v1 = o1.Bytes()
+		}
+		if o2 != nil {
+			v2 = o2.Bytes()
+		}
+		handler(op, key, v1, v2)
+		return nil
+	})
+	if !ok {
+		return CompareImmutable(t1, t2, handler)
+	}
+	return err
+}
+
+// DiffImmutableForObject is DiffImmutable for tries of objects.
+func DiffImmutableForObject(t1, t2 trie.ImmutableForObject, handler ObjectDifferenceHandler) error {
+	ok, err := ompt.Diff(t1, t2, func(op int, key []byte, o1, o2 trie.Object) error {
+		handler(op, key, o1, o2)
+		return nil
+	})
+	if !ok {
+		return CompareImmutableForObject(t1, t2, handler)
+	}
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -1043,3 +1044,67 @@ func TestOldValue_MutableSetDelete(t *testing.T) {
 		})
 	}
 }
+
+type diffItem struct {
+	op       int
+	key      string
+	exp, val string
+}
+
+func TestDiffImmutable(t *testing.T) {
+	dbase := db.NewMapDB()
+	mutable := NewMutable(dbase, nil)
+	values := make(map[string]string)
+	for i := 0; i < 300; i++ {
+		k, v := fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)
+		_, err := mutable.Set([]byte(k), []byte(v))
+		assert.NoError(t, err)
+		values[k] = v
+	}
+	s1 := mutable.GetSnapshot()
+	assert.NoError(t, s1.Flush())
+
+	var expected []diffItem
+	for i := 0; i < 300; i++ {
+		k := fmt.Sprintf("key%d", i)
+		if i%7 == 0 {
+			_, err := mutable.Delete([]byte(k))
+			assert.NoError(t, err)
+			expected = append(expected, diffItem{-1, k, values[k], ""})
+		} else if i%11 == 0 {
+			_, err := mutable.Set([]byte(k), []byte("changed"))
+			assert.NoError(t, err)
+			expected = append(expected, diffItem{0, k, values[k], "changed"})
+		}
+	}
+	for _, k := range []string{"key", "key10a", "new"} {
+		_, err := mutable.Set([]byte(k), []byte("added"))
+		assert.NoError(t, err)
+		expected = append(expected, diffItem{1, k, "", "added"})
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		return expected[i].key < expected[j].key
+	})
+	s2 := mutable.GetSnapshot()
+
+	collect := func(items *[]diffItem) BytesDifferenceHandler {
+		return func(op int, key, exp, val []byte) {
+			*items = append(*items, diffItem{op, string(key), string(exp), string(val)})
+		}
+	}
+	var diffs []diffItem
+	assert.NoError(t, DiffImmutable(s1, s2, collect(&diffs)))
+	assert.Equal(t, expected, diffs)
+
+	// with the tries loaded from the database
+	assert.NoError(t, s2.Flush())
+	i1 := NewImmutable(dbase, s1.Hash())
+	i2 := NewImmutable(dbase, s2.Hash())
+	diffs = nil
+	assert.NoError(t, DiffImmutable(i1, i2, collect(&diffs)))
+	assert.Equal(t, expected, diffs)
+
+	diffs = nil
+	assert.NoError(t, DiffImmutable(i2, i2, collect(&diffs)))
+	assert.Empty(t, diffs)
+}
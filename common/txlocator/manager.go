@@ -113,6 +113,7 @@ type txListCache struct {
 
 type manager struct {
 	lock     sync.Mutex
+	dbase    db.Database
 	lbk      db.Bucket
 	log      log.Logger
 
@@ -276,18 +277,21 @@ func (m *manager) addListAndClearOldInLock(list *txList) {
 	c.lastP = &list.next
 }
 
+// flushList writes locators of the list in a batch, so the list is
+// written entirely or not at all.
 func (m *manager) flushList(l *txList) error {
+	batch := db.NewBatch(m.dbase)
 	for ptr := l.head ; ptr != nil ; ptr = ptr.next {
 		bs := codec.BC.MustMarshalToBytes(module.TransactionLocator{
 			BlockHeight:      ptr.list.height,
 			IndexInGroup:     ptr.offset,
 			TransactionGroup: ptr.list.group,
 		})
-		if err := m.lbk.Set([]byte(ptr.id), bs); err != nil {
+		if err := batch.Set(db.TransactionLocatorByHash, []byte(ptr.id), bs); err != nil {
 			return err
 		}
 	}
-	return nil
+	return batch.Commit()
 }
 
 func (m *manager) pushFlushJobInLock(l *txList) *locatorFlushJob {
@@ -374,6 +378,7 @@ func NewManager(dbase db.Database, logger log.Logger) (module.LocatorManager, er
 		return nil, err
 	}
 	mgr := &manager{
+		dbase:    dbase,
 		lbk:      lbk,
 		log:      logger,
 		locators: make(map[string]*locator),
@@ -509,21 +514,18 @@ func WriteTransactionLocators(
 	ptl module.TransactionList,
 	ntl module.TransactionList,
 ) error {
-	bk, err := db.NewCodedBucket(dbase, db.TransactionLocatorByHash, nil)
-	if err != nil {
-		return err
-	}
+	batch := db.NewBatch(dbase)
 	for it := ptl.Iterator(); it.Has(); log.Must(it.Next()) {
 		tr, i, err := it.Get()
 		if err != nil {
 			return err
 		}
-		trLoc := module.TransactionLocator{
+		bs := codec.BC.MustMarshalToBytes(module.TransactionLocator{
 			BlockHeight:      height,
 			TransactionGroup: module.TransactionGroupPatch,
 			IndexInGroup:     i,
-		}
-		if err = bk.Set(db.Raw(tr.ID()), trLoc); err != nil {
+		})
+		if err = batch.Set(db.TransactionLocatorByHash, tr.ID(), bs); err != nil {
 			return err
 		}
 	}
@@ -532,15 +534,14 @@ func WriteTransactionLocators(
 		if err != nil {
 			return err
 		}
-		trLoc := module.TransactionLocator{
+		bs := codec.BC.MustMarshalToBytes(module.TransactionLocator{
 			BlockHeight:      height,
 			TransactionGroup: module.TransactionGroupNormal,
 			IndexInGroup:     i,
-		}
-		if err = bk.Set(db.Raw(tr.ID()), trLoc); err != nil {
+		})
+		if err = batch.Set(db.TransactionLocatorByHash, tr.ID(), bs); err != nil {
 			return err
 		}
 	}
-	return nil
+	return batch.Commit()
 }
-
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wallet
+
+import (
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/module"
+)
+
+type ed25519Wallet struct {
+	seed []byte
+	pkey []byte
+	addr *common.Address
+}
+
+func (w *ed25519Wallet) Address() module.Address {
+	return w.addr
+}
+
+func (w *ed25519Wallet) Sign(data []byte) ([]byte, error) {
+	return crypto.SignEd25519(w.seed, data)
+}
+
+func (w *ed25519Wallet) PublicKey() []byte {
+	return w.pkey
+}
+
+func (w *ed25519Wallet) KeyType() string {
+	return crypto.KeyTypeEd25519
+}
+
+// NewEd25519 returns a wallet with a new ed25519 key.
+func NewEd25519() module.Wallet {
+	w, err := NewEd25519FromSeed(crypto.GenerateEd25519Key())
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// NewEd25519FromSeed returns a wallet with the ed25519 key of the seed.
+func NewEd25519FromSeed(seed []byte) (module.Wallet, error) {
+	pk, err := crypto.Ed25519PublicKeyFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := common.NewAccountAddressFromKey(crypto.KeyTypeEd25519, pk)
+	if err != nil {
+		return nil, err
+	}
+	return &ed25519Wallet{
+		seed: append([]byte(nil), seed...),
+		pkey: pk,
+		addr: addr,
+	}, nil
+}
+
+// KeyTypeOf returns the key type of the wallet. Wallets without KeyType()
+// use secp256k1 keys.
+func KeyTypeOf(w module.BaseWallet) string {
+	if kt, ok := w.(interface{ KeyType() string }); ok {
+		return kt.KeyType()
+	}
+	return crypto.KeyTypeSecp256k1
+}
@@ -68,6 +68,7 @@ type KeyStoreData struct {
 	ID       string         `json:"id"`
 	Version  int            `json:"version"`
 	CoinType string         `json:"coinType"`
+	KeyType  string         `json:"keyType,omitempty"`
 	Crypto   CryptoData     `json:"crypto"`
 }
 
@@ -80,6 +81,28 @@ func SHA3SumKeccak256(data ...[]byte) []byte {
 }
 
 func EncryptKeyAsKeyStore(s *crypto.PrivateKey, pw []byte) ([]byte, error) {
+	addr := common.NewAccountAddressFromPublicKey(s.PublicKey())
+	if addr == nil {
+		return nil, errors.New("FailToMakeAddressForTheKey")
+	}
+	return encryptSecretAsKeyStore(s.Bytes(), "", addr, pw)
+}
+
+// EncryptEd25519KeyAsKeyStore encrypts the seed of the ed25519 key. The key
+// type is stored in the key store.
+func EncryptEd25519KeyAsKeyStore(seed []byte, pw []byte) ([]byte, error) {
+	pk, err := crypto.Ed25519PublicKeyFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := common.NewAccountAddressFromKey(crypto.KeyTypeEd25519, pk)
+	if err != nil {
+		return nil, err
+	}
+	return encryptSecretAsKeyStore(seed, crypto.KeyTypeEd25519, addr, pw)
+}
+
+func encryptSecretAsKeyStore(secret []byte, keyType string, addr module.Address, pw []byte) ([]byte, error) {
 	var ks KeyStoreData
 	var c AES128CTRParams
 	var k ScryptParams
@@ -106,7 +129,6 @@ func EncryptKeyAsKeyStore(s *crypto.PrivateKey, pw []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	secret := s.Bytes()
 	cipherText := make([]byte, len(secret))
 	enc := cipher.NewCTR(b, c.IV)
 	enc.XORKeyStream(cipherText, secret)
@@ -120,45 +142,67 @@ func EncryptKeyAsKeyStore(s *crypto.PrivateKey, pw []byte) ([]byte, error) {
 	ks.Crypto.MAC = SHA3SumKeccak256(key[16:32], cipherText)
 	ks.Version = 3
 	ks.CoinType = coinTypeICON
+	ks.KeyType = keyType
 	ks.ID = uuid.Must(uuid.NewV4()).String()
-	if addr := common.NewAccountAddressFromPublicKey(s.PublicKey()); addr == nil {
-		return nil, errors.New("FailToMakeAddressForTheKey")
-	} else {
-		ks.Address.Set(addr)
-	}
+	ks.Address.Set(addr)
 
 	return json.Marshal(&ks)
 }
 
 func DecryptKeyStore(data, pw []byte) (*crypto.PrivateKey, error) {
-	var ksData KeyStoreData
-	if err := json.Unmarshal(data, &ksData); err != nil {
+	ksData, secretBytes, err := decryptSecretFromKeyStore(data, pw)
+	if err != nil {
 		return nil, err
 	}
+	if len(ksData.KeyType) > 0 && ksData.KeyType != crypto.KeyTypeSecp256k1 {
+		return nil, errors.Errorf("UnsupportedKeyType(type=%s)", ksData.KeyType)
+	}
+	return parsePrivateKeyOfKeyStore(ksData, secretBytes)
+}
+
+func parsePrivateKeyOfKeyStore(ksData *KeyStoreData, secretBytes []byte) (*crypto.PrivateKey, error) {
+	secret, err := crypto.ParsePrivateKey(secretBytes)
+	if err != nil {
+		return nil, err
+	}
+	public := secret.PublicKey()
+	address := common.NewAccountAddressFromPublicKey(public)
+	if !address.Equal(&ksData.Address) {
+		log.Warnf("Recovered address %s != keyStore address %s",
+			address.String(), ksData.Address.String())
+	}
+	return secret, nil
+}
+
+func decryptSecretFromKeyStore(data, pw []byte) (*KeyStoreData, []byte, error) {
+	ksData := new(KeyStoreData)
+	if err := json.Unmarshal(data, ksData); err != nil {
+		return nil, nil, err
+	}
 	if ksData.CoinType != coinTypeICON {
-		return nil, errors.Errorf("InvalidCoinType(coin=%s)", ksData.CoinType)
+		return nil, nil, errors.Errorf("InvalidCoinType(coin=%s)", ksData.CoinType)
 	}
 
 	if ksData.Crypto.Cipher != cipherAES128CTR {
-		return nil, errors.Errorf("UnsupportedCipher(cipher=%s)",
+		return nil, nil, errors.Errorf("UnsupportedCipher(cipher=%s)",
 			ksData.Crypto.Cipher)
 	}
 	var cipherParams AES128CTRParams
 	if err := json.Unmarshal(ksData.Crypto.CipherParams, &cipherParams); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if ksData.Crypto.KDF != kdfScrypt {
-		return nil, errors.Errorf("UnsupportedKDF(kdf=%s)", ksData.Crypto.KDF)
+		return nil, nil, errors.Errorf("UnsupportedKDF(kdf=%s)", ksData.Crypto.KDF)
 	}
 	var kdfParams ScryptParams
 	if err := json.Unmarshal(ksData.Crypto.KDFParams, &kdfParams); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	key, err := kdfParams.Key(pw)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	cipheredBytes := ksData.Crypto.CipherText.Bytes()
@@ -168,12 +212,12 @@ func DecryptKeyStore(data, pw []byte) (*crypto.PrivateKey, error) {
 	s.Write(cipheredBytes)
 	mac := s.Sum([]byte{})
 	if !bytes.Equal(mac, ksData.Crypto.MAC.Bytes()) {
-		return nil, errors.Errorf("InvalidPassword")
+		return nil, nil, errors.Errorf("InvalidPassword")
 	}
 
 	block, err := aes.NewCipher(key[0:16])
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	secretBytes := make([]byte, len(cipheredBytes))
@@ -189,17 +233,7 @@ func DecryptKeyStore(data, pw []byte) (*crypto.PrivateKey, error) {
 	stream := cipher.NewCTR(block, ivBytes)
 	stream.XORKeyStream(secretBytes, cipheredBytes)
 
-	secret, err := crypto.ParsePrivateKey(secretBytes)
-	if err != nil {
-		return nil, err
-	}
-	public := secret.PublicKey()
-	address := common.NewAccountAddressFromPublicKey(public)
-	if !address.Equal(&ksData.Address) {
-		log.Warnf("Recovered address %s != keyStore address %s",
-			address.String(), ksData.Address.String())
-	}
-	return secret, nil
+	return ksData, secretBytes, nil
 }
 
 func ReadAddressFromKeyStore(data []byte) (module.Address, error) {
@@ -214,18 +248,31 @@ func ReadAddressFromKeyStore(data []byte) (module.Address, error) {
 }
 
 func NewFromKeyStore(data, pw []byte) (module.Wallet, error) {
-	secret, err := DecryptKeyStore(data, pw)
+	ksData, secret, err := decryptSecretFromKeyStore(data, pw)
 	if err != nil {
 		return nil, err
 	}
-	return NewFromPrivateKey(secret)
+	switch ksData.KeyType {
+	case "", crypto.KeyTypeSecp256k1:
+		sk, err := parsePrivateKeyOfKeyStore(ksData, secret)
+		if err != nil {
+			return nil, err
+		}
+		return NewFromPrivateKey(sk)
+	case crypto.KeyTypeEd25519:
+		return NewEd25519FromSeed(secret)
+	default:
+		return nil, errors.Errorf("UnsupportedKeyType(type=%s)", ksData.KeyType)
+	}
 }
 
 func KeyStoreFromWallet(w module.Wallet, pw []byte) ([]byte, error) {
-	s, ok := w.(*softwareWallet)
-	if ok {
+	switch s := w.(type) {
+	case *softwareWallet:
 		return EncryptKeyAsKeyStore(s.skey, pw)
-	} else {
+	case *ed25519Wallet:
+		return EncryptEd25519KeyAsKeyStore(s.seed, pw)
+	default:
 		return nil, nil
 	}
 }
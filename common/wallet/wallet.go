@@ -42,3 +42,12 @@ func NewFromPrivateKey(sk *crypto.PrivateKey) (module.Wallet, error) {
 		pkey: pk,
 	}, nil
 }
+
+// SignTypedData signs the digest of the typed data with the wallet.
+func SignTypedData(w module.Wallet, td *crypto.TypedData) ([]byte, error) {
+	digest, err := td.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return w.Sign(digest)
+}
@@ -0,0 +1,436 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+// instr is a decoded instruction. Meanings of immediates depend on op.
+//
+//   - block, loop, if: x is the position of else (or end), y is the
+//     position of end, and v has the number of parameters in upper 32 bits
+//     and the number of results in lower 32 bits.
+//   - else: y is the position of end.
+//   - br, br_if: x is the label depth.
+//   - br_table: x is the index of the label table in the function.
+//   - call, local.*, global.*: x is the index.
+//   - call_indirect: x is the type index.
+//   - load, store: x is the offset.
+//   - const: v is the value.
+//   - misc: x is the sub-opcode, and y is the data index if it has.
+type instr struct {
+	op byte
+	x  uint32
+	y  uint32
+	v  uint64
+}
+
+type function struct {
+	typ    *FuncType
+	locals int
+	code   []instr
+	tables [][]uint32
+}
+
+var (
+	blockEmpty = &FuncType{Params: typesNone, Results: typesNone}
+	blockI32   = &FuncType{Params: typesNone, Results: typesI32}
+	blockI64   = &FuncType{Params: typesNone, Results: typesI64}
+)
+
+func blockType(m *Module, r *reader) (*FuncType, error) {
+	bt, err := r.sleb(33)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bt == -0x40:
+		return blockEmpty, nil
+	case bt == -1:
+		// i32 in signed representation
+		return blockI32, nil
+	case bt == -2:
+		// i64 in signed representation
+		return blockI64, nil
+	case bt >= 0 && bt < int64(len(m.Types)):
+		return &m.Types[bt], nil
+	default:
+		return nil, invalidModule("InvalidBlockType(%d)", bt)
+	}
+}
+
+func compile(m *Module, ft *FuncType, body []byte) (*function, error) {
+	r := &reader{bs: body}
+	f := &function{typ: ft}
+
+	n, err := r.count()
+	if err != nil {
+		return nil, err
+	}
+	locals := append([]ValueType{}, ft.Params...)
+	for i := 0; i < n; i++ {
+		cnt, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		t, err := decodeValueType(r)
+		if err != nil {
+			return nil, err
+		}
+		f.locals += int(cnt)
+		if f.locals > maxLocals {
+			return nil, invalidModule("TooManyLocals(%d)", f.locals)
+		}
+		for j := uint32(0); j < cnt; j++ {
+			locals = append(locals, t)
+		}
+	}
+
+	v := &validator{}
+	v.pushCtrl(opBlock, -1, typesNone, ft.Results)
+	for len(v.ctrls) > 0 {
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		in := instr{op: op}
+		switch op {
+		case opUnreachable:
+			v.setUnreachable()
+		case opNop:
+		case opReturn:
+			if _, err := v.pops(ft.Results); err != nil {
+				return nil, err
+			}
+			v.setUnreachable()
+		case opDrop:
+			if _, err := v.pop(); err != nil {
+				return nil, err
+			}
+		case opSelect:
+			if _, err := v.popExpect(I32); err != nil {
+				return nil, err
+			}
+			t1, err := v.pop()
+			if err != nil {
+				return nil, err
+			}
+			t2, err := v.popExpect(t1)
+			if err != nil {
+				return nil, err
+			}
+			v.push(t2)
+		case opBlock, opLoop, opIf:
+			bt, err := blockType(m, r)
+			if err != nil {
+				return nil, err
+			}
+			in.v = uint64(len(bt.Params))<<32 | uint64(len(bt.Results))
+			if op == opIf {
+				if _, err := v.popExpect(I32); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := v.pops(bt.Params); err != nil {
+				return nil, err
+			}
+			v.pushCtrl(op, len(f.code), bt.Params, bt.Results)
+		case opElse:
+			c := v.ctrls[len(v.ctrls)-1]
+			if c.op != opIf || c.elsePos >= 0 {
+				return nil, invalidModule("UnexpectedElse")
+			}
+			if c, err = v.popCtrl(); err != nil {
+				return nil, err
+			}
+			v.pushCtrl(opIf, c.start, c.params, c.results)
+			v.ctrls[len(v.ctrls)-1].elsePos = len(f.code)
+		case opEnd:
+			c, err := v.popCtrl()
+			if err != nil {
+				return nil, err
+			}
+			if c.op == opIf && c.elsePos < 0 && !typesEqual(c.params, c.results) {
+				return nil, invalidModule("TypeMismatchWithoutElse")
+			}
+			v.pushes(c.results)
+			pos := uint32(len(f.code))
+			if c.start >= 0 {
+				b := &f.code[c.start]
+				b.x, b.y = pos, pos
+				if c.elsePos >= 0 {
+					b.x = uint32(c.elsePos)
+					f.code[c.elsePos].y = pos
+				}
+			}
+		case opBr, opBrIf:
+			if in.x, err = r.u32(); err != nil {
+				return nil, err
+			}
+			l, err := v.label(in.x)
+			if err != nil {
+				return nil, err
+			}
+			if op == opBrIf {
+				if _, err := v.popExpect(I32); err != nil {
+					return nil, err
+				}
+			}
+			ts, err := v.pops(l.labelTypes())
+			if err != nil {
+				return nil, err
+			}
+			if op == opBr {
+				v.setUnreachable()
+			} else {
+				v.pushes(ts)
+			}
+		case opBrTable:
+			cnt, err := r.count()
+			if err != nil {
+				return nil, err
+			}
+			labels := make([]uint32, cnt+1)
+			for i := range labels {
+				if labels[i], err = r.u32(); err != nil {
+					return nil, err
+				}
+				if _, err := v.label(labels[i]); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := v.popExpect(I32); err != nil {
+				return nil, err
+			}
+			dl, _ := v.label(labels[cnt])
+			arity := len(dl.labelTypes())
+			for _, depth := range labels[:cnt] {
+				l, _ := v.label(depth)
+				if len(l.labelTypes()) != arity {
+					return nil, invalidModule("InvalidLabelArity(%d)", depth)
+				}
+				ts, err := v.pops(l.labelTypes())
+				if err != nil {
+					return nil, err
+				}
+				v.pushes(ts)
+			}
+			if _, err := v.pops(dl.labelTypes()); err != nil {
+				return nil, err
+			}
+			v.setUnreachable()
+			in.x = uint32(len(f.tables))
+			f.tables = append(f.tables, labels)
+		case opCall:
+			if in.x, err = r.u32(); err != nil {
+				return nil, err
+			}
+			ct, ok := m.FuncType(in.x)
+			if !ok {
+				return nil, invalidModule("InvalidFunctionIndex(%d)", in.x)
+			}
+			if err := v.apply(ct.Params, ct.Results); err != nil {
+				return nil, err
+			}
+		case opCallIndirect:
+			if in.x, err = m.typeIndex(r); err != nil {
+				return nil, err
+			}
+			if ti, err := r.u32(); err != nil {
+				return nil, err
+			} else if ti != 0 || m.Table == nil {
+				return nil, invalidModule("InvalidTableIndex(%d)", ti)
+			}
+			if _, err := v.popExpect(I32); err != nil {
+				return nil, err
+			}
+			ct := &m.Types[in.x]
+			if err := v.apply(ct.Params, ct.Results); err != nil {
+				return nil, err
+			}
+		case opSelectType:
+			ts, err := decodeValueTypes(r)
+			if err != nil {
+				return nil, err
+			}
+			if len(ts) != 1 {
+				return nil, invalidModule("InvalidSelectTypes(n=%d)", len(ts))
+			}
+			if _, err := v.popExpect(I32); err != nil {
+				return nil, err
+			}
+			if err := v.apply([]ValueType{ts[0], ts[0]}, ts); err != nil {
+				return nil, err
+			}
+			in.op = opSelect
+		case opLocalGet, opLocalSet, opLocalTee:
+			if in.x, err = r.u32(); err != nil {
+				return nil, err
+			}
+			if int(in.x) >= len(locals) {
+				return nil, invalidModule("InvalidLocalIndex(%d)", in.x)
+			}
+			t := locals[in.x]
+			if op != opLocalGet {
+				if _, err := v.popExpect(t); err != nil {
+					return nil, err
+				}
+			}
+			if op != opLocalSet {
+				v.push(t)
+			}
+		case opGlobalGet, opGlobalSet:
+			if in.x, err = r.u32(); err != nil {
+				return nil, err
+			}
+			if int(in.x) >= len(m.Globals) {
+				return nil, invalidModule("InvalidGlobalIndex(%d)", in.x)
+			}
+			g := &m.Globals[in.x]
+			if op == opGlobalGet {
+				v.push(g.Type)
+			} else {
+				if !g.Mutable {
+					return nil, invalidModule("ImmutableGlobal(%d)", in.x)
+				}
+				if _, err := v.popExpect(g.Type); err != nil {
+					return nil, err
+				}
+			}
+		case opI32Load, opI64Load,
+			opI32Load8S, opI32Load8U, opI32Load16S, opI32Load16U,
+			opI64Load8S, opI64Load8U, opI64Load16S, opI64Load16U,
+			opI64Load32S, opI64Load32U,
+			opI32Store, opI64Store, opI32Store8, opI32Store16,
+			opI64Store8, opI64Store16, opI64Store32:
+			if m.Memory == nil {
+				return nil, invalidModule("NoMemory")
+			}
+			align, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			if in.x, err = r.u32(); err != nil {
+				return nil, err
+			}
+			t, natural := memoryType(op)
+			if align > natural {
+				return nil, invalidModule("InvalidAlignment(%d)", align)
+			}
+			if op >= opI32Store {
+				err = v.apply([]ValueType{I32, t}, typesNone)
+			} else {
+				err = v.apply(typesI32, []ValueType{t})
+			}
+			if err != nil {
+				return nil, err
+			}
+		case opMemorySize, opMemoryGrow:
+			if m.Memory == nil {
+				return nil, invalidModule("NoMemory")
+			}
+			if b, err := r.byte(); err != nil {
+				return nil, err
+			} else if b != 0 {
+				return nil, invalidModule("InvalidMemoryIndex(%d)", b)
+			}
+			if op == opMemoryGrow {
+				err = v.apply(typesI32, typesI32)
+			} else {
+				err = v.apply(typesNone, typesI32)
+			}
+			if err != nil {
+				return nil, err
+			}
+		case opI32Const:
+			c, err := r.sleb(32)
+			if err != nil {
+				return nil, err
+			}
+			in.v = uint64(uint32(c))
+			v.push(I32)
+		case opI64Const:
+			c, err := r.sleb(64)
+			if err != nil {
+				return nil, err
+			}
+			in.v = uint64(c)
+			v.push(I64)
+		case opMisc:
+			if in.x, err = r.u32(); err != nil {
+				return nil, err
+			}
+			if err := compileMisc(m, r, &in); err != nil {
+				return nil, err
+			}
+			if in.x != opDataDrop {
+				if _, err := v.pops(typesMem); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			params, results, ok := numericType(op)
+			if !ok {
+				return nil, invalidModule("UnsupportedInstruction(%#x)", op)
+			}
+			if err := v.apply(params, results); err != nil {
+				return nil, err
+			}
+		}
+		f.code = append(f.code, in)
+	}
+	if !r.eof() {
+		return nil, invalidModule("TrailingBytes")
+	}
+	return f, nil
+}
+
+func compileMisc(m *Module, r *reader, in *instr) error {
+	if m.Memory == nil {
+		return invalidModule("NoMemory")
+	}
+	switch in.x {
+	case opMemoryInit, opDataDrop:
+		var err error
+		if in.y, err = r.u32(); err != nil {
+			return err
+		}
+		if int(in.y) >= len(m.datas) {
+			return invalidModule("InvalidDataIndex(%d)", in.y)
+		}
+		if in.x == opDataDrop {
+			return nil
+		}
+		if b, err := r.byte(); err != nil {
+			return err
+		} else if b != 0 {
+			return invalidModule("InvalidMemoryIndex(%d)", b)
+		}
+	case opMemoryCopy, opMemoryFill:
+		cnt := 1
+		if in.x == opMemoryCopy {
+			cnt = 2
+		}
+		for i := 0; i < cnt; i++ {
+			if b, err := r.byte(); err != nil {
+				return err
+			} else if b != 0 {
+				return invalidModule("InvalidMemoryIndex(%d)", b)
+			}
+		}
+	default:
+		return invalidModule("UnsupportedInstruction(0xfc %d)", in.x)
+	}
+	return nil
+}
@@ -0,0 +1,448 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// label is a target of branches.
+type label struct {
+	height int
+	arity  int
+	result int
+	cont   int
+}
+
+func b2i(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// execute runs the function whose parameters start at base of the stack.
+// On return, results are placed at base.
+func (in *Instance) execute(f *function, base int) {
+	nLocals := len(f.typ.Params) + f.locals
+	for i := 0; i < f.locals; i++ {
+		in.push(0)
+	}
+	in.floor = base + nLocals
+	nResults := len(f.typ.Results)
+	labels := []label{{
+		height: in.floor,
+		arity:  nResults,
+		result: nResults,
+		cont:   len(f.code),
+	}}
+	locals := func(idx uint32) *uint64 {
+		return &in.stack[base+int(idx)]
+	}
+	branch := func(depth uint32) int {
+		l := labels[len(labels)-1-int(depth)]
+		in.unwind(l.height, l.arity)
+		if f.code[l.cont-1].op == opLoop {
+			labels = labels[:len(labels)-int(depth)]
+		} else {
+			labels = labels[:len(labels)-1-int(depth)]
+		}
+		return l.cont
+	}
+
+	code := f.code
+	for pc := 0; pc < len(code); pc++ {
+		in.used += StepInstruction
+		if in.used > in.limit {
+			in.useSteps(0)
+		}
+		ins := &code[pc]
+		switch ins.op {
+		case opUnreachable:
+			panic(abort{trap("Unreachable")})
+		case opNop:
+		case opBlock, opLoop:
+			params, results := int(ins.v>>32), int(uint32(ins.v))
+			l := label{height: in.sp - params, arity: results, result: results, cont: int(ins.y) + 1}
+			if ins.op == opLoop {
+				l.arity, l.cont = params, pc+1
+			}
+			labels = append(labels, l)
+		case opIf:
+			params, results := int(ins.v>>32), int(uint32(ins.v))
+			c := uint32(in.pop())
+			labels = append(labels, label{height: in.sp - params, arity: results, result: results, cont: int(ins.y) + 1})
+			if c == 0 {
+				if ins.x != ins.y {
+					pc = int(ins.x)
+				} else {
+					pc = int(ins.y) - 1
+				}
+			}
+		case opElse:
+			pc = int(ins.y) - 1
+		case opEnd:
+			l := labels[len(labels)-1]
+			labels = labels[:len(labels)-1]
+			in.unwind(l.height, l.result)
+		case opBr:
+			pc = branch(ins.x) - 1
+		case opBrIf:
+			if uint32(in.pop()) != 0 {
+				pc = branch(ins.x) - 1
+			}
+		case opBrTable:
+			table := f.tables[ins.x]
+			idx := uint32(in.pop())
+			if int(idx) >= len(table)-1 {
+				idx = uint32(len(table) - 1)
+			}
+			pc = branch(table[idx]) - 1
+		case opReturn:
+			pc = branch(uint32(len(labels)-1)) - 1
+		case opCall:
+			in.call(ins.x)
+		case opCallIndirect:
+			idx := uint32(in.pop())
+			if int(idx) >= len(in.table) {
+				panic(abort{trap("UndefinedElement(%d)", idx)})
+			}
+			fi := in.table[idx]
+			if fi < 0 {
+				panic(abort{trap("UninitializedElement(%d)", idx)})
+			}
+			if ft, _ := in.module.FuncType(uint32(fi)); !ft.Equal(&in.module.Types[ins.x]) {
+				panic(abort{trap("IndirectCallTypeMismatch(%d)", idx)})
+			}
+			in.call(uint32(fi))
+		case opDrop:
+			in.pop()
+		case opSelect:
+			c := uint32(in.pop())
+			v2 := in.pop()
+			v1 := in.pop()
+			if c != 0 {
+				in.push(v1)
+			} else {
+				in.push(v2)
+			}
+		case opLocalGet:
+			in.push(*locals(ins.x))
+		case opLocalSet:
+			*locals(ins.x) = in.pop()
+		case opLocalTee:
+			v := in.pop()
+			*locals(ins.x) = v
+			in.push(v)
+		case opGlobalGet:
+			in.push(in.globals[ins.x])
+		case opGlobalSet:
+			in.globals[ins.x] = in.pop()
+
+		case opI32Load:
+			in.push(uint64(binary.LittleEndian.Uint32(in.memoryRange(in.address(ins.x), 4))))
+		case opI64Load:
+			in.push(binary.LittleEndian.Uint64(in.memoryRange(in.address(ins.x), 8)))
+		case opI32Load8S:
+			in.push(uint64(uint32(int8(in.memoryRange(in.address(ins.x), 1)[0]))))
+		case opI32Load8U:
+			in.push(uint64(in.memoryRange(in.address(ins.x), 1)[0]))
+		case opI32Load16S:
+			in.push(uint64(uint32(int16(binary.LittleEndian.Uint16(in.memoryRange(in.address(ins.x), 2))))))
+		case opI32Load16U:
+			in.push(uint64(binary.LittleEndian.Uint16(in.memoryRange(in.address(ins.x), 2))))
+		case opI64Load8S:
+			in.push(uint64(int8(in.memoryRange(in.address(ins.x), 1)[0])))
+		case opI64Load8U:
+			in.push(uint64(in.memoryRange(in.address(ins.x), 1)[0]))
+		case opI64Load16S:
+			in.push(uint64(int16(binary.LittleEndian.Uint16(in.memoryRange(in.address(ins.x), 2)))))
+		case opI64Load16U:
+			in.push(uint64(binary.LittleEndian.Uint16(in.memoryRange(in.address(ins.x), 2))))
+		case opI64Load32S:
+			in.push(uint64(int32(binary.LittleEndian.Uint32(in.memoryRange(in.address(ins.x), 4)))))
+		case opI64Load32U:
+			in.push(uint64(binary.LittleEndian.Uint32(in.memoryRange(in.address(ins.x), 4))))
+		case opI32Store, opI64Store32:
+			v := in.pop()
+			binary.LittleEndian.PutUint32(in.memoryRange(in.address(ins.x), 4), uint32(v))
+		case opI64Store:
+			v := in.pop()
+			binary.LittleEndian.PutUint64(in.memoryRange(in.address(ins.x), 8), v)
+		case opI32Store8, opI64Store8:
+			v := in.pop()
+			in.memoryRange(in.address(ins.x), 1)[0] = byte(v)
+		case opI32Store16, opI64Store16:
+			v := in.pop()
+			binary.LittleEndian.PutUint16(in.memoryRange(in.address(ins.x), 2), uint16(v))
+		case opMemorySize:
+			in.push(uint64(len(in.memory) / PageSize))
+		case opMemoryGrow:
+			delta := uint32(in.pop())
+			pages := uint32(len(in.memory) / PageSize)
+			if uint64(pages)+uint64(delta) > uint64(in.maxPages) {
+				in.push(uint64(math.MaxUint32))
+				break
+			}
+			in.useSteps(int64(delta) * StepPage)
+			in.memory = append(in.memory, make([]byte, int(delta)*PageSize)...)
+			in.push(uint64(pages))
+
+		case opI32Const, opI64Const:
+			in.push(ins.v)
+
+		case opMisc:
+			in.executeMisc(ins)
+
+		default:
+			in.executeNumeric(ins.op)
+		}
+	}
+	in.unwind(base, nResults)
+}
+
+func (in *Instance) executeMisc(ins *instr) {
+	switch ins.x {
+	case opMemoryInit:
+		n := uint64(uint32(in.pop()))
+		src := uint64(uint32(in.pop()))
+		dst := uint64(uint32(in.pop()))
+		var d []byte
+		if !in.dropped[ins.y] {
+			d = in.module.datas[ins.y].init
+		}
+		if src+n > uint64(len(d)) {
+			panic(abort{trap("OutOfBoundsMemoryAccess(data=%d)", ins.y)})
+		}
+		in.useSteps(int64(n / StepBulkBytes))
+		copy(in.memoryRange(dst, n), d[src:])
+	case opDataDrop:
+		in.dropped[ins.y] = true
+	case opMemoryCopy:
+		n := uint64(uint32(in.pop()))
+		src := uint64(uint32(in.pop()))
+		dst := uint64(uint32(in.pop()))
+		in.useSteps(int64(n / StepBulkBytes))
+		copy(in.memoryRange(dst, n), in.memoryRange(src, n))
+	case opMemoryFill:
+		n := uint64(uint32(in.pop()))
+		v := byte(in.pop())
+		dst := uint64(uint32(in.pop()))
+		in.useSteps(int64(n / StepBulkBytes))
+		bs := in.memoryRange(dst, n)
+		for i := range bs {
+			bs[i] = v
+		}
+	}
+}
+
+func (in *Instance) executeNumeric(op byte) {
+	switch op {
+	case opI32Eqz:
+		in.push(b2i(uint32(in.pop()) == 0))
+	case opI64Eqz:
+		in.push(b2i(in.pop() == 0))
+	case opI32WrapI64:
+		in.push(uint64(uint32(in.pop())))
+	case opI64ExtendI32S:
+		in.push(uint64(int32(uint32(in.pop()))))
+	case opI64ExtendI32U:
+		in.push(uint64(uint32(in.pop())))
+	case opI32Extend8S:
+		in.push(uint64(uint32(int8(in.pop()))))
+	case opI32Extend16S:
+		in.push(uint64(uint32(int16(in.pop()))))
+	case opI64Extend8S:
+		in.push(uint64(int8(in.pop())))
+	case opI64Extend16S:
+		in.push(uint64(int16(in.pop())))
+	case opI64Extend32S:
+		in.push(uint64(int32(in.pop())))
+	case opI32Clz:
+		in.push(uint64(bits.LeadingZeros32(uint32(in.pop()))))
+	case opI32Ctz:
+		in.push(uint64(bits.TrailingZeros32(uint32(in.pop()))))
+	case opI32Popcnt:
+		in.push(uint64(bits.OnesCount32(uint32(in.pop()))))
+	case opI64Clz:
+		in.push(uint64(bits.LeadingZeros64(in.pop())))
+	case opI64Ctz:
+		in.push(uint64(bits.TrailingZeros64(in.pop())))
+	case opI64Popcnt:
+		in.push(uint64(bits.OnesCount64(in.pop())))
+	default:
+		v2 := in.pop()
+		v1 := in.pop()
+		if op <= opI32GeU || (op >= opI32Clz && op <= opI32Rotr) {
+			in.push(uint64(binaryI32(op, uint32(v1), uint32(v2))))
+		} else {
+			in.push(binaryI64(op, v1, v2))
+		}
+	}
+}
+
+func binaryI32(op byte, a, b uint32) uint32 {
+	switch op {
+	case opI32Eq:
+		return uint32(b2i(a == b))
+	case opI32Ne:
+		return uint32(b2i(a != b))
+	case opI32LtS:
+		return uint32(b2i(int32(a) < int32(b)))
+	case opI32LtU:
+		return uint32(b2i(a < b))
+	case opI32GtS:
+		return uint32(b2i(int32(a) > int32(b)))
+	case opI32GtU:
+		return uint32(b2i(a > b))
+	case opI32LeS:
+		return uint32(b2i(int32(a) <= int32(b)))
+	case opI32LeU:
+		return uint32(b2i(a <= b))
+	case opI32GeS:
+		return uint32(b2i(int32(a) >= int32(b)))
+	case opI32GeU:
+		return uint32(b2i(a >= b))
+	case opI32Add:
+		return a + b
+	case opI32Sub:
+		return a - b
+	case opI32Mul:
+		return a * b
+	case opI32DivS:
+		if b == 0 {
+			panic(abort{trap("IntegerDivideByZero")})
+		}
+		if int32(a) == math.MinInt32 && int32(b) == -1 {
+			panic(abort{trap("IntegerOverflow")})
+		}
+		return uint32(int32(a) / int32(b))
+	case opI32DivU:
+		if b == 0 {
+			panic(abort{trap("IntegerDivideByZero")})
+		}
+		return a / b
+	case opI32RemS:
+		if b == 0 {
+			panic(abort{trap("IntegerDivideByZero")})
+		}
+		if int32(b) == -1 {
+			return 0
+		}
+		return uint32(int32(a) % int32(b))
+	case opI32RemU:
+		if b == 0 {
+			panic(abort{trap("IntegerDivideByZero")})
+		}
+		return a % b
+	case opI32And:
+		return a & b
+	case opI32Or:
+		return a | b
+	case opI32Xor:
+		return a ^ b
+	case opI32Shl:
+		return a << (b & 31)
+	case opI32ShrS:
+		return uint32(int32(a) >> (b & 31))
+	case opI32ShrU:
+		return a >> (b & 31)
+	case opI32Rotl:
+		return bits.RotateLeft32(a, int(b&31))
+	case opI32Rotr:
+		return bits.RotateLeft32(a, -int(b&31))
+	default:
+		panic(abort{trap("UnsupportedInstruction(%#x)", op)})
+	}
+}
+
+func binaryI64(op byte, a, b uint64) uint64 {
+	switch op {
+	case opI64Eq:
+		return b2i(a == b)
+	case opI64Ne:
+		return b2i(a != b)
+	case opI64LtS:
+		return b2i(int64(a) < int64(b))
+	case opI64LtU:
+		return b2i(a < b)
+	case opI64GtS:
+		return b2i(int64(a) > int64(b))
+	case opI64GtU:
+		return b2i(a > b)
+	case opI64LeS:
+		return b2i(int64(a) <= int64(b))
+	case opI64LeU:
+		return b2i(a <= b)
+	case opI64GeS:
+		return b2i(int64(a) >= int64(b))
+	case opI64GeU:
+		return b2i(a >= b)
+	case opI64Add:
+		return a + b
+	case opI64Sub:
+		return a - b
+	case opI64Mul:
+		return a * b
+	case opI64DivS:
+		if b == 0 {
+			panic(abort{trap("IntegerDivideByZero")})
+		}
+		if int64(a) == math.MinInt64 && int64(b) == -1 {
+			panic(abort{trap("IntegerOverflow")})
+		}
+		return uint64(int64(a) / int64(b))
+	case opI64DivU:
+		if b == 0 {
+			panic(abort{trap("IntegerDivideByZero")})
+		}
+		return a / b
+	case opI64RemS:
+		if b == 0 {
+			panic(abort{trap("IntegerDivideByZero")})
+		}
+		if int64(b) == -1 {
+			return 0
+		}
+		return uint64(int64(a) % int64(b))
+	case opI64RemU:
+		if b == 0 {
+			panic(abort{trap("IntegerDivideByZero")})
+		}
+		return a % b
+	case opI64And:
+		return a & b
+	case opI64Or:
+		return a | b
+	case opI64Xor:
+		return a ^ b
+	case opI64Shl:
+		return a << (b & 63)
+	case opI64ShrS:
+		return uint64(int64(a) >> (b & 63))
+	case opI64ShrU:
+		return a >> (b & 63)
+	case opI64Rotl:
+		return bits.RotateLeft64(a, int(b&63))
+	case opI64Rotr:
+		return bits.RotateLeft64(a, -int(b&63))
+	default:
+		panic(abort{trap("UnsupportedInstruction(%#x)", op)})
+	}
+}
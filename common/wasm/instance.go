@@ -0,0 +1,315 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"runtime"
+
+	"github.com/icon-project/goloop/common/errors"
+)
+
+const (
+	PageSize = 64 * 1024
+	MaxPages = 256
+
+	maxLocals    = 4096
+	maxStack     = 64 * 1024
+	maxCallDepth = 256
+	maxTableSize = 64 * 1024
+	initialStack = 1024
+)
+
+// Steps used by instructions. Every instruction uses StepInstruction, and
+// some of them use more for their work.
+const (
+	StepInstruction = 1
+	StepPage        = 1024
+	StepBulkBytes   = 32
+)
+
+// HostFunc is a function imported by the module.
+type HostFunc struct {
+	Type FuncType
+	Func func(in *Instance, args []uint64) ([]uint64, error)
+}
+
+// Imports has host functions by module and name.
+type Imports map[string]map[string]*HostFunc
+
+// abort is used for stopping the execution with the error.
+type abort struct {
+	err error
+}
+
+// Instance is an instantiated module. It's not safe for concurrent use.
+type Instance struct {
+	module   *Module
+	hosts    []*HostFunc
+	memory   []byte
+	maxPages uint32
+	globals  []uint64
+	table    []int64
+	dropped  []bool
+
+	stack []uint64
+	sp    int
+	floor int
+	depth int
+
+	limit int64
+	used  int64
+}
+
+// NewInstance instantiates the module with imports, then it runs the start
+// function if it has. Steps for the initial memory and used by the start
+// function are counted.
+func NewInstance(m *Module, imports Imports, limit int64) (*Instance, error) {
+	in := &Instance{
+		module:  m,
+		globals: make([]uint64, len(m.Globals)),
+		dropped: make([]bool, len(m.datas)),
+		stack:   make([]uint64, initialStack),
+		limit:   limit,
+	}
+	for _, imp := range m.Imports {
+		h, ok := imports[imp.Module][imp.Name]
+		if !ok {
+			return nil, invalidModule("UnknownImport(module=%s,name=%s)", imp.Module, imp.Name)
+		}
+		if !h.Type.Equal(&m.Types[imp.Type]) {
+			return nil, invalidModule("ImportTypeMismatch(module=%s,name=%s)", imp.Module, imp.Name)
+		}
+		in.hosts = append(in.hosts, h)
+	}
+	for i, g := range m.Globals {
+		in.globals[i] = g.Init
+	}
+	if m.Memory != nil {
+		// initial pages are charged like memory.grow
+		if err := in.UseSteps(int64(m.Memory.Min) * StepPage); err != nil {
+			return nil, err
+		}
+		in.memory = make([]byte, int(m.Memory.Min)*PageSize)
+		in.maxPages = MaxPages
+		if m.Memory.Max != nil && *m.Memory.Max < in.maxPages {
+			in.maxPages = *m.Memory.Max
+		}
+	}
+	if m.Table != nil {
+		if m.Table.Min > maxTableSize {
+			return nil, invalidModule("TooLargeTable(size=%d)", m.Table.Min)
+		}
+		in.table = make([]int64, m.Table.Min)
+		for i := range in.table {
+			in.table[i] = -1
+		}
+	}
+	for _, e := range m.elements {
+		if uint64(e.offset)+uint64(len(e.funcs)) > uint64(len(in.table)) {
+			return nil, invalidModule("ElementOutOfBounds(offset=%d)", e.offset)
+		}
+		for i, f := range e.funcs {
+			in.table[int(e.offset)+i] = int64(f)
+		}
+	}
+	for i, d := range m.datas {
+		if !d.active {
+			continue
+		}
+		if uint64(d.offset)+uint64(len(d.init)) > uint64(len(in.memory)) {
+			return nil, invalidModule("DataOutOfBounds(offset=%d)", d.offset)
+		}
+		copy(in.memory[d.offset:], d.init)
+		in.dropped[i] = true
+	}
+	if m.start >= 0 {
+		err := in.invoke(func() {
+			in.call(uint32(m.start))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// invoke runs f, and returns the error stopping it.
+func (in *Instance) invoke(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch o := r.(type) {
+			case abort:
+				err = o.err
+			case runtime.Error:
+				err = trap("RuntimeError(%v)", o)
+			default:
+				panic(r)
+			}
+			in.sp, in.floor, in.depth = 0, 0, 0
+		}
+	}()
+	f()
+	return nil
+}
+
+// Call calls the exported function with arguments, and returns results.
+func (in *Instance) Call(name string, args ...uint64) ([]uint64, error) {
+	idx, ft, ok := in.module.ExportedFunc(name)
+	if !ok {
+		return nil, errors.NotFoundError.Errorf("FunctionNotFound(name=%s)", name)
+	}
+	if len(args) != len(ft.Params) {
+		return nil, errors.IllegalArgumentError.Errorf(
+			"InvalidArguments(name=%s,exp=%d,given=%d)", name, len(ft.Params), len(args))
+	}
+	if in.depth != 0 {
+		return nil, errors.InvalidStateError.New("AlreadyRunning")
+	}
+	var rs []uint64
+	err := in.invoke(func() {
+		for _, arg := range args {
+			in.push(arg)
+		}
+		in.call(idx)
+		rs = make([]uint64, len(ft.Results))
+		copy(rs, in.stack[in.sp-len(rs):in.sp])
+		in.sp = 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// UseSteps consumes steps for the work of host functions. Negative steps
+// refund steps used before. It returns ErrOutOfSteps if it exceeds the limit.
+func (in *Instance) UseSteps(steps int64) error {
+	if steps > in.limit-in.used {
+		in.used = in.limit
+		return errors.Wrapf(ErrOutOfSteps, "OutOfSteps(limit=%d)", in.limit)
+	}
+	in.used += steps
+	if in.used < 0 {
+		in.used = 0
+	}
+	return nil
+}
+
+func (in *Instance) useSteps(steps int64) {
+	if err := in.UseSteps(steps); err != nil {
+		panic(abort{err})
+	}
+}
+
+// StepsUsed returns steps used by the instance.
+func (in *Instance) StepsUsed() int64 {
+	return in.used
+}
+
+// Read returns a copy of the memory in the range.
+func (in *Instance) Read(ptr, size uint32) ([]byte, error) {
+	if uint64(ptr)+uint64(size) > uint64(len(in.memory)) {
+		return nil, trap("OutOfBoundsMemoryAccess(ptr=%d,size=%d)", ptr, size)
+	}
+	bs := make([]byte, size)
+	copy(bs, in.memory[ptr:])
+	return bs, nil
+}
+
+// Write writes bytes to the memory at ptr.
+func (in *Instance) Write(ptr uint32, bs []byte) error {
+	if uint64(ptr)+uint64(len(bs)) > uint64(len(in.memory)) {
+		return trap("OutOfBoundsMemoryAccess(ptr=%d,size=%d)", ptr, len(bs))
+	}
+	copy(in.memory[ptr:], bs)
+	return nil
+}
+
+func (in *Instance) push(v uint64) {
+	if in.sp == len(in.stack) {
+		if len(in.stack) >= maxStack {
+			panic(abort{trap("StackOverflow")})
+		}
+		stack := make([]uint64, len(in.stack)*2)
+		copy(stack, in.stack)
+		in.stack = stack
+	}
+	in.stack[in.sp] = v
+	in.sp += 1
+}
+
+func (in *Instance) pop() uint64 {
+	if in.sp <= in.floor {
+		panic(abort{trap("StackUnderflow")})
+	}
+	in.sp -= 1
+	return in.stack[in.sp]
+}
+
+// unwind drops values between height and top arity values.
+func (in *Instance) unwind(height, arity int) {
+	if in.sp-arity < height {
+		panic(abort{trap("StackUnderflow")})
+	}
+	if in.sp-arity != height {
+		copy(in.stack[height:], in.stack[in.sp-arity:in.sp])
+	}
+	in.sp = height + arity
+}
+
+func (in *Instance) call(idx uint32) {
+	if in.depth >= maxCallDepth {
+		panic(abort{trap("CallStackExhausted")})
+	}
+	ft, _ := in.module.FuncType(idx)
+	base := in.sp - len(ft.Params)
+	if base < in.floor {
+		panic(abort{trap("StackUnderflow")})
+	}
+	in.depth += 1
+	if int(idx) < len(in.hosts) {
+		args := make([]uint64, len(ft.Params))
+		copy(args, in.stack[base:in.sp])
+		in.sp = base
+		rs, err := in.hosts[idx].Func(in, args)
+		if err != nil {
+			panic(abort{err})
+		}
+		if len(rs) != len(ft.Results) {
+			panic(abort{trap("InvalidHostResults(idx=%d)", idx)})
+		}
+		for _, r := range rs {
+			in.push(r)
+		}
+	} else {
+		floor := in.floor
+		in.execute(in.module.codes[int(idx)-len(in.hosts)], base)
+		in.floor = floor
+	}
+	in.depth -= 1
+}
+
+func (in *Instance) memoryRange(addr uint64, size uint64) []byte {
+	if addr+size > uint64(len(in.memory)) {
+		panic(abort{trap("OutOfBoundsMemoryAccess(addr=%d,size=%d)", addr, size)})
+	}
+	return in.memory[addr : addr+size]
+}
+
+func (in *Instance) address(offset uint32) uint64 {
+	return uint64(uint32(in.pop())) + uint64(offset)
+}
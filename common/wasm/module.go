@@ -0,0 +1,694 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wasm implements a deterministic interpreter of WebAssembly modules.
+//
+// It supports integer instructions of WebAssembly 1.0 with sign extension,
+// multi-value and bulk memory operations. Floating point types and
+// instructions are rejected on decoding, so results never depend on the
+// platform. Every executed instruction consumes steps, and the execution
+// stops with ErrOutOfSteps if it uses more than the limit.
+package wasm
+
+import (
+	"bytes"
+
+	"github.com/icon-project/goloop/common/errors"
+)
+
+var (
+	ErrInvalidModule = errors.NewBase(errors.IllegalArgumentError, "InvalidModule")
+	ErrTrap          = errors.NewBase(errors.ExecutionFailError, "Trap")
+	ErrOutOfSteps    = errors.NewBase(errors.ExecutionFailError, "OutOfSteps")
+)
+
+func invalidModule(format string, args ...interface{}) error {
+	return errors.Wrapf(ErrInvalidModule, format, args...)
+}
+
+func trap(format string, args ...interface{}) error {
+	return errors.Wrapf(ErrTrap, format, args...)
+}
+
+type ValueType byte
+
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	default:
+		return "unknown"
+	}
+}
+
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+func (t *FuncType) Equal(t2 *FuncType) bool {
+	return bytes.Equal(valueTypeBytes(t.Params), valueTypeBytes(t2.Params)) &&
+		bytes.Equal(valueTypeBytes(t.Results), valueTypeBytes(t2.Results))
+}
+
+func valueTypeBytes(ts []ValueType) []byte {
+	bs := make([]byte, len(ts))
+	for i, t := range ts {
+		bs[i] = byte(t)
+	}
+	return bs
+}
+
+type Limits struct {
+	Min uint32
+	Max *uint32
+}
+
+type Import struct {
+	Module string
+	Name   string
+	Type   uint32
+}
+
+type ExportKind byte
+
+const (
+	ExportFunc ExportKind = iota
+	ExportTable
+	ExportMemory
+	ExportGlobal
+)
+
+type Export struct {
+	Kind  ExportKind
+	Index uint32
+}
+
+type Global struct {
+	Type    ValueType
+	Mutable bool
+	Init    uint64
+}
+
+type element struct {
+	offset uint32
+	funcs  []uint32
+}
+
+type data struct {
+	active bool
+	offset uint32
+	init   []byte
+}
+
+// Module is a decoded WebAssembly module. It can be shared by instances.
+type Module struct {
+	Types   []FuncType
+	Imports []Import
+	Funcs   []uint32
+	Table   *Limits
+	Memory  *Limits
+	Globals []Global
+	Exports map[string]Export
+	Customs map[string][]byte
+
+	start    int
+	elements []element
+	datas    []data
+	codes    []*function
+}
+
+// FuncType returns the type of the function at the index in the function
+// index space including imported functions.
+func (m *Module) FuncType(idx uint32) (*FuncType, bool) {
+	if int(idx) < len(m.Imports) {
+		return &m.Types[m.Imports[idx].Type], true
+	}
+	idx -= uint32(len(m.Imports))
+	if int(idx) < len(m.Funcs) {
+		return &m.Types[m.Funcs[idx]], true
+	}
+	return nil, false
+}
+
+// ExportedFunc returns the index and the type of the exported function.
+func (m *Module) ExportedFunc(name string) (uint32, *FuncType, bool) {
+	if e, ok := m.Exports[name]; ok && e.Kind == ExportFunc {
+		ft, _ := m.FuncType(e.Index)
+		return e.Index, ft, true
+	}
+	return 0, nil, false
+}
+
+func (m *Module) numFuncs() int {
+	return len(m.Imports) + len(m.Funcs)
+}
+
+const (
+	secCustom    = 0
+	secType      = 1
+	secImport    = 2
+	secFunction  = 3
+	secTable     = 4
+	secMemory    = 5
+	secGlobal    = 6
+	secExport    = 7
+	secStart     = 8
+	secElement   = 9
+	secCode      = 10
+	secData      = 11
+	secDataCount = 12
+)
+
+var magic = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// Decode decodes and validates the module in the binary format.
+func Decode(bs []byte) (*Module, error) {
+	if !bytes.HasPrefix(bs, magic) {
+		return nil, invalidModule("InvalidMagicOrVersion")
+	}
+	m := &Module{
+		Exports: make(map[string]Export),
+		Customs: make(map[string][]byte),
+		start:   -1,
+	}
+	r := &reader{bs: bs, pos: len(magic)}
+	var last byte
+	var bodies [][]byte
+	for !r.eof() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.count()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+		if id != secCustom {
+			if order(id) <= order(last) {
+				return nil, invalidModule("InvalidSectionOrder(id=%d)", id)
+			}
+			last = id
+		}
+		sr := &reader{bs: content}
+		switch id {
+		case secCustom:
+			err = m.decodeCustom(sr)
+		case secType:
+			err = m.decodeTypes(sr)
+		case secImport:
+			err = m.decodeImports(sr)
+		case secFunction:
+			err = m.decodeFunctions(sr)
+		case secTable:
+			err = m.decodeTable(sr)
+		case secMemory:
+			err = m.decodeMemory(sr)
+		case secGlobal:
+			err = m.decodeGlobals(sr)
+		case secExport:
+			err = m.decodeExports(sr)
+		case secStart:
+			err = m.decodeStart(sr)
+		case secElement:
+			err = m.decodeElements(sr)
+		case secCode:
+			bodies, err = m.decodeCodes(sr)
+		case secData:
+			err = m.decodeDatas(sr)
+		case secDataCount:
+			_, err = sr.u32()
+		default:
+			err = invalidModule("UnknownSection(id=%d)", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !sr.eof() {
+			return nil, invalidModule("SectionSizeMismatch(id=%d)", id)
+		}
+	}
+	if len(bodies) != len(m.Funcs) {
+		return nil, invalidModule("FunctionAndCodeMismatch")
+	}
+	for i, body := range bodies {
+		f, err := compile(m, &m.Types[m.Funcs[i]], body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "InvalidFunction(idx=%d)", len(m.Imports)+i)
+		}
+		m.codes = append(m.codes, f)
+	}
+	return m, nil
+}
+
+// order returns the position of the section, as the data count section
+// comes before the code section.
+func order(id byte) int {
+	switch id {
+	case secDataCount:
+		return secCode
+	case secCode, secData:
+		return int(id) + 1
+	default:
+		return int(id)
+	}
+}
+
+func (m *Module) decodeCustom(r *reader) error {
+	name, err := r.name()
+	if err != nil {
+		return err
+	}
+	m.Customs[name] = r.bs[r.pos:]
+	r.pos = len(r.bs)
+	return nil
+}
+
+func decodeValueType(r *reader) (ValueType, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch t := ValueType(b); t {
+	case I32, I64:
+		return t, nil
+	default:
+		return 0, invalidModule("UnsupportedValueType(%#x)", b)
+	}
+}
+
+func decodeValueTypes(r *reader) ([]ValueType, error) {
+	n, err := r.count()
+	if err != nil {
+		return nil, err
+	}
+	ts := make([]ValueType, n)
+	for i := range ts {
+		if ts[i], err = decodeValueType(r); err != nil {
+			return nil, err
+		}
+	}
+	return ts, nil
+}
+
+func (m *Module) decodeTypes(r *reader) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	m.Types = make([]FuncType, n)
+	for i := range m.Types {
+		if b, err := r.byte(); err != nil {
+			return err
+		} else if b != 0x60 {
+			return invalidModule("InvalidFuncType(%#x)", b)
+		}
+		if m.Types[i].Params, err = decodeValueTypes(r); err != nil {
+			return err
+		}
+		if m.Types[i].Results, err = decodeValueTypes(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Module) typeIndex(r *reader) (uint32, error) {
+	idx, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	if int(idx) >= len(m.Types) {
+		return 0, invalidModule("InvalidTypeIndex(%d)", idx)
+	}
+	return idx, nil
+}
+
+func (m *Module) decodeImports(r *reader) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		var imp Import
+		if imp.Module, err = r.name(); err != nil {
+			return err
+		}
+		if imp.Name, err = r.name(); err != nil {
+			return err
+		}
+		if kind, err := r.byte(); err != nil {
+			return err
+		} else if kind != 0x00 {
+			return invalidModule("UnsupportedImport(module=%s,name=%s,kind=%d)",
+				imp.Module, imp.Name, kind)
+		}
+		if imp.Type, err = m.typeIndex(r); err != nil {
+			return err
+		}
+		m.Imports = append(m.Imports, imp)
+	}
+	return nil
+}
+
+func (m *Module) decodeFunctions(r *reader) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	m.Funcs = make([]uint32, n)
+	for i := range m.Funcs {
+		if m.Funcs[i], err = m.typeIndex(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeLimits(r *reader) (*Limits, error) {
+	flag, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	l := new(Limits)
+	if l.Min, err = r.u32(); err != nil {
+		return nil, err
+	}
+	switch flag {
+	case 0x00:
+	case 0x01:
+		max, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		if max < l.Min {
+			return nil, invalidModule("InvalidLimits(min=%d,max=%d)", l.Min, max)
+		}
+		l.Max = &max
+	default:
+		return nil, invalidModule("InvalidLimitsFlag(%#x)", flag)
+	}
+	return l, nil
+}
+
+func (m *Module) decodeTable(r *reader) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	if n > 1 {
+		return invalidModule("MultipleTables")
+	}
+	for i := 0; i < n; i++ {
+		if rt, err := r.byte(); err != nil {
+			return err
+		} else if rt != 0x70 {
+			return invalidModule("UnsupportedTableType(%#x)", rt)
+		}
+		if m.Table, err = decodeLimits(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Module) decodeMemory(r *reader) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	if n > 1 {
+		return invalidModule("MultipleMemories")
+	}
+	for i := 0; i < n; i++ {
+		if m.Memory, err = decodeLimits(r); err != nil {
+			return err
+		}
+		if m.Memory.Min > MaxPages {
+			return invalidModule("TooLargeMemory(pages=%d)", m.Memory.Min)
+		}
+	}
+	return nil
+}
+
+// decodeConst decodes a constant expression, which is a constant
+// instruction followed by end.
+func (m *Module) decodeConst(r *reader, t ValueType) (uint64, error) {
+	op, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	switch {
+	case op == opI32Const && t == I32:
+		c, err := r.sleb(32)
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(uint32(c))
+	case op == opI64Const && t == I64:
+		c, err := r.sleb(64)
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(c)
+	case op == opGlobalGet:
+		idx, err := r.u32()
+		if err != nil {
+			return 0, err
+		}
+		if int(idx) >= len(m.Globals) || m.Globals[idx].Type != t {
+			return 0, invalidModule("InvalidGlobalInConst(%d)", idx)
+		}
+		v = m.Globals[idx].Init
+	default:
+		return 0, invalidModule("InvalidConstExpr(op=%#x)", op)
+	}
+	if end, err := r.byte(); err != nil {
+		return 0, err
+	} else if end != opEnd {
+		return 0, invalidModule("InvalidConstExpr(end=%#x)", end)
+	}
+	return v, nil
+}
+
+func (m *Module) decodeGlobals(r *reader) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		var g Global
+		if g.Type, err = decodeValueType(r); err != nil {
+			return err
+		}
+		if mut, err := r.byte(); err != nil {
+			return err
+		} else if mut > 1 {
+			return invalidModule("InvalidMutability(%#x)", mut)
+		} else {
+			g.Mutable = mut == 1
+		}
+		if g.Init, err = m.decodeConst(r, g.Type); err != nil {
+			return err
+		}
+		m.Globals = append(m.Globals, g)
+	}
+	return nil
+}
+
+func (m *Module) decodeExports(r *reader) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		idx, err := r.u32()
+		if err != nil {
+			return err
+		}
+		var valid bool
+		switch ExportKind(kind) {
+		case ExportFunc:
+			valid = int(idx) < m.numFuncs()
+		case ExportTable:
+			valid = idx == 0 && m.Table != nil
+		case ExportMemory:
+			valid = idx == 0 && m.Memory != nil
+		case ExportGlobal:
+			valid = int(idx) < len(m.Globals)
+		}
+		if !valid {
+			return invalidModule("InvalidExport(name=%s,kind=%d,idx=%d)", name, kind, idx)
+		}
+		if _, ok := m.Exports[name]; ok {
+			return invalidModule("DuplicateExport(name=%s)", name)
+		}
+		m.Exports[name] = Export{Kind: ExportKind(kind), Index: idx}
+	}
+	return nil
+}
+
+func (m *Module) decodeStart(r *reader) error {
+	idx, err := r.u32()
+	if err != nil {
+		return err
+	}
+	ft, ok := m.FuncType(idx)
+	if !ok || len(ft.Params) != 0 || len(ft.Results) != 0 {
+		return invalidModule("InvalidStartFunction(%d)", idx)
+	}
+	m.start = int(idx)
+	return nil
+}
+
+func (m *Module) decodeElements(r *reader) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		flag, err := r.u32()
+		if err != nil {
+			return err
+		}
+		switch flag {
+		case 0:
+		case 2:
+			if ti, err := r.u32(); err != nil {
+				return err
+			} else if ti != 0 {
+				return invalidModule("InvalidTableIndex(%d)", ti)
+			}
+		default:
+			return invalidModule("UnsupportedElement(flag=%d)", flag)
+		}
+		if m.Table == nil {
+			return invalidModule("NoTableForElement")
+		}
+		var e element
+		if e.offset, err = m.decodeOffset(r); err != nil {
+			return err
+		}
+		if flag == 2 {
+			if kind, err := r.byte(); err != nil {
+				return err
+			} else if kind != 0x00 {
+				return invalidModule("InvalidElementKind(%#x)", kind)
+			}
+		}
+		cnt, err := r.count()
+		if err != nil {
+			return err
+		}
+		e.funcs = make([]uint32, cnt)
+		for j := range e.funcs {
+			if e.funcs[j], err = r.u32(); err != nil {
+				return err
+			}
+			if int(e.funcs[j]) >= m.numFuncs() {
+				return invalidModule("InvalidFunctionIndex(%d)", e.funcs[j])
+			}
+		}
+		m.elements = append(m.elements, e)
+	}
+	return nil
+}
+
+func (m *Module) decodeOffset(r *reader) (uint32, error) {
+	v, err := m.decodeConst(r, I32)
+	return uint32(v), err
+}
+
+func (m *Module) decodeCodes(r *reader) ([][]byte, error) {
+	n, err := r.count()
+	if err != nil {
+		return nil, err
+	}
+	bodies := make([][]byte, n)
+	for i := range bodies {
+		size, err := r.count()
+		if err != nil {
+			return nil, err
+		}
+		if bodies[i], err = r.bytes(size); err != nil {
+			return nil, err
+		}
+	}
+	return bodies, nil
+}
+
+func (m *Module) decodeDatas(r *reader) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		flag, err := r.u32()
+		if err != nil {
+			return err
+		}
+		var d data
+		switch flag {
+		case 0, 2:
+			if flag == 2 {
+				if mi, err := r.u32(); err != nil {
+					return err
+				} else if mi != 0 {
+					return invalidModule("InvalidMemoryIndex(%d)", mi)
+				}
+			}
+			if m.Memory == nil {
+				return invalidModule("NoMemoryForData")
+			}
+			d.active = true
+			if d.offset, err = m.decodeOffset(r); err != nil {
+				return err
+			}
+		case 1:
+		default:
+			return invalidModule("UnsupportedData(flag=%d)", flag)
+		}
+		size, err := r.count()
+		if err != nil {
+			return err
+		}
+		if d.init, err = r.bytes(size); err != nil {
+			return err
+		}
+		m.datas = append(m.datas, d)
+	}
+	return nil
+}
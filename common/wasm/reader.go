@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+// reader decodes values of the binary format from bytes.
+type reader struct {
+	bs  []byte
+	pos int
+}
+
+func (r *reader) eof() bool {
+	return r.pos >= len(r.bs)
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.bs) {
+		return 0, invalidModule("UnexpectedEOF(pos=%d)", r.pos)
+	}
+	b := r.bs[r.pos]
+	r.pos += 1
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || len(r.bs)-r.pos < n {
+		return nil, invalidModule("UnexpectedEOF(pos=%d,size=%d)", r.pos, n)
+	}
+	bs := r.bs[r.pos : r.pos+n]
+	r.pos += n
+	return bs, nil
+}
+
+func (r *reader) uleb(bits uint) (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < bits; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			if (bits < 64 && v>>bits != 0) || (shift == 63 && b > 1) {
+				return 0, invalidModule("IntegerTooLarge(pos=%d)", r.pos)
+			}
+			return v, nil
+		}
+	}
+	return 0, invalidModule("IntegerRepresentationTooLong(pos=%d)", r.pos)
+}
+
+func (r *reader) sleb(bits uint) (int64, error) {
+	var v int64
+	for shift := uint(0); shift < bits; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		v |= int64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			if shift+7 < 64 && b&0x40 != 0 {
+				v |= -1 << (shift + 7)
+			}
+			if (bits < 64 && (v < -(1<<(bits-1)) || v >= 1<<(bits-1))) ||
+				(shift == 63 && b != 0 && b != 0x7f) {
+				return 0, invalidModule("IntegerTooLarge(pos=%d)", r.pos)
+			}
+			return v, nil
+		}
+	}
+	return 0, invalidModule("IntegerRepresentationTooLong(pos=%d)", r.pos)
+}
+
+func (r *reader) u32() (uint32, error) {
+	v, err := r.uleb(32)
+	return uint32(v), err
+}
+
+// count reads the length of a vector. It shouldn't exceed remaining bytes,
+// because every element takes at least a byte.
+func (r *reader) count() (int, error) {
+	n, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	if int(n) > len(r.bs)-r.pos {
+		return 0, invalidModule("InvalidLength(pos=%d,len=%d)", r.pos, n)
+	}
+	return int(n), nil
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.count()
+	if err != nil {
+		return "", err
+	}
+	bs, err := r.bytes(n)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
@@ -0,0 +1,220 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+// anyType is the type of values popped from the stack of unreachable code.
+// It matches any other type.
+const anyType ValueType = 0
+
+var (
+	typesNone = []ValueType{}
+	typesI32  = []ValueType{I32}
+	typesI64  = []ValueType{I64}
+	typesI32s = []ValueType{I32, I32}
+	typesI64s = []ValueType{I64, I64}
+	typesMem  = []ValueType{I32, I32, I32}
+)
+
+// control is a frame of structured instructions in a function body.
+// start is the position of block, loop or if (-1 for the function body),
+// and elsePos is the position of else if it has.
+type control struct {
+	op          byte
+	start       int
+	elsePos     int
+	params      []ValueType
+	results     []ValueType
+	height      int
+	unreachable bool
+}
+
+// labelTypes returns types of values for branches to the frame.
+func (c *control) labelTypes() []ValueType {
+	if c.op == opLoop {
+		return c.params
+	}
+	return c.results
+}
+
+// validator tracks types of the operand stack while compiling a function
+// body as the validation algorithm of the specification.
+type validator struct {
+	vals  []ValueType
+	ctrls []control
+}
+
+func (v *validator) push(t ValueType) {
+	v.vals = append(v.vals, t)
+}
+
+func (v *validator) pushes(ts []ValueType) {
+	v.vals = append(v.vals, ts...)
+}
+
+func (v *validator) pop() (ValueType, error) {
+	c := &v.ctrls[len(v.ctrls)-1]
+	if len(v.vals) == c.height {
+		if c.unreachable {
+			return anyType, nil
+		}
+		return 0, invalidModule("StackUnderflow")
+	}
+	t := v.vals[len(v.vals)-1]
+	v.vals = v.vals[:len(v.vals)-1]
+	return t, nil
+}
+
+func (v *validator) popExpect(exp ValueType) (ValueType, error) {
+	t, err := v.pop()
+	if err != nil {
+		return 0, err
+	}
+	if t != exp && t != anyType && exp != anyType {
+		return 0, invalidModule("TypeMismatch(exp=%s,real=%s)", exp, t)
+	}
+	if t == anyType {
+		return exp, nil
+	}
+	return t, nil
+}
+
+// pops pops values of types, and returns popped types.
+func (v *validator) pops(ts []ValueType) ([]ValueType, error) {
+	popped := make([]ValueType, len(ts))
+	for i := len(ts) - 1; i >= 0; i-- {
+		t, err := v.popExpect(ts[i])
+		if err != nil {
+			return nil, err
+		}
+		popped[i] = t
+	}
+	return popped, nil
+}
+
+func (v *validator) pushCtrl(op byte, start int, params, results []ValueType) {
+	v.ctrls = append(v.ctrls, control{
+		op:      op,
+		start:   start,
+		elsePos: -1,
+		params:  params,
+		results: results,
+		height:  len(v.vals),
+	})
+	v.pushes(params)
+}
+
+func (v *validator) popCtrl() (control, error) {
+	c := v.ctrls[len(v.ctrls)-1]
+	if _, err := v.pops(c.results); err != nil {
+		return c, err
+	}
+	if len(v.vals) != c.height {
+		return c, invalidModule("ExtraValuesInBlock(n=%d)", len(v.vals)-c.height)
+	}
+	v.ctrls = v.ctrls[:len(v.ctrls)-1]
+	return c, nil
+}
+
+// label returns the frame of the label depth.
+func (v *validator) label(depth uint32) (*control, error) {
+	if int(depth) >= len(v.ctrls) {
+		return nil, invalidModule("InvalidLabel(%d)", depth)
+	}
+	return &v.ctrls[len(v.ctrls)-1-int(depth)], nil
+}
+
+func (v *validator) setUnreachable() {
+	c := &v.ctrls[len(v.ctrls)-1]
+	v.vals = v.vals[:c.height]
+	c.unreachable = true
+}
+
+// apply pops values of params and pushes results.
+func (v *validator) apply(params, results []ValueType) error {
+	if _, err := v.pops(params); err != nil {
+		return err
+	}
+	v.pushes(results)
+	return nil
+}
+
+// numericType returns types of parameters and results of the numeric
+// instruction without immediates. It returns false for other instructions.
+func numericType(op byte) ([]ValueType, []ValueType, bool) {
+	switch {
+	case op == opI32Eqz:
+		return typesI32, typesI32, true
+	case op >= opI32Eq && op <= opI32GeU:
+		return typesI32s, typesI32, true
+	case op == opI64Eqz:
+		return typesI64, typesI32, true
+	case op >= opI64Eq && op <= opI64GeU:
+		return typesI64s, typesI32, true
+	case op >= opI32Clz && op <= opI32Popcnt:
+		return typesI32, typesI32, true
+	case op >= opI32Add && op <= opI32Rotr:
+		return typesI32s, typesI32, true
+	case op >= opI64Clz && op <= opI64Popcnt:
+		return typesI64, typesI64, true
+	case op >= opI64Add && op <= opI64Rotr:
+		return typesI64s, typesI64, true
+	case op == opI32WrapI64:
+		return typesI64, typesI32, true
+	case op == opI64ExtendI32S, op == opI64ExtendI32U:
+		return typesI32, typesI64, true
+	case op == opI32Extend8S, op == opI32Extend16S:
+		return typesI32, typesI32, true
+	case op >= opI64Extend8S && op <= opI64Extend32S:
+		return typesI64, typesI64, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// memoryType returns the type of the value and the natural alignment
+// (log2 of the access size) of the load or store instruction.
+func memoryType(op byte) (ValueType, uint32) {
+	switch op {
+	case opI32Load, opI32Store:
+		return I32, 2
+	case opI64Load, opI64Store:
+		return I64, 3
+	case opI32Load8S, opI32Load8U, opI32Store8:
+		return I32, 0
+	case opI32Load16S, opI32Load16U, opI32Store16:
+		return I32, 1
+	case opI64Load8S, opI64Load8U, opI64Store8:
+		return I64, 0
+	case opI64Load16S, opI64Load16U, opI64Store16:
+		return I64, 1
+	default:
+		// opI64Load32S, opI64Load32U, opI64Store32
+		return I64, 2
+	}
+}
+
+func typesEqual(a, b []ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,430 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/icon-project/goloop/common/errors"
+)
+
+func uleb(v uint64) []byte {
+	var bs []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			bs = append(bs, b|0x80)
+		} else {
+			return append(bs, b)
+		}
+	}
+}
+
+func sleb(v int64) []byte {
+	var bs []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(bs, b)
+		}
+		bs = append(bs, b|0x80)
+	}
+}
+
+func cat(items ...[]byte) []byte {
+	var bs []byte
+	for _, item := range items {
+		bs = append(bs, item...)
+	}
+	return bs
+}
+
+func vec(items ...[]byte) []byte {
+	return cat(uleb(uint64(len(items))), cat(items...))
+}
+
+func str(s string) []byte {
+	return cat(uleb(uint64(len(s))), []byte(s))
+}
+
+func section(id byte, items ...[]byte) []byte {
+	content := vec(items...)
+	return cat([]byte{id}, uleb(uint64(len(content))), content)
+}
+
+func funcType(params, results []ValueType) []byte {
+	return cat([]byte{0x60}, vec(valueTypes(params)...), vec(valueTypes(results)...))
+}
+
+func valueTypes(ts []ValueType) [][]byte {
+	var items [][]byte
+	for _, t := range ts {
+		items = append(items, []byte{byte(t)})
+	}
+	return items
+}
+
+func body(locals [][]byte, code ...[]byte) []byte {
+	content := cat(vec(locals...), cat(code...), []byte{opEnd})
+	return cat(uleb(uint64(len(content))), content)
+}
+
+func op(code byte, imms ...[]byte) []byte {
+	return cat([]byte{code}, cat(imms...))
+}
+
+func i32(v int32) []byte {
+	return op(opI32Const, sleb(int64(v)))
+}
+
+func i64(v int64) []byte {
+	return op(opI64Const, sleb(v))
+}
+
+func idx(i int) []byte {
+	return uleb(uint64(i))
+}
+
+func export(name string, kind ExportKind, i int) []byte {
+	return cat(str(name), []byte{byte(kind)}, idx(i))
+}
+
+var (
+	tI32 = []ValueType{I32}
+	tI64 = []ValueType{I64}
+)
+
+// testModule has following functions.
+//
+//	0: host.inc(i32) -> i32
+//	1: add(i32, i32) -> i32
+//	2: fact(i64) -> i64, recursive
+//	3: sum(i32) -> i32, sum of 1..n with a loop
+//	4: pick(i32) -> i32, br_table returning 10, 20 or 30
+//	5: store(i32, i64), store and load back
+//	6: div(i32, i32) -> i32
+//	7: spin(), infinite loop
+//	8: inc(i32) -> i32, calls the host function
+//	9: grow(i32) -> i32
+//	10: counter() -> i32, increments the global
+func testModule() []byte {
+	types := section(secType,
+		funcType(tI32, tI32),                  // 0
+		funcType([]ValueType{I32, I32}, tI32), // 1
+		funcType(tI64, tI64),                  // 2
+		funcType([]ValueType{I32, I64}, tI64), // 3
+		funcType(nil, nil),                    // 4
+		funcType(nil, tI32),                   // 5
+	)
+	imports := section(secImport,
+		cat(str("host"), str("inc"), []byte{0x00}, idx(0)),
+	)
+	funcs := section(secFunction,
+		idx(1), idx(2), idx(0), idx(0), idx(3), idx(1), idx(4), idx(0), idx(0), idx(5),
+	)
+	memory := section(secMemory, []byte{0x01, 0x01, 0x02})
+	globals := section(secGlobal, cat([]byte{byte(I32), 0x01}, i32(100), []byte{opEnd}))
+	exports := section(secExport,
+		export("add", ExportFunc, 1),
+		export("fact", ExportFunc, 2),
+		export("sum", ExportFunc, 3),
+		export("pick", ExportFunc, 4),
+		export("store", ExportFunc, 5),
+		export("div", ExportFunc, 6),
+		export("spin", ExportFunc, 7),
+		export("inc", ExportFunc, 8),
+		export("grow", ExportFunc, 9),
+		export("counter", ExportFunc, 10),
+		export("memory", ExportMemory, 0),
+	)
+	codes := section(secCode,
+		// add
+		body(nil, op(opLocalGet, idx(0)), op(opLocalGet, idx(1)), op(opI32Add)),
+		// fact
+		body(nil,
+			op(opLocalGet, idx(0)), op(opI64Eqz),
+			op(opIf, []byte{byte(I64)}),
+			i64(1),
+			op(opElse),
+			op(opLocalGet, idx(0)),
+			op(opLocalGet, idx(0)), i64(1), op(opI64Sub),
+			op(opCall, idx(2)),
+			op(opI64Mul),
+			op(opEnd),
+		),
+		// sum
+		body([][]byte{cat(idx(1), []byte{byte(I32)})},
+			op(opBlock, []byte{0x40}),
+			op(opLoop, []byte{0x40}),
+			op(opLocalGet, idx(0)), op(opI32Eqz), op(opBrIf, idx(1)),
+			op(opLocalGet, idx(1)), op(opLocalGet, idx(0)), op(opI32Add), op(opLocalSet, idx(1)),
+			op(opLocalGet, idx(0)), i32(1), op(opI32Sub), op(opLocalSet, idx(0)),
+			op(opBr, idx(0)),
+			op(opEnd),
+			op(opEnd),
+			op(opLocalGet, idx(1)),
+		),
+		// pick
+		body(nil,
+			op(opBlock, []byte{0x40}),
+			op(opBlock, []byte{0x40}),
+			op(opBlock, []byte{0x40}),
+			op(opLocalGet, idx(0)),
+			op(opBrTable, vec(idx(0), idx(1)), idx(2)),
+			op(opEnd),
+			i32(10), op(opReturn),
+			op(opEnd),
+			i32(20), op(opReturn),
+			op(opEnd),
+			i32(30),
+		),
+		// store
+		body(nil,
+			op(opLocalGet, idx(0)), op(opLocalGet, idx(1)), op(opI64Store, idx(3), idx(0)),
+			op(opLocalGet, idx(0)), op(opI64Load, idx(3), idx(0)),
+		),
+		// div
+		body(nil, op(opLocalGet, idx(0)), op(opLocalGet, idx(1)), op(opI32DivS)),
+		// spin
+		body(nil, op(opLoop, []byte{0x40}), op(opBr, idx(0)), op(opEnd)),
+		// inc
+		body(nil, op(opLocalGet, idx(0)), op(opCall, idx(0))),
+		// grow
+		body(nil, op(opLocalGet, idx(0)), op(opMemoryGrow, []byte{0x00})),
+		// counter
+		body(nil,
+			op(opGlobalGet, idx(0)), i32(1), op(opI32Add), op(opGlobalSet, idx(0)),
+			op(opGlobalGet, idx(0)),
+		),
+	)
+	return cat(magic, types, imports, funcs, memory, globals, exports, codes)
+}
+
+func testImports(calls *int) Imports {
+	return Imports{
+		"host": {
+			"inc": &HostFunc{
+				Type: FuncType{Params: tI32, Results: tI32},
+				Func: func(in *Instance, args []uint64) ([]uint64, error) {
+					*calls += 1
+					if err := in.UseSteps(10); err != nil {
+						return nil, err
+					}
+					return []uint64{uint64(uint32(args[0]) + 1)}, nil
+				},
+			},
+		},
+	}
+}
+
+func TestInstance_Call(t *testing.T) {
+	m, err := Decode(testModule())
+	require.NoError(t, err)
+
+	var calls int
+	in, err := NewInstance(m, testImports(&calls), 1000000)
+	assert.NoError(t, err)
+
+	neg := func(v int32) uint64 {
+		return uint64(uint32(v))
+	}
+
+	cases := []struct {
+		name string
+		args []uint64
+		exp  uint64
+	}{
+		{"add", []uint64{3, 4}, 7},
+		{"add", []uint64{neg(-1), 1}, 0},
+		{"fact", []uint64{0}, 1},
+		{"fact", []uint64{20}, 2432902008176640000},
+		{"sum", []uint64{100}, 5050},
+		{"pick", []uint64{0}, 10},
+		{"pick", []uint64{1}, 20},
+		{"pick", []uint64{2}, 30},
+		{"pick", []uint64{100}, 30},
+		{"store", []uint64{16, 0x1122334455667788}, 0x1122334455667788},
+		{"div", []uint64{neg(-7), 2}, neg(-3)},
+		{"inc", []uint64{41}, 42},
+		{"grow", []uint64{1}, 1},
+		{"grow", []uint64{1}, neg(-1)},
+		{"counter", nil, 101},
+		{"counter", nil, 102},
+	}
+	for _, c := range cases {
+		rs, err := in.Call(c.name, c.args...)
+		if assert.NoError(t, err, c.name) {
+			assert.Equal(t, []uint64{c.exp}, rs, c.name)
+		}
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestInstance_Trap(t *testing.T) {
+	m, err := Decode(testModule())
+	require.NoError(t, err)
+
+	var calls int
+	in, err := NewInstance(m, testImports(&calls), 1000000)
+	assert.NoError(t, err)
+
+	_, err = in.Call("div", 1, 0)
+	assert.True(t, errors.Is(err, ErrTrap), err)
+
+	_, err = in.Call("div", uint64(0x80000000), uint64(0xffffffff))
+	assert.True(t, errors.Is(err, ErrTrap), err)
+
+	_, err = in.Call("store", 2*PageSize-4, 1)
+	assert.True(t, errors.Is(err, ErrTrap), err)
+
+	_, err = in.Call("fact", 1000)
+	assert.True(t, errors.Is(err, ErrTrap), err)
+
+	_, err = in.Call("nothing")
+	assert.Error(t, err)
+
+	// it still works after traps
+	rs, err := in.Call("add", 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3}, rs)
+}
+
+func TestInstance_Steps(t *testing.T) {
+	m, err := Decode(testModule())
+	require.NoError(t, err)
+
+	var calls int
+	_, err = NewInstance(m, testImports(&calls), StepPage-1)
+	assert.True(t, errors.Is(err, ErrOutOfSteps), err)
+
+	in, err := NewInstance(m, testImports(&calls), StepPage+1000)
+	assert.NoError(t, err)
+	// one page of the initial memory
+	assert.EqualValues(t, StepPage, in.StepsUsed())
+
+	_, err = in.Call("add", 1, 2)
+	assert.NoError(t, err)
+	// local.get, local.get, i32.add and end
+	assert.EqualValues(t, StepPage+4, in.StepsUsed())
+
+	_, err = in.Call("inc", 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, StepPage+4+3+10, in.StepsUsed())
+
+	_, err = in.Call("spin")
+	assert.True(t, errors.Is(err, ErrOutOfSteps), err)
+	assert.EqualValues(t, StepPage+1000, in.StepsUsed())
+
+	// steps are counted in the same way for the same execution
+	in1, _ := NewInstance(m, testImports(&calls), 1000000)
+	in2, _ := NewInstance(m, testImports(&calls), 1000000)
+	_, err = in1.Call("sum", 1000)
+	assert.NoError(t, err)
+	_, err = in2.Call("sum", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, in1.StepsUsed(), in2.StepsUsed())
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	cases := map[string][]byte{
+		"magic": []byte("\x00asm\x02\x00\x00\x00"),
+		"float": cat(magic,
+			section(secType, funcType([]ValueType{0x7d}, nil)),
+		),
+		"floatOp": cat(magic,
+			section(secType, funcType(nil, nil)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, op(0x43, []byte{0, 0, 0, 0}), op(opDrop))),
+		),
+		"label": cat(magic,
+			section(secType, funcType(nil, nil)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, op(opBr, idx(1)))),
+		),
+		"order": cat(magic,
+			section(secFunction),
+			section(secType),
+		),
+		"noMemory": cat(magic,
+			section(secType, funcType(nil, tI32)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, i32(0), op(opI32Load, idx(2), idx(0)))),
+		),
+		"alignment": cat(magic,
+			section(secType, funcType(nil, tI32)),
+			section(secFunction, idx(0)),
+			section(secMemory, []byte{0x00, 0x01}),
+			section(secCode, body(nil, i32(0), op(opI32Load, idx(3), idx(0)))),
+		),
+		"resultType": cat(magic,
+			section(secType, funcType(nil, tI32)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, i64(1))),
+		),
+		"operandType": cat(magic,
+			section(secType, funcType(nil, tI32)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, i32(1), i64(1), op(opI32Add))),
+		),
+		"underflow": cat(magic,
+			section(secType, funcType(nil, tI32)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, i32(1), op(opI32Add))),
+		),
+		"extraValues": cat(magic,
+			section(secType, funcType(nil, nil)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, i32(1))),
+		),
+		"blockUnderflow": cat(magic,
+			section(secType, funcType(nil, tI32)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, i32(1), op(opBlock, []byte{byte(I32)}), op(opEnd), op(opI32Add))),
+		),
+		"ifWithoutElse": cat(magic,
+			section(secType, funcType(nil, tI32)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, i32(1), op(opIf, []byte{byte(I32)}), i32(2), op(opEnd))),
+		),
+		"localType": cat(magic,
+			section(secType, funcType(tI64, nil)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, i32(1), op(opLocalSet, idx(0)))),
+		),
+		"brValue": cat(magic,
+			section(secType, funcType(nil, tI32)),
+			section(secFunction, idx(0)),
+			section(secCode, body(nil, i64(1), op(opBr, idx(0)))),
+		),
+	}
+	for name, bs := range cases {
+		_, err := Decode(bs)
+		assert.True(t, errors.Is(err, ErrInvalidModule), name)
+	}
+}
+
+func TestDecode_Unreachable(t *testing.T) {
+	// values after unreachable, br and return may have any type
+	bs := cat(magic,
+		section(secType, funcType(nil, tI32)),
+		section(secFunction, idx(0), idx(0), idx(0)),
+		section(secCode,
+			body(nil, op(opUnreachable), op(opI32Add)),
+			body(nil, op(opBlock, []byte{byte(I32)}), i32(1), op(opBr, idx(0)), op(opI64Eqz), op(opEnd)),
+			body(nil, i32(1), op(opReturn), op(opSelect)),
+		),
+	)
+	_, err := Decode(bs)
+	assert.NoError(t, err)
+}
+
+func TestNewInstance_UnknownImport(t *testing.T) {
+	m, err := Decode(testModule())
+	require.NoError(t, err)
+
+	_, err = NewInstance(m, Imports{}, StepPage)
+	assert.True(t, errors.Is(err, ErrInvalidModule), err)
+}
@@ -308,11 +308,13 @@ func (cs *consensus) OnReceive(
 	msg, err := UnmarshalMessage(sp.Uint16(), bs)
 	if err != nil {
 		cs.log.Warnf("malformed consensus message: OnReceive(subprotocol:%v, from:%v): %+v\n", sp, common.HexPre(id.Bytes()), err)
+		module.ReportPeer(cs.ph, id, module.ViolationInvalidMessage, "MalformedConsensusMessage")
 		return false, err
 	}
 	cs.log.Debugf("OnReceive(msg:%v, from:%v)\n", msg, common.HexPre(id.Bytes()))
 	if err = msg.Verify(cs); err != nil {
 		cs.log.Warnf("consensus message verify failed: OnReceive(msg:%v, from:%v): %+v\n", msg, common.HexPre(id.Bytes()), err)
+		module.ReportPeer(cs.ph, id, module.ViolationInvalidMessage, "InvalidConsensusMessage")
 		return false, err
 	}
 	switch m := msg.(type) {
@@ -1041,12 +1043,30 @@ func (cs *consensus) enterTransactionWait() {
 	cs.resetForNewStep(stepTransactionWait)
 
 	waitTx := cs.minimizeBlockGen
+	var idleTimeout time.Duration
+	if !waitTx {
+		idleTimeout = cs.c.Regulator().IdleTimeout(
+			time.UnixMicro(cs.lastBlock.Timestamp()))
+		waitTx = idleTimeout > 0
+	}
 	if len(cs.lastBlock.NormalTransactions().Hash()) > 0 {
 		waitTx = false
 	}
 
 	if waitTx {
 		hrs := cs.hrs
+		if idleTimeout > 0 {
+			cs.timer = time.AfterFunc(idleTimeout, func() {
+				cs.mutex.Lock()
+				defer cs.mutex.Unlock()
+
+				if cs.hrs != hrs || !cs.started {
+					return
+				}
+				cs.log.Debugf("idle timeout, propose empty block\n")
+				cs.enterPropose()
+			})
+		}
 		callback, err := cs.c.BlockManager().WaitForTransaction(cs.lastBlock.ID(), func() {
 			cs.mutex.Lock()
 			defer cs.mutex.Unlock()
@@ -490,6 +490,7 @@ func (f *fetcher) onReceive(pi module.ProtocolInfo, b []byte) {
 		var msg BlockMetadata
 		_, err := codec.UnmarshalFromBytes(b, &msg)
 		if err != nil {
+			module.ReportPeer(f.cl.ph, f.id, module.ViolationInvalidMessage, "MalformedBlockMetadata")
 			return
 		}
 		if msg.RequestID != f.requestID {
@@ -514,6 +515,7 @@ func (f *fetcher) onReceive(pi module.ProtocolInfo, b []byte) {
 		var msg BlockData
 		_, err := codec.UnmarshalFromBytes(b, &msg)
 		if err != nil {
+			module.ReportPeer(f.cl.ph, f.id, module.ViolationInvalidMessage, "MalformedBlockData")
 			return
 		}
 		if msg.RequestID != f.requestID {
@@ -535,8 +537,10 @@ func (f *fetcher) onReceive(pi module.ProtocolInfo, b []byte) {
 			r := io.MultiReader(bufs...)
 			blk, err := f.cl.bm.NewBlockDataFromReader(r)
 			if err != nil {
+				module.ReportPeer(f.cl.ph, f.id, module.ViolationInvalidBlock, "InvalidBlockData")
 				f.cl.onResult(f, err, nil, nil)
 			} else if blk.Height() != f.height {
+				module.ReportPeer(f.cl.ph, f.id, module.ViolationInvalidBlock, "BadHeight")
 				f.cl.onResult(f, errors.Errorf("bad Height"), nil, nil)
 			} else {
 				f.cl.onResult(f, nil, blk, f.voteList)
@@ -547,6 +551,7 @@ func (f *fetcher) onReceive(pi module.ProtocolInfo, b []byte) {
 				f.timer.Stop()
 				f.timer = nil
 			}
+			module.ReportPeer(f.cl.ph, f.id, module.ViolationInvalidBlock, "BadDataLength")
 			f.cl.onResult(f, errors.Errorf("bad data"), nil, nil)
 		}
 	}
@@ -300,6 +300,7 @@ func (h *sconHandler) processMsg(msgItem *MessageItem) {
 		_, err := codec.UnmarshalFromBytes(msgItem.b, &msg)
 		if err != nil {
 			h.log.Debugf("Fail to decode request %+v", err)
+			module.ReportPeer(h.ph, h.id, module.ViolationInvalidMessage, "MalformedBlockRequest")
 			return
 		}
 		if len(h.nextItems) < maxNextItems {
@@ -307,6 +308,7 @@ func (h *sconHandler) processMsg(msgItem *MessageItem) {
 			h.nextItems = append(h.nextItems, &msg)
 		} else {
 			h.log.Debugf("Received BlockRequest %d ignored\n", msg.Height)
+			module.ReportPeer(h.ph, h.id, module.ViolationFlooding, "TooManyBlockRequests")
 		}
 	} else if msgItem.pi == ProtoCancelAllBlockRequests {
 		h.cancelAllRequests()
@@ -76,6 +76,10 @@ func (c *testChain) ConcurrencyLevel() int {
 	return 1
 }
 
+func (c *testChain) OptimisticExecution() bool {
+	return false
+}
+
 func (c *testChain) NormalTxPoolSize() int {
 	return 5000
 }
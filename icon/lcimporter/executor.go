@@ -525,7 +525,7 @@ func NewExecutor(chain module.Chain, dbase db.Database, cfg *Config) (*Executor,
 	idb := chain.Database()
 
 	// build converter
-	rdb := cache.AttachManager(dbase, "", 5, 0, 0)
+	rdb := cache.AttachManager(dbase, "", 5, 0, 0, nil)
 	chain = NewChain(chain, rdb)
 	store, err := lcstore.OpenStore(cfg.StoreURI, cfg.MaxRPS)
 	if err != nil {
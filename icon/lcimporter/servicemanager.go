@@ -224,6 +224,10 @@ func (sm *ServiceManager) GetBalance(result []byte, addr module.Address) (*big.I
 	return nil, errors.ErrInvalidState
 }
 
+func (sm *ServiceManager) GetTokenBalance(result []byte, id int64, addr module.Address) (*big.Int, error) {
+	return nil, errors.ErrInvalidState
+}
+
 func (sm *ServiceManager) GetTotalSupply(result []byte) (*big.Int, error) {
 	return nil, errors.ErrInvalidState
 }
@@ -73,6 +73,7 @@ public class EEProxy extends Proxy {
         public static final int GETOBJGRAPH = 13;
         public static final int SETOBJGRAPH = 14;
         public static final int SETFEEPCT = 15;
+        public static final int PING = 17;
     }
 
     public static class SetValueFlag {
@@ -103,7 +104,7 @@ public class EEProxy extends Proxy {
     }
 
     public void connect(String uuid) throws IOException {
-        sendMessage(MsgType.VERSION, 1, uuid, "java");
+        sendMessage(MsgType.VERSION, 2, uuid, "java");
     }
 
     public void close() throws IOException {
@@ -130,6 +131,10 @@ public class EEProxy extends Proxy {
                 case MsgType.RESULT:
                     logger.trace("[RESULT]");
                     return msg.value;
+                case MsgType.PING:
+                    logger.trace("[PING]");
+                    handlePing(msg.value);
+                    break;
                 case MsgType.CLOSE:
                     // TODO: unwind stack
                     logger.trace("[CLOSE]");
@@ -252,6 +257,13 @@ public class EEProxy extends Proxy {
         mOnGetApiListener = listener;
     }
 
+    private void handlePing(Value raw) throws IOException {
+        int seq = raw.asIntegerValue().asInt();
+        Runtime rt = Runtime.getRuntime();
+        long memory = rt.totalMemory() - rt.freeMemory();
+        sendMessage(MsgType.PING, seq, memory);
+    }
+
     private void handleGetApi(String path) throws IOException {
         if (mOnGetApiListener == null) {
             RuntimeAssertionError.unreachable("no getAPI handler");
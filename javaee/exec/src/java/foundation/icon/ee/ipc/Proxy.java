@@ -91,6 +91,8 @@ public abstract class Proxy {
             packer.packBoolean((boolean) obj);
         } else if (obj instanceof Integer) {
             packer.packInt((int) obj);
+        } else if (obj instanceof Long) {
+            packer.packLong((long) obj);
         } else if (obj instanceof String) {
             packer.packString((String) obj);
         } else if (obj instanceof byte[]) {
@@ -60,6 +60,16 @@ public class Governance {
         system.rejectScore(txHash);
     }
 
+    @External
+    public void scheduleScoreUpdate(byte[] txHash, BigInteger height, BigInteger stepLimit) {
+        system.scheduleScoreUpdate(txHash, height, stepLimit);
+    }
+
+    @External
+    public void cancelScoreUpdate(Address address) {
+        system.cancelScoreUpdate(address);
+    }
+
     @External
     public void blockScore(Address address) {
         system.blockScore(address);
@@ -54,6 +54,15 @@ class SystemInterface {
         Context.call(CHAIN_SCORE, "rejectScore", (Object) txHash);
     }
 
+    void scheduleScoreUpdate(byte[] txHash, BigInteger height, BigInteger stepLimit) {
+        validateHash(txHash);
+        Context.call(CHAIN_SCORE, "scheduleScoreUpdate", txHash, height, stepLimit);
+    }
+
+    void cancelScoreUpdate(Address address) {
+        Context.call(CHAIN_SCORE, "cancelScoreUpdate", address);
+    }
+
     void blockScore(Address address) {
         Context.call(CHAIN_SCORE, "blockScore", address);
     }
@@ -29,6 +29,7 @@ type Chain interface {
 	NetID() int
 	Channel() string
 	ConcurrencyLevel() int
+	OptimisticExecution() bool
 	NormalTxPoolSize() int
 	PatchTxPoolSize() int
 	MaxBlockTxBytes() int
@@ -56,7 +57,7 @@ type Chain interface {
 	Stop() error
 	Import(src string, height int64) error
 	Prune(gs string, dbt string, height int64) error
-	Backup(file string, extra []string) error
+	Backup(file, base string, extra []string) error
 	RunTask(task string, params json.RawMessage) error
 	Term() error
 	State() (string, int64, error)
@@ -84,6 +85,11 @@ type Regulator interface {
 	MinCommitTimeout() time.Duration
 	OnTxExecution(count int, ed time.Duration, fd time.Duration)
 	SetBlockInterval(i time.Duration, d time.Duration)
+
+	// IdleTimeout returns how long consensus may wait for transactions
+	// before it proposes an empty block following the block made at
+	// lastBlockTime. Zero means that it shall not wait.
+	IdleTimeout(lastBlockTime time.Time) time.Duration
 }
 
 type GenesisType int
@@ -83,6 +83,7 @@ const (
 const (
 	TransactionVersion2 = 2
 	TransactionVersion3 = 3
+	TransactionVersion4 = 4
 )
 
 type JSONVersion int
@@ -39,6 +39,53 @@ type ProtocolHandler interface {
 
 type OnResult func(isRelay bool, err error)
 
+// PeerViolation is a kind of protocol violation of a peer.
+type PeerViolation byte
+
+const (
+	// ViolationInvalidMessage is for malformed or unexpected messages.
+	ViolationInvalidMessage PeerViolation = iota
+	// ViolationInvalidProposal is for proposals or votes failing validation.
+	ViolationInvalidProposal
+	// ViolationInvalidBlock is for blocks or state data failing validation.
+	ViolationInvalidBlock
+	// ViolationInvalidTransaction is for transactions failing validation.
+	ViolationInvalidTransaction
+	// ViolationFlooding is for sending too many messages.
+	ViolationFlooding
+)
+
+func (v PeerViolation) String() string {
+	switch v {
+	case ViolationInvalidMessage:
+		return "InvalidMessage"
+	case ViolationInvalidProposal:
+		return "InvalidProposal"
+	case ViolationInvalidBlock:
+		return "InvalidBlock"
+	case ViolationInvalidTransaction:
+		return "InvalidTransaction"
+	case ViolationFlooding:
+		return "Flooding"
+	default:
+		return fmt.Sprintf("Unknown(%d)", v)
+	}
+}
+
+// PeerReporter is implemented by a ProtocolHandler accepting reports
+// about protocol violations of peers.
+type PeerReporter interface {
+	ReportPeer(id PeerID, v PeerViolation, reason string)
+}
+
+// ReportPeer reports the violation of the peer to ph if ph supports
+// PeerReporter.
+func ReportPeer(ph ProtocolHandler, id PeerID, v PeerViolation, reason string) {
+	if pr, ok := ph.(PeerReporter); ok && id != nil {
+		pr.ReportPeer(id, v, reason)
+	}
+}
+
 type AsyncProtocolHandler interface {
 	ProtocolHandler
 	HandleInBackground() (OnResult, error)
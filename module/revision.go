@@ -39,6 +39,9 @@ const (
 	ReportDoubleSign
 	FixJCLSteps
 	ReportConfigureEvents
+	ContractValidatedTx
+	BatchTransaction
+	AccountKeyTypes
 	LastRevisionBit
 
 	UseNIDInConsensusMessage = ReportDoubleSign
@@ -338,6 +338,9 @@ type ServiceManager interface {
 	// GetBalance returns balance of the account
 	GetBalance(result []byte, addr Address) (*big.Int, error)
 
+	// GetTokenBalance returns balance of the native token of the account
+	GetTokenBalance(result []byte, id int64, addr Address) (*big.Int, error)
+
 	// GetTotalSupply returns total supplied coin
 	GetTotalSupply(result []byte) (*big.Int, error)
 
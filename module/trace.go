@@ -61,6 +61,9 @@ const (
 	Ghost
 	Reward
 	RegPRep
+	TokenMint
+	TokenBurn
+	TokenTransfer
 )
 
 type ExecutionPhase int
@@ -81,3 +84,9 @@ type TraceCallback interface {
 	OnFrameExit(success bool) error
 	OnBalanceChange(opType OpType, from, to Address, amount *big.Int) error
 }
+
+// TokenTraceCallback is implemented by the TraceCallback tracing balance
+// changes of native tokens.
+type TokenTraceCallback interface {
+	OnTokenBalanceChange(opType OpType, token int64, from, to Address, amount *big.Int) error
+}
@@ -157,6 +157,38 @@ func (a *Authenticator) isSupportedSecureSuite(channel string, ss SecureSuite) b
 	return false
 }
 
+// secureSuitesFor returns secure suites for the peer. Only SecureSuiteNone is
+// used for connections encrypted by the transport.
+func (a *Authenticator) secureSuitesFor(p *Peer) []SecureSuite {
+	if p.isEncryptedConn() {
+		return []SecureSuite{SecureSuiteNone}
+	}
+	sss := a.secureSuites[p.Channel()]
+	if len(sss) == 0 {
+		sss = DefaultSecureSuites
+	}
+	return sss
+}
+
+func (a *Authenticator) isSupportedSecureSuiteFor(p *Peer, ss SecureSuite) bool {
+	if p.isEncryptedConn() {
+		return ss == SecureSuiteNone
+	}
+	return a.isSupportedSecureSuite(p.Channel(), ss)
+}
+
+func (a *Authenticator) resolveSecureSuiteFor(p *Peer, sss []SecureSuite) SecureSuite {
+	if !p.isEncryptedConn() {
+		return a.resolveSecureSuite(p.Channel(), sss)
+	}
+	for _, ss := range sss {
+		if ss == SecureSuiteNone {
+			return ss
+		}
+	}
+	return SecureSuiteUnknown
+}
+
 func (a *Authenticator) resolveSecureSuite(channel string, sss []SecureSuite) SecureSuite {
 	for _, ss := range sss {
 		if a.isSupportedSecureSuite(channel, ss) {
@@ -215,7 +247,7 @@ func (a *Authenticator) resolveSecureAeadSuite(channel string, sass []SecureAead
 }
 
 func (a *Authenticator) applySecureConn(p *Peer, ss SecureSuite, sas SecureAeadSuite, param []byte, req bool) error {
-	if !a.isSupportedSecureSuite(p.Channel(), ss) {
+	if !a.isSupportedSecureSuiteFor(p, ss) {
 		return errors.Wrapf(ErrIllegalArgument, "invalid SecureSuite %d", ss)
 	}
 	//When SecureSuite is SecureSuiteNone, fix SecureAeadSuite as SecureAeadSuiteNone
@@ -227,6 +259,13 @@ func (a *Authenticator) applySecureConn(p *Peer, ss SecureSuite, sas SecureAeadS
 	if err := p.secureKey.setup(sas, param, p.In(), a.secureKeyNum); err != nil {
 		return errors.Wrapf(err, "fail to secureKey.setup")
 	}
+	if qc, ok := p.conn.(*quicConn); ok {
+		binding, err := qc.channelBinding()
+		if err != nil {
+			return errors.Wrapf(err, "fail to get channel binding")
+		}
+		p.secureKey.bind(binding)
+	}
 	switch ss {
 	case SecureSuiteEcdhe:
 		if secureConn, err := NewSecureConn(p.conn, sas, p.secureKey); err != nil {
@@ -283,10 +322,7 @@ type SignatureResponse struct {
 
 func (a *Authenticator) sendSecureRequest(p *Peer) {
 	p.secureKey = newSecureKey(DefaultSecureEllipticCurve, DefaultSecureKeyLogWriter)
-	sms := a.secureSuites[p.Channel()]
-	if len(sms) == 0 {
-		sms = DefaultSecureSuites
-	}
+	sms := a.secureSuitesFor(p)
 	sas := a.secureAeads[p.Channel()]
 	if len(sas) == 0 {
 		sas = DefaultSecureAeadSuites
@@ -316,7 +352,7 @@ func (a *Authenticator) handleSecureRequest(pkt *Packet, p *Peer) {
 	p.setChannel(rm.Channel)
 	m := &SecureResponse{
 		Channel:         p.Channel(),
-		SecureSuite:     a.resolveSecureSuite(p.Channel(), rm.SecureSuites),
+		SecureSuite:     a.resolveSecureSuiteFor(p, rm.SecureSuites),
 		SecureAeadSuite: SecureAeadSuiteNone,
 		SecureError:     SecureErrorNone,
 	}
@@ -0,0 +1,239 @@
+package network
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	DefaultCaptureFile       = "capture/packets.cap"
+	DefaultCaptureMaxSize    = 100 // megabytes
+	DefaultCaptureMaxBackups = 10
+)
+
+type CaptureDirection byte
+
+const (
+	CaptureInbound CaptureDirection = iota
+	CaptureOutbound
+)
+
+func (d CaptureDirection) String() string {
+	switch d {
+	case CaptureInbound:
+		return "in"
+	case CaptureOutbound:
+		return "out"
+	default:
+		return "unknown"
+	}
+}
+
+// CaptureRecord is a packet recorded by the capture. Peer is the peer which
+// sent the packet for inbound packets, or the destination peer of unicast
+// for outbound packets.
+type CaptureRecord struct {
+	Timestamp   int64 // unix time in microseconds
+	Direction   CaptureDirection
+	Protocol    module.ProtocolInfo
+	SubProtocol module.ProtocolInfo
+	Peer        []byte
+	Src         []byte
+	Dest        byte
+	TTL         byte
+	Payload     []byte
+}
+
+func (r *CaptureRecord) Time() time.Time {
+	return time.UnixMicro(r.Timestamp)
+}
+
+func (r *CaptureRecord) PeerID() module.PeerID {
+	if len(r.Peer) == 0 {
+		return nil
+	}
+	return NewPeerID(r.Peer)
+}
+
+func (r *CaptureRecord) SrcID() module.PeerID {
+	if len(r.Src) == 0 {
+		return nil
+	}
+	return NewPeerID(r.Src)
+}
+
+func newCapture(l log.Logger) *capture {
+	return &capture{logger: l}
+}
+
+type capture struct {
+	mtx    sync.Mutex
+	w      io.Writer
+	file   string
+	count  int64
+	logger log.Logger
+}
+
+func (c *capture) start(cfg *log.WriterConfig) error {
+	w, err := log.NewWriter(cfg)
+	if err != nil {
+		return err
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c._stop()
+	c.w = w
+	c.file = cfg.Filename
+	c.count = 0
+	c.logger.Infof("start capture file=%s", c.file)
+	return nil
+}
+
+func (c *capture) _stop() {
+	if c.w == nil {
+		return
+	}
+	if closer, ok := c.w.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			c.logger.Warnf("fail to close capture file=%s err=%+v", c.file, err)
+		}
+	}
+	c.logger.Infof("stop capture file=%s count=%d", c.file, c.count)
+	c.w = nil
+	c.file = ""
+}
+
+func (c *capture) stop() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c._stop()
+}
+
+func (c *capture) record(d CaptureDirection, pkt *Packet, peer module.PeerID) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.w == nil {
+		return
+	}
+	rec := &CaptureRecord{
+		Timestamp:   time.Now().UnixMicro(),
+		Direction:   d,
+		Protocol:    pkt.protocol,
+		SubProtocol: pkt.subProtocol,
+		Dest:        pkt.dest,
+		TTL:         pkt.ttl,
+		Payload:     pkt.payload,
+	}
+	if peer != nil {
+		rec.Peer = peer.Bytes()
+	}
+	if pkt.src != nil {
+		rec.Src = pkt.src.Bytes()
+	}
+	// write a record at once, so rotation never splits a record.
+	b, err := codec.BC.MarshalToBytes(rec)
+	if err != nil {
+		c.logger.Warnf("fail to marshal capture record err=%+v", err)
+		return
+	}
+	if _, err = c.w.Write(b); err != nil {
+		c.logger.Warnf("fail to write capture file=%s err=%+v", c.file, err)
+		return
+	}
+	c.count++
+}
+
+func (c *capture) inspect() map[string]interface{} {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.w == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"file":  c.file,
+		"count": c.count,
+	}
+}
+
+// StartCapture starts to record every packet sent or received by reactors of
+// the network manager to the rotating file. It replaces the previous capture
+// if it's already started.
+func StartCapture(nm module.NetworkManager, cfg *log.WriterConfig) error {
+	m, ok := nm.(*manager)
+	if !ok {
+		return errors.UnsupportedError.New("CaptureNotSupported")
+	}
+	if len(cfg.Filename) == 0 {
+		return errors.IllegalArgumentError.New("EmptyCaptureFile")
+	}
+	return m.capture.start(cfg)
+}
+
+// StopCapture stops the capture of the network manager if it's started.
+func StopCapture(nm module.NetworkManager) {
+	if m, ok := nm.(*manager); ok {
+		m.capture.stop()
+	}
+}
+
+type CaptureReader struct {
+	d codec.DecodeAndCloser
+}
+
+// NewCaptureReader returns reader for the capture file written by
+// StartCapture.
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{d: codec.BC.NewDecoder(bufio.NewReader(r))}
+}
+
+// Next returns the next record. It returns io.EOF at the end of the capture.
+func (r *CaptureReader) Next() (*CaptureRecord, error) {
+	rec := new(CaptureRecord)
+	if err := r.d.Decode(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// ReplayCapture delivers inbound packets of the protocol in the capture to
+// the reactor in recorded order. OnJoin is called for each peer before the
+// first packet from it. It returns the number of delivered packets.
+func ReplayCapture(r io.Reader, pi module.ProtocolInfo, reactor module.Reactor) (int, error) {
+	cr := NewCaptureReader(r)
+	joined := make(map[string]bool)
+	cnt := 0
+	for idx := 0; ; idx++ {
+		rec, err := cr.Next()
+		if err != nil {
+			if err == io.EOF {
+				return cnt, nil
+			}
+			return cnt, errors.Wrapf(err, "InvalidCapture(index=%d)", idx)
+		}
+		if rec.Direction != CaptureInbound || rec.Protocol != pi {
+			continue
+		}
+		id := rec.PeerID()
+		if id == nil {
+			continue
+		}
+		if !joined[id.String()] {
+			joined[id.String()] = true
+			reactor.OnJoin(id)
+		}
+		// errors of the reactor are part of the replayed behavior.
+		_, _ = reactor.OnReceive(rec.SubProtocol, rec.Payload, id)
+		cnt++
+	}
+}
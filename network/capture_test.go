@@ -0,0 +1,86 @@
+package network
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+)
+
+type replayReactor struct {
+	joined   []module.PeerID
+	received [][]byte
+}
+
+func (r *replayReactor) OnReceive(pi module.ProtocolInfo, b []byte, id module.PeerID) (bool, error) {
+	r.received = append(r.received, b)
+	return false, nil
+}
+
+func (r *replayReactor) OnJoin(id module.PeerID) {
+	r.joined = append(r.joined, id)
+}
+
+func (r *replayReactor) OnLeave(id module.PeerID) {}
+
+func Test_capture_record(t *testing.T) {
+	file := path.Join(t.TempDir(), DefaultCaptureFile)
+	c := newCapture(testLogger())
+
+	pi := module.ProtocolInfo(0x0100)
+	spi := module.ProtocolInfo(0x0101)
+	p1 := generatePeerID()
+	p2 := generatePeerID()
+
+	// not started, nothing recorded
+	c.record(CaptureInbound, NewPacket(pi, spi, []byte("ignored")), p1)
+	assert.Nil(t, c.inspect())
+
+	assert.NoError(t, c.start(&log.WriterConfig{Filename: file}))
+	in1 := NewPacket(pi, spi, []byte("in1"))
+	in1.src = p1
+	c.record(CaptureInbound, in1, p1)
+	c.record(CaptureOutbound, NewPacket(pi, spi, []byte("out")), p1)
+	c.record(CaptureInbound, NewPacket(module.ProtocolInfo(0x0200), spi, []byte("other")), p2)
+	c.record(CaptureInbound, NewPacket(pi, spi, []byte("in2")), p2)
+	c.record(CaptureInbound, NewPacket(pi, spi, []byte("in3")), p1)
+	assert.EqualValues(t, 5, c.inspect()["count"])
+	c.stop()
+	assert.Nil(t, c.inspect())
+
+	b, err := os.ReadFile(file)
+	assert.NoError(t, err)
+
+	cr := NewCaptureReader(bytes.NewReader(b))
+	rec, err := cr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, CaptureInbound, rec.Direction)
+	assert.Equal(t, pi, rec.Protocol)
+	assert.Equal(t, spi, rec.SubProtocol)
+	assert.True(t, p1.Equal(rec.PeerID()))
+	assert.True(t, p1.Equal(rec.SrcID()))
+	assert.Equal(t, []byte("in1"), rec.Payload)
+	rec, err = cr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, CaptureOutbound, rec.Direction)
+	assert.Nil(t, rec.SrcID())
+
+	r := &replayReactor{}
+	cnt, err := ReplayCapture(bytes.NewReader(b), pi, r)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cnt)
+	assert.Equal(t, [][]byte{[]byte("in1"), []byte("in2"), []byte("in3")}, r.received)
+	assert.Len(t, r.joined, 2)
+	assert.True(t, p1.Equal(r.joined[0]))
+	assert.True(t, p2.Equal(r.joined[1]))
+
+	_, err = ReplayCapture(bytes.NewReader(b[:len(b)-1]), pi, &replayReactor{})
+	assert.Error(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
@@ -31,6 +31,18 @@ func newChannelNegotiator(netAddress NetAddress, id module.PeerID, l log.Logger)
 	return cn
 }
 
+func (cn *ChannelNegotiator) getNetAddress() NetAddress {
+	cn.mtx.RLock()
+	defer cn.mtx.RUnlock()
+	return cn.netAddress
+}
+
+func (cn *ChannelNegotiator) setNetAddress(na NetAddress) {
+	cn.mtx.Lock()
+	defer cn.mtx.Unlock()
+	cn.netAddress = na
+}
+
 func (cn *ChannelNegotiator) onPeer(p *Peer) {
 	cn.logger.Traceln("onPeer", p)
 	if !p.In() {
@@ -146,7 +158,7 @@ func (cn *ChannelNegotiator) sendJoinRequest(p *Peer) {
 		p.CloseByError(err)
 		return
 	}
-	m := &JoinRequest{Channel: p.Channel(), Addr: cn.netAddress, Protocols: pis.Array()}
+	m := &JoinRequest{Channel: p.Channel(), Addr: cn.getNetAddress(), Protocols: pis.Array()}
 	cn.sendMessage(p2pProtoChan, p2pProtoChanJoinReq, m, p)
 	cn.logger.Traceln("sendJoinRequest", m, p)
 }
@@ -170,7 +182,7 @@ func (cn *ChannelNegotiator) handleJoinRequest(pkt *Packet, p *Peer) {
 	}
 	p.setNetAddress(rm.Addr)
 
-	m := &JoinResponse{Channel: p.Channel(), Addr: cn.netAddress, Protocols: p.ProtocolInfos().Array()}
+	m := &JoinResponse{Channel: p.Channel(), Addr: cn.getNetAddress(), Protocols: p.ProtocolInfos().Array()}
 	cn.sendMessage(p2pProtoChan, p2pProtoChanJoinResp, m, p)
 
 	cn.nextOnPeer(p)
@@ -18,6 +18,11 @@ func Inspect(c module.Chain, informal bool) map[string]interface{} {
 	}
 	m := make(map[string]interface{})
 	m["p2p"] = inspectP2P(mgr, informal)
+	m["reputation"] = mgr.p2p.rep.inspect()
+	m["address"] = mgr.t.inspectAddress()
+	if capture := mgr.capture.inspect(); capture != nil {
+		m["capture"] = capture
+	}
 	if informal {
 		m["protocol"] = inspectProtocol(mgr)
 	}
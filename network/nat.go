@@ -0,0 +1,640 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/huin/goupnp/dcps/internetgateway2"
+	"github.com/jackpal/gateway"
+	natpmp "github.com/jackpal/go-nat-pmp"
+
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	NATNone  = "none"
+	NATUPnP  = "upnp"
+	NATPMP   = "pmp"
+	NATAny   = "any"
+	NATLocal = "local"
+)
+
+const (
+	DefaultObservedAddressThreshold = 3
+	DefaultObservedAddressMax       = 32
+	DefaultPortMappingLifetime      = 20 * time.Minute
+	DefaultPortMappingRetry         = time.Minute
+	DefaultPortMappingTimeout       = 5 * time.Second
+	DefaultPortMappingDescription   = "goloop p2p"
+)
+
+// PortMapper maps a port of the gateway to the local port, so that peers
+// outside of NAT can connect to the node. protocol is "tcp" or "udp".
+type PortMapper interface {
+	ExternalIP() (net.IP, error)
+	AddMapping(protocol string, extPort, intPort int, lifetime time.Duration) (int, error)
+	DeleteMapping(protocol string, extPort, intPort int) error
+	String() string
+}
+
+// NewPortMapper returns the PortMapper for the method, one of
+// none, upnp, pmp, any and local[:IP]. It returns nil for none.
+func NewPortMapper(method string) (PortMapper, error) {
+	name, param := method, ""
+	if idx := strings.IndexByte(method, ':'); idx >= 0 {
+		name, param = method[:idx], method[idx+1:]
+	}
+	switch name {
+	case "", NATNone:
+		return nil, nil
+	case NATUPnP:
+		return &upnpPortMapper{}, nil
+	case NATPMP:
+		return &pmpPortMapper{}, nil
+	case NATAny:
+		return &anyPortMapper{
+			candidates: []PortMapper{&pmpPortMapper{}, &upnpPortMapper{}},
+		}, nil
+	case NATLocal:
+		var ip net.IP
+		if len(param) > 0 {
+			if ip = net.ParseIP(param); ip == nil {
+				return nil, fmt.Errorf("invalid IP %s for local NAT", param)
+			}
+		}
+		return NewLocalPortMapper(ip), nil
+	default:
+		return nil, fmt.Errorf("unknown NAT method %s", method)
+	}
+}
+
+// SetPortMapper sets the PortMapper for the transport. The port is mapped
+// while the transport is listening.
+func SetPortMapper(nt module.NetworkTransport, pm PortMapper) {
+	if t, ok := nt.(*transport); ok {
+		t.setPortMapper(pm)
+	}
+}
+
+type upnpClient interface {
+	AddPortMappingCtx(ctx context.Context, NewRemoteHost string, NewExternalPort uint16, NewProtocol string, NewInternalPort uint16, NewInternalClient string, NewEnabled bool, NewPortMappingDescription string, NewLeaseDuration uint32) error
+	DeletePortMappingCtx(ctx context.Context, NewRemoteHost string, NewExternalPort uint16, NewProtocol string) error
+	GetExternalIPAddressCtx(ctx context.Context) (string, error)
+	LocalAddr() net.IP
+}
+
+// upnpPortMapper uses UPnP IGD. The gateway is discovered on the first use.
+type upnpPortMapper struct {
+	mtx    sync.Mutex
+	client upnpClient
+}
+
+func discoverUPnP(ctx context.Context) (upnpClient, error) {
+	if cs, _, err := internetgateway2.NewWANIPConnection2ClientsCtx(ctx); err == nil && len(cs) > 0 {
+		return cs[0], nil
+	}
+	if cs, _, err := internetgateway2.NewWANIPConnection1ClientsCtx(ctx); err == nil && len(cs) > 0 {
+		return cs[0], nil
+	}
+	if cs, _, err := internetgateway2.NewWANPPPConnection1ClientsCtx(ctx); err == nil && len(cs) > 0 {
+		return cs[0], nil
+	}
+	return nil, fmt.Errorf("no UPnP gateway found")
+}
+
+func (m *upnpPortMapper) getClient(ctx context.Context) (upnpClient, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.client == nil {
+		c, err := discoverUPnP(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.client = c
+	}
+	return m.client, nil
+}
+
+func (m *upnpPortMapper) ExternalIP() (net.IP, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultPortMappingTimeout)
+	defer cancel()
+	c, err := m.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, err := c.GetExternalIPAddressCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid external IP %s", s)
+	}
+	return ip, nil
+}
+
+func (m *upnpPortMapper) AddMapping(protocol string, extPort, intPort int, lifetime time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultPortMappingTimeout)
+	defer cancel()
+	c, err := m.getClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if extPort == 0 {
+		extPort = intPort
+	}
+	err = c.AddPortMappingCtx(ctx, "", uint16(extPort), strings.ToUpper(protocol),
+		uint16(intPort), c.LocalAddr().String(), true, DefaultPortMappingDescription,
+		uint32(lifetime/time.Second))
+	if err != nil {
+		return 0, err
+	}
+	return extPort, nil
+}
+
+func (m *upnpPortMapper) DeleteMapping(protocol string, extPort, intPort int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultPortMappingTimeout)
+	defer cancel()
+	c, err := m.getClient(ctx)
+	if err != nil {
+		return err
+	}
+	return c.DeletePortMappingCtx(ctx, "", uint16(extPort), strings.ToUpper(protocol))
+}
+
+func (m *upnpPortMapper) String() string {
+	return NATUPnP
+}
+
+// pmpPortMapper uses NAT-PMP with the default gateway.
+type pmpPortMapper struct {
+	mtx    sync.Mutex
+	client *natpmp.Client
+}
+
+func (m *pmpPortMapper) getClient() (*natpmp.Client, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.client == nil {
+		gw, err := gateway.DiscoverGateway()
+		if err != nil {
+			return nil, err
+		}
+		m.client = natpmp.NewClientWithTimeout(gw, DefaultPortMappingTimeout)
+	}
+	return m.client, nil
+}
+
+func (m *pmpPortMapper) ExternalIP() (net.IP, error) {
+	c, err := m.getClient()
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.GetExternalAddress()
+	if err != nil {
+		return nil, err
+	}
+	return net.IP(r.ExternalIPAddress[:]), nil
+}
+
+func (m *pmpPortMapper) AddMapping(protocol string, extPort, intPort int, lifetime time.Duration) (int, error) {
+	c, err := m.getClient()
+	if err != nil {
+		return 0, err
+	}
+	if extPort == 0 {
+		extPort = intPort
+	}
+	r, err := c.AddPortMapping(protocol, intPort, extPort, int(lifetime/time.Second))
+	if err != nil {
+		return 0, err
+	}
+	return int(r.MappedExternalPort), nil
+}
+
+func (m *pmpPortMapper) DeleteMapping(protocol string, extPort, intPort int) error {
+	c, err := m.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = c.AddPortMapping(protocol, intPort, 0, 0)
+	return err
+}
+
+func (m *pmpPortMapper) String() string {
+	return NATPMP
+}
+
+// anyPortMapper uses the first candidate which maps the port successfully.
+type anyPortMapper struct {
+	mtx        sync.Mutex
+	candidates []PortMapper
+	selected   PortMapper
+}
+
+func (m *anyPortMapper) getSelected() PortMapper {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.selected
+}
+
+func (m *anyPortMapper) ExternalIP() (net.IP, error) {
+	if pm := m.getSelected(); pm != nil {
+		return pm.ExternalIP()
+	}
+	return nil, fmt.Errorf("no port mapping")
+}
+
+func (m *anyPortMapper) AddMapping(protocol string, extPort, intPort int, lifetime time.Duration) (int, error) {
+	if pm := m.getSelected(); pm != nil {
+		return pm.AddMapping(protocol, extPort, intPort, lifetime)
+	}
+	var errs []string
+	for _, pm := range m.candidates {
+		port, err := pm.AddMapping(protocol, extPort, intPort, lifetime)
+		if err == nil {
+			m.mtx.Lock()
+			m.selected = pm
+			m.mtx.Unlock()
+			return port, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s:%v", pm, err))
+	}
+	return 0, fmt.Errorf("fail to map port %s", strings.Join(errs, ","))
+}
+
+func (m *anyPortMapper) DeleteMapping(protocol string, extPort, intPort int) error {
+	if pm := m.getSelected(); pm != nil {
+		return pm.DeleteMapping(protocol, extPort, intPort)
+	}
+	return nil
+}
+
+func (m *anyPortMapper) String() string {
+	if pm := m.getSelected(); pm != nil {
+		return NATAny + "(" + pm.String() + ")"
+	}
+	return NATAny
+}
+
+// LocalPortMapper stands in for the gateway without any network access. It
+// maps ports as requested and returns the given IP as the external IP. It's
+// used for tests and private networks.
+type LocalPortMapper struct {
+	mtx      sync.Mutex
+	ip       net.IP
+	mappings map[string]int
+}
+
+// NewLocalPortMapper returns LocalPortMapper with the external IP. If ip is
+// nil, IP of the local interface is used.
+func NewLocalPortMapper(ip net.IP) *LocalPortMapper {
+	return &LocalPortMapper{ip: ip, mappings: make(map[string]int)}
+}
+
+func (m *LocalPortMapper) ExternalIP() (net.IP, error) {
+	if m.ip != nil {
+		return m.ip, nil
+	}
+	return localIP(), nil
+}
+
+func (m *LocalPortMapper) AddMapping(protocol string, extPort, intPort int, lifetime time.Duration) (int, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if extPort == 0 {
+		extPort = intPort
+	}
+	m.mappings[protocol+"/"+strconv.Itoa(extPort)] = intPort
+	return extPort, nil
+}
+
+func (m *LocalPortMapper) DeleteMapping(protocol string, extPort, intPort int) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	delete(m.mappings, protocol+"/"+strconv.Itoa(extPort))
+	return nil
+}
+
+// Mappings returns the internal ports by protocol and external port.
+func (m *LocalPortMapper) Mappings() map[string]int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	r := make(map[string]int, len(m.mappings))
+	for k, v := range m.mappings {
+		r[k] = v
+	}
+	return r
+}
+
+func (m *LocalPortMapper) String() string {
+	return NATLocal
+}
+
+// localIP returns IP of the first non-loopback interface, or loopback
+// if there is no one.
+func localIP() net.IP {
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipn, ok := addr.(*net.IPNet); ok {
+				ip := ipn.IP.To4()
+				if ip != nil && ip.IsGlobalUnicast() {
+					return ip
+				}
+			}
+		}
+	}
+	return net.IPv4(127, 0, 0, 1).To4()
+}
+
+func isAutoNetAddress(na NetAddress) bool {
+	host, _, err := net.SplitHostPort(string(na))
+	if err != nil {
+		return false
+	}
+	if len(host) == 0 {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
+
+// addressManager keeps the address of the node advertised to peers. If the
+// host of the configured address is empty or unspecified, it's selected by
+// the external IP of the port mapping, the address observed by peers or
+// the local interface in order.
+type addressManager struct {
+	mtx      sync.Mutex
+	auto     bool
+	address  NetAddress
+	port     string
+	basePort string
+	external net.IP
+	observed map[string]int
+	votes    map[string]*observedVote
+	voteSeq  uint64
+	onChange func(na NetAddress)
+
+	pm      PortMapper
+	mapping chan struct{}
+	mapped  int
+
+	logger log.Logger
+}
+
+func newAddressManager(na NetAddress, l log.Logger) *addressManager {
+	am := &addressManager{
+		address:  na,
+		observed: make(map[string]int),
+		votes:    make(map[string]*observedVote),
+		logger:   l.WithFields(log.Fields{LoggerFieldKeySubModule: "address"}),
+	}
+	if isAutoNetAddress(na) {
+		_, am.port, _ = net.SplitHostPort(string(na))
+		am.basePort = am.port
+		am.auto = true
+		am.address = NetAddress(net.JoinHostPort(localIP().String(), am.port))
+		am.logger.Infoln("use address", am.address)
+	}
+	return am
+}
+
+func (am *addressManager) NetAddress() NetAddress {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+	return am.address
+}
+
+func (am *addressManager) _selectHost() string {
+	if am.external != nil {
+		return am.external.String()
+	}
+	var host string
+	var cnt int
+	for h, l := range am.observed {
+		if l >= DefaultObservedAddressThreshold && l > cnt {
+			host, cnt = h, l
+		}
+	}
+	if len(host) > 0 {
+		return host
+	}
+	return localIP().String()
+}
+
+// update selects the address again and calls onChange if it's changed.
+func (am *addressManager) update() {
+	am.mtx.Lock()
+	if !am.auto {
+		am.mtx.Unlock()
+		return
+	}
+	na := NetAddress(net.JoinHostPort(am._selectHost(), am.port))
+	if na == am.address {
+		am.mtx.Unlock()
+		return
+	}
+	am.logger.Infoln("address changed", am.address, "->", na)
+	am.address = na
+	cb := am.onChange
+	am.mtx.Unlock()
+
+	if cb != nil {
+		cb(na)
+	}
+}
+
+// observedVote is the address of the node observed by peers in a subnet.
+type observedVote struct {
+	host string
+	seq  uint64
+}
+
+// subnetOf returns the subnet of the IP, /24 for IPv4 and /64 for IPv6, so
+// that peers in the same subnet are counted once.
+func subnetOf(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
+
+// observe records the address of the node observed by the peer at the
+// observer address. Only the host is used, since the port of the outgoing
+// connection is not the listening port. Observations are counted by the
+// subnet of the observer, and the oldest one is evicted if there are too
+// many.
+func (am *addressManager) observe(observer NetAddress, na NetAddress) {
+	host, _, err := net.SplitHostPort(string(na))
+	if err != nil {
+		return
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsUnspecified() || ip.IsLoopback() {
+		return
+	}
+	host = ip.String()
+	oh, _, err := net.SplitHostPort(string(observer))
+	if err != nil {
+		return
+	}
+	oip := net.ParseIP(oh)
+	if oip == nil {
+		return
+	}
+	subnet := subnetOf(oip)
+
+	am.mtx.Lock()
+	am.voteSeq++
+	if v, ok := am.votes[subnet]; ok {
+		v.seq = am.voteSeq
+		if v.host == host {
+			am.mtx.Unlock()
+			return
+		}
+		// a subnet observes one address
+		am._unvote(v.host)
+		v.host = host
+	} else {
+		if len(am.votes) >= DefaultObservedAddressMax {
+			am._evictOldest()
+		}
+		am.votes[subnet] = &observedVote{host: host, seq: am.voteSeq}
+	}
+	am.observed[host]++
+	am.mtx.Unlock()
+
+	am.update()
+}
+
+func (am *addressManager) _unvote(host string) {
+	if am.observed[host] <= 1 {
+		delete(am.observed, host)
+	} else {
+		am.observed[host]--
+	}
+}
+
+func (am *addressManager) _evictOldest() {
+	var oldest string
+	var seq uint64
+	for subnet, v := range am.votes {
+		if len(oldest) == 0 || v.seq < seq {
+			oldest, seq = subnet, v.seq
+		}
+	}
+	if v, ok := am.votes[oldest]; ok {
+		am._unvote(v.host)
+		delete(am.votes, oldest)
+	}
+}
+
+func (am *addressManager) setExternal(ip net.IP, port int) {
+	am.mtx.Lock()
+	am.external = ip
+	if ip != nil && port > 0 {
+		am.port = strconv.Itoa(port)
+	} else {
+		am.port = am.basePort
+	}
+	am.mtx.Unlock()
+
+	am.update()
+}
+
+// startMapping maps the port with the PortMapper and keeps it until
+// stopMapping is called.
+func (am *addressManager) startMapping(pm PortMapper, protocol string, port int) {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+
+	if am.mapping != nil {
+		return
+	}
+	am.pm = pm
+	am.mapping = make(chan struct{})
+	go am.mappingRoutine(pm, protocol, port, am.mapping)
+}
+
+func (am *addressManager) stopMapping() {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+
+	if am.mapping != nil {
+		close(am.mapping)
+		am.mapping = nil
+	}
+}
+
+func (am *addressManager) mappingRoutine(pm PortMapper, protocol string, port int, stop chan struct{}) {
+	extPort := 0
+	for {
+		next := DefaultPortMappingLifetime / 2
+		mapped, err := pm.AddMapping(protocol, extPort, port, DefaultPortMappingLifetime)
+		if err == nil {
+			var ip net.IP
+			if ip, err = pm.ExternalIP(); err == nil {
+				if extPort != mapped {
+					am.logger.Infof("port mapped by %s %s %s:%d -> %d",
+						pm, protocol, ip, mapped, port)
+				}
+				extPort = mapped
+				am.mtx.Lock()
+				am.mapped = mapped
+				am.mtx.Unlock()
+				am.setExternal(ip, mapped)
+			}
+		}
+		if err != nil {
+			am.logger.Warnf("fail to map port by %s err=%+v", pm, err)
+			next = DefaultPortMappingRetry
+		}
+		select {
+		case <-stop:
+			if extPort != 0 {
+				if err := pm.DeleteMapping(protocol, extPort, port); err != nil {
+					am.logger.Infof("fail to delete port mapping by %s err=%+v", pm, err)
+				}
+			}
+			am.mtx.Lock()
+			am.mapped = 0
+			am.mtx.Unlock()
+			am.setExternal(nil, 0)
+			return
+		case <-time.After(next):
+		}
+	}
+}
+
+func (am *addressManager) inspect() map[string]interface{} {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+
+	m := make(map[string]interface{})
+	m["address"] = string(am.address)
+	m["auto"] = am.auto
+	if am.pm != nil {
+		m["nat"] = am.pm.String()
+		m["mapped"] = am.mapped
+	}
+	if am.external != nil {
+		m["external"] = am.external.String()
+	}
+	observed := make(map[string]int)
+	for h, l := range am.observed {
+		observed[h] = l
+	}
+	m["observed"] = observed
+	return m
+}
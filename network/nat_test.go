@@ -0,0 +1,146 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewPortMapper(t *testing.T) {
+	for _, method := range []string{"", NATNone} {
+		pm, err := NewPortMapper(method)
+		assert.NoError(t, err)
+		assert.Nil(t, pm)
+	}
+	for _, method := range []string{NATUPnP, NATPMP, NATAny, NATLocal} {
+		pm, err := NewPortMapper(method)
+		assert.NoError(t, err)
+		assert.Equal(t, method, pm.String())
+	}
+
+	pm, err := NewPortMapper("local:10.1.2.3")
+	assert.NoError(t, err)
+	ip, err := pm.ExternalIP()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.1.2.3", ip.String())
+
+	_, err = NewPortMapper("local:invalid")
+	assert.Error(t, err)
+	_, err = NewPortMapper("unknown")
+	assert.Error(t, err)
+}
+
+func Test_addressManager_fixed(t *testing.T) {
+	na := NetAddress("10.0.0.1:8080")
+	am := newAddressManager(na, testLogger())
+	am.observe("10.1.0.1:7100", "1.2.3.4:1234")
+	am.observe("10.2.0.1:7100", "1.2.3.4:5678")
+	am.observe("10.3.0.1:7100", "1.2.3.4:5678")
+	assert.Equal(t, na, am.NetAddress())
+}
+
+func Test_addressManager_observe(t *testing.T) {
+	am := newAddressManager(":8080", testLogger())
+	assert.Equal(t, NetAddress(net.JoinHostPort(localIP().String(), "8080")), am.NetAddress())
+
+	var changed []NetAddress
+	am.onChange = func(na NetAddress) {
+		changed = append(changed, na)
+	}
+
+	p1, p2, p3, p4 := NetAddress("10.1.0.1:7100"), NetAddress("10.2.0.1:7100"),
+		NetAddress("10.3.0.1:7100"), NetAddress("10.4.0.1:7100")
+	// loopback and invalid addresses are ignored
+	am.observe(p1, "127.0.0.1:1234")
+	am.observe(p2, "invalid")
+	am.observe("invalid", "1.2.3.4:1234")
+	assert.Len(t, changed, 0)
+
+	am.observe(p1, "1.2.3.4:1234")
+	am.observe(p1, "1.2.3.4:5678")
+	assert.Len(t, changed, 0)
+
+	// peers in the same subnet are counted once
+	am.observe("10.1.0.2:7100", "1.2.3.4:5678")
+	am.observe(p2, "1.2.3.4:5678")
+	assert.Len(t, changed, 0)
+
+	am.observe(p3, "1.2.3.4:5678")
+	assert.Equal(t, NetAddress("1.2.3.4:8080"), am.NetAddress())
+	assert.Equal(t, []NetAddress{"1.2.3.4:8080"}, changed)
+
+	// a subnet observes only one address
+	am.observe(p1, "5.6.7.8:1234")
+	am.observe(p4, "5.6.7.8:1234")
+	assert.Equal(t, NetAddress(net.JoinHostPort(localIP().String(), "8080")), am.NetAddress())
+	am.observe(p3, "5.6.7.8:1234")
+	assert.Equal(t, NetAddress("5.6.7.8:8080"), am.NetAddress())
+	assert.Equal(t, map[string]int{"1.2.3.4": 1, "5.6.7.8": 3}, am.inspect()["observed"])
+}
+
+func Test_addressManager_observeEvict(t *testing.T) {
+	am := newAddressManager(":8080", testLogger())
+	for i := 0; i < DefaultObservedAddressMax; i++ {
+		am.observe(NetAddress(fmt.Sprintf("10.%d.0.1:7100", i)), "1.2.3.4:1234")
+	}
+	assert.Equal(t, map[string]int{"1.2.3.4": DefaultObservedAddressMax}, am.inspect()["observed"])
+
+	// the oldest one is evicted for a new subnet
+	am.observe("10.0.0.1:7100", "1.2.3.4:1234")
+	am.observe("10.100.0.1:7100", "5.6.7.8:1234")
+	assert.Len(t, am.votes, DefaultObservedAddressMax)
+	assert.NotContains(t, am.votes, "10.1.0.0/24")
+	assert.Contains(t, am.votes, "10.0.0.0/24")
+	assert.Equal(t, map[string]int{
+		"1.2.3.4": DefaultObservedAddressMax - 1,
+		"5.6.7.8": 1,
+	}, am.inspect()["observed"])
+	assert.Equal(t, NetAddress("1.2.3.4:8080"), am.NetAddress())
+}
+
+func Test_addressManager_mapping(t *testing.T) {
+	am := newAddressManager("0.0.0.0:8080", testLogger())
+	am.observe("10.1.0.1:7100", "1.2.3.4:1234")
+	am.observe("10.2.0.1:7100", "1.2.3.4:5678")
+	am.observe("10.3.0.1:7100", "1.2.3.4:5678")
+	assert.Equal(t, NetAddress("1.2.3.4:8080"), am.NetAddress())
+
+	// external IP of the mapping is preferred to observed one
+	pm := NewLocalPortMapper(net.ParseIP("10.1.2.3"))
+	am.startMapping(pm, "tcp", 8080)
+	assert.Eventually(t, func() bool {
+		return am.NetAddress() == "10.1.2.3:8080"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, map[string]int{"tcp/8080": 8080}, pm.Mappings())
+	assert.Equal(t, NATLocal, am.inspect()["nat"])
+
+	am.stopMapping()
+	assert.Eventually(t, func() bool {
+		return am.NetAddress() == "1.2.3.4:8080"
+	}, time.Second, 10*time.Millisecond)
+	assert.Len(t, pm.Mappings(), 0)
+}
+
+func Test_transport_portMapper(t *testing.T) {
+	_, port, err := net.SplitHostPort(getAvailableLocalhostAddress(t))
+	assert.NoError(t, err)
+	w := walletFromGeneratedPrivateKey()
+	nt := NewTransport(":"+port, w, testLogger()).(*transport)
+	assert.Equal(t, net.JoinHostPort(localIP().String(), port), nt.Address())
+
+	pm := NewLocalPortMapper(net.ParseIP("10.1.2.3"))
+	SetPortMapper(nt, pm)
+	assert.NoError(t, nt.Listen())
+	assert.Eventually(t, func() bool {
+		return nt.Address() == "10.1.2.3:"+port
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, NetAddress(nt.Address()), nt.cn.getNetAddress())
+
+	assert.NoError(t, nt.Close())
+	assert.Eventually(t, func() bool {
+		return len(pm.Mappings()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
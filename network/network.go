@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/log"
@@ -17,6 +18,8 @@ type transportForManager interface {
 	removeProtocol(channel string, pi module.ProtocolInfo)
 	registerPeerHandler(channel string, ph PeerHandler, mtr *metric.NetworkMetric) bool
 	unregisterPeerHandler(channel string)
+	observeAddress(observer NetAddress, na NetAddress)
+	inspectAddress() map[string]interface{}
 }
 
 type manager struct {
@@ -35,6 +38,8 @@ type manager struct {
 	mtr *metric.NetworkMetric
 
 	streamReactors []*streamReactor
+
+	capture *capture
 }
 
 func NewManager(c module.Chain, nt module.NetworkTransport, trustSeeds string, roles ...module.Role) module.NetworkManager {
@@ -45,12 +50,14 @@ func NewManager(c module.Chain, nt module.NetworkTransport, trustSeeds string, r
 		logger:           c.Logger().WithFields(log.Fields{log.FieldKeyModule: "NM"}),
 		mtr:              metric.NewNetworkMetric(c.MetricContext()),
 	}
+	m.capture = newCapture(m.logger.WithFields(log.Fields{LoggerFieldKeySubModule: "capture"}))
 	m.p2p = newPeerToPeer(
 		m.channel,
 		&Peer{id: nt.PeerID(), netAddress: NetAddress(nt.Address())},
 		m.t.GetDialer(m.channel),
 		m.mtr,
 		m.logger)
+	m.p2p.onObserved = m.t.observeAddress
 
 	m.SetInitialRoles(roles...)
 	m.SetTrustSeeds(trustSeeds)
@@ -79,6 +86,35 @@ func (m *manager) getPeersByProtocol(pi module.ProtocolInfo) []module.PeerID {
 	return toPeerIDs(m.p2p.getPeersByProtocol(pi))
 }
 
+func (m *manager) BanList() []BanEntry {
+	return m.p2p.rep.banList()
+}
+
+func (m *manager) Ban(target string, d time.Duration, reason string) error {
+	if err := m.p2p.rep.ban(target, d, reason); err != nil {
+		return err
+	}
+	for _, p := range m.p2p.findPeers(func(p *Peer) bool {
+		return p.ID().String() == target || p.RemoteIP() == target
+	}) {
+		p.CloseByError(ErrBannedPeer)
+	}
+	return nil
+}
+
+func (m *manager) Unban(target string) bool {
+	return m.p2p.rep.unban(target)
+}
+
+// LoadBanList loads banned peers from the file and keeps them in the file
+// on every update.
+func LoadBanList(nm module.NetworkManager, file string) error {
+	if m, ok := nm.(*manager); ok {
+		return m.p2p.rep.load(file)
+	}
+	return nil
+}
+
 func (m *manager) Term() {
 	defer m.mtx.Unlock()
 	m.mtx.Lock()
@@ -96,6 +132,7 @@ func (m *manager) Term() {
 		ph.Term()
 		m.t.removeProtocol(m.channel, module.ProtocolInfo(k))
 	}
+	m.capture.stop()
 }
 
 func (m *manager) Start() error {
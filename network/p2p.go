@@ -101,6 +101,12 @@ type PeerToPeer struct {
 	cLimit    map[PeerConnectionType]int
 	cLimitMtx sync.RWMutex
 
+	//peer scores and bans
+	rep *reputation
+
+	//addresses of self observed by peers
+	onObserved func(observer NetAddress, na NetAddress)
+
 	//monitor
 	mtr *metric.NetworkMetric
 
@@ -146,6 +152,8 @@ func newPeerToPeer(channel string, self *Peer, d *Dialer, mtr *metric.NetworkMet
 		//
 		cLimit: make(map[PeerConnectionType]int),
 		//
+		rep: newReputation(l.WithFields(log.Fields{LoggerFieldKeySubModule: "reputation"})),
+		//
 		mtr: mtr,
 	}
 	for connType := p2pConnTypeNone; connType < p2pConnTypeReserved; connType++ {
@@ -226,6 +234,10 @@ func (p2p *PeerToPeer) supportedProtocols() []module.ProtocolInfo {
 }
 
 func (p2p *PeerToPeer) dial(na NetAddress) error {
+	if p2p.rep.isBanned(nil, ipOf(string(na))) {
+		p2p.logger.Debugln("Dial ignore banned", na)
+		return nil
+	}
 	if err := p2p.dialer.Dial(string(na)); err != nil {
 		if err == ErrAlreadyDialing {
 			p2p.logger.Infoln("Dial ignore", na, err)
@@ -282,6 +294,12 @@ func (p2p *PeerToPeer) onPeer(p *Peer) {
 		p.CloseByError(fmt.Errorf("onPeer not allowed connection"))
 		return
 	}
+	if p2p.rep.isBanned(p.ID(), p.RemoteIP()) {
+		p2p.logger.Infoln("onPeer", "banned peer", p)
+		p2p.onEvent(p2pEventNotAllowed, p)
+		p.CloseByError(ErrBannedPeer)
+		return
+	}
 	if p2p.isTrustSeed(p) {
 		p2p.trustSeeds.SetAndRemoveByData(p.DialNetAddress(), string(p.NetAddress()))
 	}
@@ -421,7 +439,8 @@ func (p2p *PeerToPeer) onPacket(pkt *Packet, p *Peer) {
 }
 
 type QueryMessage struct {
-	Role PeerRoleFlag
+	Role     PeerRoleFlag
+	Observed NetAddress
 }
 
 type QueryResultMessage struct {
@@ -431,6 +450,7 @@ type QueryResultMessage struct {
 	Children []NetAddress
 	Nephews  []NetAddress
 	Message  string
+	Observed NetAddress
 }
 
 type RttMessage struct {
@@ -540,6 +560,19 @@ func (p2p *PeerToPeer) NetAddress() NetAddress {
 	return p2p.self.NetAddress()
 }
 
+func (p2p *PeerToPeer) setNetAddress(na NetAddress) {
+	p2p.self.setNetAddress(na)
+}
+
+// observed handles the address of self observed by the peer. Only peers of
+// outgoing connections are used, since anyone may connect to the node and
+// report an arbitrary address.
+func (p2p *PeerToPeer) observed(p *Peer, na NetAddress) {
+	if p2p.onObserved != nil && len(na) > 0 && !p.In() {
+		p2p.onObserved(p.RemoteNetAddress(), na)
+	}
+}
+
 func (p2p *PeerToPeer) startRtt(p *Peer) {
 	p.rtt.StartWithAfterFunc(DefaultRttLogTimeout, func() {
 		p2p.logger.Warnln("RTT Timeout", DefaultRttLogTimeout, p)
@@ -555,7 +588,7 @@ func (p2p *PeerToPeer) stopRtt(p *Peer) time.Duration {
 }
 
 func (p2p *PeerToPeer) sendQuery(p *Peer) {
-	m := &QueryMessage{Role: p2p.Role()}
+	m := &QueryMessage{Role: p2p.Role(), Observed: p.RemoteNetAddress()}
 	pkt := newPacket(p2pProtoControl, p2pProtoQueryReq, p2p.encode(m), p2p.ID())
 	pkt.destPeer = p.ID()
 	err := p.sendPacket(pkt)
@@ -575,12 +608,14 @@ func (p2p *PeerToPeer) handleQuery(pkt *Packet, p *Peer) {
 		return
 	}
 	p2p.logger.Traceln("handleQuery", qm, p)
+	p2p.observed(p, qm.Observed)
 
 	r := p2p.Role()
 	m := &QueryResultMessage{
 		Role:     r,
 		Children: p2p.getNetAddresses(p2pConnTypeChildren),
 		Nephews:  p2p.getNetAddresses(p2pConnTypeNephew),
+		Observed: p.RemoteNetAddress(),
 	}
 	rr := p2p.resolveRole(qm.Role, p.ID(), true)
 	if rr != qm.Role {
@@ -658,6 +693,7 @@ func (p2p *PeerToPeer) handleQueryResult(pkt *Packet, p *Peer) {
 		qrm.Nephews = qrm.Nephews[:DefaultQueryElementLength]
 	}
 	p2p.logger.Traceln("handleQueryResult", qrm, p)
+	p2p.observed(p, qrm.Observed)
 
 	p.children.ClearAndAdd(qrm.Children...)
 	p.nephews.ClearAndAdd(qrm.Nephews...)
@@ -1069,6 +1105,28 @@ func (p2p *PeerToPeer) getPeerByProtocol(id module.PeerID, pi module.ProtocolInf
 	}, joinPeerConnectionTypes...)
 }
 
+// reportPeer applies the violation to the score of the peer. It closes
+// connections to the peer if the peer gets banned.
+func (p2p *PeerToPeer) reportPeer(id module.PeerID, v module.PeerViolation, reason string) {
+	ps := p2p.findPeers(func(p *Peer) bool {
+		return p.ID().Equal(id)
+	})
+	var ip string
+	if len(ps) > 0 {
+		ip = ps[0].RemoteIP()
+	}
+	if !p2p.rep.report(id, ip, v, reason) {
+		return
+	}
+	p2p.logger.Infoln("reportPeer", "banned", id, ip, v, reason)
+	ps = p2p.findPeers(func(p *Peer) bool {
+		return p.ID().Equal(id) || (len(ip) > 0 && p.RemoteIP() == ip)
+	})
+	for _, p := range ps {
+		p.CloseByError(ErrBannedPeer)
+	}
+}
+
 func (p2p *PeerToPeer) getPeers() []*Peer {
 	return p2p.findPeers(nil, joinPeerConnectionTypes...)
 }
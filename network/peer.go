@@ -70,6 +70,18 @@ type Peer struct {
 	metricMtx sync.RWMutex
 }
 
+// packetSender is implemented by connections which send packets by
+// themselves instead of a stream of bytes.
+type packetSender interface {
+	sendPacket(pkt *Packet) error
+}
+
+// readyConn is implemented by connections which need to know the end of
+// handshakes.
+type readyConn interface {
+	setReady()
+}
+
 type packetCbFunc func(pkt *Packet, p *Peer)
 type closeCbFunc func(p *Peer)
 
@@ -117,6 +129,22 @@ func (p *Peer) ConnString() string {
 	}
 }
 
+// RemoteIP returns IP address of the remote side of the connection.
+func (p *Peer) RemoteIP() string {
+	if p == nil || p.conn == nil || p.conn.RemoteAddr() == nil {
+		return ""
+	}
+	return ipOf(p.conn.RemoteAddr().String())
+}
+
+// RemoteNetAddress returns the address of the connection seen by this node.
+func (p *Peer) RemoteNetAddress() NetAddress {
+	if p == nil || p.conn == nil || p.conn.RemoteAddr() == nil {
+		return ""
+	}
+	return NetAddress(p.conn.RemoteAddr().String())
+}
+
 func (p *Peer) In() bool {
 	return p.in
 }
@@ -405,12 +433,30 @@ func (p *Peer) sendDirect(pkt *Packet) error {
 
 	if err := p.conn.SetWriteDeadline(time.Now().Add(DefaultSendTimeout)); err != nil {
 		return err
-	} else if err := p.writer.WritePacket(pkt); err != nil {
+	}
+	if ps, ok := p.conn.(packetSender); ok {
+		return ps.sendPacket(pkt)
+	}
+	if err := p.writer.WritePacket(pkt); err != nil {
 		return err
 	}
 	return nil
 }
 
+// onHandshake is called when the peer passes all handshakes.
+func (p *Peer) onHandshake() {
+	if rc, ok := p.conn.(readyConn); ok {
+		rc.setReady()
+	}
+}
+
+// isEncryptedConn returns whether the connection is encrypted by the
+// transport itself.
+func (p *Peer) isEncryptedConn() bool {
+	_, ok := p.conn.(*quicConn)
+	return ok
+}
+
 func (p *Peer) sendRoutine() {
 	secondTick := time.NewTicker(time.Second)
 	defer secondTick.Stop()
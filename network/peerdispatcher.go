@@ -74,6 +74,17 @@ func (pd *PeerDispatcher) getByChannel(channel string) (*channelPeerHandler, boo
 	return v, ok
 }
 
+func (pd *PeerDispatcher) channelPeerHandlers() []PeerHandler {
+	pd.peerHandlerMapMtx.RLock()
+	defer pd.peerHandlerMapMtx.RUnlock()
+
+	phs := make([]PeerHandler, 0, len(pd.peerHandlerMap))
+	for _, cph := range pd.peerHandlerMap {
+		phs = append(phs, cph.ph)
+	}
+	return phs
+}
+
 func (pd *PeerDispatcher) registerPeerHandler(ph PeerHandler, pushBack bool) {
 	pd.peerHandlersMtx.Lock()
 	defer pd.peerHandlersMtx.Unlock()
@@ -126,6 +137,7 @@ func (pd *PeerDispatcher) dispatchPeer(p *Peer) {
 //callback from PeerHandler.nextOnPeer
 func (pd *PeerDispatcher) onPeer(p *Peer) {
 	pd.logger.Traceln("onPeer", p)
+	p.onHandshake()
 	if v, ok := pd.getByChannel(p.Channel()); ok {
 		p.setMetric(v.mtr)
 		p.setPacketCbFunc(v.ph.onPacket)
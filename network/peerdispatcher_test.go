@@ -39,7 +39,7 @@ func Test_PeerDispatcher(t *testing.T) {
 		dialPHs = append(dialPHs, ph)
 	}
 	listenPD := newPeerDispatcher(id, logger, listenPHs...)
-	l := newListener(getAvailableLocalhostAddress(t), listenPD.onAccept, logger)
+	l := newListener(getAvailableLocalhostAddress(t), tcpTransport{}, listenPD.onAccept, logger)
 	if err := l.Listen(); err != nil {
 		assert.FailNow(t, err.Error())
 	}
@@ -67,7 +67,7 @@ func Test_PeerDispatcher(t *testing.T) {
 		dialPD.registerPeerHandler(ph, true)
 	}
 
-	if err := newDialer(testChannel, dialPD.onConnect).Dial(l.address); err != nil {
+	if err := newDialer(testChannel, tcpTransport{}, dialPD.onConnect).Dial(l.address); err != nil {
 		assert.FailNow(t, err.Error())
 	}
 	assertPeerHandler := func(n int) {
@@ -109,7 +109,7 @@ func Test_PeerDispatcher(t *testing.T) {
 		ph.onCloseFunc = channelOnCloseFunc(ph, dialCh, i)
 		dialPD.registerByChannel(channelFunc(i), ph, mtr)
 
-		if err := newDialer(channelFunc(i), dialPD.onConnect).Dial(l.address); err != nil {
+		if err := newDialer(channelFunc(i), tcpTransport{}, dialPD.onConnect).Dial(l.address); err != nil {
 			assert.FailNow(t, err.Error())
 		}
 		assertPeerHandler(numOfPeerHandler)
@@ -121,7 +121,7 @@ func Test_PeerDispatcher(t *testing.T) {
 
 	//not registered channel case
 	listenPD.unregisterByChannel(channelFunc(0))
-	if err := newDialer(channelFunc(0), dialPD.onConnect).Dial(l.address); err != nil {
+	if err := newDialer(channelFunc(0), tcpTransport{}, dialPD.onConnect).Dial(l.address); err != nil {
 		assert.FailNow(t, err.Error())
 	}
 	assertPeerHandler(numOfPeerHandler)
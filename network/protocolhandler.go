@@ -192,11 +192,13 @@ func (ph *protocolHandler) onPacket(pkt *Packet, p *Peer) {
 		case module.NotRegisteredProtocolPolicyClose:
 			fallthrough
 		default:
+			ph.m.p2p.reportPeer(p.ID(), module.ViolationInvalidMessage, "NotRegisteredProtocol")
 			p.CloseByError(ErrNotRegisteredProtocol)
 			ph.logger.Infoln("onPacket", "not registered protocol", ph.name, pkt.protocol, pkt.subProtocol, p.ID())
 		}
 	}
 	if ok {
+		ph.m.capture.record(CaptureInbound, pkt, p.ID())
 		ctx := context.WithValue(context.Background(), p2pContextKeyPacket, pkt)
 		ctx = context.WithValue(ctx, p2pContextKeyPeer, p)
 		if ok = ph.receiveQueue.Push(ctx); !ok {
@@ -269,6 +271,7 @@ func (ph *protocolHandler) send(
 	pkt.ttl = ttl
 	pkt.destPeer = destPeer
 	pkt.forceSend = forceSend
+	ph.m.capture.record(CaptureOutbound, pkt, destPeer)
 	return ph.m.send(pkt)
 }
 
@@ -296,6 +299,11 @@ func (ph *protocolHandler) Broadcast(pi module.ProtocolInfo, b []byte, bt module
 	return nil
 }
 
+func (ph *protocolHandler) ReportPeer(id module.PeerID, v module.PeerViolation, reason string) {
+	ph.logger.Debugln("ReportPeer", ph.name, id, v, reason)
+	ph.m.p2p.reportPeer(id, v, reason)
+}
+
 func (ph *protocolHandler) GetPeers() []module.PeerID {
 	return ph.m.getPeersByProtocol(ph.protocol)
 }
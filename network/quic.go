@@ -0,0 +1,582 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+
+	"github.com/icon-project/goloop/common/errors"
+)
+
+const (
+	DefaultQuicNet             = "udp4"
+	DefaultQuicALPN            = "goloop-p2p"
+	DefaultQuicKeepAlivePeriod = 10 * time.Second
+	DefaultQuicMaxIdleTimeout  = 30 * time.Second
+	DefaultQuicStreamQueueSize = 64
+	DefaultQuicCloseLinger     = time.Second
+	quicFrameHeaderSize        = 4
+	quicFrameClose             = 0x80000000
+	quicFrameMaxSize           = packetHeaderSize + DefaultPacketPayloadMax + packetFooterSize + packetExtendMaxLen
+	quicBindingLabel           = "EXPORTER-goloop-p2p-auth"
+	quicBindingSize            = 32
+)
+
+// quicTransport establishes connections over QUIC. Encryption is done by
+// QUIC with a self-signed certificate, and the identity of the peer is
+// verified by Authenticator as TCP. Since the certificate isn't verified,
+// Authenticator binds the keying material exported from the TLS session
+// to the signatures, so that a relay between two TLS sessions is detected.
+type quicTransport struct {
+	tlsConfig *tls.Config
+}
+
+func newQuicTransport() (*quicTransport, error) {
+	cert, err := newSelfSignedCertificate()
+	if err != nil {
+		return nil, err
+	}
+	return &quicTransport{
+		tlsConfig: &tls.Config{
+			Certificates:       []tls.Certificate{cert},
+			NextProtos:         []string{DefaultQuicALPN},
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionTLS13,
+		},
+	}, nil
+}
+
+func newSelfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: DefaultQuicALPN},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func (t *quicTransport) quicConfig() *quic.Config {
+	return &quic.Config{
+		HandshakeIdleTimeout: DefaultDialTimeout,
+		MaxIdleTimeout:       DefaultQuicMaxIdleTimeout,
+		KeepAlivePeriod:      DefaultQuicKeepAlivePeriod,
+	}
+}
+
+func (t *quicTransport) Protocol() string {
+	return "udp"
+}
+
+func (t *quicTransport) Listen(address string) (net.Listener, error) {
+	addr, err := net.ResolveUDPAddr(DefaultQuicNet, address)
+	if err != nil {
+		return nil, err
+	}
+	uc, err := net.ListenUDP(DefaultQuicNet, addr)
+	if err != nil {
+		return nil, err
+	}
+	ln, err := quic.Listen(uc, t.tlsConfig, t.quicConfig())
+	if err != nil {
+		_ = uc.Close()
+		return nil, err
+	}
+	ql := &quicListener{
+		ln:     ln,
+		uc:     uc,
+		connCh: make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+	go ql.acceptRoutine()
+	return ql, nil
+}
+
+func (t *quicTransport) DialTimeout(address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := quic.DialAddr(ctx, address, t.tlsConfig, t.quicConfig())
+	if err != nil {
+		return nil, err
+	}
+	ctrl, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		_ = conn.CloseWithError(0, err.Error())
+		return nil, err
+	}
+	return newQuicConn(conn, ctrl), nil
+}
+
+// quicListener implements net.Listener. A connection is returned by Accept
+// after the dialer opens the control stream.
+type quicListener struct {
+	ln        *quic.Listener
+	uc        *net.UDPConn
+	connCh    chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *quicListener) acceptRoutine() {
+	for {
+		conn, err := l.ln.Accept(context.Background())
+		if err != nil {
+			_ = l.Close()
+			return
+		}
+		go l.acceptControl(conn)
+	}
+}
+
+func (l *quicListener) acceptControl(conn quic.Connection) {
+	ctx, cancel := context.WithTimeout(conn.Context(), DefaultDialTimeout)
+	defer cancel()
+	ctrl, err := conn.AcceptStream(ctx)
+	if err != nil {
+		_ = conn.CloseWithError(0, err.Error())
+		return
+	}
+	select {
+	case l.connCh <- newQuicConn(conn, ctrl):
+	case <-l.closed:
+		_ = conn.CloseWithError(0, "listener closed")
+	}
+}
+
+func (l *quicListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.connCh:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *quicListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		err = l.ln.Close()
+		if cerr := l.uc.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+func (l *quicListener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// quicStream sends frames of a priority through a stream in the background,
+// so that slow streams don't block others.
+type quicStream struct {
+	s  quic.Stream
+	ch chan []byte
+}
+
+// quicConn implements net.Conn over a QUIC connection. Every packet is sent
+// as a frame. Before handshakes are done, all frames go through the control
+// stream. After that, each priority of packets has its own stream. Frames
+// from all streams are merged into one stream of packets for reading.
+type quicConn struct {
+	conn quic.Connection
+	ctrl quic.Stream
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	mtx       sync.Mutex
+	streams   map[uint8]*quicStream
+	writers   sync.WaitGroup
+	readers   sync.WaitGroup
+	accepted  int
+	expected  int
+	streamsIn chan struct{}
+	deadline  time.Time
+	ready     chan struct{}
+	readyOnce sync.Once
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newQuicConn(conn quic.Connection, ctrl quic.Stream) *quicConn {
+	pr, pw := io.Pipe()
+	c := &quicConn{
+		conn:      conn,
+		ctrl:      ctrl,
+		pr:        pr,
+		pw:        pw,
+		streams:   make(map[uint8]*quicStream),
+		expected:  -1,
+		streamsIn: make(chan struct{}),
+		ready:     make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	go c.readRoutine(ctrl, false)
+	go c.acceptRoutine()
+	return c
+}
+
+// channelBinding returns the keying material exported from the TLS session.
+// Both ends of the connection get the same value, and the value differs
+// for each TLS session.
+func (c *quicConn) channelBinding() ([]byte, error) {
+	cs := c.conn.ConnectionState().TLS
+	return cs.ExportKeyingMaterial(quicBindingLabel, nil, quicBindingSize)
+}
+
+// setReady is called after handshakes. Frames from other streams than the
+// control stream are delivered only after it, so they can't overtake
+// handshake messages.
+func (c *quicConn) setReady() {
+	c.readyOnce.Do(func() {
+		close(c.ready)
+	})
+}
+
+func (c *quicConn) isReady() bool {
+	select {
+	case <-c.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *quicConn) acceptRoutine() {
+	for {
+		s, err := c.conn.AcceptStream(context.Background())
+		if err != nil {
+			c.closeByError(err)
+			return
+		}
+		c.mtx.Lock()
+		c.readers.Add(1)
+		c.accepted++
+		c._checkStreamsIn()
+		c.mtx.Unlock()
+		go func() {
+			defer c.readers.Done()
+			c.readRoutine(s, true)
+		}()
+	}
+}
+
+func (c *quicConn) _checkStreamsIn() {
+	if c.expected >= 0 && c.accepted >= c.expected {
+		select {
+		case <-c.streamsIn:
+		default:
+			close(c.streamsIn)
+		}
+	}
+}
+
+// onCloseFrame handles the close frame from the remote. It has the number
+// of streams opened by the remote, and frames of the streams may arrive
+// after the close frame. So the connection is closed after all of them
+// are read.
+func (c *quicConn) onCloseFrame(streams int) {
+	c.mtx.Lock()
+	c.expected = streams
+	c._checkStreamsIn()
+	c.mtx.Unlock()
+	go func() {
+		linger := time.After(DefaultQuicCloseLinger)
+		select {
+		case <-c.streamsIn:
+		case <-linger:
+			c.closeByError(io.EOF)
+			return
+		}
+		done := make(chan struct{})
+		go func() {
+			c.readers.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-linger:
+		}
+		c.closeByError(io.EOF)
+	}()
+}
+
+// onReadEnd handles the end of the stream. On graceful close, the remote
+// sends the close frame before closing the control stream, and the
+// connection is closed by onCloseFrame.
+func (c *quicConn) onReadEnd(err error, ctrl bool) {
+	if err != io.EOF {
+		c.closeByError(err)
+		return
+	}
+	if !ctrl {
+		return
+	}
+	c.mtx.Lock()
+	closing := c.expected >= 0
+	c.mtx.Unlock()
+	if !closing {
+		c.closeByError(io.EOF)
+	}
+}
+
+func (c *quicConn) readRoutine(s quic.Stream, wait bool) {
+	hdr := make([]byte, quicFrameHeaderSize)
+	for {
+		if _, err := io.ReadFull(s, hdr); err != nil {
+			c.onReadEnd(err, !wait)
+			return
+		}
+		sz := binary.BigEndian.Uint32(hdr)
+		if !wait && sz&quicFrameClose != 0 {
+			c.onCloseFrame(int(sz &^ quicFrameClose))
+			continue
+		}
+		if sz == 0 || sz > quicFrameMaxSize {
+			c.closeByError(errors.Errorf("InvalidFrameSize(size=%d)", sz))
+			return
+		}
+		frame := make([]byte, sz)
+		if _, err := io.ReadFull(s, frame); err != nil {
+			c.closeByError(err)
+			return
+		}
+		if wait {
+			select {
+			case <-c.ready:
+			case <-c.closed:
+				return
+			}
+		}
+		// io.PipeWriter handles concurrent writes sequentially, so a frame
+		// is never mixed with others.
+		if _, err := c.pw.Write(frame); err != nil {
+			return
+		}
+	}
+}
+
+func writeFrame(s quic.Stream, deadline time.Time, b []byte) error {
+	if err := s.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	hdr := make([]byte, quicFrameHeaderSize)
+	binary.BigEndian.PutUint32(hdr, uint32(len(b)))
+	if _, err := s.Write(hdr); err != nil {
+		return err
+	}
+	_, err := s.Write(b)
+	return err
+}
+
+func writeCloseFrame(s quic.Stream, streams int) error {
+	if err := s.SetWriteDeadline(time.Now().Add(DefaultSendTimeout)); err != nil {
+		return err
+	}
+	hdr := make([]byte, quicFrameHeaderSize)
+	binary.BigEndian.PutUint32(hdr, quicFrameClose|uint32(streams))
+	_, err := s.Write(hdr)
+	return err
+}
+
+func (c *quicConn) writeRoutine(qs *quicStream) {
+	defer c.writers.Done()
+	for {
+		select {
+		case b := <-qs.ch:
+			if err := writeFrame(qs.s, time.Now().Add(DefaultSendTimeout), b); err != nil {
+				c.closeByError(err)
+				return
+			}
+		case <-c.closed:
+			c.flush(qs)
+			return
+		}
+	}
+}
+
+// flush writes queued frames and closes the stream on graceful close.
+func (c *quicConn) flush(qs *quicStream) {
+	deadline := time.Now().Add(DefaultSendTimeout)
+	for {
+		select {
+		case b := <-qs.ch:
+			if err := writeFrame(qs.s, deadline, b); err != nil {
+				return
+			}
+		default:
+			_ = qs.s.Close()
+			return
+		}
+	}
+}
+
+func (c *quicConn) streamFor(priority uint8) (*quicStream, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if qs, ok := c.streams[priority]; ok {
+		return qs, nil
+	}
+	if c.isClosed() {
+		return nil, net.ErrClosed
+	}
+	s, err := c.conn.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	qs := &quicStream{s: s, ch: make(chan []byte, DefaultQuicStreamQueueSize)}
+	c.streams[priority] = qs
+	c.writers.Add(1)
+	go c.writeRoutine(qs)
+	return qs, nil
+}
+
+// sendPacket sends the packet as a frame.
+func (c *quicConn) sendPacket(pkt *Packet) error {
+	buf := bytes.NewBuffer(nil)
+	if _, err := pkt.WriteTo(buf); err != nil {
+		return err
+	}
+	if c.isClosed() {
+		return net.ErrClosed
+	}
+	if !c.isReady() {
+		return c.writeControl(buf.Bytes())
+	}
+	qs, err := c.streamFor(pkt.priority)
+	if err != nil {
+		return err
+	}
+	select {
+	case qs.ch <- buf.Bytes():
+		return nil
+	case <-c.closed:
+		return net.ErrClosed
+	case <-time.After(DefaultSendTimeout):
+		return errors.TimeoutError.New("QuicStreamQueueFull")
+	}
+}
+
+func (c *quicConn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *quicConn) writeControl(b []byte) error {
+	if c.isClosed() {
+		return net.ErrClosed
+	}
+	c.mtx.Lock()
+	deadline := c.deadline
+	c.mtx.Unlock()
+	return writeFrame(c.ctrl, deadline, b)
+}
+
+func (c *quicConn) Read(b []byte) (int, error) {
+	return c.pr.Read(b)
+}
+
+// Write sends b as a frame through the control stream. It should be called
+// with a whole packet.
+func (c *quicConn) Write(b []byte) (int, error) {
+	if err := c.writeControl(b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *quicConn) closeByError(err error) {
+	c.closeOnce.Do(func() {
+		c.mtx.Lock()
+		close(c.closed)
+		c.mtx.Unlock()
+		_ = c.conn.CloseWithError(0, "")
+		_ = c.pw.CloseWithError(err)
+	})
+}
+
+// Close closes the connection after sending queued frames. Closing QUIC
+// connection discards data not delivered yet, so it waits for the remote
+// to close the connection for a while.
+func (c *quicConn) Close() error {
+	c.closeOnce.Do(func() {
+		// no more writers after this
+		c.mtx.Lock()
+		close(c.closed)
+		c.mtx.Unlock()
+		_ = c.pw.CloseWithError(io.EOF)
+		go func() {
+			c.writers.Wait()
+			c.mtx.Lock()
+			streams := len(c.streams)
+			c.mtx.Unlock()
+			if err := writeCloseFrame(c.ctrl, streams); err == nil {
+				_ = c.ctrl.Close()
+			}
+			select {
+			case <-c.conn.Context().Done():
+			case <-time.After(DefaultQuicCloseLinger):
+			}
+			_ = c.conn.CloseWithError(0, "")
+		}()
+	})
+	return nil
+}
+
+func (c *quicConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *quicConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *quicConn) SetDeadline(t time.Time) error {
+	return c.SetWriteDeadline(t)
+}
+
+func (c *quicConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *quicConn) SetWriteDeadline(t time.Time) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.deadline = t
+	return nil
+}
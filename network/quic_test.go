@@ -0,0 +1,203 @@
+package network
+
+import (
+	"encoding/hex"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/server/metric"
+)
+
+func newTestQuicConnPair(t *testing.T) (*quicConn, *quicConn, func()) {
+	qt, err := newQuicTransport()
+	assert.NoError(t, err)
+	ln, err := qt.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+
+	dc, err := qt.DialTimeout(ln.Addr().String(), DefaultDialTimeout)
+	assert.NoError(t, err)
+	dialed := dc.(*quicConn)
+
+	// the listener returns the connection after the first frame
+	pkt := newPacket(p2pProtoControl, p2pProtoControl, []byte("hello"), generatePeerID())
+	assert.NoError(t, dialed.sendPacket(pkt))
+	ac, err := ln.Accept()
+	assert.NoError(t, err)
+	accepted := ac.(*quicConn)
+
+	return dialed, accepted, func() {
+		_ = dialed.Close()
+		_ = accepted.Close()
+		_ = ln.Close()
+	}
+}
+
+func Test_quicConn(t *testing.T) {
+	dialed, accepted, closeAll := newTestQuicConnPair(t)
+	defer closeAll()
+
+	pr := NewPacketReader(accepted)
+	pkt, err := pr.ReadPacket()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), pkt.payload)
+
+	// frames on other streams are delivered after handshakes
+	id := generatePeerID()
+	dialed.setReady()
+	for i, priority := range []uint8{1, 2, 1, 2} {
+		pkt := newPacket(p2pProtoControl, p2pProtoControl, []byte{byte(i)}, id)
+		pkt.priority = priority
+		assert.NoError(t, dialed.sendPacket(pkt))
+	}
+	received := make(chan []byte, 4)
+	go func() {
+		for {
+			pkt, err := pr.ReadPacket()
+			if err != nil {
+				close(received)
+				return
+			}
+			received <- pkt.payload
+		}
+	}()
+	select {
+	case <-received:
+		assert.Fail(t, "received before ready")
+	case <-time.After(100 * time.Millisecond):
+	}
+	accepted.setReady()
+
+	var byPriority [3][]byte
+	for i := 0; i < 4; i++ {
+		select {
+		case b := <-received:
+			byPriority[1+b[0]%2] = append(byPriority[1+b[0]%2], b[0])
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "timeout")
+		}
+	}
+	// packets of same priority keep the order
+	assert.Equal(t, []byte{0, 2}, byPriority[1])
+	assert.Equal(t, []byte{1, 3}, byPriority[2])
+
+	// queued frames are sent on graceful close
+	last := newPacket(p2pProtoControl, p2pProtoControl, []byte("bye"), id)
+	last.priority = 3
+	assert.NoError(t, dialed.sendPacket(last))
+	assert.NoError(t, dialed.Close())
+	select {
+	case b := <-received:
+		assert.Equal(t, []byte("bye"), b)
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "timeout")
+	}
+	select {
+	case _, ok := <-received:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "timeout")
+	}
+	_, err = dialed.Write([]byte("closed"))
+	assert.Error(t, err)
+}
+
+func Test_quicConn_invalidFrame(t *testing.T) {
+	dialed, accepted, closeAll := newTestQuicConnPair(t)
+	defer closeAll()
+
+	pr := NewPacketReader(accepted)
+	_, err := pr.ReadPacket()
+	assert.NoError(t, err)
+
+	_, err = dialed.ctrl.Write([]byte{0, 0, 0, 0})
+	assert.NoError(t, err)
+	_, err = pr.ReadPacket()
+	assert.Error(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func Test_quicConn_channelBinding(t *testing.T) {
+	dialed, accepted, closeAll := newTestQuicConnPair(t)
+	defer closeAll()
+
+	b1, err := dialed.channelBinding()
+	assert.NoError(t, err)
+	assert.Len(t, b1, quicBindingSize)
+	b2, err := accepted.channelBinding()
+	assert.NoError(t, err)
+	assert.Equal(t, b1, b2)
+
+	// a relay makes two TLS sessions which have different bindings
+	dialed2, _, closeAll2 := newTestQuicConnPair(t)
+	defer closeAll2()
+	b3, err := dialed2.channelBinding()
+	assert.NoError(t, err)
+	assert.NotEqual(t, b1, b3)
+}
+
+func Test_transport_quic(t *testing.T) {
+	lv := log.GlobalLogger().GetLevel()
+	if testing.Verbose() {
+		lv = log.TraceLevel
+	}
+	newQuic := func() *transport {
+		w := walletFromGeneratedPrivateKey()
+		l := log.WithFields(log.Fields{
+			log.FieldKeyWallet: hex.EncodeToString(w.Address().ID()),
+		})
+		l.SetLevel(lv)
+		addr, err := net.ResolveUDPAddr(DefaultQuicNet, getAvailableLocalhostAddress(t))
+		assert.NoError(t, err)
+		return NewTransportOf(TransportQUIC, addr.String(), w, l).(*transport)
+	}
+	nt1 := newQuic()
+	nt2 := newQuic()
+
+	tph1 := newTestTransportPeerHandler("TestPeerHandler1", t, nt1.PeerID(), nt1.logger)
+	tph2 := newTestTransportPeerHandler("TestPeerHandler2", t, nt2.PeerID(), nt2.logger)
+	tph2.wg = &sync.WaitGroup{}
+
+	mtr := metric.NewNetworkMetric(metric.DefaultMetricContext())
+	nt1.registerPeerHandler(testChannel, tph1, mtr)
+	nt2.registerPeerHandler(testChannel, tph2, mtr)
+	nt1.addProtocol(testChannel, p2pProtoControl)
+	nt2.addProtocol(testChannel, p2pProtoControl)
+
+	assert.NoError(t, nt1.Listen())
+	assert.NoError(t, nt2.Listen())
+
+	// QUIC is encrypted already, so SecureSuiteNone is used for any setting.
+	d := nt2.GetDialer(testChannel)
+	for _, ss := range []SecureSuite{SecureSuiteNone, SecureSuiteTls, SecureSuiteEcdhe} {
+		assert.NoError(t, nt1.SetSecureSuites(testChannel, ss.String()))
+		assert.NoError(t, nt2.SetSecureSuites(testChannel, ss.String()))
+		tph1.expectedSecureSuite = SecureSuiteNone
+		tph2.expectedSecureSuite = SecureSuiteNone
+		tph2.wg.Add(1)
+
+		if err := d.Dial(nt1.Address()); err != nil {
+			assert.FailNow(t, err.Error(), "Transport.Dial fail")
+		}
+		tph2.wg.Wait()
+	}
+	assert.NoError(t, nt1.Close())
+	assert.NoError(t, nt2.Close())
+}
+
+func Test_newNetTransport(t *testing.T) {
+	for _, name := range []string{"", TransportTCP, TransportQUIC} {
+		assert.True(t, IsValidTransport(name))
+		nt, err := newNetTransport(name)
+		assert.NoError(t, err)
+		assert.NotNil(t, nt)
+	}
+	assert.False(t, IsValidTransport("udp"))
+	_, err := newNetTransport("udp")
+	assert.Error(t, err)
+}
@@ -0,0 +1,308 @@
+package network
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	DefaultBanThreshold     = 100
+	DefaultBanDuration      = time.Hour
+	DefaultScoreDecayPeriod = 10 * time.Second
+)
+
+const (
+	BanTypePeer = "peer"
+	BanTypeIP   = "ip"
+)
+
+var violationPenalties = map[module.PeerViolation]int{
+	module.ViolationInvalidMessage:     20,
+	module.ViolationInvalidProposal:    50,
+	module.ViolationInvalidBlock:       50,
+	module.ViolationInvalidTransaction: 5,
+	module.ViolationFlooding:           10,
+}
+
+// maliciousViolations are violations which honest peers can't make, so the
+// IP is banned with the peer for them. Others may come from the peers having
+// different views, like relayed messages verified with an old revision.
+var maliciousViolations = map[module.PeerViolation]bool{
+	module.ViolationInvalidProposal: true,
+	module.ViolationInvalidBlock:    true,
+}
+
+type BanEntry struct {
+	Target string    `json:"target"`
+	Type   string    `json:"type"`
+	Reason string    `json:"reason,omitempty"`
+	Expire time.Time `json:"expire"`
+}
+
+func (e *BanEntry) expired(now time.Time) bool {
+	return !now.Before(e.Expire)
+}
+
+// PeerBanner is implemented by the network manager to manage banned peers.
+type PeerBanner interface {
+	BanList() []BanEntry
+	Ban(target string, d time.Duration, reason string) error
+	Unban(target string) bool
+}
+
+type peerScore struct {
+	score int
+	last  time.Time
+}
+
+// decayed returns the score recovered by elapsed time since the last update.
+func (s *peerScore) decayed(now time.Time) int {
+	score := s.score - int(now.Sub(s.last)/DefaultScoreDecayPeriod)
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+type reputation struct {
+	mtx       sync.Mutex
+	scores    map[string]*peerScore
+	lastPrune time.Time
+	bans      map[string]*BanEntry
+	file      string
+	logger    log.Logger
+}
+
+func newReputation(l log.Logger) *reputation {
+	return &reputation{
+		scores: make(map[string]*peerScore),
+		bans:   make(map[string]*BanEntry),
+		logger: l,
+	}
+}
+
+// ipOf returns IP part of the address. It returns empty string if the
+// address doesn't have valid IP.
+func ipOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+func banTypeOf(target string) string {
+	if net.ParseIP(target) != nil {
+		return BanTypeIP
+	}
+	return BanTypePeer
+}
+
+func (r *reputation) load(file string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.file = file
+	b, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "FailToReadBanList(file=%s)", file)
+	}
+	var entries []BanEntry
+	if err = json.Unmarshal(b, &entries); err != nil {
+		return errors.Wrapf(err, "InvalidBanList(file=%s)", file)
+	}
+	now := time.Now()
+	for i := range entries {
+		e := entries[i]
+		if !e.expired(now) {
+			r.bans[e.Target] = &e
+		}
+	}
+	return nil
+}
+
+func (r *reputation) _save() {
+	if len(r.file) == 0 {
+		return
+	}
+	b, err := json.Marshal(r._banList())
+	if err != nil {
+		r.logger.Warnf("fail to marshal ban list err=%+v", err)
+		return
+	}
+	if err = os.WriteFile(r.file, b, 0644); err != nil {
+		r.logger.Warnf("fail to save ban list file=%s err=%+v", r.file, err)
+	}
+}
+
+func (r *reputation) _banList() []BanEntry {
+	now := time.Now()
+	entries := make([]BanEntry, 0, len(r.bans))
+	for k, e := range r.bans {
+		if e.expired(now) {
+			delete(r.bans, k)
+			continue
+		}
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Expire.Before(entries[j].Expire)
+	})
+	return entries
+}
+
+func (r *reputation) banList() []BanEntry {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r._banList()
+}
+
+func (r *reputation) _ban(target string, d time.Duration, reason string) {
+	e := &BanEntry{
+		Target: target,
+		Type:   banTypeOf(target),
+		Reason: reason,
+		Expire: time.Now().Add(d),
+	}
+	if old, ok := r.bans[target]; ok && old.Expire.After(e.Expire) {
+		e.Expire = old.Expire
+	}
+	r.bans[target] = e
+	r.logger.Infof("ban %s %s until %s reason=%s", e.Type, target, e.Expire, reason)
+}
+
+func (r *reputation) ban(target string, d time.Duration, reason string) error {
+	if len(target) == 0 || d <= 0 {
+		return errors.IllegalArgumentError.Errorf(
+			"InvalidBan(target=%s,duration=%s)", target, d)
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r._ban(target, d, reason)
+	r._save()
+	return nil
+}
+
+func (r *reputation) unban(target string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, ok := r.bans[target]; !ok {
+		return false
+	}
+	delete(r.bans, target)
+	delete(r.scores, target)
+	r._save()
+	r.logger.Infof("unban %s", target)
+	return true
+}
+
+func (r *reputation) _isBanned(target string, now time.Time) bool {
+	if len(target) == 0 {
+		return false
+	}
+	e, ok := r.bans[target]
+	if !ok {
+		return false
+	}
+	if e.expired(now) {
+		delete(r.bans, target)
+		return false
+	}
+	return true
+}
+
+// isBanned returns whether the peer or the IP is banned.
+func (r *reputation) isBanned(id module.PeerID, ip string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+	if id != nil && r._isBanned(id.String(), now) {
+		return true
+	}
+	return r._isBanned(ip, now)
+}
+
+// report adds penalty of the violation to the score of the peer, and bans
+// the peer if the score reaches the threshold. The IP is also banned if the
+// violation is malicious. It returns true if the peer is banned.
+func (r *reputation) report(id module.PeerID, ip string, v module.PeerViolation, reason string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+	r._pruneScores(now)
+	key := id.String()
+	s, ok := r.scores[key]
+	if !ok {
+		s = &peerScore{}
+		r.scores[key] = s
+	}
+	s.score = s.decayed(now) + violationPenalties[v]
+	s.last = now
+	r.logger.Debugf("report peer=%s violation=%s reason=%s score=%d",
+		key, v, reason, s.score)
+	if s.score < DefaultBanThreshold {
+		return false
+	}
+	delete(r.scores, key)
+	banReason := v.String()
+	if len(reason) > 0 {
+		banReason += ":" + reason
+	}
+	r._ban(key, DefaultBanDuration, banReason)
+	if len(ip) > 0 && maliciousViolations[v] {
+		r._ban(ip, DefaultBanDuration, banReason)
+	}
+	r._save()
+	return true
+}
+
+// _pruneScores removes scores fully recovered by decay. It scans the scores
+// at most once in a decay period.
+func (r *reputation) _pruneScores(now time.Time) {
+	if now.Sub(r.lastPrune) < DefaultScoreDecayPeriod {
+		return
+	}
+	r.lastPrune = now
+	for k, s := range r.scores {
+		if s.decayed(now) <= 0 {
+			delete(r.scores, k)
+		}
+	}
+}
+
+func (r *reputation) inspect() map[string]interface{} {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+	r._pruneScores(now)
+	scores := make(map[string]int)
+	for k, s := range r.scores {
+		if score := s.decayed(now); score > 0 {
+			scores[k] = score
+		}
+	}
+	return map[string]interface{}{
+		"scores": scores,
+		"bans":   r._banList(),
+	}
+}
@@ -0,0 +1,112 @@
+package network
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/module"
+)
+
+func Test_reputation_report(t *testing.T) {
+	r := newReputation(testLogger())
+	id := generatePeerID()
+	ip := "127.0.0.1"
+
+	assert.False(t, r.isBanned(id, ip))
+	assert.False(t, r.report(id, ip, module.ViolationInvalidProposal, "first"))
+	assert.False(t, r.isBanned(id, ip))
+	assert.True(t, r.report(id, ip, module.ViolationInvalidBlock, "second"))
+
+	assert.True(t, r.isBanned(id, ""))
+	assert.True(t, r.isBanned(nil, ip))
+	assert.True(t, r.isBanned(generatePeerID(), ip))
+	assert.False(t, r.isBanned(generatePeerID(), "127.0.0.2"))
+
+	bans := r.banList()
+	assert.Len(t, bans, 2)
+	for _, e := range bans {
+		switch e.Target {
+		case id.String():
+			assert.Equal(t, BanTypePeer, e.Type)
+		case ip:
+			assert.Equal(t, BanTypeIP, e.Type)
+		default:
+			assert.Failf(t, "unexpected ban entry", "entry=%+v", e)
+		}
+		assert.Contains(t, e.Reason, module.ViolationInvalidBlock.String())
+	}
+
+	assert.True(t, r.unban(ip))
+	assert.False(t, r.unban(ip))
+	assert.False(t, r.isBanned(nil, ip))
+	assert.True(t, r.isBanned(id, ip))
+
+	// the IP isn't banned for violations which may not be malicious
+	id2 := generatePeerID()
+	for i := 0; i < 4; i++ {
+		assert.False(t, r.report(id2, ip, module.ViolationInvalidMessage, "invalid"))
+	}
+	assert.True(t, r.report(id2, ip, module.ViolationInvalidMessage, "invalid"))
+	assert.True(t, r.isBanned(id2, ""))
+	assert.False(t, r.isBanned(nil, ip))
+}
+
+func Test_reputation_decay(t *testing.T) {
+	s := &peerScore{score: 10, last: time.Now()}
+	assert.Equal(t, 10, s.decayed(s.last))
+	assert.Equal(t, 7, s.decayed(s.last.Add(3*DefaultScoreDecayPeriod)))
+	assert.Equal(t, 0, s.decayed(s.last.Add(20*DefaultScoreDecayPeriod)))
+}
+
+func Test_reputation_pruneScores(t *testing.T) {
+	r := newReputation(testLogger())
+	old := time.Now().Add(-20 * DefaultScoreDecayPeriod)
+	for i := 0; i < 10; i++ {
+		r.scores[generatePeerID().String()] = &peerScore{score: 10, last: old}
+	}
+	id := generatePeerID()
+	assert.False(t, r.report(id, "", module.ViolationInvalidTransaction, "tx"))
+	assert.Len(t, r.scores, 1)
+	assert.Contains(t, r.scores, id.String())
+}
+
+func Test_reputation_ban(t *testing.T) {
+	r := newReputation(testLogger())
+	assert.Error(t, r.ban("", time.Minute, "empty"))
+	assert.Error(t, r.ban("127.0.0.1", 0, "zero"))
+
+	assert.NoError(t, r.ban("127.0.0.1", time.Millisecond, "short"))
+	time.Sleep(5 * time.Millisecond)
+	assert.False(t, r.isBanned(nil, "127.0.0.1"))
+	assert.Len(t, r.banList(), 0)
+}
+
+func Test_reputation_persist(t *testing.T) {
+	file := path.Join(t.TempDir(), "bans.json")
+	id := generatePeerID()
+
+	r := newReputation(testLogger())
+	assert.NoError(t, r.load(file))
+	assert.NoError(t, r.ban(id.String(), time.Hour, "manual"))
+	assert.NoError(t, r.ban("10.0.0.1", time.Hour, "manual"))
+
+	r2 := newReputation(testLogger())
+	assert.NoError(t, r2.load(file))
+	assert.True(t, r2.isBanned(id, ""))
+	assert.True(t, r2.isBanned(nil, "10.0.0.1"))
+
+	assert.True(t, r2.unban("10.0.0.1"))
+	r3 := newReputation(testLogger())
+	assert.NoError(t, r3.load(file))
+	assert.Equal(t, []BanEntry{r2.banList()[0]}, r3.banList())
+}
+
+func Test_ipOf(t *testing.T) {
+	assert.Equal(t, "127.0.0.1", ipOf("127.0.0.1:8080"))
+	assert.Equal(t, "::1", ipOf("[::1]:8080"))
+	assert.Equal(t, "10.0.0.1", ipOf("10.0.0.1"))
+	assert.Equal(t, "", ipOf("localhost:8080"))
+}
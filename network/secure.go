@@ -186,6 +186,12 @@ func (k *secureKey) setup(sa SecureAeadSuite, peerPublicKey []byte, defaultLower
 	return k.hkdf(numOfSecret)
 }
 
+// bind appends the channel binding of the connection to the content for
+// signatures.
+func (k *secureKey) bind(binding []byte) {
+	k.extra = append(k.extra, binding...)
+}
+
 func (k *secureKey) setPeerPublicKey(publicKey []byte, defaultLower bool) error {
 	c := k.Curve
 	k.pX, k.pY = elliptic.Unmarshal(c, publicKey)
@@ -3,57 +3,176 @@ package network
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/server/metric"
 )
 
+const (
+	TransportTCP  = "tcp"
+	TransportQUIC = "quic"
+)
+
+// netTransport establishes connections for the transport. Peers are
+// authenticated and negotiated by the same handshakes on any of them.
+type netTransport interface {
+	Listen(address string) (net.Listener, error)
+	DialTimeout(address string, timeout time.Duration) (net.Conn, error)
+	Protocol() string
+}
+
+type tcpTransport struct{}
+
+func (t tcpTransport) Protocol() string {
+	return "tcp"
+}
+
+func (t tcpTransport) Listen(address string) (net.Listener, error) {
+	return net.Listen(DefaultTransportNet, address)
+}
+
+func (t tcpTransport) DialTimeout(address string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout(DefaultTransportNet, address, timeout)
+}
+
+func newNetTransport(name string) (netTransport, error) {
+	switch name {
+	case "", TransportTCP:
+		return tcpTransport{}, nil
+	case TransportQUIC:
+		return newQuicTransport()
+	default:
+		return nil, fmt.Errorf("unknown transport %s", name)
+	}
+}
+
+// IsValidTransport returns whether the name is a supported transport.
+func IsValidTransport(name string) bool {
+	switch name {
+	case "", TransportTCP, TransportQUIC:
+		return true
+	default:
+		return false
+	}
+}
+
 type transport struct {
-	l       *Listener
-	id      module.PeerID
-	address NetAddress
-	a       *Authenticator
-	cn      *ChannelNegotiator
-	pd      *PeerDispatcher
-	dMap    map[string]*Dialer
-	logger  log.Logger
+	nt     netTransport
+	l      *Listener
+	id     module.PeerID
+	am     *addressManager
+	pm     PortMapper
+	mtx    sync.Mutex
+	a      *Authenticator
+	cn     *ChannelNegotiator
+	pd     *PeerDispatcher
+	dMap   map[string]*Dialer
+	logger log.Logger
 }
 
 func NewTransport(address string, w module.Wallet, l log.Logger) module.NetworkTransport {
+	return NewTransportOf(TransportTCP, address, w, l)
+}
+
+// NewTransportOf returns the transport using the network, TransportTCP or
+// TransportQUIC.
+func NewTransportOf(network string, address string, w module.Wallet, l log.Logger) module.NetworkTransport {
 	na := NetAddress(address)
 	if err := na.Validate(); err != nil {
 		l.Panicf("invalid P2P Address err:%+v", err)
 	}
+	nt, err := newNetTransport(network)
+	if err != nil {
+		l.Panicf("fail to create P2P transport err:%+v", err)
+	}
 	transportLogger := l.WithFields(log.Fields{log.FieldKeyModule: "TP"})
 	id := NewPeerIDFromAddress(w.Address())
 	a := newAuthenticator(w, transportLogger)
 	cn := newChannelNegotiator(na, id, transportLogger)
 	pd := newPeerDispatcher(id, transportLogger, a, cn)
-	listener := newListener(address, pd.onAccept, transportLogger)
+	listener := newListener(address, nt, pd.onAccept, transportLogger)
+	am := newAddressManager(na, transportLogger)
+	cn.setNetAddress(am.NetAddress())
 	t := &transport{
-		l:       listener,
-		id:      id,
-		address: na,
-		a:       a,
-		cn:      cn,
-		pd:      pd,
-		dMap:    make(map[string]*Dialer),
-		logger:  transportLogger,
+		nt:     nt,
+		l:      listener,
+		id:     id,
+		am:     am,
+		a:      a,
+		cn:     cn,
+		pd:     pd,
+		dMap:   make(map[string]*Dialer),
+		logger: transportLogger,
 	}
+	am.onChange = t.onAddressChange
 	return t
 }
 
 func (t *transport) Listen() error {
-	return t.l.Listen()
+	if err := t.l.Listen(); err != nil {
+		return err
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t._startMapping()
+	return nil
 }
 
 func (t *transport) Close() error {
+	t.am.stopMapping()
 	return t.l.Close()
 }
 
+func (t *transport) _startMapping() {
+	if t.pm == nil {
+		return
+	}
+	_, port, err := net.SplitHostPort(t.l.Address())
+	if err != nil {
+		t.logger.Warnf("fail to get port of listen address err=%+v", err)
+		return
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p == 0 {
+		t.logger.Warnf("invalid port of listen address %s", t.l.Address())
+		return
+	}
+	t.am.startMapping(t.pm, t.nt.Protocol(), p)
+}
+
+func (t *transport) setPortMapper(pm PortMapper) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.am.stopMapping()
+	t.pm = pm
+	if t.l.isListening() {
+		t._startMapping()
+	}
+}
+
+func (t *transport) onAddressChange(na NetAddress) {
+	t.cn.setNetAddress(na)
+	for _, ph := range t.pd.channelPeerHandlers() {
+		if p2p, ok := ph.(*PeerToPeer); ok {
+			p2p.setNetAddress(na)
+		}
+	}
+}
+
+func (t *transport) observeAddress(observer NetAddress, na NetAddress) {
+	t.am.observe(observer, na)
+}
+
+func (t *transport) inspectAddress() map[string]interface{} {
+	return t.am.inspect()
+}
+
 func (t *transport) Dial(address string, channel string) error {
 	d := t.GetDialer(channel)
 	return d.Dial(address)
@@ -64,7 +183,7 @@ func (t *transport) PeerID() module.PeerID {
 }
 
 func (t *transport) Address() string {
-	return string(t.address)
+	return string(t.am.NetAddress())
 }
 
 func (t *transport) SetListenAddress(address string) error {
@@ -78,7 +197,7 @@ func (t *transport) GetListenAddress() string {
 func (t *transport) GetDialer(channel string) *Dialer {
 	d, ok := t.dMap[channel]
 	if !ok {
-		d = newDialer(channel, t.pd.onConnect)
+		d = newDialer(channel, t.nt, t.pd.onConnect)
 		t.dMap[channel] = d
 	}
 	return d
@@ -145,6 +264,9 @@ func (t *transport) removeProtocol(channel string, pi module.ProtocolInfo) {
 }
 
 func (t *transport) registerPeerHandler(channel string, ph PeerHandler, mtr *metric.NetworkMetric) bool {
+	if p2p, ok := ph.(*PeerToPeer); ok {
+		p2p.setNetAddress(t.am.NetAddress())
+	}
 	return t.pd.registerByChannel(channel, ph, mtr)
 }
 
@@ -154,6 +276,7 @@ func (t *transport) unregisterPeerHandler(channel string) {
 
 type Listener struct {
 	address  string
+	nt       netTransport
 	ln       net.Listener
 	mtx      sync.Mutex
 	closeCh  chan bool
@@ -164,9 +287,10 @@ type Listener struct {
 
 type acceptCbFunc func(conn net.Conn)
 
-func newListener(address string, cbFunc acceptCbFunc, l log.Logger) *Listener {
+func newListener(address string, nt netTransport, cbFunc acceptCbFunc, l log.Logger) *Listener {
 	return &Listener{
 		address:  address,
+		nt:       nt,
 		onAccept: cbFunc,
 		logger:   l.WithFields(log.Fields{LoggerFieldKeySubModule: "listener"}),
 	}
@@ -192,6 +316,13 @@ func (l *Listener) SetAddress(address string) error {
 	return nil
 }
 
+func (l *Listener) isListening() bool {
+	defer l.mtx.Unlock()
+	l.mtx.Lock()
+
+	return l.ln != nil
+}
+
 func (l *Listener) Listen() error {
 	defer l.mtx.Unlock()
 	l.mtx.Lock()
@@ -199,7 +330,7 @@ func (l *Listener) Listen() error {
 	if l.ln != nil {
 		return ErrAlreadyListened
 	}
-	ln, err := net.Listen(DefaultTransportNet, l.address)
+	ln, err := l.nt.Listen(l.address)
 	if err != nil {
 		return err
 	}
@@ -240,6 +371,7 @@ func (l *Listener) acceptRoutine() {
 }
 
 type Dialer struct {
+	nt        netTransport
 	onConnect connectCbFunc
 	channel   string
 	dialing   *Set
@@ -247,8 +379,9 @@ type Dialer struct {
 
 type connectCbFunc func(conn net.Conn, addr, channel string)
 
-func newDialer(channel string, cbFunc connectCbFunc) *Dialer {
+func newDialer(channel string, nt netTransport, cbFunc connectCbFunc) *Dialer {
 	return &Dialer{
+		nt:        nt,
 		onConnect: cbFunc,
 		channel:   channel,
 		dialing:   NewSet(),
@@ -259,7 +392,7 @@ func (d *Dialer) Dial(addr string) error {
 	if !d.dialing.Add(addr) {
 		return ErrAlreadyDialing
 	}
-	conn, err := net.DialTimeout(DefaultTransportNet, addr, DefaultDialTimeout)
+	conn, err := d.nt.DialTimeout(addr, DefaultDialTimeout)
 	_ = d.dialing.Remove(addr)
 	if err != nil {
 		return err
@@ -5,11 +5,13 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/server"
 	"github.com/icon-project/goloop/server/jsonrpc"
+	"github.com/icon-project/goloop/service/eeproxy"
 )
 
 const (
@@ -22,6 +24,8 @@ type StaticConfig struct {
 	CliSocket     string `json:"node_sock"` // relative path
 	P2PAddr       string `json:"p2p"`
 	P2PListenAddr string `json:"p2p_listen"`
+	P2PTransport  string `json:"p2p_transport,omitempty"`
+	P2PNAT        string `json:"p2p_nat,omitempty"`
 	RPCAddr       string `json:"rpc_addr"`
 	RPCDump       bool   `json:"rpc_dump"`
 	EESocket      string `json:"ee_socket"`
@@ -102,11 +106,18 @@ func (c *StaticConfig) AbsBaseDir() string {
 }
 
 const (
-	DefaultEEInstances = 1
+	DefaultEEInstances      = 1
+	DefaultEEPingInterval   = 10000
+	DefaultEEPingTimeout    = 5000
+	DefaultEERestartBackoff = 1000
 )
 
 type RuntimeConfig struct {
 	EEInstances       int    `json:"eeInstances"`
+	EEPingInterval    int64  `json:"eePingInterval"`
+	EEPingTimeout     int64  `json:"eePingTimeout"`
+	EEHangTimeout     int64  `json:"eeHangTimeout"`
+	EERestartBackoff  int64  `json:"eeRestartBackoff"`
 	RPCDefaultChannel string `json:"rpcDefaultChannel"`
 	RPCIncludeDebug   bool   `json:"rpcIncludeDebug"`
 	RPCRosetta        bool   `json:"rpcRosetta"`
@@ -117,6 +128,17 @@ type RuntimeConfig struct {
 	FilePath string `json:"-"` // absolute path
 }
 
+// SupervisorConfig returns the configuration for supervising executors.
+// Durations in the configuration are in milliseconds.
+func (c *RuntimeConfig) SupervisorConfig() eeproxy.SupervisorConfig {
+	return eeproxy.SupervisorConfig{
+		PingInterval:   time.Duration(c.EEPingInterval) * time.Millisecond,
+		PingTimeout:    time.Duration(c.EEPingTimeout) * time.Millisecond,
+		HangTimeout:    time.Duration(c.EEHangTimeout) * time.Millisecond,
+		RestartBackoff: time.Duration(c.EERestartBackoff) * time.Millisecond,
+	}
+}
+
 func (c *RuntimeConfig) load() error {
 	log.Println("load ", c.FilePath)
 	if _, err := os.Stat(c.FilePath); err != nil {
@@ -146,10 +168,13 @@ func (c *RuntimeConfig) save() error {
 
 func loadRuntimeConfig(baseDir string) (*RuntimeConfig, error) {
 	cfg := &RuntimeConfig{
-		EEInstances:   DefaultEEInstances,
-		RPCBatchLimit: jsonrpc.DefaultBatchLimit,
-		FilePath:      path.Join(baseDir, "rconfig.json"),
-		WSMaxSession:  server.DefaultWSMaxSession,
+		EEInstances:      DefaultEEInstances,
+		EEPingInterval:   DefaultEEPingInterval,
+		EEPingTimeout:    DefaultEEPingTimeout,
+		EERestartBackoff: DefaultEERestartBackoff,
+		RPCBatchLimit:    jsonrpc.DefaultBatchLimit,
+		FilePath:         path.Join(baseDir, "rconfig.json"),
+		WSMaxSession:     server.DefaultWSMaxSession,
 	}
 	if err := cfg.load(); err != nil {
 		if os.IsNotExist(err) {
@@ -14,6 +14,7 @@ import (
 
 	"github.com/icon-project/goloop/chain"
 	"github.com/icon-project/goloop/chain/gs"
+	"github.com/icon-project/goloop/common/db"
 	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/module"
@@ -21,6 +22,7 @@ import (
 	"github.com/icon-project/goloop/server"
 	"github.com/icon-project/goloop/server/metric"
 	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/state"
 )
 
 var (
@@ -400,10 +402,12 @@ func (n *Node) JoinChain(
 		Role:             p.Role,
 		GenesisStorage:   genesisStorage,
 		ConcurrencyLevel: p.ConcurrencyLevel,
+		OptimisticExec:   p.OptimisticExec,
 		NormalTxPoolSize: p.NormalTxPoolSize,
 		PatchTxPoolSize:  p.PatchTxPoolSize,
 		MaxBlockTxBytes:  p.MaxBlockTxBytes,
 		NodeCache:        p.NodeCache,
+		NodeCacheMemory:  p.NodeCacheMemory,
 		DefWaitTimeout:   p.DefWaitTimeout,
 		MaxWaitTimeout:   p.MaxWaitTimeout,
 		TxTimeout:        p.TxTimeout,
@@ -413,6 +417,14 @@ func (n *Node) JoinChain(
 		ChildrenLimit:    p.ChildrenLimit,
 		NephewsLimit:     p.NephewsLimit,
 		ValidateTxOnSend: p.ValidateTxOnSend,
+		SkipEmptyBlock:   p.SkipEmptyBlock,
+		MaxIdleInterval:  p.MaxIdleInterval,
+		MinBlockInterval: p.MinBlockInterval,
+		MaxBlockInterval: p.MaxBlockInterval,
+		NetworkCapture:   p.NetworkCapture,
+		PruneRetention:   p.PruneRetention,
+		PruneCheckpoint:  p.PruneCheckpoint,
+		Archive:          p.Archive,
 	}
 
 	if err := cfg.Save(); err != nil {
@@ -505,6 +517,102 @@ func (n *Node) VerifyChain(cid int) error {
 	return c.Verify()
 }
 
+func (n *Node) _peerBanner(cid int) (network.PeerBanner, error) {
+	c, err := n._get(cid)
+	if err != nil {
+		return nil, err
+	}
+	if pb, ok := c.NetworkManager().(network.PeerBanner); ok {
+		return pb, nil
+	}
+	return nil, errors.InvalidStateError.Errorf("NetworkNotReady(cid=%d)", cid)
+}
+
+func (n *Node) GetChainBans(cid int) ([]network.BanEntry, error) {
+	defer n.mtx.RUnlock()
+	n.mtx.RLock()
+
+	pb, err := n._peerBanner(cid)
+	if err != nil {
+		return nil, err
+	}
+	return pb.BanList(), nil
+}
+
+func (n *Node) BanChainPeer(cid int, target string, d time.Duration, reason string) error {
+	defer n.mtx.RUnlock()
+	n.mtx.RLock()
+
+	pb, err := n._peerBanner(cid)
+	if err != nil {
+		return err
+	}
+	return pb.Ban(target, d, reason)
+}
+
+func (n *Node) UnbanChainPeer(cid int, target string) error {
+	defer n.mtx.RUnlock()
+	n.mtx.RLock()
+
+	pb, err := n._peerBanner(cid)
+	if err != nil {
+		return err
+	}
+	if !pb.Unban(target) {
+		return errors.NotFoundError.Errorf("NotBanned(target=%s)", target)
+	}
+	return nil
+}
+
+func (n *Node) _archiveDatabase(cid int) (db.Database, error) {
+	c, err := n._get(cid)
+	if err != nil {
+		return nil, err
+	}
+	if !c.cfg.Archive {
+		return nil, errors.InvalidStateError.Errorf("NotArchiveNode(cid=%d)", cid)
+	}
+	dbase := c.Database()
+	if dbase == nil {
+		return nil, errors.InvalidStateError.Errorf("DatabaseNotReady(cid=%d)", cid)
+	}
+	return dbase, nil
+}
+
+// GetChainArchive returns the state index and the changeset of the height
+// stored by the archive node.
+func (n *Node) GetChainArchive(cid int, height int64) (*chain.StateIndex, *state.ChangeSet, error) {
+	defer n.mtx.RUnlock()
+	n.mtx.RLock()
+
+	dbase, err := n._archiveDatabase(cid)
+	if err != nil {
+		return nil, nil, err
+	}
+	si, err := chain.GetStateIndex(dbase, height)
+	if err != nil {
+		return nil, nil, err
+	}
+	cs, err := chain.GetChangeSet(dbase, height)
+	if err != nil {
+		return nil, nil, err
+	}
+	return si, cs, nil
+}
+
+// GetChainAccountHistory returns heights in [from, to) where the account
+// has been changed. Zero to means no limit.
+func (n *Node) GetChainAccountHistory(cid int, addr module.Address, from, to int64) ([]int64, error) {
+	defer n.mtx.RUnlock()
+	n.mtx.RLock()
+
+	dbase, err := n._archiveDatabase(cid)
+	if err != nil {
+		return nil, err
+	}
+	return chain.GetAccountHistory(dbase, addr.ID(), from, to)
+}
+
 func (n *Node) ImportChain(cid int, s string, height int64) error {
 	defer n.mtx.RUnlock()
 	n.mtx.RLock()
@@ -529,7 +637,7 @@ func (n *Node) PruneChain(cid int, dbt string, height int64) error {
 	return c.Prune(gs, dbt, height)
 }
 
-func (n *Node) BackupChain(cid int, manual bool) (string, error) {
+func (n *Node) BackupChain(cid int, manual bool, base string) (string, error) {
 	defer n.mtx.RUnlock()
 	n.mtx.RLock()
 
@@ -539,18 +647,25 @@ func (n *Node) BackupChain(cid int, manual bool) (string, error) {
 	}
 
 	if manual {
-		return "manual", c.Backup("", nil)
+		return "manual", c.Backup("", "", nil)
 	}
 	backupDir := n.cfg.ResolveAbsolute(n.cfg.BackupDir)
 	if err := os.MkdirAll(backupDir, 0700); err != nil {
 		return "", errors.InvalidStateError.Wrapf(err,
 			"Fail to make backup directory=%s", backupDir)
 	}
+	if base != "" {
+		if base != path.Base(base) {
+			return "", errors.IllegalArgumentError.Errorf(
+				"InvalidBase(base=%s)", base)
+		}
+		base = path.Join(backupDir, base)
+	}
 	now := time.Now()
 	name := fmt.Sprintf("%#x_%#x_%s_%s.zip", c.CID(), c.NID(), c.Channel(),
 		now.Format("20060102-150405"))
 	file := path.Join(backupDir, name)
-	return name, c.Backup(file, []string{ChainGenesisZipFileName, ChainConfigFileName})
+	return name, c.Backup(file, base, []string{ChainGenesisZipFileName, ChainConfigFileName})
 }
 
 type BackupInfo struct {
@@ -666,6 +781,18 @@ func (n *Node) ConfigureChain(cid int, key string, value string) error {
 			} else {
 				c.cfg.AutoStart = as
 			}
+		case "networkCapture":
+			if capture, err := strconv.ParseBool(value); err != nil {
+				return err
+			} else if capture {
+				if err := network.StartCapture(c.NetworkManager(), c.cfg.CaptureWriterConfig()); err != nil {
+					return err
+				}
+				c.cfg.NetworkCapture = true
+			} else {
+				network.StopCapture(c.NetworkManager())
+				c.cfg.NetworkCapture = false
+			}
 		default:
 			return errors.ErrInvalidState
 		}
@@ -679,6 +806,12 @@ func (n *Node) ConfigureChain(cid int, key string, value string) error {
 			} else {
 				c.cfg.ConcurrencyLevel = intVal
 			}
+		case "optimisticExecution":
+			if oe, err := strconv.ParseBool(value); err != nil {
+				return errors.Wrapf(err, "invalid value type")
+			} else {
+				c.cfg.OptimisticExec = oe
+			}
 		case "normalTxPool":
 			if intVal, err := strconv.Atoi(value); err != nil {
 				return errors.Wrapf(err, "invalid value type")
@@ -702,6 +835,14 @@ func (n *Node) ConfigureChain(cid int, key string, value string) error {
 				return errors.Errorf("InvalidNodeCacheOption(%s)", value)
 			}
 			c.cfg.NodeCache = value
+		case "nodeCacheMemory":
+			if intVal, err := strconv.Atoi(value); err != nil {
+				return errors.Wrapf(err, "invalid value type")
+			} else if intVal < 0 {
+				return errors.Errorf("InvalidNodeCacheMemory(%d)", intVal)
+			} else {
+				c.cfg.NodeCacheMemory = intVal
+			}
 		case "defaultWaitTimeout":
 			if intVal, err := strconv.ParseInt(value, 0, 64); err != nil {
 				return errors.Wrapf(err, "invalid value type")
@@ -766,10 +907,58 @@ func (n *Node) ConfigureChain(cid int, key string, value string) error {
 			}
 		case "validateTxOnSend":
 			if bc, err := strconv.ParseBool(value); err != nil {
-				return errors.Wrapf(err, "InvalidValueType(exp=bool,val=%s)", value)
+				return errors.Wrapf(err, "invalid value type")
 			} else {
 				c.cfg.ValidateTxOnSend = bc
 			}
+		case "skipEmptyBlock":
+			if bc, err := strconv.ParseBool(value); err != nil {
+				return errors.Wrapf(err, "invalid value type")
+			} else {
+				c.cfg.SkipEmptyBlock = bc
+			}
+		case "maxIdleInterval":
+			if intVal, err := strconv.ParseInt(value, 0, 64); err != nil {
+				return errors.Wrapf(err, "invalid value type")
+			} else {
+				c.cfg.MaxIdleInterval = intVal
+			}
+		case "minBlockInterval":
+			if intVal, err := strconv.ParseInt(value, 0, 64); err != nil {
+				return errors.Wrapf(err, "invalid value type")
+			} else {
+				c.cfg.MinBlockInterval = intVal
+			}
+		case "maxBlockInterval":
+			if intVal, err := strconv.ParseInt(value, 0, 64); err != nil {
+				return errors.Wrapf(err, "invalid value type")
+			} else {
+				c.cfg.MaxBlockInterval = intVal
+			}
+		case "networkCapture":
+			if capture, err := strconv.ParseBool(value); err != nil {
+				return err
+			} else {
+				c.cfg.NetworkCapture = capture
+			}
+		case "pruneRetention":
+			if intVal, err := strconv.ParseInt(value, 0, 64); err != nil {
+				return errors.Wrapf(err, "invalid value type")
+			} else {
+				c.cfg.PruneRetention = intVal
+			}
+		case "pruneCheckpoint":
+			if intVal, err := strconv.ParseInt(value, 0, 64); err != nil {
+				return errors.Wrapf(err, "invalid value type")
+			} else {
+				c.cfg.PruneCheckpoint = intVal
+			}
+		case "archive":
+			if archive, err := strconv.ParseBool(value); err != nil {
+				return err
+			} else {
+				c.cfg.Archive = archive
+			}
 		default:
 			return errors.Errorf("not found key %s", key)
 		}
@@ -865,6 +1054,25 @@ func (n *Node) Configure(key string, value string) error {
 		if err := n.pm.SetInstances(n.rcfg.EEInstances, n.rcfg.EEInstances, n.rcfg.EEInstances); err != nil {
 			return err
 		}
+	case "eePingInterval", "eePingTimeout", "eeHangTimeout", "eeRestartBackoff":
+		intVal, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value type")
+		}
+		if intVal < 0 {
+			return errors.Errorf("negative value")
+		}
+		switch key {
+		case "eePingInterval":
+			n.rcfg.EEPingInterval = intVal
+		case "eePingTimeout":
+			n.rcfg.EEPingTimeout = intVal
+		case "eeHangTimeout":
+			n.rcfg.EEHangTimeout = intVal
+		case "eeRestartBackoff":
+			n.rcfg.EERestartBackoff = intVal
+		}
+		n.pm.SetSupervision(n.rcfg.SupervisorConfig())
 	case "rpcDefaultChannel":
 		n.rcfg.RPCDefaultChannel = value
 		n.srv.SetDefaultChannel(n.rcfg.RPCDefaultChannel)
@@ -930,10 +1138,15 @@ func NewNode(
 		log.Panicf("fail to load runtime config err=%+v", err)
 	}
 
-	nt := network.NewTransport(cfg.P2PAddr, w, l)
+	nt := network.NewTransportOf(cfg.P2PTransport, cfg.P2PAddr, w, l)
 	if cfg.P2PListenAddr != "" {
 		_ = nt.SetListenAddress(cfg.P2PListenAddr)
 	}
+	if pm, err := network.NewPortMapper(cfg.P2PNAT); err != nil {
+		log.Panicf("fail to create port mapper err=%+v", err)
+	} else if pm != nil {
+		network.SetPortMapper(nt, pm)
+	}
 	config := &server.Config{
 		ServerAddress:         cfg.RPCAddr,
 		JSONRPCDump:           cfg.RPCDump,
@@ -959,6 +1172,7 @@ func NewNode(
 	if err := pm.SetInstances(rcfg.EEInstances, rcfg.EEInstances, rcfg.EEInstances); err != nil {
 		log.Panicf("fail to EEManager.SetInstances err=%+v", err)
 	}
+	pm.SetSupervision(rcfg.SupervisorConfig())
 	go func() {
 		if err := pm.Loop(); err != nil {
 			log.Panic(err)
@@ -38,6 +38,10 @@ const (
 	UrlUserRes  = "/:" + ParamID
 	TaskID      = "task"
 
+	ParamBanTarget = "target"
+	ParamHeight    = "height"
+	ParamAddress   = "address"
+
 	UrlDB    = "/db"
 	ParamBK  = "bucket"
 	ParamKey = "key"
@@ -61,6 +65,25 @@ type SystemView struct {
 	Config interface{} `json:"config"`
 }
 
+type EngineView struct {
+	Type      string         `json:"type"`
+	Active    int            `json:"active"`
+	Restarts  int            `json:"restarts"`
+	Executors []ExecutorView `json:"executors"`
+}
+
+type ExecutorView struct {
+	UID            string    `json:"uid"`
+	Version        uint16    `json:"version"`
+	State          string    `json:"state"`
+	Since          time.Time `json:"since"`
+	Calls          int64     `json:"calls"`
+	AverageLatency string    `json:"averageLatency"`
+	LastActive     time.Time `json:"lastActive"`
+	PingRTT        string    `json:"pingRTT"`
+	Memory         int64     `json:"memory"`
+}
+
 type StatsView struct {
 	Chains    []map[string]interface{} `json:"chains"`
 	Timestamp time.Time                `json:"timestamp"`
@@ -89,10 +112,12 @@ type ChainConfig struct {
 	SeedAddr         string `json:"seedAddress"`
 	Role             uint   `json:"role"`
 	ConcurrencyLevel int    `json:"concurrencyLevel,omitempty"`
+	OptimisticExec   bool   `json:"optimisticExecution,omitempty"`
 	NormalTxPoolSize int    `json:"normalTxPool,omitempty"`
 	PatchTxPoolSize  int    `json:"patchTxPool,omitempty"`
 	MaxBlockTxBytes  int    `json:"maxBlockTxBytes,omitempty"`
 	NodeCache        string `json:"nodeCache,omitempty"`
+	NodeCacheMemory  int    `json:"nodeCacheMemory,omitempty"`
 	Channel          string `json:"channel"`
 	SecureSuites     string `json:"secureSuites"`
 	SecureAeads      string `json:"secureAeads"`
@@ -103,6 +128,14 @@ type ChainConfig struct {
 	ChildrenLimit    *int   `json:"childrenLimit,omitempty"`
 	NephewsLimit     *int   `json:"nephewsLimit,omitempty"`
 	ValidateTxOnSend bool   `json:"validateTxOnSend,omitempty"`
+	SkipEmptyBlock   bool   `json:"skipEmptyBlock,omitempty"`
+	MaxIdleInterval  int64  `json:"maxIdleInterval,omitempty"`
+	MinBlockInterval int64  `json:"minBlockInterval,omitempty"`
+	MaxBlockInterval int64  `json:"maxBlockInterval,omitempty"`
+	NetworkCapture   bool   `json:"networkCapture,omitempty"`
+	PruneRetention   int64  `json:"pruneRetention,omitempty"`
+	PruneCheckpoint  int64  `json:"pruneCheckpoint,omitempty"`
+	Archive          bool   `json:"archive,omitempty"`
 }
 
 type ChainResetParam struct {
@@ -121,7 +154,55 @@ type ChainPruneParam struct {
 }
 
 type ChainBackupParam struct {
-	Manual bool `json:"manual,omitempty"`
+	Manual bool   `json:"manual,omitempty"`
+	Base   string `json:"base,omitempty"`
+}
+
+type ChainDBStatParam struct {
+	Compact bool `json:"compact,omitempty"`
+}
+
+type ChainDBCheckParam struct {
+	Heights int64 `json:"heights,omitempty"`
+}
+
+type ChainMigrateParam struct {
+	DBType string `json:"dbType,omitempty"`
+}
+
+type ChainExportSnapshotParam struct {
+	Height int64  `json:"height,omitempty"`
+	Dir    string `json:"dir,omitempty"`
+}
+
+type ChainImportSnapshotParam struct {
+	Source    string          `json:"source"`
+	BlockHash common.HexBytes `json:"blockHash"`
+}
+
+type ChainBanParam struct {
+	Target   string `json:"target"`
+	Duration int64  `json:"duration,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type AccountChangeView struct {
+	Key     common.HexBytes   `json:"key"`
+	Removed bool              `json:"removed,omitempty"`
+	Storage []common.HexBytes `json:"storage,omitempty"`
+}
+
+type ChainArchiveView struct {
+	Height             int64               `json:"height"`
+	BlockID            common.HexBytes     `json:"blockId"`
+	Result             common.HexBytes     `json:"result"`
+	NextValidatorsHash common.HexBytes     `json:"nextValidatorsHash"`
+	Changes            []AccountChangeView `json:"changes"`
+}
+
+type AccountHistoryView struct {
+	Address *common.Address `json:"address"`
+	Heights []int64         `json:"heights"`
 }
 
 type ConfigureParam struct {
@@ -171,10 +252,12 @@ func NewChainConfig(cfg *chain.Config) *ChainConfig {
 		SeedAddr:         cfg.SeedAddr,
 		Role:             cfg.Role,
 		ConcurrencyLevel: cfg.ConcurrencyLevel,
+		OptimisticExec:   cfg.OptimisticExec,
 		NormalTxPoolSize: cfg.NormalTxPoolSize,
 		PatchTxPoolSize:  cfg.PatchTxPoolSize,
 		MaxBlockTxBytes:  cfg.MaxBlockTxBytes,
 		NodeCache:        cfg.NodeCache,
+		NodeCacheMemory:  cfg.NodeCacheMemory,
 		Channel:          cfg.Channel,
 		SecureSuites:     cfg.SecureSuites,
 		SecureAeads:      cfg.SecureAeads,
@@ -185,6 +268,14 @@ func NewChainConfig(cfg *chain.Config) *ChainConfig {
 		ChildrenLimit:    cfg.ChildrenLimit,
 		NephewsLimit:     cfg.NephewsLimit,
 		ValidateTxOnSend: cfg.ValidateTxOnSend,
+		SkipEmptyBlock:   cfg.SkipEmptyBlock,
+		MaxIdleInterval:  cfg.MaxIdleInterval,
+		MinBlockInterval: cfg.MinBlockInterval,
+		MaxBlockInterval: cfg.MaxBlockInterval,
+		NetworkCapture:   cfg.NetworkCapture,
+		PruneRetention:   cfg.PruneRetention,
+		PruneCheckpoint:  cfg.PruneCheckpoint,
+		Archive:          cfg.Archive,
 	}
 	return v
 }
@@ -216,6 +307,7 @@ func RegisterRest(n *Node) {
 	_ = RegisterInspectFunc("metrics", metric.Inspect)
 	_ = RegisterInspectFunc("network", network.Inspect)
 	_ = RegisterInspectFunc("service", service.Inspect)
+	_ = RegisterInspectFunc("chain", chain.Inspect)
 
 	// json rpc
 	n.srv.RegisterAPIHandler(n.cliSrv.e.Group("/api"))
@@ -237,6 +329,11 @@ func (r *Rest) RegisterChainHandlers(g *echo.Group) {
 	g.POST(UrlChainRes+"/import", r.ImportChain, r.ChainInjector)
 	g.POST(UrlChainRes+"/prune", r.PruneChain, r.ChainInjector)
 	g.POST(UrlChainRes+"/backup", r.BackupChain, r.ChainInjector)
+	g.GET(UrlChainRes+"/ban", r.GetChainBans, r.ChainInjector)
+	g.POST(UrlChainRes+"/ban", r.BanChainPeer, r.ChainInjector)
+	g.GET(UrlChainRes+"/archive/:"+ParamHeight, r.GetChainArchive, r.ChainInjector)
+	g.GET(UrlChainRes+"/history/:"+ParamAddress, r.GetChainAccountHistory, r.ChainInjector)
+	g.DELETE(UrlChainRes+"/ban/:"+ParamBanTarget, r.UnbanChainPeer, r.ChainInjector)
 	route := g.GET(UrlChainRes+"/genesis", r.GetChainGenesis, r.ChainInjector)
 	if r.a != nil {
 		r.a.SetSkip(route, false)
@@ -421,13 +518,109 @@ func (r *Rest) BackupChain(ctx echo.Context) error {
 	if err := ctx.Bind(param); err != nil {
 		return echo.ErrBadRequest
 	}
-	if name, err := r.n.BackupChain(c.CID(), param.Manual); err != nil {
+	if name, err := r.n.BackupChain(c.CID(), param.Manual, param.Base); err != nil {
 		return err
 	} else {
 		return ctx.String(http.StatusOK, name)
 	}
 }
 
+func (r *Rest) GetChainBans(ctx echo.Context) error {
+	c := ctx.Get("chain").(*Chain)
+	bans, err := r.n.GetChainBans(c.CID())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, bans)
+}
+
+func (r *Rest) BanChainPeer(ctx echo.Context) error {
+	c := ctx.Get("chain").(*Chain)
+	param := &ChainBanParam{}
+	if err := ctx.Bind(param); err != nil {
+		return echo.ErrBadRequest
+	}
+	if len(param.Target) == 0 || param.Duration < 0 {
+		return echo.ErrBadRequest
+	}
+	d := network.DefaultBanDuration
+	if param.Duration > 0 {
+		d = time.Duration(param.Duration) * time.Millisecond
+	}
+	if err := r.n.BanChainPeer(c.CID(), param.Target, d, param.Reason); err != nil {
+		return err
+	}
+	return ctx.String(http.StatusOK, "OK")
+}
+
+func (r *Rest) UnbanChainPeer(ctx echo.Context) error {
+	c := ctx.Get("chain").(*Chain)
+	if err := r.n.UnbanChainPeer(c.CID(), ctx.Param(ParamBanTarget)); err != nil {
+		if errors.NotFoundError.Equals(err) {
+			return ctx.String(http.StatusNotFound, fmt.Sprintf("%+v", err))
+		}
+		return err
+	}
+	return ctx.String(http.StatusOK, "OK")
+}
+
+func (r *Rest) GetChainArchive(ctx echo.Context) error {
+	c := ctx.Get("chain").(*Chain)
+	height, err := strconv.ParseInt(ctx.Param(ParamHeight), 0, 64)
+	if err != nil || height < 0 {
+		return echo.ErrBadRequest
+	}
+	si, cs, err := r.n.GetChainArchive(c.CID(), height)
+	if err != nil {
+		if errors.NotFoundError.Equals(err) {
+			return ctx.String(http.StatusNotFound, fmt.Sprintf("%+v", err))
+		}
+		return err
+	}
+	v := &ChainArchiveView{
+		Height:             height,
+		BlockID:            si.BlockID,
+		Result:             si.Result,
+		NextValidatorsHash: si.NextValidatorsHash,
+		Changes:            make([]AccountChangeView, 0, len(cs.Accounts)),
+	}
+	for _, ac := range cs.Accounts {
+		acv := AccountChangeView{Key: ac.Key, Removed: ac.Removed}
+		for _, key := range ac.Storage {
+			acv.Storage = append(acv.Storage, key)
+		}
+		v.Changes = append(v.Changes, acv)
+	}
+	return ctx.JSON(http.StatusOK, v)
+}
+
+func (r *Rest) GetChainAccountHistory(ctx echo.Context) error {
+	c := ctx.Get("chain").(*Chain)
+	addr, err := common.NewAddressFromString(ctx.Param(ParamAddress))
+	if err != nil {
+		return echo.ErrBadRequest
+	}
+	var from, to int64
+	if param := ctx.QueryParam("from"); len(param) > 0 {
+		if from, err = strconv.ParseInt(param, 0, 64); err != nil {
+			return echo.ErrBadRequest
+		}
+	}
+	if param := ctx.QueryParam("to"); len(param) > 0 {
+		if to, err = strconv.ParseInt(param, 0, 64); err != nil {
+			return echo.ErrBadRequest
+		}
+	}
+	heights, err := r.n.GetChainAccountHistory(c.CID(), addr, from, to)
+	if err != nil {
+		return err
+	}
+	if heights == nil {
+		heights = []int64{}
+	}
+	return ctx.JSON(http.StatusOK, &AccountHistoryView{Address: addr, Heights: heights})
+}
+
 func (r *Rest) GetChainGenesis(ctx echo.Context) error {
 	c := ctx.Get("chain").(*Chain)
 	gsFile := path.Join(c.cfg.AbsBaseDir(), ChainGenesisZipFileName)
@@ -472,6 +665,7 @@ func (r *Rest) RegisterSystemHandlers(g *echo.Group) {
 	g.GET("", r.GetSystem)
 	g.GET("/configure", r.GetSystemConfig)
 	g.POST("/configure", r.ConfigureSystem)
+	g.GET("/executors", r.GetExecutors)
 	r.RegistryBackupHandlers(g.Group("/backup"))
 	r.RegistryRestoreHandlers(g.Group("/restore"))
 }
@@ -511,6 +705,34 @@ func (r *Rest) ConfigureSystem(ctx echo.Context) error {
 	return ctx.String(http.StatusOK, "OK")
 }
 
+func (r *Rest) GetExecutors(ctx echo.Context) error {
+	stats := r.n.pm.Stats()
+	v := make([]*EngineView, 0, len(stats))
+	for _, es := range stats {
+		ev := &EngineView{
+			Type:      es.Type,
+			Active:    es.Active,
+			Restarts:  es.Restarts,
+			Executors: make([]ExecutorView, 0, len(es.Executors)),
+		}
+		for _, s := range es.Executors {
+			ev.Executors = append(ev.Executors, ExecutorView{
+				UID:            s.UID,
+				Version:        s.Version,
+				State:          s.State,
+				Since:          s.Since,
+				Calls:          s.Calls,
+				AverageLatency: s.AverageLatency.String(),
+				LastActive:     s.LastActive,
+				PingRTT:        s.PingRTT.String(),
+				Memory:         s.Memory,
+			})
+		}
+		v = append(v, ev)
+	}
+	return ctx.JSON(http.StatusOK, v)
+}
+
 func (r *Rest) RegistryBackupHandlers(g *echo.Group) {
 	g.GET("", r.GetBackups)
 }
@@ -18,13 +18,17 @@ package node
 
 import (
 	"archive/zip"
+	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"sync"
 
 	"github.com/icon-project/goloop/chain"
+	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/codec"
 	"github.com/icon-project/goloop/common/errors"
 )
@@ -102,35 +106,27 @@ func (m *RestoreManager) Start(node *Node, file string, baseDir string, overwrit
 		}
 	}()
 
-	zr, err := zip.OpenReader(file)
+	zrs, info, err := openBackupChain(file)
 	if err != nil {
-		return errors.IllegalArgumentError.Wrapf(err,
-			"ZipOpenFailure(backup=%s)", file)
+		return err
 	}
 	defer func() {
 		if ret != nil {
-			zr.Close()
+			closeBackupChain(zrs)
 		}
 	}()
 
-	info, err := chain.ReadBackupInfo(&zr.Reader)
-	if err != nil {
-		return errors.IllegalArgumentError.Wrap(err,
-			"InvalidBackupInfo")
-	}
-
-	if info.Codec != codec.BC.Name() {
-		return errors.IllegalArgumentError.Errorf(
-			"IncompatibleCodec(backup=%s,system=%s)",
-			info.Codec, codec.BC.Name())
-	}
-
 	if err := node.CanAdd(int(info.CID.Value), int(info.NID.Value), info.Channel, overwrite); err != nil {
 		return err
 	}
 
+	total := 0
+	for _, zr := range zrs {
+		total += len(zr.File)
+	}
+
 	go func() {
-		if err := m._restore(node, zr, tmpDir, overwrite); err != nil {
+		if err := m._restore(node, zrs, tmpDir, overwrite); err != nil {
 			node.logger.Debugf("Restore failed err=%+v", err)
 			if errors.InterruptedError.Equals(err) {
 				m._setState(RestoreNone, nil)
@@ -146,10 +142,86 @@ func (m *RestoreManager) Start(node *Node, file string, baseDir string, overwrit
 	m.overwrite = overwrite
 	m.state = RestoreStarted
 	m.current = 0
-	m.total = len(zr.File)
+	m.total = total
 	return nil
 }
 
+func openBackup(file string) (*zip.ReadCloser, *chain.BackupInfo, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, nil, errors.IllegalArgumentError.Wrapf(err,
+			"ZipOpenFailure(backup=%s)", file)
+	}
+	info, err := chain.ReadBackupInfo(&zr.Reader)
+	if err != nil {
+		zr.Close()
+		return nil, nil, errors.IllegalArgumentError.Wrap(err,
+			"InvalidBackupInfo")
+	}
+
+	if info.Codec != codec.BC.Name() {
+		zr.Close()
+		return nil, nil, errors.IllegalArgumentError.Errorf(
+			"IncompatibleCodec(backup=%s,system=%s)",
+			info.Codec, codec.BC.Name())
+	}
+	return zr, info, nil
+}
+
+// openBackupChain opens the backup and its bases in the same directory.
+// It returns them in the order to apply, from the full backup to the given
+// one, with the information of the given one.
+func openBackupChain(file string) (zrs []*zip.ReadCloser, info *chain.BackupInfo, ret error) {
+	defer func() {
+		if ret != nil {
+			closeBackupChain(zrs)
+		}
+	}()
+	zr, info, err := openBackup(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	zrs = append(zrs, zr)
+	visited := map[string]bool{path.Base(file): true}
+	for child := info; child.Base != ""; {
+		base := child.Base
+		if visited[base] {
+			return zrs, nil, errors.IllegalArgumentError.Errorf(
+				"CyclicBackupChain(base=%s)", base)
+		}
+		visited[base] = true
+		bzr, binfo, err := openBackup(path.Join(path.Dir(file), base))
+		if err != nil {
+			return zrs, nil, err
+		}
+		zrs = append([]*zip.ReadCloser{bzr}, zrs...)
+		if binfo.CID != info.CID || binfo.NID != info.NID || binfo.Channel != info.Channel {
+			return zrs, nil, errors.IllegalArgumentError.Errorf(
+				"InvalidBase(base=%s)", base)
+		}
+		// the base should be the one used for the backup, and it should
+		// have the list of files matching with its ID.
+		id, err := chain.BackupIDOf(&bzr.Reader)
+		if err != nil {
+			return zrs, nil, err
+		}
+		if len(child.BaseID) == 0 || !bytes.Equal(child.BaseID, binfo.ID) ||
+			!bytes.Equal(id, binfo.ID) {
+			return zrs, nil, errors.IllegalArgumentError.Errorf(
+				"BaseMismatch(base=%s,expected=%s,id=%s)",
+				base, child.BaseID, common.HexBytes(id))
+		}
+		child = binfo
+	}
+	return zrs, info, nil
+}
+
+func closeBackupChain(zrs []*zip.ReadCloser) {
+	for _, zr := range zrs {
+		zr.Close()
+	}
+}
+
 func (m *RestoreManager) _onRestored(idx int) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -221,6 +293,10 @@ func zipExtract(file *zip.File, tmpDir string) (ret error) {
 	if err := os.MkdirAll(path.Dir(target), 0755); err != nil {
 		return err
 	}
+	// increments overwrite files of the base.
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 
 	fd, err := os.OpenFile(target,
 		os.O_CREATE|os.O_EXCL|os.O_RDWR|os.O_TRUNC, mode.Perm())
@@ -234,19 +310,57 @@ func zipExtract(file *zip.File, tmpDir string) (ret error) {
 	return err
 }
 
-func (m *RestoreManager) _restore(node *Node, zr *zip.ReadCloser, tmpDir string, overwrite bool) (ret error) {
+// removeUnlisted removes files not in the list, which were removed after
+// the base backup.
+func removeUnlisted(tmpDir string, files []chain.BackupFile) error {
+	listed := make(map[string]bool, len(files))
+	for _, f := range files {
+		listed[f.Name] = true
+	}
+	return filepath.WalkDir(tmpDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		name, err := filepath.Rel(tmpDir, p)
+		if err != nil {
+			return err
+		}
+		if !listed[filepath.ToSlash(name)] {
+			return os.Remove(p)
+		}
+		return nil
+	})
+}
+
+func (m *RestoreManager) _restore(node *Node, zrs []*zip.ReadCloser, tmpDir string, overwrite bool) (ret error) {
 	defer func() {
 		if ret != nil {
 			os.RemoveAll(tmpDir)
 		}
 	}()
-	defer zr.Close()
-
-	for idx, file := range zr.File {
-		if err := zipExtract(file, tmpDir); err != nil {
-			return err
+	defer closeBackupChain(zrs)
+
+	idx := 0
+	for _, zr := range zrs {
+		for _, file := range zr.File {
+			if file.Name != chain.BackupFileList {
+				if err := zipExtract(file, tmpDir); err != nil {
+					return err
+				}
+			}
+			if err := m._onRestored(idx); err != nil {
+				return err
+			}
+			idx += 1
 		}
-		if err := m._onRestored(idx); err != nil {
+	}
+
+	files, err := chain.ReadBackupFiles(&zrs[len(zrs)-1].Reader)
+	if err != nil {
+		return err
+	}
+	if files != nil {
+		if err := removeUnlisted(tmpDir, files); err != nil {
 			return err
 		}
 	}
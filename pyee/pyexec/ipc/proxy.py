@@ -12,6 +12,7 @@
 # See the License for the specific language governing permissions and
 # limitations under the License.
 
+import resource
 import traceback
 from abc import ABCMeta, abstractmethod
 from typing import Any, Tuple, List, Union, Callable, Optional
@@ -50,6 +51,7 @@ class Message(object):
     CLOSE = 11
     SETFEEPCT = 15
     CONTAINS = 16
+    PING = 17
 
 
 class InvokeFlag(object):
@@ -415,6 +417,11 @@ class ServiceManagerProxy:
             self.debug(f"Exception in GETAPI:\n{e_str}", TAG)
             self.__client.send(Message.GETAPI, [Status.SYSTEM_FAILURE, None])
 
+    def __handle_ping(self, data):
+        # ru_maxrss is in kilobytes on linux
+        memory = resource.getrusage(resource.RUSAGE_SELF).ru_maxrss * 1024
+        self.__client.send(Message.PING, [data, memory])
+
     def loop(self):
         while True:
             msg, data = self.__client.receive()
@@ -422,6 +429,8 @@ class ServiceManagerProxy:
                 self.__handle_invoke(data)
             elif msg == Message.GETAPI:
                 self.__handle_get_api(data)
+            elif msg == Message.PING:
+                self.__handle_ping(data)
             elif msg == Message.CLOSE:
                 return
 
@@ -27,7 +27,7 @@ from .logger import Logger
 from .service_engine import ServiceEngine
 
 TAG = 'PyExec'
-version_number = 1
+version_number = 2
 
 
 class EECodec(Codec):
@@ -0,0 +1,46 @@
+package metric
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	msOptimisticTxs       = stats.Int64("execution_optimistic_txs", "transactions executed optimistically in a block", stats.UnitDimensionless)
+	msOptimisticConflicts = stats.Int64("execution_optimistic_conflicts", "transactions having conflicts in a block", stats.UnitDimensionless)
+	msOptimisticReexecs   = stats.Int64("execution_optimistic_reexecutions", "transactions executed again in a block", stats.UnitDimensionless)
+	msHotAccount          = stats.Int64("execution_hot_account", "conflicts on the most conflicting account in a block", stats.UnitDimensionless)
+	executionMks          = []tag.Key{}
+)
+
+func RegisterExecution() {
+	RegisterMetricView(msOptimisticTxs, view.LastValue(), executionMks)
+	RegisterMetricView(msOptimisticConflicts, view.LastValue(), executionMks)
+	RegisterMetricView(msOptimisticReexecs, view.LastValue(), executionMks)
+	RegisterMetricView(msHotAccount, view.LastValue(), executionMks)
+}
+
+type ExecutionMetric struct {
+	ctx context.Context
+}
+
+// OnOptimisticExecution records statistics of the optimistic execution of a
+// block. hot is the number of conflicts on the most conflicting account.
+// Accounts aren't used for tags to bound the number of series, so they are
+// left in the log.
+func (m *ExecutionMetric) OnOptimisticExecution(txs, conflicts, reexecs, hot int) {
+	stats.Record(m.ctx,
+		msOptimisticTxs.M(int64(txs)),
+		msOptimisticConflicts.M(int64(conflicts)),
+		msOptimisticReexecs.M(int64(reexecs)),
+		msHotAccount.M(int64(hot)))
+}
+
+func NewExecutionMetric(ctx context.Context) *ExecutionMetric {
+	return &ExecutionMetric{
+		ctx: ctx,
+	}
+}
@@ -0,0 +1,81 @@
+package metric
+
+import (
+	"context"
+	"sync"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	msNodeCacheLimit  = stats.Int64("nodecache_limit", "memory budget of node caches and API info cache", stats.UnitBytes)
+	msNodeCacheBytes  = stats.Int64("nodecache_bytes", "bytes of entries in node caches and API info cache", stats.UnitBytes)
+	msNodeCacheHits   = stats.Int64("nodecache_hits", "number of node cache hits", stats.UnitDimensionless)
+	msNodeCacheMisses = stats.Int64("nodecache_misses", "number of node cache misses", stats.UnitDimensionless)
+	msNodeCacheEvicts = stats.Int64("nodecache_evicts", "number of evicted entries", stats.UnitDimensionless)
+	nodeCacheMks      = []tag.Key{}
+
+	ncms    = make(map[*NodeCacheMetric]struct{})
+	ncmsMtx sync.Mutex
+)
+
+func RegisterNodeCache() {
+	RegisterMetricView(msNodeCacheLimit, view.LastValue(), nodeCacheMks)
+	RegisterMetricView(msNodeCacheBytes, view.LastValue(), nodeCacheMks)
+	RegisterMetricView(msNodeCacheHits, view.LastValue(), nodeCacheMks)
+	RegisterMetricView(msNodeCacheMisses, view.LastValue(), nodeCacheMks)
+	RegisterMetricView(msNodeCacheEvicts, view.LastValue(), nodeCacheMks)
+
+	RegisterBeforeExportFunc(func() {
+		ncmsMtx.Lock()
+		defer ncmsMtx.Unlock()
+		for m := range ncms {
+			m.record()
+		}
+	})
+}
+
+type NodeCacheStat struct {
+	Limit  int64
+	Bytes  int64
+	Hits   int64
+	Misses int64
+	Evicts int64
+}
+
+// NodeCacheMetric records statistics of node caches of the chain before
+// metrics are exported. Counters are cumulative since the caches are made.
+type NodeCacheMetric struct {
+	ctx  context.Context
+	stat func() NodeCacheStat
+}
+
+func (m *NodeCacheMetric) record() {
+	s := m.stat()
+	stats.Record(m.ctx,
+		msNodeCacheLimit.M(s.Limit),
+		msNodeCacheBytes.M(s.Bytes),
+		msNodeCacheHits.M(s.Hits),
+		msNodeCacheMisses.M(s.Misses),
+		msNodeCacheEvicts.M(s.Evicts))
+}
+
+// Close stops recording statistics.
+func (m *NodeCacheMetric) Close() {
+	ncmsMtx.Lock()
+	defer ncmsMtx.Unlock()
+	delete(ncms, m)
+}
+
+func NewNodeCacheMetric(ctx context.Context, stat func() NodeCacheStat) *NodeCacheMetric {
+	m := &NodeCacheMetric{
+		ctx:  ctx,
+		stat: stat,
+	}
+	ncmsMtx.Lock()
+	defer ncmsMtx.Unlock()
+	ncms[m] = struct{}{}
+	return m
+}
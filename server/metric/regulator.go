@@ -0,0 +1,50 @@
+package metric
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	msBlockInterval = stats.Int64("regulator_block_interval", "block_interval", stats.UnitMilliseconds)
+	msMaxTxCount    = stats.Int64("regulator_max_tx_count", "max_tx_count", stats.UnitDimensionless)
+	msBlockLoad     = stats.Int64("regulator_block_load", "block_load", stats.UnitDimensionless)
+	msAdjust        = stats.Int64("regulator_adjust", "adjust", stats.UnitDimensionless)
+	mkDecision      = NewMetricKey("decision")
+	regulatorMks    = []tag.Key{}
+	adjustMks       = []tag.Key{mkDecision}
+)
+
+func RegisterRegulator() {
+	RegisterMetricView(msBlockInterval, view.LastValue(), regulatorMks)
+	RegisterMetricView(msMaxTxCount, view.LastValue(), regulatorMks)
+	RegisterMetricView(msBlockLoad, view.LastValue(), regulatorMks)
+	RegisterMetricView(msAdjust, view.Count(), adjustMks)
+}
+
+type RegulatorMetric struct {
+	ctx context.Context
+}
+
+// OnAdjust records the decision of the regulator. load is the ratio of
+// the number of transactions in the last block to the number of transactions
+// executable within the target block interval.
+func (m *RegulatorMetric) OnAdjust(interval time.Duration, maxTxCount int, load float64, decision string) {
+	stats.Record(m.ctx,
+		msBlockInterval.M(int64(interval/time.Millisecond)),
+		msMaxTxCount.M(int64(maxTxCount)),
+		msBlockLoad.M(int64(load*100)))
+	if len(decision) > 0 {
+		stats.Record(GetMetricContext(m.ctx, &mkDecision, decision), msAdjust.M(1))
+	}
+}
+
+func NewRegulatorMetric(ctx context.Context) *RegulatorMetric {
+	return &RegulatorMetric{
+		ctx: ctx,
+	}
+}
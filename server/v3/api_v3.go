@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -38,6 +39,7 @@ func MethodRepository(mtr *metric.JsonrpcMetric) *jsonrpc.MethodRepository {
 	mr.RegisterMethod("icx_getBlockByHash", getBlockByHash)
 	mr.RegisterMethod("icx_call", call)
 	mr.RegisterMethod("icx_getBalance", getBalance)
+	mr.RegisterMethod("icx_getTokenBalance", getTokenBalance)
 	mr.RegisterMethod("icx_getScoreApi", getScoreApi)
 	mr.RegisterMethod("icx_getTotalSupply", getTotalSupply)
 	mr.RegisterMethod("icx_getTransactionResult", getTransactionResult)
@@ -53,6 +55,7 @@ func MethodRepository(mtr *metric.JsonrpcMetric) *jsonrpc.MethodRepository {
 	mr.RegisterMethod("icx_getProofForEvents", getProofForEvents)
 	mr.RegisterMethod("icx_getScoreStatus", getScoreStatus)
 	mr.RegisterMethod("icx_getNetworkInfo", getNetworkInfo)
+	mr.RegisterMethod("icx_getTypedDataHash", getTypedDataHash)
 
 	mr.RegisterMethod("btp_getNetworkInfo", getBTPNetworkInfo)
 	mr.RegisterMethod("btp_getNetworkTypeInfo", getBTPNetworkTypeInfo)
@@ -350,6 +353,37 @@ func getBalance(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, erro
 	return &balance, nil
 }
 
+func getTokenBalance(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	var c contextWithSM
+	if err := c.Init(ctx); err != nil {
+		return nil, err
+	}
+
+	var param TokenBalanceParam
+	if err := params.Convert(&param); err != nil {
+		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, c.debug)
+	}
+	id, err := param.ID.Int64()
+	if err != nil {
+		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, c.debug)
+	}
+
+	var balance common.HexInt
+	blk, err := c.GetBlockByHeight(param.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := c.sm.GetTokenBalance(blk.Result(), id, param.Address.Address())
+	if errors.NotFoundError.Equals(err) {
+		return nil, jsonrpc.ErrorCodeNotFound.Wrap(err, c.debug)
+	} else if err != nil {
+		return nil, jsonrpc.ErrorCodeSystem.Wrap(err, c.debug)
+	}
+	balance.Set(b)
+	return &balance, nil
+}
+
 func getScoreApi(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
 	var c contextWithSM
 	if err := c.Init(ctx); err != nil {
@@ -490,14 +524,34 @@ func getTransactionByHash(ctx *jsonrpc.Context, params *jsonrpc.Params) (interfa
 	return result, nil
 }
 
+// transactionParamOf returns the parameter for the version of the transaction.
+// Transactions of version 4 are sent by the contracts with the proof instead
+// of the signature.
+func transactionParamOf(params *jsonrpc.Params, forEstimate bool) interface{} {
+	var header struct {
+		Version jsonrpc.HexInt `json:"version"`
+	}
+	if err := json.Unmarshal(params.RawMessage(), &header); err == nil {
+		if v, err := header.Version.Int64(); err == nil && v == module.TransactionVersion4 {
+			if forEstimate {
+				return new(ContractTransactionParamForEstimate)
+			}
+			return new(ContractTransactionParam)
+		}
+	}
+	if forEstimate {
+		return new(TransactionParamForEstimate)
+	}
+	return new(TransactionParam)
+}
+
 func sendTransaction(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
 	var c contextWithSM
 	if err := c.Init(ctx); err != nil {
 		return nil, err
 	}
 
-	var param TransactionParam
-	if err := params.Convert(&param); err != nil {
+	if err := params.Convert(transactionParamOf(params, false)); err != nil {
 		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, c.debug)
 	}
 
@@ -729,6 +783,27 @@ func getScoreStatus(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{},
 	return jso, nil
 }
 
+func getTypedDataHash(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	var c contextWithChain
+	if err := c.Init(ctx); err != nil {
+		return nil, err
+	}
+	var param TypedDataParam
+	if err := params.Convert(&param); err != nil {
+		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, c.debug)
+	}
+	if nid, err := param.Data.NID(); err != nil {
+		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, c.debug)
+	} else if nid != int64(c.chain.NID()) {
+		return nil, jsonrpc.ErrorCodeInvalidParams.Errorf("InvalidNID(nid=%#x)", nid)
+	}
+	digest, err := param.Data.Digest()
+	if err != nil {
+		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, c.debug)
+	}
+	return "0x" + hex.EncodeToString(digest), nil
+}
+
 type NetworkInfo struct {
 	Platform  string         `json:"platform"`
 	NID       jsonrpc.HexInt `json:"nid"`
@@ -1021,8 +1096,7 @@ func sendTransactionAndWait(ctx *jsonrpc.Context, params *jsonrpc.Params) (inter
 		maxLimit = true
 	}
 
-	var param TransactionParam
-	if err := params.Convert(&param); err != nil {
+	if err := params.Convert(transactionParamOf(params, false)); err != nil {
 		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, c.debug)
 	}
 
@@ -1241,8 +1315,7 @@ func estimateStep(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, er
 		return nil, err
 	}
 
-	var param TransactionParamForEstimate
-	if err := params.Convert(&param); err != nil {
+	if err := params.Convert(transactionParamOf(params, true)); err != nil {
 		return nil, jsonrpc.ErrorCodeInvalidParams.Wrap(err, c.debug)
 	}
 
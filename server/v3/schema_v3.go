@@ -1,6 +1,7 @@
 package v3
 
 import (
+	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/common/intconv"
 	"github.com/icon-project/goloop/server/jsonrpc"
 )
@@ -38,11 +39,21 @@ type AddressParam struct {
 	Height  jsonrpc.HexInt  `json:"height,omitempty" validate:"optional,t_int"`
 }
 
+type TokenBalanceParam struct {
+	ID      jsonrpc.HexInt  `json:"id" validate:"required,t_int"`
+	Address jsonrpc.Address `json:"address" validate:"required,t_addr"`
+	Height  jsonrpc.HexInt  `json:"height,omitempty" validate:"optional,t_int"`
+}
+
 type ScoreAddressParam struct {
 	Address jsonrpc.Address `json:"address" validate:"required,t_addr_score"`
 	Height  jsonrpc.HexInt  `json:"height,omitempty" validate:"optional,t_int"`
 }
 
+type TypedDataParam struct {
+	Data *crypto.TypedData `json:"data" validate:"required"`
+}
+
 type TransactionHashParam struct {
 	Hash jsonrpc.HexBytes `json:"txHash" validate:"required,t_hash"`
 }
@@ -55,21 +66,49 @@ type TransactionParamForEstimate struct {
 	Timestamp   jsonrpc.HexInt  `json:"timestamp" validate:"required,t_int"`
 	NetworkID   jsonrpc.HexInt  `json:"nid" validate:"required,t_int"`
 	Nonce       jsonrpc.HexInt  `json:"nonce,omitempty" validate:"optional,t_int"`
-	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit"`
+	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit|batch"`
 	Data        interface{}     `json:"data,omitempty"`
 }
 
 type TransactionParam struct {
+	Version     jsonrpc.HexInt   `json:"version" validate:"required,t_int"`
+	FromAddress jsonrpc.Address  `json:"from" validate:"required,t_addr_eoa"`
+	ToAddress   jsonrpc.Address  `json:"to" validate:"required,t_addr"`
+	Value       jsonrpc.HexInt   `json:"value,omitempty" validate:"optional,t_int"`
+	StepLimit   jsonrpc.HexInt   `json:"stepLimit" validate:"required,t_int"`
+	Timestamp   jsonrpc.HexInt   `json:"timestamp" validate:"required,t_int"`
+	NetworkID   jsonrpc.HexInt   `json:"nid" validate:"required,t_int"`
+	Nonce       jsonrpc.HexInt   `json:"nonce,omitempty" validate:"optional,t_int"`
+	Signature   string           `json:"signature" validate:"required,t_sig"`
+	KeyType     string           `json:"keyType,omitempty" validate:"optional,oneof=ed25519"`
+	PublicKey   jsonrpc.HexBytes `json:"publicKey,omitempty" validate:"required_with=KeyType"`
+	DataType    string           `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit|batch"`
+	Data        interface{}      `json:"data,omitempty"`
+}
+
+type ContractTransactionParamForEstimate struct {
 	Version     jsonrpc.HexInt  `json:"version" validate:"required,t_int"`
-	FromAddress jsonrpc.Address `json:"from" validate:"required,t_addr_eoa"`
+	FromAddress jsonrpc.Address `json:"from" validate:"required,t_addr_score"`
+	ToAddress   jsonrpc.Address `json:"to" validate:"required,t_addr"`
+	Value       jsonrpc.HexInt  `json:"value,omitempty" validate:"optional,t_int"`
+	Timestamp   jsonrpc.HexInt  `json:"timestamp" validate:"required,t_int"`
+	NetworkID   jsonrpc.HexInt  `json:"nid" validate:"required,t_int"`
+	Nonce       jsonrpc.HexInt  `json:"nonce,omitempty" validate:"optional,t_int"`
+	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit|batch"`
+	Data        interface{}     `json:"data,omitempty"`
+}
+
+type ContractTransactionParam struct {
+	Version     jsonrpc.HexInt  `json:"version" validate:"required,t_int"`
+	FromAddress jsonrpc.Address `json:"from" validate:"required,t_addr_score"`
 	ToAddress   jsonrpc.Address `json:"to" validate:"required,t_addr"`
 	Value       jsonrpc.HexInt  `json:"value,omitempty" validate:"optional,t_int"`
 	StepLimit   jsonrpc.HexInt  `json:"stepLimit" validate:"required,t_int"`
 	Timestamp   jsonrpc.HexInt  `json:"timestamp" validate:"required,t_int"`
 	NetworkID   jsonrpc.HexInt  `json:"nid" validate:"required,t_int"`
 	Nonce       jsonrpc.HexInt  `json:"nonce,omitempty" validate:"optional,t_int"`
-	Signature   string          `json:"signature" validate:"required,t_sig"`
-	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit"`
+	Proof       string          `json:"proof" validate:"required"`
+	DataType    string          `json:"dataType,omitempty" validate:"optional,call|deploy|message|deposit|batch"`
 	Data        interface{}     `json:"data,omitempty"`
 }
 
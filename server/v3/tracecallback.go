@@ -143,3 +143,12 @@ func (t *traceCallback) OnBalanceChange(opType module.OpType, from, to module.Ad
 	}
 	return nil
 }
+
+func (t *traceCallback) OnTokenBalanceChange(opType module.OpType, token int64, from, to module.Address, amount *big.Int) error {
+	if t.bt != nil {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		return t.bt.OnTokenBalanceChange(opType, token, from, to, amount)
+	}
+	return nil
+}
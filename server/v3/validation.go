@@ -12,7 +12,8 @@ import (
 
 var (
 	hexString          = regexp.MustCompile("^0x[0-9a-f]+$")
-	deployContentTypes = []string{"application/zip", "application/java"}
+	proofString        = regexp.MustCompile("^0x([0-9a-f]{2})*$")
+	deployContentTypes = []string{"application/zip", "application/java", "application/wasm"}
 )
 
 func RegisterValidationRule(v *jsonrpc.Validator) {
@@ -21,9 +22,10 @@ func RegisterValidationRule(v *jsonrpc.Validator) {
 	v.RegisterValidation("deploy", isDeploy)
 	v.RegisterValidation("message", isMessage)
 	v.RegisterValidation("deposit", isDeposit)
+	v.RegisterValidation("batch", isBatch)
 
-	// validate : CallParam.Data, TransactionParam.Data
-	v.RegisterStructValidation(DataParamValidation, CallParam{}, TransactionParam{})
+	// validate : CallParam.Data, TransactionParam.Data, ContractTransactionParam.Data
+	v.RegisterStructValidation(DataParamValidation, CallParam{}, TransactionParam{}, ContractTransactionParam{})
 
 }
 
@@ -43,6 +45,10 @@ func isDeposit(fl validator.FieldLevel) bool {
 	return fl.Field().String() == contract.DataTypeDeposit
 }
 
+func isBatch(fl validator.FieldLevel) bool {
+	return fl.Field().String() == contract.DataTypeBatch
+}
+
 func DataParamValidation(sl validator.StructLevel) {
 	switch sl.Current().Interface().(type) {
 	case CallParam:
@@ -54,34 +60,50 @@ func DataParamValidation(sl validator.StructLevel) {
 		}
 	case TransactionParam:
 		txParam := sl.Current().Interface().(TransactionParam)
-		if txParam.DataType != "" {
-			switch txParam.DataType {
-			case contract.DataTypeCall:
-				if data, ok := txParam.Data.(map[string]interface{}); ok {
-					validateCallDataParam(sl, txParam.Data, data)
-				} else {
-					sl.ReportError(txParam.Data, "Data", "", "data", "")
-				}
-			case contract.DataTypeDeploy:
-				if data, ok := txParam.Data.(map[string]interface{}); ok {
-					validateDeployDataParam(sl, txParam.Data, data)
-				} else {
-					sl.ReportError(txParam.Data, "Data", "", "data", "")
-				}
-			case contract.DataTypeMessage:
-				if data, ok := txParam.Data.(string); ok {
-					if !hexString.MatchString(data) {
-						sl.ReportError(txParam.Data, "Data", "", "data", "")
-					}
-				} else {
-					sl.ReportError(txParam.Data, "Data", "", "data", "")
-				}
-			case contract.DataTypeDeposit:
-				if data, ok := txParam.Data.(map[string]interface{}); ok {
-					validateDepositDataParam(sl, txParam.Data, data)
-				} else {
-					sl.ReportError(txParam.Data, "Data", "", "data", "")
+		validateTxDataParam(sl, txParam.DataType, txParam.Data)
+	case ContractTransactionParam:
+		txParam := sl.Current().Interface().(ContractTransactionParam)
+		if !proofString.MatchString(txParam.Proof) {
+			sl.ReportError(txParam.Proof, "Proof", "", "proof", "")
+		}
+		validateTxDataParam(sl, txParam.DataType, txParam.Data)
+	}
+}
+
+func validateTxDataParam(sl validator.StructLevel, dataType string, data interface{}) {
+	if dataType != "" {
+		switch dataType {
+		case contract.DataTypeCall:
+			if obj, ok := data.(map[string]interface{}); ok {
+				validateCallDataParam(sl, data, obj)
+			} else {
+				sl.ReportError(data, "Data", "", "data", "")
+			}
+		case contract.DataTypeDeploy:
+			if obj, ok := data.(map[string]interface{}); ok {
+				validateDeployDataParam(sl, data, obj)
+			} else {
+				sl.ReportError(data, "Data", "", "data", "")
+			}
+		case contract.DataTypeMessage:
+			if obj, ok := data.(string); ok {
+				if !hexString.MatchString(obj) {
+					sl.ReportError(data, "Data", "", "data", "")
 				}
+			} else {
+				sl.ReportError(data, "Data", "", "data", "")
+			}
+		case contract.DataTypeDeposit:
+			if obj, ok := data.(map[string]interface{}); ok {
+				validateDepositDataParam(sl, data, obj)
+			} else {
+				sl.ReportError(data, "Data", "", "data", "")
+			}
+		case contract.DataTypeBatch:
+			if obj, ok := data.([]interface{}); ok && len(obj) > 0 {
+				validateBatchDataParam(sl, data, obj)
+			} else {
+				sl.ReportError(data, "Data", "", "data", "")
 			}
 		}
 	}
@@ -121,6 +143,37 @@ func validateCallDataParam(sl validator.StructLevel, field interface{}, data map
 	}
 }
 
+func validateBatchDataParam(sl validator.StructLevel, field interface{}, data []interface{}) {
+	for i, item := range data {
+		call, ok := item.(map[string]interface{})
+		if !ok {
+			sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d]", i), "")
+			continue
+		}
+		// data[i].to : required
+		if _, ok := call["to"]; !ok {
+			sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d].to", i), "")
+		}
+		// data[i].value : optional
+		if v, ok := call["value"]; ok && !isHexString(v) {
+			sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d].value", i), "")
+		}
+		// data[i].params : optional, only with data[i].method
+		if params, ok := call["params"]; ok {
+			if _, ok := call["method"]; !ok {
+				sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d].method", i), "")
+			}
+			if paramsMap, ok := params.(map[string]interface{}); ok {
+				for k, pv := range paramsMap {
+					validateRPCData(sl, fmt.Sprintf("Data[%d].params.%s", i, k), pv)
+				}
+			} else {
+				sl.ReportError(field, "Data", "", fmt.Sprintf("data[%d].params", i), "")
+			}
+		}
+	}
+}
+
 func isHexString(v interface{}) bool {
 	if v == nil {
 		return false
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/txresult"
+)
+
+// BatchCallLimit is the maximum number of calls in a batch.
+const BatchCallLimit = 64
+
+// BatchCallJSON is a call in the data of the batch transaction. A call
+// without method transfers the value to the address.
+type BatchCallJSON struct {
+	To     common.Address  `json:"to"`
+	Value  *common.HexInt  `json:"value,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+func ParseBatchData(data []byte) ([]*BatchCallJSON, error) {
+	var calls []*BatchCallJSON
+	jd := json.NewDecoder(bytes.NewBuffer(data))
+	jd.DisallowUnknownFields()
+	if err := jd.Decode(&calls); err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrapf(err,
+			"InvalidJSON(json=%s)", data)
+	}
+	if len(calls) == 0 || len(calls) > BatchCallLimit {
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"InvalidNumberOfCalls(n=%d)", len(calls))
+	}
+	for idx, call := range calls {
+		if call == nil {
+			return nil, scoreresult.InvalidParameterError.Errorf(
+				"NullCall(idx=%d)", idx)
+		}
+		if call.Value != nil && call.Value.Sign() < 0 {
+			return nil, scoreresult.InvalidParameterError.Errorf(
+				"InvalidValue(idx=%d,value=%s)", idx, call.Value)
+		}
+		if call.Method == "" && call.Params != nil {
+			return nil, scoreresult.InvalidParameterError.Errorf(
+				"ParamsWithoutMethod(idx=%d)", idx)
+		}
+	}
+	return calls, nil
+}
+
+type batchResult struct {
+	status   error
+	stepUsed *big.Int
+	result   *codec.TypedObj
+}
+
+// BatchHandler executes the calls of the batch in order. Each call is
+// executed in its own frame as an inter-call from the sender, and if one of
+// them fails, all changes of the batch are reverted.
+type BatchHandler struct {
+	*CommonHandler
+	calls   []*BatchCallJSON
+	results []*batchResult
+}
+
+func newBatchHandler(ch *CommonHandler, data []byte) (*BatchHandler, error) {
+	calls, err := ParseBatchData(data)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchHandler{CommonHandler: ch, calls: calls}, nil
+}
+
+func (h *BatchHandler) Prepare(ctx Context) (state.WorldContext, error) {
+	lq := []state.LockRequest{{state.WorldIDStr, state.AccountWriteLock}}
+	return ctx.GetFuture(lq), nil
+}
+
+func (h *BatchHandler) handlerFor(call *BatchCallJSON) (ContractHandler, error) {
+	value := big.NewInt(0)
+	if call.Value != nil {
+		value = &call.Value.Int
+	}
+	ch := NewCommonHandler(h.From, &call.To, value, true, h.Log)
+	if call.Method == "" {
+		if call.To.IsContract() {
+			fallback := newCallHandlerWithParams(ch, scoreapi.FallbackMethodName, nil, false)
+			return newTransferAndCallHandler(ch, fallback), nil
+		}
+		return newTransferHandler(ch), nil
+	}
+	data, err := json.Marshal(&DataCallJSON{Method: call.Method, Params: call.Params})
+	if err != nil {
+		return nil, err
+	}
+	handler, err := newCallHandlerWithData(ch, data)
+	if err != nil {
+		return nil, err
+	}
+	if value.Sign() == 1 {
+		return newTransferAndCallHandler(ch, handler), nil
+	}
+	return handler, nil
+}
+
+func (h *BatchHandler) ExecuteSync(cc CallContext) (err error, ro *codec.TypedObj, addr module.Address) {
+	h.Log.TSystemf("BATCH start from=%s calls=%d", h.From, len(h.calls))
+	defer func() {
+		if err != nil {
+			h.Log.TSystemf("BATCH done status=%s msg=%v", err.Error(), err)
+		}
+	}()
+
+	h.results = h.results[:0]
+	for idx, call := range h.calls {
+		handler, err := h.handlerFor(call)
+		if err != nil {
+			h.results = append(h.results, &batchResult{status: err, stepUsed: new(big.Int)})
+			return err, nil, nil
+		}
+		h.Log.TSystemf("BATCH call idx=%d to=%s method=%s", idx, &call.To, call.Method)
+		status, stepUsed, result, _ := cc.Call(handler, cc.StepAvailable())
+		cc.DeductSteps(stepUsed)
+		h.results = append(h.results, &batchResult{status, stepUsed, result})
+		if status != nil {
+			return status, nil, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// GetResults adds the results of the calls to the receipt. Results are added
+// until the first failure.
+func (h *BatchHandler) GetResults(r txresult.Receipt) {
+	for _, br := range h.results {
+		s, _ := scoreresult.StatusOf(br.status)
+		r.AddCallResult(s, br.stepUsed, br.result)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBatchData(t *testing.T) {
+	call := `{"to":"cx0000000000000000000000000000000000000001","method":"approve","params":{"amount":"0x10"}}`
+	tests := []struct {
+		name  string
+		data  string
+		calls int
+	}{
+		{"Calls", `[` + call + `,{"to":"hx0000000000000000000000000000000000000002","value":"0x1"}]`, 2},
+		{"Empty", `[]`, 0},
+		{"NotList", call, 0},
+		{"NullCall", `[null]`, 0},
+		{"UnknownField", `[{"to":"cx0000000000000000000000000000000000000001","data":"0x1"}]`, 0},
+		{"NegativeValue", `[{"to":"hx0000000000000000000000000000000000000002","value":"-0x1"}]`, 0},
+		{"ParamsWithoutMethod", `[{"to":"cx0000000000000000000000000000000000000001","params":{}}]`, 0},
+		{"TooManyCalls", `[` + strings.Repeat(call+",", BatchCallLimit) + call + `]`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, err := ParseBatchData([]byte(tt.data))
+			if tt.calls == 0 {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, calls, tt.calls)
+			assert.Equal(t, "approve", calls[0].Method)
+			assert.Equal(t, "", calls[1].Method)
+			assert.Equal(t, int64(1), calls[1].Value.Int64())
+		})
+	}
+}
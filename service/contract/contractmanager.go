@@ -79,6 +79,7 @@ const (
 	DataTypeDeploy  = "deploy"
 	DataTypeDeposit = "deposit"
 	DataTypePatch   = "patch"
+	DataTypeBatch   = "batch"
 	DataTypeDSR     = "dsr"		// for double sign report(DSR)
 )
 
@@ -201,6 +202,8 @@ func (cm *contractManager) GetHandler(from, to module.Address, value *big.Int, c
 		return newPatchHandler(ch, data)
 	case CTypeDeposit:
 		return newDepositHandler(ch, data)
+	case CTypeBatch:
+		return newBatchHandler(ch, data)
 	}
 	return handler, nil
 }
@@ -352,6 +355,7 @@ func NewContractManager(db db.Database, contractDir string, log log.Logger) (Con
 
 const (
 	javaCode               = "code.jar"
+	wasmCode               = "code.wasm"
 	tmpRoot                = "tmp"
 	tmpPattern             = "tmp-*"
 	contractPythonRootFile = "package.json"
@@ -435,12 +439,20 @@ func storePython(dst string, code []byte, log log.Logger) (ret error) {
 }
 
 func storeJava(path string, code []byte, log log.Logger) error {
+	return storeFile(path, javaCode, code)
+}
+
+func storeWasm(path string, code []byte, log log.Logger) error {
+	return storeFile(path, wasmCode, code)
+}
+
+func storeFile(path string, name string, code []byte) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		if err = os.MkdirAll(path, 0755); err != nil {
 			return errors.WithCode(err, errors.CriticalIOError)
 		}
 	}
-	sPath := filepath.Join(path, javaCode)
+	sPath := filepath.Join(path, name)
 	if err := os.WriteFile(sPath, code, 0755); err != nil {
 		_ = os.RemoveAll(sPath)
 		return errors.WithCode(err, errors.CriticalIOError)
@@ -455,6 +467,8 @@ func storeByEEType(e state.EEType, path string, code []byte, log log.Logger) err
 		err = storePython(path, code, log)
 	case state.JavaEE:
 		err = storeJava(path, code, log)
+	case state.WasmEE:
+		err = storeWasm(path, code, log)
 	default:
 		err = scoreresult.Errorf(module.StatusInvalidParameter,
 			"UnexpectedEEType(%v)\n", e)
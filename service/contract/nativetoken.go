@@ -0,0 +1,387 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contract
+
+import (
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// Events of native tokens emitted by the system account. Identifier of the
+// token and addresses are indexed.
+const (
+	EventTokenCreated  = "TokenCreated(int,Address,str,str,int)"
+	EventTokenMint     = "TokenMint(int,Address,int)"
+	EventTokenBurn     = "TokenBurn(int,Address,int)"
+	EventTokenTransfer = "TokenTransfer(int,Address,Address,int)"
+	EventTokenApproval = "TokenApproval(int,Address,Address,int)"
+)
+
+const (
+	TokenNameLimit   = 64
+	TokenSymbolLimit = 16
+	TokenDecimalsMax = 72
+)
+
+// Token is the native fungible token. Only the owner can mint or burn the
+// token.
+type Token struct {
+	Owner       *common.Address
+	Name        string
+	Symbol      string
+	Decimals    int
+	TotalSupply *big.Int
+}
+
+func (t *Token) ToJSON(id int64) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          id,
+		"owner":       t.Owner,
+		"name":        t.Name,
+		"symbol":      t.Symbol,
+		"decimals":    int64(t.Decimals),
+		"totalSupply": t.TotalSupply,
+	}
+}
+
+// TokenDB stores native tokens with balances and allowances in the token
+// account, so changes of them don't affect the system storage.
+type TokenDB struct {
+	tokens     *containerdb.DictDB
+	lastID     *containerdb.VarDB
+	balances   *containerdb.DictDB
+	allowances *containerdb.DictDB
+}
+
+func NewTokenDB(as containerdb.BytesStoreState) *TokenDB {
+	return &TokenDB{
+		tokens:     scoredb.NewDictDB(as, state.VarTokens, 1),
+		lastID:     scoredb.NewVarDB(as, state.VarTokenID),
+		balances:   scoredb.NewDictDB(as, state.VarTokenBalances, 2),
+		allowances: scoredb.NewDictDB(as, state.VarTokenAllowances, 3),
+	}
+}
+
+// Get returns the token for the id. It returns nil if there is no such token.
+func (db *TokenDB) Get(id int64) (*Token, error) {
+	v := db.tokens.Get(id)
+	if v == nil {
+		return nil, nil
+	}
+	t := new(Token)
+	if _, err := codec.BC.UnmarshalFromBytes(v.Bytes(), t); err != nil {
+		return nil, errors.CriticalFormatError.Wrap(err, "InvalidToken")
+	}
+	return t, nil
+}
+
+func (db *TokenDB) BalanceOf(id int64, owner module.Address) *big.Int {
+	return containerdb.BigIntSafe(db.balances.Get(id, owner))
+}
+
+func (db *TokenDB) Allowance(id int64, owner, spender module.Address) *big.Int {
+	return containerdb.BigIntSafe(db.allowances.Get(id, owner, spender))
+}
+
+// tokenStore updates TokenDB charging steps for the changes.
+type tokenStore struct {
+	*TokenDB
+	cc CallContext
+}
+
+func newTokenStore(cc CallContext) *tokenStore {
+	return &tokenStore{NewTokenDB(cc.GetAccountState(state.TokenID)), cc}
+}
+
+func (s *tokenStore) apply(db *containerdb.DictDB, value []byte, keys ...interface{}) error {
+	old := db.Get(keys...)
+	var ok bool
+	switch {
+	case len(value) == 0:
+		if old == nil {
+			return nil
+		}
+		ok = s.cc.ApplySteps(state.StepTypeDelete, len(old.Bytes()))
+	case old == nil:
+		ok = s.cc.ApplySteps(state.StepTypeSet, len(value))
+	default:
+		ok = s.cc.ApplySteps(state.StepTypeReplace, len(value))
+	}
+	if !ok {
+		return scoreresult.ErrOutOfStep
+	}
+	if len(value) == 0 {
+		return db.Delete(keys...)
+	}
+	return db.Set(append(keys, value)...)
+}
+
+func (s *tokenStore) setToken(id int64, t *Token) error {
+	return s.apply(s.tokens, codec.BC.MustMarshalToBytes(t), id)
+}
+
+func amountBytes(v *big.Int) []byte {
+	if v.Sign() == 0 {
+		return nil
+	}
+	return intconv.BigIntToBytes(v)
+}
+
+func (s *tokenStore) setBalance(id int64, owner module.Address, v *big.Int) error {
+	return s.apply(s.balances, amountBytes(v), id, owner)
+}
+
+func (s *tokenStore) setAllowance(id int64, owner, spender module.Address, v *big.Int) error {
+	return s.apply(s.allowances, amountBytes(v), id, owner, spender)
+}
+
+func (s *tokenStore) onEvent(indexed, data [][]byte) error {
+	size := 0
+	for _, v := range indexed {
+		size += len(v)
+	}
+	for _, v := range data {
+		size += len(v)
+	}
+	if !s.cc.ApplySteps(state.StepTypeEventLog, size) {
+		return scoreresult.ErrOutOfStep
+	}
+	s.cc.OnEvent(state.SystemAddress, indexed, data)
+	return nil
+}
+
+func (s *tokenStore) getToken(id int64) (*Token, error) {
+	if t, err := s.Get(id); err != nil {
+		return nil, err
+	} else if t == nil {
+		return nil, scoreresult.InvalidParameterError.Errorf("NoToken(id=%d)", id)
+	} else {
+		return t, nil
+	}
+}
+
+func checkTokenAmount(amount *big.Int) error {
+	if amount == nil || amount.Sign() < 0 {
+		return scoreresult.InvalidParameterError.Errorf("InvalidAmount(%s)", amount)
+	}
+	return nil
+}
+
+// transfer moves the amount between accounts. Nil from or to is used for
+// minting or burning.
+func (s *tokenStore) transfer(id int64, from, to module.Address, amount *big.Int) error {
+	if from != nil {
+		bal := s.BalanceOf(id, from)
+		if bal.Cmp(amount) < 0 {
+			return scoreresult.InvalidParameterError.Errorf(
+				"NotEnoughBalance(id=%d,balance=%s,amount=%s)", id, bal, amount)
+		}
+		if err := s.setBalance(id, from, new(big.Int).Sub(bal, amount)); err != nil {
+			return err
+		}
+	}
+	if to != nil {
+		bal := s.BalanceOf(id, to)
+		if err := s.setBalance(id, to, new(big.Int).Add(bal, amount)); err != nil {
+			return err
+		}
+	}
+	idBytes := intconv.Int64ToBytes(id)
+	logger := s.cc.FrameLogger()
+	switch {
+	case from == nil:
+		logger.OnTokenBalanceChange(module.TokenMint, id, nil, to, amount)
+		return s.onEvent(
+			[][]byte{[]byte(EventTokenMint), idBytes, to.Bytes()},
+			[][]byte{intconv.BigIntToBytes(amount)},
+		)
+	case to == nil:
+		logger.OnTokenBalanceChange(module.TokenBurn, id, from, nil, amount)
+		return s.onEvent(
+			[][]byte{[]byte(EventTokenBurn), idBytes, from.Bytes()},
+			[][]byte{intconv.BigIntToBytes(amount)},
+		)
+	default:
+		logger.OnTokenBalanceChange(module.TokenTransfer, id, from, to, amount)
+		return s.onEvent(
+			[][]byte{[]byte(EventTokenTransfer), idBytes, from.Bytes(), to.Bytes()},
+			[][]byte{intconv.BigIntToBytes(amount)},
+		)
+	}
+}
+
+// CreateToken registers a new token of the owner, and the initial supply is
+// given to the owner. It returns the id of the token.
+func CreateToken(cc CallContext, owner module.Address, name, symbol string,
+	decimals int64, supply *big.Int) (int64, error) {
+	if len(name) == 0 || len(name) > TokenNameLimit ||
+		len(symbol) == 0 || len(symbol) > TokenSymbolLimit {
+		return 0, scoreresult.InvalidParameterError.Errorf(
+			"InvalidNameOrSymbol(name=%q,symbol=%q)", name, symbol)
+	}
+	if decimals < 0 || decimals > TokenDecimalsMax {
+		return 0, scoreresult.InvalidParameterError.Errorf("InvalidDecimals(%d)", decimals)
+	}
+	if supply == nil {
+		supply = new(big.Int)
+	}
+	if err := checkTokenAmount(supply); err != nil {
+		return 0, err
+	}
+	s := newTokenStore(cc)
+	id := s.lastID.Int64() + 1
+	if err := s.lastID.Set(id); err != nil {
+		return 0, err
+	}
+	t := &Token{
+		Owner:       common.AddressToPtr(owner),
+		Name:        name,
+		Symbol:      symbol,
+		Decimals:    int(decimals),
+		TotalSupply: supply,
+	}
+	if err := s.setToken(id, t); err != nil {
+		return 0, err
+	}
+	if err := s.onEvent(
+		[][]byte{[]byte(EventTokenCreated), intconv.Int64ToBytes(id), owner.Bytes()},
+		[][]byte{[]byte(name), []byte(symbol), intconv.Int64ToBytes(decimals)},
+	); err != nil {
+		return 0, err
+	}
+	if supply.Sign() > 0 {
+		if err := s.transfer(id, nil, owner, supply); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// MintToken issues the amount of the token to the account. It's allowed
+// only for the owner of the token.
+func MintToken(cc CallContext, owner module.Address, id int64, to module.Address, amount *big.Int) error {
+	if err := checkTokenAmount(amount); err != nil {
+		return err
+	}
+	if to == nil {
+		return scoreresult.InvalidParameterError.New("NoReceiver")
+	}
+	s := newTokenStore(cc)
+	t, err := s.getToken(id)
+	if err != nil {
+		return err
+	}
+	if !t.Owner.Equal(owner) {
+		return scoreresult.AccessDeniedError.Errorf("NotOwner(id=%d,owner=%s)", id, t.Owner)
+	}
+	t.TotalSupply = new(big.Int).Add(t.TotalSupply, amount)
+	if err := s.setToken(id, t); err != nil {
+		return err
+	}
+	return s.transfer(id, nil, to, amount)
+}
+
+// BurnToken destroys the amount of the token from the balance of the owner
+// of the token.
+func BurnToken(cc CallContext, owner module.Address, id int64, amount *big.Int) error {
+	if err := checkTokenAmount(amount); err != nil {
+		return err
+	}
+	s := newTokenStore(cc)
+	t, err := s.getToken(id)
+	if err != nil {
+		return err
+	}
+	if !t.Owner.Equal(owner) {
+		return scoreresult.AccessDeniedError.Errorf("NotOwner(id=%d,owner=%s)", id, t.Owner)
+	}
+	t.TotalSupply = new(big.Int).Sub(t.TotalSupply, amount)
+	if err := s.setToken(id, t); err != nil {
+		return err
+	}
+	return s.transfer(id, owner, nil, amount)
+}
+
+// TransferToken transfers the amount of the token from the account.
+func TransferToken(cc CallContext, from module.Address, id int64, to module.Address, amount *big.Int) error {
+	if err := checkTokenAmount(amount); err != nil {
+		return err
+	}
+	if to == nil {
+		return scoreresult.InvalidParameterError.New("NoReceiver")
+	}
+	s := newTokenStore(cc)
+	if _, err := s.getToken(id); err != nil {
+		return err
+	}
+	return s.transfer(id, from, to, amount)
+}
+
+// ApproveToken sets the amount of the token which the spender can transfer
+// from the balance of the owner.
+func ApproveToken(cc CallContext, owner module.Address, id int64, spender module.Address, amount *big.Int) error {
+	if err := checkTokenAmount(amount); err != nil {
+		return err
+	}
+	if spender == nil {
+		return scoreresult.InvalidParameterError.New("NoSpender")
+	}
+	s := newTokenStore(cc)
+	if _, err := s.getToken(id); err != nil {
+		return err
+	}
+	if err := s.setAllowance(id, owner, spender, amount); err != nil {
+		return err
+	}
+	return s.onEvent(
+		[][]byte{[]byte(EventTokenApproval), intconv.Int64ToBytes(id), owner.Bytes(), spender.Bytes()},
+		[][]byte{intconv.BigIntToBytes(amount)},
+	)
+}
+
+// TransferTokenFrom transfers the amount of the token from the account by the
+// spender within the allowance.
+func TransferTokenFrom(cc CallContext, spender module.Address, id int64, from, to module.Address, amount *big.Int) error {
+	if err := checkTokenAmount(amount); err != nil {
+		return err
+	}
+	if from == nil || to == nil {
+		return scoreresult.InvalidParameterError.New("NoSenderOrReceiver")
+	}
+	s := newTokenStore(cc)
+	if _, err := s.getToken(id); err != nil {
+		return err
+	}
+	allowance := s.Allowance(id, from, spender)
+	if allowance.Cmp(amount) < 0 {
+		return scoreresult.InvalidParameterError.Errorf(
+			"NotEnoughAllowance(id=%d,allowance=%s,amount=%s)", id, allowance, amount)
+	}
+	if err := s.setAllowance(id, from, spender, new(big.Int).Sub(allowance, amount)); err != nil {
+		return err
+	}
+	return s.transfer(id, from, to, amount)
+}
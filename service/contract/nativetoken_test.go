@@ -0,0 +1,129 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contract
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/trace"
+)
+
+type testTokenCallContext struct {
+	testRSCallContext
+	events []string
+}
+
+func (cc *testTokenCallContext) ApplySteps(t state.StepType, n int) bool {
+	return true
+}
+
+func (cc *testTokenCallContext) OnEvent(addr module.Address, indexed, data [][]byte) {
+	cc.events = append(cc.events, string(indexed[0]))
+}
+
+func (cc *testTokenCallContext) FrameLogger() *trace.Logger {
+	return trace.NewLogger(log.New(), nil)
+}
+
+func TestNativeToken_Basic(t *testing.T) {
+	cc := new(testTokenCallContext)
+	owner := common.MustNewAddressFromString("hx0000000000000000000000000000000000000001")
+	user1 := common.MustNewAddressFromString("hx0000000000000000000000000000000000000002")
+	user2 := common.MustNewAddressFromString("cx0000000000000000000000000000000000000003")
+
+	id, err := CreateToken(cc, owner, "Test Token", "TT", 18, big.NewInt(1000))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+	assert.Equal(t, []string{EventTokenCreated, EventTokenMint}, cc.events)
+
+	// tokens are kept apart from the system storage
+	_, ok := cc.accounts[state.SystemIDStr]
+	assert.False(t, ok)
+
+	tdb := NewTokenDB(cc.GetAccountState(state.TokenID))
+	tk, err := tdb.Get(id)
+	assert.NoError(t, err)
+	assert.EqualValues(t, &Token{owner, "Test Token", "TT", 18, big.NewInt(1000)}, tk)
+	assert.EqualValues(t, big.NewInt(1000), tdb.BalanceOf(id, owner))
+
+	// mint and burn are allowed only for the owner
+	err = MintToken(cc, user1, id, user1, big.NewInt(10))
+	assert.True(t, scoreresult.AccessDeniedError.Equals(err))
+	assert.NoError(t, MintToken(cc, owner, id, user1, big.NewInt(500)))
+	assert.NoError(t, BurnToken(cc, owner, id, big.NewInt(200)))
+	tk, _ = tdb.Get(id)
+	assert.EqualValues(t, big.NewInt(1300), tk.TotalSupply)
+	assert.EqualValues(t, big.NewInt(800), tdb.BalanceOf(id, owner))
+	assert.EqualValues(t, big.NewInt(500), tdb.BalanceOf(id, user1))
+
+	// transfer
+	err = TransferToken(cc, user1, id, user2, big.NewInt(501))
+	assert.True(t, scoreresult.InvalidParameterError.Equals(err))
+	assert.NoError(t, TransferToken(cc, user1, id, user2, big.NewInt(500)))
+	assert.EqualValues(t, big.NewInt(0), tdb.BalanceOf(id, user1))
+	assert.EqualValues(t, big.NewInt(500), tdb.BalanceOf(id, user2))
+	err = TransferToken(cc, user1, id+1, user2, big.NewInt(0))
+	assert.True(t, scoreresult.InvalidParameterError.Equals(err))
+	err = TransferToken(cc, user2, id, user1, big.NewInt(-1))
+	assert.True(t, scoreresult.InvalidParameterError.Equals(err))
+
+	// allowance
+	assert.NoError(t, ApproveToken(cc, owner, id, user1, big.NewInt(300)))
+	assert.EqualValues(t, big.NewInt(300), tdb.Allowance(id, owner, user1))
+	err = TransferTokenFrom(cc, user1, id, owner, user2, big.NewInt(301))
+	assert.True(t, scoreresult.InvalidParameterError.Equals(err))
+	assert.NoError(t, TransferTokenFrom(cc, user1, id, owner, user2, big.NewInt(100)))
+	assert.EqualValues(t, big.NewInt(200), tdb.Allowance(id, owner, user1))
+	assert.EqualValues(t, big.NewInt(700), tdb.BalanceOf(id, owner))
+	assert.EqualValues(t, big.NewInt(600), tdb.BalanceOf(id, user2))
+	assert.Equal(t, EventTokenTransfer, cc.events[len(cc.events)-1])
+}
+
+func TestNativeToken_Invalid(t *testing.T) {
+	cc := new(testTokenCallContext)
+	owner := common.MustNewAddressFromString("hx0000000000000000000000000000000000000001")
+
+	cases := []struct {
+		name, symbol string
+		decimals     int64
+		supply       *big.Int
+	}{
+		{"", "TT", 18, nil},
+		{"Test", "", 18, nil},
+		{"Test", "TOO_LONG_SYMBOL_NAME", 18, nil},
+		{"Test", "TT", -1, nil},
+		{"Test", "TT", TokenDecimalsMax + 1, nil},
+		{"Test", "TT", 18, big.NewInt(-1)},
+	}
+	for _, c := range cases {
+		_, err := CreateToken(cc, owner, c.name, c.symbol, c.decimals, c.supply)
+		assert.True(t, scoreresult.InvalidParameterError.Equals(err))
+	}
+
+	id, err := CreateToken(cc, owner, "Test", "TT", 0, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+	assert.Equal(t, []string{EventTokenCreated}, cc.events)
+}
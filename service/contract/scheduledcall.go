@@ -0,0 +1,538 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/txresult"
+)
+
+const (
+	// ScheduledCallLimit is the maximum number of calls scheduled at a
+	// height.
+	ScheduledCallLimit = 100
+
+	// ScheduledCallStepFactor limits the sum of step limits of calls at a
+	// height to the factor times the step limit for invoke.
+	ScheduledCallStepFactor = 4
+
+	// ScheduledCallFeeSteps is the steps for the scheduling fee, which is
+	// charged from the prepaid on scheduling and not returned on cancel.
+	ScheduledCallFeeSteps = 1_000_000
+
+	// ScheduledCallRetention is the number of blocks keeping results of
+	// executed calls. They are removed after the period.
+	ScheduledCallRetention = 43200
+)
+
+// ScheduledCall is the call registered for execution at the specific height.
+// Prepaid is kept in the system account until the call is executed or
+// cancelled. Fee is the scheduling fee already paid to the treasury. Result
+// is set after the execution.
+type ScheduledCall struct {
+	Owner     *common.Address
+	To        *common.Address
+	Method    string
+	Params    []byte
+	Height    int64
+	StepLimit *big.Int
+	Prepaid   *big.Int
+	Fee       *big.Int
+	Result    *ScheduledCallResult
+}
+
+// ScheduledCallResult is the result of the scheduled call. Fee is the amount
+// charged from the prepaid, and the rest is returned to the owner. EventLogs
+// are logs of the call, which are also in the logs bloom of the block.
+type ScheduledCallResult struct {
+	Status    int
+	StepUsed  *big.Int
+	Fee       *big.Int
+	EventLogs []*ScheduledCallLog
+}
+
+// ScheduledCallLog is the event log emitted by the scheduled call.
+type ScheduledCallLog struct {
+	Addr    common.Address
+	Indexed [][]byte
+	Data    [][]byte
+}
+
+func (l *ScheduledCallLog) ToJSON() map[string]interface{} {
+	indexed := make([]interface{}, len(l.Indexed))
+	for i, v := range l.Indexed {
+		if i == 0 {
+			indexed[i] = string(v)
+		} else {
+			indexed[i] = common.HexBytes(v)
+		}
+	}
+	data := make([]interface{}, len(l.Data))
+	for i, v := range l.Data {
+		data[i] = common.HexBytes(v)
+	}
+	return map[string]interface{}{
+		"scoreAddress": &l.Addr,
+		"indexed":      indexed,
+		"data":         data,
+	}
+}
+
+func (c *ScheduledCall) ToJSON(id int64) map[string]interface{} {
+	jso := map[string]interface{}{
+		"id":        id,
+		"owner":     c.Owner,
+		"to":        c.To,
+		"method":    c.Method,
+		"height":    c.Height,
+		"stepLimit": c.StepLimit,
+		"prepaid":   c.Prepaid,
+		"fee":       c.Fee,
+	}
+	if len(c.Params) > 0 {
+		jso["params"] = string(c.Params)
+	}
+	if r := c.Result; r != nil {
+		logs := make([]interface{}, len(r.EventLogs))
+		for i, l := range r.EventLogs {
+			logs[i] = l.ToJSON()
+		}
+		jso["result"] = map[string]interface{}{
+			"status":    int64(r.Status),
+			"stepUsed":  r.StepUsed,
+			"fee":       r.Fee,
+			"eventLogs": logs,
+		}
+	}
+	return jso
+}
+
+// ScheduledCallDB stores scheduled calls in the system account.
+type ScheduledCallDB struct {
+	byHeight *containerdb.DictDB
+	calls    *containerdb.DictDB
+	lastID   *containerdb.VarDB
+}
+
+func NewScheduledCallDB(as containerdb.BytesStoreState) *ScheduledCallDB {
+	return &ScheduledCallDB{
+		byHeight: scoredb.NewDictDB(as, state.VarScheduledCalls, 1),
+		calls:    scoredb.NewDictDB(as, state.VarScheduledCall, 1),
+		lastID:   scoredb.NewVarDB(as, state.VarScheduledCallID),
+	}
+}
+
+// CallsAt returns identifiers of calls scheduled at the height in the order
+// of scheduling.
+func (db *ScheduledCallDB) CallsAt(height int64) ([]int64, error) {
+	var ids []int64
+	if v := db.byHeight.Get(height); v != nil {
+		if _, err := codec.BC.UnmarshalFromBytes(v.Bytes(), &ids); err != nil {
+			return nil, errors.CriticalFormatError.Wrap(err, "InvalidScheduledCalls")
+		}
+	}
+	return ids, nil
+}
+
+// StepLimitAt returns the sum of step limits of calls scheduled at the
+// height.
+func (db *ScheduledCallDB) StepLimitAt(height int64) (*big.Int, error) {
+	ids, err := db.CallsAt(height)
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	for _, id := range ids {
+		c, err := db.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, errors.CriticalFormatError.Errorf("NoScheduledCall(id=%d)", id)
+		}
+		total.Add(total, c.StepLimit)
+	}
+	return total, nil
+}
+
+func (db *ScheduledCallDB) setCallsAt(height int64, ids []int64) error {
+	if len(ids) == 0 {
+		return db.byHeight.Delete(height)
+	}
+	return db.byHeight.Set(height, codec.BC.MustMarshalToBytes(ids))
+}
+
+// Get returns the call for the id. It returns nil if there is no such call.
+func (db *ScheduledCallDB) Get(id int64) (*ScheduledCall, error) {
+	v := db.calls.Get(id)
+	if v == nil {
+		return nil, nil
+	}
+	c := new(ScheduledCall)
+	if _, err := codec.BC.UnmarshalFromBytes(v.Bytes(), c); err != nil {
+		return nil, errors.CriticalFormatError.Wrap(err, "InvalidScheduledCall")
+	}
+	return c, nil
+}
+
+func (db *ScheduledCallDB) set(id int64, c *ScheduledCall) error {
+	return db.calls.Set(id, codec.BC.MustMarshalToBytes(c))
+}
+
+// Add schedules the call at its height, and returns the id of the call.
+func (db *ScheduledCallDB) Add(c *ScheduledCall) (int64, error) {
+	ids, err := db.CallsAt(c.Height)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) >= ScheduledCallLimit {
+		return 0, scoreresult.InvalidParameterError.Errorf(
+			"TooManyCalls(height=%d)", c.Height)
+	}
+	id := db.lastID.Int64() + 1
+	if err := db.lastID.Set(id); err != nil {
+		return 0, err
+	}
+	if err := db.set(id, c); err != nil {
+		return 0, err
+	}
+	if err := db.setCallsAt(c.Height, append(ids, id)); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// prune removes calls executed at the height with their results.
+func (db *ScheduledCallDB) prune(height int64) error {
+	ids, err := db.CallsAt(height)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	for _, id := range ids {
+		if err := db.calls.Delete(id); err != nil {
+			return err
+		}
+	}
+	return db.setCallsAt(height, nil)
+}
+
+// Remove removes the pending call, and returns it. It returns nil if there
+// is no pending call for the id.
+func (db *ScheduledCallDB) Remove(id int64) (*ScheduledCall, error) {
+	c, err := db.Get(id)
+	if err != nil || c == nil || c.Result != nil {
+		return nil, err
+	}
+	ids, err := db.CallsAt(c.Height)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range ids {
+		if v == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if err := db.setCallsAt(c.Height, ids); err != nil {
+		return nil, err
+	}
+	if err := db.calls.Delete(id); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// scheduledCallStepCap returns the limit of the sum of steps used by calls
+// at a height.
+func scheduledCallStepCap(ctx Context) *big.Int {
+	return new(big.Int).Mul(ctx.GetStepLimit(state.StepLimitTypeInvoke),
+		big.NewInt(ScheduledCallStepFactor))
+}
+
+// ScheduleCall registers the call of the method of the contract from the
+// owner at the height. The prepaid, which should be already transferred to
+// the system account, should cover the scheduling fee and the step limit
+// with the current step price. The sum of step limits of calls at the height
+// can't exceed the cap. The scheduling fee is paid to the treasury
+// immediately.
+func ScheduleCall(cc CallContext, owner, to module.Address, method string, params []byte,
+	height int64, limit, prepaid *big.Int) (int64, error) {
+	if height <= cc.BlockHeight() {
+		return 0, scoreresult.InvalidParameterError.Errorf(
+			"InvalidHeight(height=%d,current=%d)", height, cc.BlockHeight())
+	}
+	if to == nil || !to.IsContract() || len(method) == 0 {
+		return 0, scoreresult.InvalidParameterError.Errorf(
+			"InvalidTarget(to=%s,method=%s)", to, method)
+	}
+	if len(params) > 0 {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(params, &obj); err != nil {
+			return 0, scoreresult.InvalidParameterError.Wrapf(err,
+				"InvalidParams(params=%s)", params)
+		}
+	}
+	if limit == nil || limit.Sign() <= 0 ||
+		limit.Cmp(cc.GetStepLimit(state.StepLimitTypeInvoke)) > 0 {
+		return 0, scoreresult.InvalidParameterError.Errorf("InvalidStepLimit(limit=%s)", limit)
+	}
+	fee := new(big.Int).Mul(big.NewInt(ScheduledCallFeeSteps), cc.StepPrice())
+	required := new(big.Int).Mul(limit, cc.StepPrice())
+	required.Add(required, fee)
+	if prepaid == nil || prepaid.Cmp(required) < 0 {
+		return 0, scoreresult.InvalidParameterError.Errorf(
+			"NotEnoughPrepaid(prepaid=%s,required=%s)", prepaid, required)
+	}
+	sysAs := cc.GetAccountState(state.SystemID)
+	sdb := NewScheduledCallDB(sysAs)
+	total, err := sdb.StepLimitAt(height)
+	if err != nil {
+		return 0, err
+	}
+	if total.Add(total, limit).Cmp(scheduledCallStepCap(cc)) > 0 {
+		return 0, scoreresult.InvalidParameterError.Errorf(
+			"TooManySteps(height=%d,total=%s)", height, total)
+	}
+	c := &ScheduledCall{
+		Owner:     common.AddressToPtr(owner),
+		To:        common.AddressToPtr(to),
+		Method:    method,
+		Params:    params,
+		Height:    height,
+		StepLimit: limit,
+		Prepaid:   new(big.Int).Sub(prepaid, fee),
+		Fee:       fee,
+	}
+	bal := sysAs.GetBalance()
+	if bal.Cmp(fee) < 0 {
+		return 0, errors.InvalidStateError.Errorf(
+			"NotEnoughEscrow(balance=%s,fee=%s)", bal, fee)
+	}
+	sysAs.SetBalance(new(big.Int).Sub(bal, fee))
+	treasury := cc.GetAccountState(cc.Treasury().ID())
+	treasury.SetBalance(new(big.Int).Add(treasury.GetBalance(), fee))
+	return sdb.Add(c)
+}
+
+// CancelScheduledCall removes the pending call of the owner, and returns
+// the prepaid to the owner. It returns nil if there is no pending call.
+func CancelScheduledCall(cc CallContext, owner module.Address, id int64) (*ScheduledCall, error) {
+	sysAs := cc.GetAccountState(state.SystemID)
+	sdb := NewScheduledCallDB(sysAs)
+	if c, err := sdb.Get(id); err != nil || c == nil || c.Result != nil {
+		return nil, err
+	} else if !c.Owner.Equal(owner) {
+		return nil, scoreresult.AccessDeniedError.Errorf(
+			"NotOwner(id=%d,owner=%s)", id, c.Owner)
+	}
+	c, err := sdb.Remove(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := refundPrepaid(cc, sysAs, c, new(big.Int)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// refundPrepaid moves the prepaid of the call from the system account. The
+// fee is excluded from the amount returned to the owner.
+func refundPrepaid(ctx Context, sysAs state.AccountState, c *ScheduledCall, fee *big.Int) error {
+	bal := sysAs.GetBalance()
+	if bal.Cmp(c.Prepaid) < 0 {
+		return errors.InvalidStateError.Errorf(
+			"NotEnoughEscrow(balance=%s,prepaid=%s)", bal, c.Prepaid)
+	}
+	sysAs.SetBalance(new(big.Int).Sub(bal, c.Prepaid))
+	as := ctx.GetAccountState(c.Owner.ID())
+	as.SetBalance(new(big.Int).Add(as.GetBalance(), new(big.Int).Sub(c.Prepaid, fee)))
+	return nil
+}
+
+// ScheduledCallHash returns the hash used as the transaction hash while
+// executing the scheduled call.
+func ScheduledCallHash(id int64) []byte {
+	return crypto.SHA3Sum256([]byte(fmt.Sprintf("scheduled_call:%d", id)))
+}
+
+// ApplyScheduledCalls executes calls scheduled at the current height in the
+// order of scheduling. The fee is paid with the prepaid of the call, or with
+// deposits of the contracts sharing the fee, and the rest of the prepaid is
+// returned to the owner. Steps of calls are limited by the rest of the cap
+// for the height, as the step limit for invoke may be lowered after
+// scheduling. Results are kept with the calls for ScheduledCallRetention
+// blocks, and calls executed before are removed. It returns receipts of the
+// calls for logs bloom and BTP messages of the block, and an error only for
+// system failures.
+func ApplyScheduledCalls(ctx Context) ([]txresult.Receipt, error) {
+	height := ctx.BlockHeight()
+	sysAs := ctx.GetAccountState(state.SystemID)
+	sdb := NewScheduledCallDB(sysAs)
+	if err := sdb.prune(height - ScheduledCallRetention); err != nil {
+		return nil, err
+	}
+	ids, err := sdb.CallsAt(height)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	receipts := make([]txresult.Receipt, 0, len(ids))
+	steps := scheduledCallStepCap(ctx)
+	for _, id := range ids {
+		c, err := sdb.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, errors.CriticalFormatError.Errorf("NoScheduledCall(id=%d)", id)
+		}
+		ctx.SetTransactionInfo(&state.TransactionInfo{
+			Group:     module.TransactionGroupNormal,
+			Index:     0,
+			Hash:      ScheduledCallHash(id),
+			From:      c.Owner,
+			Timestamp: ctx.BlockTimeStamp(),
+		})
+		receipt, r, toTreasury, err := applyScheduledCall(ctx, c, steps)
+		if err != nil {
+			return nil, err
+		}
+		steps.Sub(steps, r.StepUsed)
+		if err := refundPrepaid(ctx, sysAs, c, r.Fee); err != nil {
+			return nil, err
+		}
+		treasury := ctx.GetAccountState(ctx.Treasury().ID())
+		treasury.SetBalance(new(big.Int).Add(treasury.GetBalance(), toTreasury))
+		c.Result = r
+		if err := sdb.set(id, c); err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+func applyScheduledCall(ctx Context, c *ScheduledCall, steps *big.Int) (txresult.Receipt, *ScheduledCallResult, *big.Int, error) {
+	price := ctx.StepPrice()
+	limit := c.StepLimit
+	if invokeLimit := ctx.GetStepLimit(state.StepLimitTypeInvoke); limit.Cmp(invokeLimit) > 0 {
+		limit = invokeLimit
+	}
+	if limit.Cmp(steps) > 0 {
+		limit = steps
+	}
+	if price.Sign() > 0 {
+		if payable := new(big.Int).Div(c.Prepaid, price); limit.Cmp(payable) > 0 {
+			limit = payable
+		}
+	}
+	cc := NewCallContext(ctx, limit, false)
+	defer cc.Dispose()
+
+	logger := cc.FrameLogger()
+	status, err := executeScheduledCall(cc, c)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	stepUsed := cc.StepUsed()
+	stepToPay := stepUsed
+	if cc.FeeSharingEnabled() && price.Sign() > 0 {
+		redeemed, err := cc.RedeemSteps(stepToPay)
+		if err != nil {
+			return nil, nil, nil, err
+		} else if redeemed != nil {
+			stepToPay = new(big.Int).Sub(stepToPay, redeemed)
+		}
+	}
+	fee := new(big.Int).Mul(stepToPay, price)
+	toTreasury := fee
+	if ctx.Revision().Has(module.FixLostFeeByDeposit) {
+		toTreasury = new(big.Int).Mul(stepUsed, price)
+	}
+
+	s, _ := scoreresult.StatusOf(status)
+	if status != nil {
+		logger.Warnf("Fail to execute scheduled call to=%s method=%s err=%+v",
+			c.To, c.Method, status)
+	}
+	receipt := txresult.NewReceipt(ctx.Database(), ctx.Revision(), c.To)
+	if status == nil {
+		cc.GetEventLogs(receipt)
+		cc.GetBTPMessages(receipt)
+	}
+	if redeemed := cc.GetRedeemLogs(receipt); redeemed && stepToPay.Sign() != 0 {
+		receipt.AddPayment(c.Owner, stepToPay, stepToPay)
+	}
+	receipt.SetResult(s, stepUsed, price, nil)
+	receipt.SetReason(status)
+
+	var logs []*ScheduledCallLog
+	for itr := receipt.EventLogIterator(); itr.Has(); itr.Next() {
+		ev, err := itr.Get()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		logs = append(logs, &ScheduledCallLog{
+			Addr:    *common.AddressToPtr(ev.Address()),
+			Indexed: ev.Indexed(),
+			Data:    ev.Data(),
+		})
+	}
+	return receipt, &ScheduledCallResult{
+		Status:    int(s),
+		StepUsed:  stepUsed,
+		Fee:       fee,
+		EventLogs: logs,
+	}, toTreasury, nil
+}
+
+func executeScheduledCall(cc CallContext, c *ScheduledCall) (status error, err error) {
+	if !cc.ApplySteps(state.StepTypeDefault, 1) ||
+		!cc.ApplySteps(state.StepTypeInput, len(c.Params)) {
+		return scoreresult.ErrOutOfStep, nil
+	}
+	data, err := json.Marshal(&DataCallJSON{Method: c.Method, Params: c.Params})
+	if err != nil {
+		return nil, err
+	}
+	ch := NewCommonHandler(c.Owner, c.To, big.NewInt(0), false, cc.FrameLogger())
+	handler, err := newCallHandlerWithData(ch, data)
+	if err != nil {
+		return err, nil
+	}
+	status, used, _, _ := cc.Call(handler, cc.StepAvailable())
+	cc.DeductSteps(used)
+	if code := errors.CodeOf(status); code == errors.ExecutionFailError ||
+		errors.IsCriticalCode(code) {
+		return nil, status
+	} else if code == scoreresult.TimeoutError {
+		cc.DeductSteps(cc.StepAvailable())
+	}
+	return status, nil
+}
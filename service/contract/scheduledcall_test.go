@@ -0,0 +1,160 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contract
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+func newTestCall(id int, height int64) *ScheduledCall {
+	return &ScheduledCall{
+		Owner:     common.MustNewAddressFromString(fmt.Sprintf("hx%040x", id)),
+		To:        common.MustNewAddressFromString(fmt.Sprintf("cx%040x", id)),
+		Method:    "run",
+		Params:    []byte(fmt.Sprintf(`{"id":"0x%x"}`, id)),
+		Height:    height,
+		StepLimit: big.NewInt(int64(id) * 1000),
+		Prepaid:   big.NewInt(int64(id) * 2000),
+	}
+}
+
+func TestScheduledCallDB_AddRemove(t *testing.T) {
+	sdb := NewScheduledCallDB(&testAccountState{})
+	c1, c2, c3 := newTestCall(1, 10), newTestCall(2, 10), newTestCall(3, 20)
+
+	for i, c := range []*ScheduledCall{c1, c2, c3} {
+		id, err := sdb.Add(c)
+		assert.NoError(t, err)
+		assert.EqualValues(t, i+1, id)
+	}
+
+	ids, err := sdb.CallsAt(10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int64{1, 2}, ids)
+
+	c, err := sdb.Get(3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, c3, c)
+
+	c, err = sdb.Remove(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, c1, c)
+	c, err = sdb.Get(1)
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	ids, err = sdb.CallsAt(10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int64{2}, ids)
+
+	// executed calls can't be removed
+	c2.Result = &ScheduledCallResult{Status: 0, StepUsed: big.NewInt(100), Fee: big.NewInt(200)}
+	assert.NoError(t, sdb.set(2, c2))
+	c, err = sdb.Remove(2)
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+	c, err = sdb.Get(2)
+	assert.NoError(t, err)
+	assert.EqualValues(t, c2, c)
+
+	// identifiers are not reused
+	id, err := sdb.Add(newTestCall(4, 20))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, id)
+	ids, err = sdb.CallsAt(20)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int64{3, 4}, ids)
+}
+
+func TestScheduledCallDB_Limit(t *testing.T) {
+	sdb := NewScheduledCallDB(&testAccountState{})
+	for i := 0; i < ScheduledCallLimit; i++ {
+		_, err := sdb.Add(newTestCall(i+1, 10))
+		assert.NoError(t, err)
+	}
+	_, err := sdb.Add(newTestCall(ScheduledCallLimit+1, 10))
+	assert.True(t, scoreresult.InvalidParameterError.Equals(err))
+
+	_, err = sdb.Add(newTestCall(ScheduledCallLimit+1, 11))
+	assert.NoError(t, err)
+}
+
+func TestScheduledCallDB_StepLimitAt(t *testing.T) {
+	sdb := NewScheduledCallDB(&testAccountState{})
+	for _, c := range []*ScheduledCall{newTestCall(1, 10), newTestCall(2, 10), newTestCall(3, 20)} {
+		_, err := sdb.Add(c)
+		assert.NoError(t, err)
+	}
+	total, err := sdb.StepLimitAt(10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3000, total.Int64())
+
+	_, err = sdb.Remove(1)
+	assert.NoError(t, err)
+	total, err = sdb.StepLimitAt(10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2000, total.Int64())
+
+	total, err = sdb.StepLimitAt(30)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total.Int64())
+}
+
+func TestScheduledCallDB_Prune(t *testing.T) {
+	sdb := NewScheduledCallDB(&testAccountState{})
+	c1, c2, c3 := newTestCall(1, 10), newTestCall(2, 10), newTestCall(3, 20)
+	for _, c := range []*ScheduledCall{c1, c2, c3} {
+		_, err := sdb.Add(c)
+		assert.NoError(t, err)
+	}
+
+	c1.Result = &ScheduledCallResult{
+		Status:   0,
+		StepUsed: big.NewInt(100),
+		Fee:      big.NewInt(200),
+		EventLogs: []*ScheduledCallLog{{
+			Addr:    *c1.To,
+			Indexed: [][]byte{[]byte("Done(int)"), {0x01}},
+			Data:    [][]byte{{0x02}},
+		}},
+	}
+	assert.NoError(t, sdb.set(1, c1))
+	c, err := sdb.Get(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, c1, c)
+
+	assert.NoError(t, sdb.prune(10))
+	for _, id := range []int64{1, 2} {
+		c, err = sdb.Get(id)
+		assert.NoError(t, err)
+		assert.Nil(t, c)
+	}
+	ids, err := sdb.CallsAt(10)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	c, err = sdb.Get(3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, c3, c)
+}
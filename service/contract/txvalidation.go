@@ -0,0 +1,90 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contract
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// ValidationMethod is the name of the read-only method of the contract,
+// which authorizes the transactions sent from the contract. It's called by
+// the system address with the hash and the proof of the transaction, and it
+// should return true to accept the transaction.
+const ValidationMethod = "validateTransaction"
+
+// ValidateTransaction calls the validation method of the wallet contract
+// with the limit of steps. It returns the steps used for the call, and the
+// status of the validation. The status is nil only if the contract accepts
+// the transaction.
+func ValidateTransaction(cc CallContext, wallet module.Address, txHash, proof []byte, limit *big.Int) (*big.Int, error) {
+	as := cc.GetAccountState(wallet.ID())
+	if !as.IsContract() || as.ActiveContract() == nil {
+		return nil, scoreresult.AccessDeniedError.Errorf(
+			"NotActiveWallet(addr=%s)", wallet)
+	}
+	info, err := as.APIInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, scoreresult.ErrContractNotFound
+	}
+	method := info.GetMethod(ValidationMethod)
+	if method == nil || !method.IsCallable() || !method.IsReadOnly() {
+		return nil, scoreresult.AccessDeniedError.Errorf(
+			"NoValidationMethod(addr=%s)", wallet)
+	}
+	params, err := json.Marshal(map[string]interface{}{
+		"txHash": common.HexBytes(txHash),
+		"proof":  common.HexBytes(proof),
+	})
+	if err != nil {
+		return nil, err
+	}
+	paramObj, err := method.ConvertParamsToTypedObj(params, false)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := newCallHandlerWithParams(
+		NewCommonHandler(state.SystemAddress, wallet, big.NewInt(0), false, cc.FrameLogger()),
+		ValidationMethod, paramObj, false)
+	status, stepUsed, result, _ := cc.Call(handler, limit)
+	if status != nil {
+		return stepUsed, status
+	}
+	if value, err := common.DecodeAny(result); err == nil {
+		switch v := value.(type) {
+		case bool:
+			if v {
+				return stepUsed, nil
+			}
+		case *common.HexInt:
+			if v.Cmp(big.NewInt(1)) == 0 {
+				return stepUsed, nil
+			}
+		}
+	}
+	return stepUsed, scoreresult.AccessDeniedError.Errorf(
+		"RejectedByWallet(addr=%s)", wallet)
+}
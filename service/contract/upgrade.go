@@ -0,0 +1,390 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contract
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/containerdb"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// MigrationMethod is the name of the method of the contract, which is
+// invoked right after the activation of the scheduled update for migrating
+// the state. It's invoked by the system address without parameters, so the
+// contract may reject other callers.
+const MigrationMethod = "onUpdate"
+
+// UpgradeHistoryLimit is the number of upgrade records kept for a contract.
+const UpgradeHistoryLimit = 20
+
+// ScheduledUpdate is the pending update of the contract accepted by the
+// governance for activation at the specific height.
+type ScheduledUpdate struct {
+	Address     *common.Address
+	TxHash      []byte
+	AuditTxHash []byte
+	StepLimit   *big.Int
+}
+
+// UpgradeRecord is the result of the scheduled update.
+type UpgradeRecord struct {
+	Height      int64
+	TxHash      []byte
+	AuditTxHash []byte
+	CodeHash    []byte
+	Status      int
+	StepUsed    *big.Int
+}
+
+type UpgradeHistory []UpgradeRecord
+
+func (h *UpgradeHistory) Push(r UpgradeRecord) {
+	hh := *h
+	if len(hh)+1 > UpgradeHistoryLimit {
+		copy(hh[0:], hh[len(hh)-UpgradeHistoryLimit+1:])
+		hh = hh[0 : UpgradeHistoryLimit-1]
+	}
+	*h = append(hh, r)
+}
+
+func (h *UpgradeHistory) Bytes() []byte {
+	if len(*h) == 0 {
+		return nil
+	}
+	return codec.BC.MustMarshalToBytes(*h)
+}
+
+func UpgradeHistoryFromBytes(bs []byte) (UpgradeHistory, error) {
+	var history UpgradeHistory
+	if len(bs) > 0 {
+		if _, err := codec.BC.UnmarshalFromBytes(bs, &history); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+// UpgradeDB stores scheduled updates and upgrade history of contracts in
+// the system account.
+type UpgradeDB struct {
+	byHeight  *containerdb.DictDB
+	byAddress *containerdb.DictDB
+	history   *containerdb.DictDB
+}
+
+func NewUpgradeDB(as containerdb.BytesStoreState) *UpgradeDB {
+	return &UpgradeDB{
+		byHeight:  scoredb.NewDictDB(as, state.VarScheduledUpdates, 1),
+		byAddress: scoredb.NewDictDB(as, state.VarScheduledUpdateOf, 1),
+		history:   scoredb.NewDictDB(as, state.VarUpgradeHistory, 1),
+	}
+}
+
+// UpdatesAt returns updates scheduled at the height in the order of
+// scheduling.
+func (db *UpgradeDB) UpdatesAt(height int64) ([]*ScheduledUpdate, error) {
+	var updates []*ScheduledUpdate
+	if v := db.byHeight.Get(height); v != nil {
+		if _, err := codec.BC.UnmarshalFromBytes(v.Bytes(), &updates); err != nil {
+			return nil, errors.CriticalFormatError.Wrap(err, "InvalidScheduledUpdates")
+		}
+	}
+	return updates, nil
+}
+
+func (db *UpgradeDB) setUpdatesAt(height int64, updates []*ScheduledUpdate) error {
+	if len(updates) == 0 {
+		return db.byHeight.Delete(height)
+	}
+	return db.byHeight.Set(height, codec.BC.MustMarshalToBytes(updates))
+}
+
+// Scheduled returns the update scheduled for the contract and its height.
+// It returns nil if there is no scheduled update.
+func (db *UpgradeDB) Scheduled(addr module.Address) (*ScheduledUpdate, int64, error) {
+	v := db.byAddress.Get(addr)
+	if v == nil {
+		return nil, 0, nil
+	}
+	height := v.Int64()
+	updates, err := db.UpdatesAt(height)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, u := range updates {
+		if u.Address.Equal(addr) {
+			return u, height, nil
+		}
+	}
+	return nil, 0, errors.CriticalFormatError.Errorf("NoScheduledUpdate(addr=%s,height=%d)", addr, height)
+}
+
+// Schedule schedules the update at the height. Only one update may be
+// scheduled for a contract.
+func (db *UpgradeDB) Schedule(height int64, u *ScheduledUpdate) error {
+	if db.byAddress.Get(u.Address) != nil {
+		return scoreresult.InvalidParameterError.Errorf("AlreadyScheduled(addr=%s)", u.Address)
+	}
+	updates, err := db.UpdatesAt(height)
+	if err != nil {
+		return err
+	}
+	if err := db.setUpdatesAt(height, append(updates, u)); err != nil {
+		return err
+	}
+	return db.byAddress.Set(u.Address, height)
+}
+
+// Cancel removes the update scheduled for the contract, and returns it.
+// It returns nil if there is no scheduled update.
+func (db *UpgradeDB) Cancel(addr module.Address) (*ScheduledUpdate, error) {
+	v := db.byAddress.Get(addr)
+	if v == nil {
+		return nil, nil
+	}
+	height := v.Int64()
+	updates, err := db.UpdatesAt(height)
+	if err != nil {
+		return nil, err
+	}
+	var removed *ScheduledUpdate
+	for i, u := range updates {
+		if u.Address.Equal(addr) {
+			removed = u
+			updates = append(updates[:i], updates[i+1:]...)
+			break
+		}
+	}
+	if err := db.setUpdatesAt(height, updates); err != nil {
+		return nil, err
+	}
+	if err := db.byAddress.Delete(addr); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// CancelTx removes the update scheduled for the contract if it's for the
+// deploy transaction.
+func (db *UpgradeDB) CancelTx(addr module.Address, txHash []byte) error {
+	u, _, err := db.Scheduled(addr)
+	if err != nil || u == nil || !bytes.Equal(u.TxHash, txHash) {
+		return err
+	}
+	_, err = db.Cancel(addr)
+	return err
+}
+
+func (db *UpgradeDB) History(addr module.Address) (UpgradeHistory, error) {
+	var bs []byte
+	if v := db.history.Get(addr); v != nil {
+		bs = v.Bytes()
+	}
+	history, err := UpgradeHistoryFromBytes(bs)
+	if err != nil {
+		return nil, errors.CriticalFormatError.Wrap(err, "InvalidUpgradeHistory")
+	}
+	return history, nil
+}
+
+func (db *UpgradeDB) AddHistory(addr module.Address, r UpgradeRecord) error {
+	history, err := db.History(addr)
+	if err != nil {
+		return err
+	}
+	history.Push(r)
+	return db.history.Set(addr, history.Bytes())
+}
+
+// ScheduleUpdate schedules the pending update deployed by the transaction
+// to be activated at the height. The migration method is invoked with the
+// step limit after the activation.
+func ScheduleUpdate(cc CallContext, txHash, auditTxHash []byte, height int64, limit *big.Int) (module.Address, error) {
+	if height <= cc.BlockHeight() {
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"InvalidHeight(height=%d,current=%d)", height, cc.BlockHeight())
+	}
+	if limit == nil || limit.Sign() <= 0 ||
+		limit.Cmp(cc.GetStepLimit(state.StepLimitTypeInvoke)) > 0 {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidStepLimit(limit=%s)", limit)
+	}
+	sysAs := cc.GetAccountState(state.SystemID)
+	h2a := scoredb.NewDictDB(sysAs, state.VarTxHashToAddress, 1)
+	value := h2a.Get(txHash)
+	if value == nil {
+		return nil, scoreresult.ContractNotFoundError.New("NoSCOREForTx")
+	}
+	scoreAddr := value.Address()
+	as := cc.GetAccountState(scoreAddr.ID())
+	if next := as.NextContract(); next == nil || next.Status() != state.CSPending {
+		return nil, scoreresult.ContractNotFoundError.New("NoContractToAccept")
+	}
+	if as.Contract() == nil {
+		return nil, scoreresult.InvalidParameterError.New("NotUpdate")
+	}
+	u := &ScheduledUpdate{
+		Address:     common.AddressToPtr(scoreAddr),
+		TxHash:      txHash,
+		AuditTxHash: auditTxHash,
+		StepLimit:   limit,
+	}
+	if err := NewUpgradeDB(sysAs).Schedule(height, u); err != nil {
+		return nil, err
+	}
+	return scoreAddr, nil
+}
+
+// ApplyScheduledUpdates activates updates scheduled at the current height
+// and invokes their migration methods. A failure of the update reverts
+// the update leaving the contract pending, and it's recorded in the history.
+// It returns an error only for system failures.
+func ApplyScheduledUpdates(ctx Context) error {
+	height := ctx.BlockHeight()
+	udb := NewUpgradeDB(ctx.GetAccountState(state.SystemID))
+	updates, err := udb.UpdatesAt(height)
+	if err != nil || len(updates) == 0 {
+		return err
+	}
+	ctx.SetTransactionInfo(&state.TransactionInfo{
+		Group:     module.TransactionGroupNormal,
+		Index:     0,
+		Hash:      []byte{},
+		From:      state.SystemAddress,
+		Timestamp: ctx.BlockTimeStamp(),
+	})
+	for _, u := range updates {
+		if _, err := udb.Cancel(u.Address); err != nil {
+			return err
+		}
+		r, err := applyScheduledUpdate(ctx, u)
+		if err != nil {
+			return err
+		}
+		r.Height = height
+		if err := udb.AddHistory(u.Address, *r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyScheduledUpdate(ctx Context, u *ScheduledUpdate) (*UpgradeRecord, error) {
+	cc := NewCallContext(ctx, ctx.GetStepLimit(state.StepLimitTypeInvoke), false)
+	defer cc.Dispose()
+
+	logger := cc.FrameLogger()
+	ch := NewCommonHandler(ctx.Governance(), u.Address, big.NewInt(0), false, logger)
+	uh := &UpgradeHandler{
+		CommonHandler: ch,
+		txHash:        u.TxHash,
+		auditTxHash:   u.AuditTxHash,
+		stepLimit:     u.StepLimit,
+	}
+	status, stepUsed, _, _ := cc.Call(uh, cc.StepAvailable())
+	if code := errors.CodeOf(status); code == errors.ExecutionFailError ||
+		errors.IsCriticalCode(code) {
+		return nil, status
+	}
+
+	r := &UpgradeRecord{
+		TxHash:      u.TxHash,
+		AuditTxHash: u.AuditTxHash,
+		StepUsed:    stepUsed,
+	}
+	as := cc.GetAccountState(u.Address.ID())
+	if status == nil {
+		logger.Infof("Scheduled update is activated score=%s tx=%#x", u.Address, u.TxHash)
+		r.Status = int(module.StatusSuccess)
+		r.CodeHash = as.Contract().CodeHash()
+	} else {
+		logger.Warnf("Fail to apply scheduled update score=%s tx=%#x err=%+v",
+			u.Address, u.TxHash, status)
+		s, _ := scoreresult.StatusOf(status)
+		r.Status = int(s)
+		if next := as.NextContract(); next != nil {
+			r.CodeHash = next.CodeHash()
+		}
+	}
+	return r, nil
+}
+
+// UpgradeHandler activates the pending update of the contract, and invokes
+// the migration method of the contract if it has one.
+type UpgradeHandler struct {
+	*CommonHandler
+	txHash      []byte
+	auditTxHash []byte
+	stepLimit   *big.Int
+}
+
+// It's never called
+func (h *UpgradeHandler) Prepare(ctx Context) (state.WorldContext, error) {
+	lq := []state.LockRequest{{state.WorldIDStr, state.AccountWriteLock}}
+	return ctx.GetFuture(lq), nil
+}
+
+func (h *UpgradeHandler) ExecuteSync(cc CallContext) (err error, obj *codec.TypedObj, addr module.Address) {
+	h.Log.TSystemf("UPGRADE start score=%s txhash=0x%x", h.To, h.txHash)
+	defer func() {
+		if err != nil {
+			h.Log.TSystemf("UPGRADE done status=%v", err)
+		} else {
+			h.Log.TSystem("UPGRADE done status=SUCCESS")
+		}
+	}()
+
+	ah := NewAcceptHandler(
+		NewCommonHandler(h.From, state.SystemAddress, big.NewInt(0), false, h.Log),
+		h.txHash, h.auditTxHash)
+	status, stepUsed, _, _ := cc.Call(ah, cc.StepAvailable())
+	cc.DeductSteps(stepUsed)
+	if status != nil {
+		return status, nil, nil
+	}
+
+	as := cc.GetAccountState(h.To.ID())
+	info, err := as.APIInfo()
+	if err != nil {
+		return err, nil, nil
+	}
+	method := info.GetMethod(MigrationMethod)
+	if method == nil || !method.IsCallable() || method.IsReadOnly() {
+		return nil, nil, nil
+	}
+	params, err := info.ConvertParamsToTypedObj(MigrationMethod, nil)
+	if err != nil {
+		return err, nil, nil
+	}
+	limit := h.stepLimit
+	if avail := cc.StepAvailable(); avail.Cmp(limit) < 0 {
+		limit = avail
+	}
+	handler := newCallHandlerWithParams(
+		NewCommonHandler(state.SystemAddress, h.To, big.NewInt(0), false, h.Log),
+		MigrationMethod, params, false)
+	status, stepUsed, _, _ = cc.Call(handler, limit)
+	cc.DeductSteps(stepUsed)
+	return status, nil, nil
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package contract
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+func newTestUpdate(id int) *ScheduledUpdate {
+	return &ScheduledUpdate{
+		Address:     common.MustNewAddressFromString(fmt.Sprintf("cx%040x", id)),
+		TxHash:      []byte(fmt.Sprintf("tx%d", id)),
+		AuditTxHash: []byte(fmt.Sprintf("audit%d", id)),
+		StepLimit:   big.NewInt(int64(id) * 1000),
+	}
+}
+
+func TestUpgradeDB_Schedule(t *testing.T) {
+	udb := NewUpgradeDB(&testAccountState{})
+	u1, u2, u3 := newTestUpdate(1), newTestUpdate(2), newTestUpdate(3)
+
+	assert.NoError(t, udb.Schedule(10, u1))
+	assert.NoError(t, udb.Schedule(10, u2))
+	assert.NoError(t, udb.Schedule(20, u3))
+
+	err := udb.Schedule(30, newTestUpdate(1))
+	assert.True(t, scoreresult.InvalidParameterError.Equals(err))
+
+	updates, err := udb.UpdatesAt(10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*ScheduledUpdate{u1, u2}, updates)
+
+	u, height, err := udb.Scheduled(u3.Address)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 20, height)
+	assert.EqualValues(t, u3, u)
+
+	// cancel with other transaction is ignored
+	assert.NoError(t, udb.CancelTx(u1.Address, u2.TxHash))
+	u, _, err = udb.Scheduled(u1.Address)
+	assert.NoError(t, err)
+	assert.EqualValues(t, u1, u)
+
+	assert.NoError(t, udb.CancelTx(u1.Address, u1.TxHash))
+	u, _, err = udb.Scheduled(u1.Address)
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+
+	updates, err = udb.UpdatesAt(10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*ScheduledUpdate{u2}, updates)
+
+	u, err = udb.Cancel(u2.Address)
+	assert.NoError(t, err)
+	assert.EqualValues(t, u2, u)
+	updates, err = udb.UpdatesAt(10)
+	assert.NoError(t, err)
+	assert.Len(t, updates, 0)
+
+	u, err = udb.Cancel(u2.Address)
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+
+	// it may be scheduled again after the cancel
+	assert.NoError(t, udb.Schedule(30, u1))
+}
+
+func TestUpgradeDB_History(t *testing.T) {
+	udb := NewUpgradeDB(&testAccountState{})
+	addr := common.MustNewAddressFromString("cx01")
+
+	history, err := udb.History(addr)
+	assert.NoError(t, err)
+	assert.Len(t, history, 0)
+
+	for i := 1; i <= UpgradeHistoryLimit+5; i++ {
+		assert.NoError(t, udb.AddHistory(addr, UpgradeRecord{
+			Height:   int64(i),
+			TxHash:   []byte{byte(i)},
+			CodeHash: []byte{byte(i)},
+			StepUsed: big.NewInt(int64(i)),
+		}))
+	}
+
+	history, err = udb.History(addr)
+	assert.NoError(t, err)
+	assert.Len(t, history, UpgradeHistoryLimit)
+	assert.EqualValues(t, 6, history[0].Height)
+	assert.EqualValues(t, UpgradeHistoryLimit+5, history[len(history)-1].Height)
+	assert.EqualValues(t, big.NewInt(UpgradeHistoryLimit+5), history[len(history)-1].StepUsed)
+}
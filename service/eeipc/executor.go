@@ -0,0 +1,231 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package eeipc implements the side of execution environments for the IPC
+// protocol of eeproxy. Execution environments running in the process of the
+// node use it to talk to eeproxy like external ones.
+package eeipc
+
+import (
+	"sync"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/ipc"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+// Runtime runs contracts for the Executor.
+type Runtime interface {
+	// GetAPI returns the API of the contract for the code.
+	GetAPI(code string) (*scoreapi.Info, error)
+
+	// Invoke runs the method requested by the message. It returns the
+	// result with steps used. Errors should have valid codes of
+	// scoreresult, otherwise it's reported as UnknownFailure.
+	Invoke(e *Executor, m *InvokeMessage) (*codec.TypedObj, int64, error)
+}
+
+// Executor handles requests from eeproxy through the connection, and runs
+// contracts with the Runtime.
+type Executor struct {
+	conn    ipc.Connection
+	uid     string
+	runtime Runtime
+	log     log.Logger
+
+	lock   sync.Mutex
+	closed bool
+
+	// result of the last call, which is set while it waits for the result.
+	result *ResultMessage
+}
+
+// Connect connects to eeproxy, and sends the version message with the uid
+// and the type of the execution environment.
+func Connect(net, addr, uid, eeType string, rt Runtime, l log.Logger) (*Executor, error) {
+	conn, err := ipc.Dial(net, addr)
+	if err != nil {
+		return nil, err
+	}
+	e := &Executor{
+		conn:    conn,
+		uid:     uid,
+		runtime: rt,
+		log:     l,
+	}
+	conn.SetHandler(MsgINVOKE, e)
+	conn.SetHandler(MsgRESULT, e)
+	conn.SetHandler(MsgGETAPI, e)
+	conn.SetHandler(MsgCLOSE, e)
+	conn.SetHandler(MsgPING, e)
+	m := &VersionMessage{
+		Version: ProtocolVersion,
+		UID:     uid,
+		Type:    eeType,
+	}
+	if err := conn.Send(MsgVERSION, m); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Executor) Log() log.Logger {
+	return e.log
+}
+
+// Run handles messages until the connection is closed.
+func (e *Executor) Run() error {
+	for {
+		if err := e.conn.HandleMessage(); err != nil {
+			if e.isClosed() {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (e *Executor) isClosed() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.closed
+}
+
+// Close closes the connection. Run returns after it.
+func (e *Executor) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.conn.Close()
+}
+
+// Send sends the message to eeproxy.
+func (e *Executor) Send(msg uint, data interface{}) error {
+	return e.conn.Send(msg, data)
+}
+
+// SendAndReceive sends the message, then it receives the response of the
+// same message.
+func (e *Executor) SendAndReceive(msg uint, data interface{}, resp interface{}) error {
+	return e.conn.SendAndReceive(msg, data, resp)
+}
+
+func (e *Executor) HandleMessage(c ipc.Connection, msg uint, data []byte) error {
+	switch msg {
+	case MsgINVOKE:
+		var m InvokeMessage
+		if _, err := codec.MP.UnmarshalFromBytes(data, &m); err != nil {
+			return err
+		}
+		return e.invoke(&m)
+
+	case MsgGETAPI:
+		var code string
+		if _, err := codec.MP.UnmarshalFromBytes(data, &code); err != nil {
+			return err
+		}
+		return e.getAPI(code)
+
+	case MsgRESULT:
+		var m ResultMessage
+		if _, err := codec.MP.UnmarshalFromBytes(data, &m); err != nil {
+			return err
+		}
+		if e.result != nil {
+			return errors.InvalidStateError.New("UnexpectedResult")
+		}
+		e.result = &m
+		return nil
+
+	case MsgCLOSE:
+		return e.Close()
+
+	case MsgPING:
+		var seq int
+		if _, err := codec.MP.UnmarshalFromBytes(data, &seq); err != nil {
+			return err
+		}
+		return e.conn.Send(MsgPING, &PongMessage{Seq: seq})
+
+	default:
+		return errors.IllegalArgumentError.Errorf("UnknownMessage(msg=%d)", msg)
+	}
+}
+
+// Call sends the call message, then it handles messages until it gets the
+// result of the call. Calls from other contracts may be invoked while it
+// waits.
+func (e *Executor) Call(m *CallMessage) (*ResultMessage, error) {
+	if err := e.conn.Send(MsgCALL, m); err != nil {
+		return nil, err
+	}
+	for e.result == nil {
+		if err := e.conn.HandleMessage(); err != nil {
+			return nil, err
+		}
+	}
+	r := e.result
+	e.result = nil
+	return r, nil
+}
+
+func (e *Executor) getAPI(code string) error {
+	var m GetAPIMessage
+	if api, err := e.runtime.GetAPI(code); err != nil {
+		e.log.Debugf("FailToGetAPI(code=%s) err=%+v", code, err)
+		m.Status = StatusOf(err)
+	} else {
+		m.Status = errors.Success
+		m.Info = api
+	}
+	return e.conn.Send(MsgGETAPI, &m)
+}
+
+// StatusOf returns the code for the status of the result message.
+func StatusOf(err error) errors.Code {
+	if scoreresult.IsValid(err) {
+		return errors.CodeOf(err)
+	}
+	return scoreresult.UnknownFailureError
+}
+
+func (e *Executor) invoke(m *InvokeMessage) error {
+	result, steps, err := e.runtime.Invoke(e, m)
+
+	var r ResultMessage
+	r.StepUsed.SetInt64(steps)
+	r.EID = m.EID
+	if m.State != nil {
+		r.PrevEID = m.State.PrevEID
+	}
+	if err == nil {
+		r.Status = errors.Success
+		r.Result = result
+	} else {
+		e.log.Debugf("Invoke(method=%s) fails err=%+v", m.Method, err)
+		r.Status = StatusOf(err)
+		r.Result = common.MustEncodeAny(err.Error())
+	}
+	return e.conn.Send(MsgRESULT, &r)
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eeipc
+
+import (
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/service/scoreapi"
+)
+
+// Messages and their formats are same as the ones used by eeproxy.
+const (
+	MsgVERSION    = 0
+	MsgINVOKE     = 1
+	MsgRESULT     = 2
+	MsgGETVALUE   = 3
+	MsgSETVALUE   = 4
+	MsgCALL       = 5
+	MsgEVENT      = 6
+	MsgGETBALANCE = 8
+	MsgGETAPI     = 9
+	MsgLOG        = 10
+	MsgCLOSE      = 11
+	MsgSETFEEPCT  = 15
+	MsgPING       = 17
+)
+
+const (
+	ProtocolVersion = 2
+)
+
+type VersionMessage struct {
+	Version uint16 `codec:"version"`
+	UID     string
+	Type    string
+}
+
+const (
+	InvokeFlagReadOnly = 1 << iota
+	InvokeFlagTrace
+)
+
+type CodeState struct {
+	NexHash   int
+	GraphHash []byte
+	PrevEID   int
+}
+
+type InvokeMessage struct {
+	Code   string `codec:"code"`
+	Flag   int
+	From   *common.Address `codec:"from"`
+	To     common.Address  `codec:"to"`
+	Value  common.HexInt   `codec:"value"`
+	Limit  common.HexInt   `codec:"limit"`
+	Method string          `codec:"method"`
+	Params *codec.TypedObj `codec:"params"`
+	Info   *codec.TypedObj `codec:"info"`
+	CID    []byte
+	EID    int
+	State  *CodeState
+}
+
+// CodeMask is for the code in the status of the result message. Upper bits
+// are used for flags.
+const CodeMask = (1 << 24) - 1
+
+type ResultMessage struct {
+	Status   errors.Code
+	StepUsed common.HexInt
+	Result   *codec.TypedObj
+	EID      int
+	PrevEID  int
+}
+
+type GetValueMessage struct {
+	Success bool
+	Value   []byte
+}
+
+const (
+	FlagDELETE uint16 = 1 << iota
+	FlagOLDVALUE
+)
+
+type SetValueMessage struct {
+	Key   []byte `codec:"key"`
+	Flag  uint16
+	Value []byte `codec:"value"`
+}
+
+type OldValueMessage struct {
+	HasOld  bool
+	OldSize int
+}
+
+type CallMessage struct {
+	To       common.Address
+	Value    common.HexInt
+	Limit    common.HexInt
+	DataType string
+	Data     *codec.TypedObj
+}
+
+type EventMessage struct {
+	Indexed [][]byte
+	Data    [][]byte
+}
+
+type GetAPIMessage struct {
+	Status errors.Code
+	Info   *scoreapi.Info
+}
+
+const (
+	LogFlagTrace = 1 << iota
+)
+
+// PongMessage is the answer for the ping with its sequence. Memory isn't
+// reported, because it's shared with the node.
+type PongMessage struct {
+	Seq    int
+	Memory int64
+}
+
+type LogMessage struct {
+	Level   log.Level
+	Flag    int
+	Message string
+}
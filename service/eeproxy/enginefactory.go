@@ -25,6 +25,12 @@ func AllocEngines(l log.Logger, names ...string) ([]Engine, error) {
 			} else {
 				engines[i] = engine
 			}
+		case "wasm":
+			if engine, err := NewWasmEE(l); err != nil {
+				return nil, err
+			} else {
+				engines[i] = engine
+			}
 		default:
 			return nil, errors.IllegalArgumentError.Errorf(
 				"IllegalEngineName(name=%s)", name)
@@ -29,6 +29,8 @@ const (
 type Manager interface {
 	GetExecutor(pr RequestPriority) *Executor
 	SetInstances(total, tx, query int) error
+	SetSupervision(c SupervisorConfig)
+	Stats() []EngineStats
 	Loop() error
 	Close() error
 }
@@ -77,6 +79,9 @@ type engine struct {
 	active int
 	ready  *proxy
 	using  *proxy
+
+	restarts int
+	policy   restartPolicy
 }
 
 type executorState struct {
@@ -96,6 +101,11 @@ type executorManager struct {
 	executorLimit  int
 	executorStates [numberOfPriorities]executorState
 
+	supervision SupervisorConfig
+	kick        chan struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+
 	log log.Logger
 }
 
@@ -179,6 +189,9 @@ func (em *executorManager) OnClose(c ipc.Connection) {
 }
 
 func (em *executorManager) Close() error {
+	em.closeOnce.Do(func() {
+		close(em.done)
+	})
 	if err := em.server.Close(); err != nil {
 		return err
 	}
@@ -260,6 +273,7 @@ func (em *executorManager) SetInstances(total, tx, query int) error {
 }
 
 func (em *executorManager) Loop() error {
+	go em.supervise()
 	return em.server.Loop()
 }
 
@@ -297,6 +311,8 @@ func NewManager(net, addr string, l log.Logger, engines ...Engine) (Manager, err
 	srv.SetHandler(em)
 	em.server = srv
 	em.log = l.WithFields(log.Fields{log.FieldKeyModule: "EEP"})
+	em.kick = make(chan struct{}, 1)
+	em.done = make(chan struct{})
 
 	for i := 0; i < len(em.executorStates); i++ {
 		em.executorStates[i].waiter = sync.NewCond(&em.lock)
@@ -3,6 +3,7 @@ package eeproxy
 import (
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/gofrs/uuid"
 
@@ -37,6 +38,7 @@ const (
 	msgSETOBJGRAPH = 14
 	msgSETFEEPCT   = 15
 	msgCONTAINS    = 16
+	msgPING        = 17
 )
 
 type proxyState int
@@ -93,9 +95,10 @@ type proxyManager interface {
 }
 
 type callFrame struct {
-	addr module.Address
-	ctx  CallContext
-	log  *trace.Logger
+	addr  module.Address
+	ctx   CallContext
+	log   *trace.Logger
+	start time.Time
 
 	prev *callFrame
 }
@@ -114,6 +117,7 @@ type proxy struct {
 	log *trace.Logger
 
 	frame *callFrame
+	stats proxyStats
 
 	next  *proxy
 	pprev **proxy
@@ -271,12 +275,14 @@ func (p *proxy) Invoke(
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	p.frame = &callFrame{
-		addr: to,
-		ctx:  ctx,
-		log:  p.log,
-		prev: p.frame,
+		addr:  to,
+		ctx:   ctx,
+		log:   p.log,
+		start: time.Now(),
+		prev:  p.frame,
 	}
 	p.log = logger
+	p.stats.onSend(p.frame.start)
 	return p.conn.Send(msgINVOKE, &m)
 }
 
@@ -288,12 +294,14 @@ func (p *proxy) GetAPI(ctx CallContext, code string) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	p.frame = &callFrame{
-		addr: nil,
-		ctx:  ctx,
-		log:  p.log,
-		prev: p.frame,
+		addr:  nil,
+		ctx:   ctx,
+		log:   p.log,
+		start: time.Now(),
+		prev:  p.frame,
 	}
 	p.log = logger
+	p.stats.onSend(p.frame.start)
 	return p.conn.Send(msgGETAPI, code)
 }
 
@@ -355,6 +363,10 @@ func (p *proxy) SendResult(ctx CallContext, status error, steps *big.Int, result
 	}
 	m.EID = eid
 	m.PrevEID = last
+
+	p.lock.Lock()
+	p.stats.onSend(time.Now())
+	p.lock.Unlock()
 	return p.conn.Send(msgRESULT, &m)
 }
 
@@ -366,6 +378,7 @@ func (p *proxy) popFrame() *callFrame {
 		frame := p.frame
 		p.log = frame.log
 		p.frame = frame.prev
+		p.stats.onDone(time.Since(frame.start))
 		return frame
 	}
 	return nil
@@ -393,6 +406,10 @@ func (p *proxy) tryToBeReadyInLock() bool {
 }
 
 func (p *proxy) HandleMessage(c ipc.Connection, msg uint, data []byte) error {
+	p.lock.Lock()
+	p.stats.onReceive(time.Now(), msg)
+	p.lock.Unlock()
+
 	switch msg {
 	case msgRESULT:
 		var m resultMessage
@@ -604,6 +621,16 @@ func (p *proxy) HandleMessage(c ipc.Connection, msg uint, data []byte) error {
 			p, m.Prefix, m.Value, m.Limit, yn, cnt, sz)
 		return p.conn.Send(msgCONTAINS, &res)
 
+	case msgPING:
+		var m pongMessage
+		if _, err := codec.MP.UnmarshalFromBytes(data, &m); err != nil {
+			return err
+		}
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		p.stats.onPong(time.Now(), &m)
+		return nil
+
 	default:
 		p.log.Warnf("Proxy[%p].HandleMessage(msg=%d) UnknownMessage", msg)
 		return errors.ErrIllegalArgument
@@ -682,7 +709,8 @@ func newProxy(m proxyManager, c ipc.Connection, l log.Logger, t string, v uint16
 		scoreType: t,
 		version:   v,
 		uid:       uid,
-		state:     stateIdle,
+		state:     stateReady,
+		stats:     newProxyStats(time.Now()),
 	}
 	c.SetHandler(msgRESULT, p)
 	c.SetHandler(msgGETVALUE, p)
@@ -698,12 +726,13 @@ func newProxy(m proxyManager, c ipc.Connection, l log.Logger, t string, v uint16
 	c.SetHandler(msgSETOBJGRAPH, p)
 	c.SetHandler(msgSETFEEPCT, p)
 	c.SetHandler(msgCONTAINS, p)
+	c.SetHandler(msgPING, p)
 
+	// it becomes ready before it's attached, so others can reserve it.
 	if err := m.onReady(p); err != nil {
 		p.state = stateStopped
 		return nil, err
 	}
-	p.state = stateReady
 	return p, nil
 }
 
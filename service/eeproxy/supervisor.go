@@ -0,0 +1,379 @@
+package eeproxy
+
+import (
+	"sort"
+	"time"
+)
+
+// PingVersion is the protocol version of execution environments answering
+// to the ping. Execution environments of older versions are not probed.
+const PingVersion = 2
+
+const (
+	minSupervisorTick = 10 * time.Millisecond
+	maxSupervisorTick = time.Second
+	maxRestartBackoff = time.Minute
+)
+
+// SupervisorConfig is the configuration for supervising executors.
+// Zero value for a duration disables the related check.
+type SupervisorConfig struct {
+	// PingInterval is the interval of pings to idle executors.
+	PingInterval time.Duration
+
+	// PingTimeout is the time to wait for the answer of the ping before it
+	// restarts the executor.
+	PingTimeout time.Duration
+
+	// HangTimeout is the time to wait for a message from the executor
+	// handling a request before it restarts the executor.
+	HangTimeout time.Duration
+
+	// RestartBackoff is the delay between restarts of executors of an
+	// engine. It's doubled on consecutive restarts up to a minute.
+	RestartBackoff time.Duration
+}
+
+func (c *SupervisorConfig) enabled() bool {
+	return c.PingInterval > 0 || c.HangTimeout > 0
+}
+
+func (c *SupervisorConfig) tick() time.Duration {
+	tick := maxSupervisorTick
+	for _, d := range []time.Duration{c.PingInterval, c.PingTimeout, c.HangTimeout} {
+		if d > 0 && d/2 < tick {
+			tick = d / 2
+		}
+	}
+	if tick < minSupervisorTick {
+		tick = minSupervisorTick
+	}
+	return tick
+}
+
+// ExecutorStats is the state and statistics of an executor.
+type ExecutorStats struct {
+	UID            string
+	Version        uint16
+	State          string
+	Since          time.Time
+	Calls          int64
+	AverageLatency time.Duration
+	LastActive     time.Time
+	PingRTT        time.Duration
+	Memory         int64
+}
+
+// EngineStats is the state of executors of an engine.
+type EngineStats struct {
+	Type      string
+	Active    int
+	Restarts  int
+	Executors []ExecutorStats
+}
+
+type pongMessage struct {
+	Seq    int
+	Memory int64
+}
+
+type proxyStats struct {
+	since   time.Time
+	calls   int64
+	latency time.Duration
+
+	// active is the time of the last message from or to the executor, and
+	// pending is whether the executor is supposed to work for the request.
+	active  time.Time
+	pending bool
+
+	pingSeq int
+	pingAt  time.Time
+	pongAt  time.Time
+	pingRTT time.Duration
+	memory  int64
+}
+
+func newProxyStats(now time.Time) proxyStats {
+	return proxyStats{
+		since:  now,
+		active: now,
+	}
+}
+
+func (s *proxyStats) onSend(now time.Time) {
+	s.active = now
+	s.pending = true
+}
+
+func (s *proxyStats) onReceive(now time.Time, msg uint) {
+	s.active = now
+	if msg == msgCALL || msg == msgRESULT || msg == msgGETAPI {
+		s.pending = false
+	}
+}
+
+func (s *proxyStats) onDone(latency time.Duration) {
+	s.calls += 1
+	s.latency += latency
+}
+
+func (s *proxyStats) onPong(now time.Time, m *pongMessage) {
+	if m.Seq != s.pingSeq || s.pingAt.IsZero() {
+		return
+	}
+	s.pingRTT = now.Sub(s.pingAt)
+	s.pingAt = time.Time{}
+	s.pongAt = now
+	s.memory = m.Memory
+}
+
+func (p *proxy) getStats() ExecutorStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var state string
+	switch {
+	case p.state >= stateStopped:
+		state = "stopped"
+	case p.frame != nil && p.stats.pending:
+		state = "busy"
+	case p.frame != nil:
+		state = "waiting"
+	case p.state == stateReserved:
+		state = "reserved"
+	default:
+		state = "idle"
+	}
+	s := ExecutorStats{
+		UID:        p.uid,
+		Version:    p.version,
+		State:      state,
+		Since:      p.stats.since,
+		Calls:      p.stats.calls,
+		LastActive: p.stats.active,
+		PingRTT:    p.stats.pingRTT,
+		Memory:     p.stats.memory,
+	}
+	if p.stats.calls > 0 {
+		s.AverageLatency = p.stats.latency / time.Duration(p.stats.calls)
+	}
+	return s
+}
+
+type checkResult int
+
+const (
+	checkOK checkResult = iota
+	checkPing
+	checkRestart
+)
+
+// checkIdle checks the executor in the ready list. It returns checkPing
+// if it's time to ping, and checkRestart if it misses the answer.
+func (p *proxy) checkIdle(now time.Time, c *SupervisorConfig) checkResult {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if c.PingInterval <= 0 || p.version < PingVersion || p.state != stateReady {
+		return checkOK
+	}
+	if !p.stats.pingAt.IsZero() {
+		if c.PingTimeout > 0 && now.Sub(p.stats.pingAt) > c.PingTimeout {
+			return checkRestart
+		}
+		return checkOK
+	}
+	last := p.stats.active
+	if p.stats.pongAt.After(last) {
+		last = p.stats.pongAt
+	}
+	if now.Sub(last) >= c.PingInterval {
+		return checkPing
+	}
+	return checkOK
+}
+
+// checkBusy checks the executor in use. It returns checkRestart if the
+// executor doesn't send any message for the request too long.
+func (p *proxy) checkBusy(now time.Time, c *SupervisorConfig) checkResult {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if c.HangTimeout <= 0 || p.state != stateReserved || p.frame == nil || !p.stats.pending {
+		return checkOK
+	}
+	if now.Sub(p.stats.active) > c.HangTimeout {
+		return checkRestart
+	}
+	return checkOK
+}
+
+func (p *proxy) ping(now time.Time) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// it shouldn't be sent after the request, because executors expect
+	// specific messages while they handle the request.
+	if p.state != stateReady || p.frame != nil {
+		return nil
+	}
+	p.stats.pingSeq += 1
+	p.stats.pingAt = now
+	return p.conn.Send(msgPING, p.stats.pingSeq)
+}
+
+type restartPolicy struct {
+	count int
+	last  time.Time
+	next  time.Time
+}
+
+// allow returns whether it may restart an executor now. Delays are doubled
+// for consecutive restarts, and they are reset after a quiet period.
+func (r *restartPolicy) allow(now time.Time, base time.Duration) bool {
+	if now.Before(r.next) {
+		return false
+	}
+	if now.Sub(r.last) > maxRestartBackoff {
+		r.count = 0
+	}
+	backoff := base
+	for i := 0; i < r.count && backoff < maxRestartBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRestartBackoff {
+		backoff = maxRestartBackoff
+	}
+	r.count += 1
+	r.last = now
+	r.next = now.Add(backoff)
+	return true
+}
+
+func (em *executorManager) SetSupervision(c SupervisorConfig) {
+	em.lock.Lock()
+	defer em.lock.Unlock()
+
+	em.supervision = c
+	select {
+	case em.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (em *executorManager) Stats() []EngineStats {
+	em.lock.Lock()
+	defer em.lock.Unlock()
+
+	stats := make([]EngineStats, 0, len(em.engines))
+	for name, e := range em.engines {
+		es := EngineStats{
+			Type:     name,
+			Active:   e.active,
+			Restarts: e.restarts,
+		}
+		for _, l := range []*proxy{e.ready, e.using} {
+			for p := l; p != nil; p = p.next {
+				es.Executors = append(es.Executors, p.getStats())
+			}
+		}
+		stats = append(stats, es)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Type < stats[j].Type
+	})
+	return stats
+}
+
+func (em *executorManager) supervise() {
+	for {
+		em.lock.Lock()
+		c := em.supervision
+		em.lock.Unlock()
+
+		var timer <-chan time.Time
+		if c.enabled() {
+			timer = time.After(c.tick())
+		}
+		select {
+		case <-em.done:
+			return
+		case <-em.kick:
+		case now := <-timer:
+			em.check(now, &c)
+		}
+	}
+}
+
+type restartItem struct {
+	engine string
+	proxy  *proxy
+	idle   bool
+}
+
+func (em *executorManager) check(now time.Time, c *SupervisorConfig) {
+	var pings []*proxy
+	var restarts []restartItem
+
+	em.lock.Lock()
+	for name, e := range em.engines {
+		for p := e.ready; p != nil; p = p.next {
+			switch p.checkIdle(now, c) {
+			case checkPing:
+				pings = append(pings, p)
+			case checkRestart:
+				restarts = append(restarts, restartItem{name, p, true})
+			}
+		}
+		for p := e.using; p != nil; p = p.next {
+			if p.checkBusy(now, c) == checkRestart {
+				restarts = append(restarts, restartItem{name, p, false})
+			}
+		}
+	}
+	for i := 0; i < len(restarts); {
+		item := restarts[i]
+		e := em.engines[item.engine]
+		if !e.policy.allow(now, c.RestartBackoff) {
+			restarts = append(restarts[:i], restarts[i+1:]...)
+			continue
+		}
+		e.restarts += 1
+		if item.idle {
+			// it's not available any more, and closing the connection
+			// doesn't affect the count.
+			item.proxy.detach()
+			e.active -= 1
+		}
+		i++
+	}
+	em.lock.Unlock()
+
+	for _, p := range pings {
+		if err := p.ping(now); err != nil {
+			em.log.Warnf("Fail to ping proxy=%s-%s err=%+v", p.scoreType, p.uid, err)
+		}
+	}
+	for _, item := range restarts {
+		p := item.proxy
+		if item.idle {
+			em.log.Warnf("Restart proxy=%s-%s (no answer for ping)", p.scoreType, p.uid)
+		} else {
+			em.log.Warnf("Restart proxy=%s-%s (no progress for %s)",
+				p.scoreType, p.uid, c.HangTimeout)
+		}
+		if item.idle {
+			if err := p.Kill(); err != nil {
+				em.log.Warnf("Fail to kill proxy=%s-%s err=%+v", p.scoreType, p.uid, err)
+			}
+			_ = p.close()
+		} else {
+			// closing the connection fails the request in progress.
+			if err := em.kill(p.uid); err != nil {
+				em.log.Warnf("Fail to kill proxy=%s-%s err=%+v", p.scoreType, p.uid, err)
+				_ = p.conn.Close()
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package eeproxy_test
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/mockee"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/state"
+)
+
+type resultContext struct {
+	result chan error
+}
+
+func (c *resultContext) GetValue(key []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *resultContext) SetValue(key []byte, value []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *resultContext) DeleteValue(key []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *resultContext) ArrayDBContains(prefix, value []byte, limit int64) (bool, int, int, error) {
+	return false, 0, 0, nil
+}
+
+func (c *resultContext) GetInfo() *codec.TypedObj {
+	return common.MustEncodeAny(map[string]interface{}{})
+}
+
+func (c *resultContext) GetBalance(addr module.Address) *big.Int {
+	return new(big.Int)
+}
+
+func (c *resultContext) OnEvent(addr module.Address, indexed, data [][]byte) error {
+	return nil
+}
+
+func (c *resultContext) OnResult(status error, flag int, steps *big.Int, r *codec.TypedObj) {
+	c.result <- status
+}
+
+func (c *resultContext) OnCall(from, to module.Address, value, limit *big.Int, dataType string, dataObj *codec.TypedObj) {
+}
+
+func (c *resultContext) OnAPI(status error, info *scoreapi.Info) {
+	c.result <- status
+}
+
+func (c *resultContext) OnSetFeeProportion(portion int) {
+}
+
+func (c *resultContext) SetCode(code []byte) error {
+	return nil
+}
+
+func (c *resultContext) GetObjGraph(bool) (int, []byte, []byte, error) {
+	return 0, nil, nil, nil
+}
+
+func (c *resultContext) SetObjGraph(flags bool, nextHash int, objGraph []byte) error {
+	return nil
+}
+
+func (c *resultContext) Logger() log.Logger {
+	return log.GlobalLogger()
+}
+
+func newTestManager(t *testing.T, c *mockee.Contract) (eeproxy.Manager, string) {
+	dir := t.TempDir()
+	code := filepath.Join(dir, "contract")
+	require.NoError(t, os.MkdirAll(code, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(code, "code.jar"), []byte("test"), 0644))
+
+	engine, err := mockee.NewEngine(state.JavaEE, log.GlobalLogger())
+	require.NoError(t, err)
+	engine.Register("test", c)
+	mgr, err := eeproxy.NewManager("unix", filepath.Join(dir, "ee.sock"), log.GlobalLogger(), engine)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		mgr.Close()
+	})
+	go mgr.Loop()
+	require.NoError(t, mgr.SetInstances(1, 1, 1))
+	return mgr, code
+}
+
+func executorsOf(mgr eeproxy.Manager) []eeproxy.ExecutorStats {
+	stats := mgr.Stats()
+	if len(stats) != 1 {
+		return nil
+	}
+	return stats[0].Executors
+}
+
+func TestManager_Ping(t *testing.T) {
+	mgr, _ := newTestManager(t, &mockee.Contract{})
+	mgr.SetSupervision(eeproxy.SupervisorConfig{
+		PingInterval: 20 * time.Millisecond,
+		PingTimeout:  time.Second,
+	})
+
+	assert.Eventually(t, func() bool {
+		executors := executorsOf(mgr)
+		return len(executors) == 1 && executors[0].PingRTT > 0
+	}, 3*time.Second, 10*time.Millisecond)
+
+	stats := mgr.Stats()
+	assert.Equal(t, string(state.JavaEE), stats[0].Type)
+	assert.Equal(t, 0, stats[0].Restarts)
+	assert.Equal(t, "idle", stats[0].Executors[0].State)
+	assert.EqualValues(t, eeproxy.PingVersion, stats[0].Executors[0].Version)
+}
+
+func TestManager_RestartOnHang(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := &mockee.Contract{
+		Methods: []*scoreapi.Method{{
+			Type:  scoreapi.Function,
+			Name:  "hang",
+			Flags: scoreapi.FlagExternal,
+		}, {
+			Type:  scoreapi.Function,
+			Name:  "ok",
+			Flags: scoreapi.FlagExternal,
+		}},
+		Handlers: map[string]mockee.Handler{
+			"hang": func(ctx *mockee.Context, params []interface{}) (interface{}, error) {
+				<-release
+				return nil, nil
+			},
+			"ok": func(ctx *mockee.Context, params []interface{}) (interface{}, error) {
+				return nil, nil
+			},
+		},
+	}
+	mgr, code := newTestManager(t, c)
+	mgr.SetSupervision(eeproxy.SupervisorConfig{
+		HangTimeout:    50 * time.Millisecond,
+		RestartBackoff: 10 * time.Millisecond,
+	})
+
+	to := common.MustNewAddressFromString("cx0000000000000000000000000000000000000001")
+	invoke := func(method string) error {
+		ex := mgr.GetExecutor(eeproxy.ForTransaction)
+		defer ex.Release()
+		ctx := &resultContext{result: make(chan error, 1)}
+		proxy := ex.Get(string(state.JavaEE))
+		err := proxy.Invoke(ctx, code, false, nil, to, new(big.Int),
+			big.NewInt(1000), method, common.MustEncodeAny([]interface{}{}), nil, 1, nil)
+		require.NoError(t, err)
+		select {
+		case err := <-ctx.result:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("no result")
+			return nil
+		}
+	}
+
+	assert.NoError(t, invoke("ok"))
+	err := invoke("hang")
+	assert.True(t, errors.ExecutionFailError.Equals(err), err)
+	assert.Equal(t, 1, mgr.Stats()[0].Restarts)
+
+	// a new executor handles requests after the restart
+	assert.NoError(t, invoke("ok"))
+}
@@ -0,0 +1,151 @@
+package eeproxy
+
+import (
+	"sync"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/ipc"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/service/eeipc"
+	"github.com/icon-project/goloop/service/wasmee"
+)
+
+const (
+	WasmEE = "wasmee"
+)
+
+type wasmInstance struct {
+	uid      string
+	executor *eeipc.Executor
+	status   InstanceStatus
+}
+
+// wasmExecutionEngine runs executors for WebAssembly contracts in the
+// process. Each instance connects to the manager like external ones.
+type wasmExecutionEngine struct {
+	lock      sync.Mutex
+	target    int
+	instances map[string]*wasmInstance
+	net, addr string
+	logger    log.Logger
+}
+
+func (e *wasmExecutionEngine) Type() string {
+	return wasmee.EEType
+}
+
+func (e *wasmExecutionEngine) Init(net, addr string) error {
+	e.net = net
+	e.addr = addr
+	return nil
+}
+
+func (e *wasmExecutionEngine) SetInstances(n int) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if n < 0 {
+		return errors.ErrIllegalArgument
+	}
+
+	e.target = n
+	for e.target > len(e.instances) {
+		if err := e.startNew(); err != nil {
+			e.logger.Errorf("Fail to start execution engine err=%+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *wasmExecutionEngine) OnAttach(uid string) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if is, ok := e.instances[uid]; ok {
+		is.status = instanceOnline
+		return true
+	}
+	return false
+}
+
+func (e *wasmExecutionEngine) OnEnd(uid string) bool {
+	return true
+}
+
+func (e *wasmExecutionEngine) Kill(uid string) (bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if is, ok := e.instances[uid]; ok {
+		return true, is.executor.Close()
+	} else {
+		return false, nil
+	}
+}
+
+func (e *wasmExecutionEngine) start(i *wasmInstance) error {
+	i.uid = newUID()
+	logger := e.logger.WithFields(log.Fields{log.FieldKeyEID: i.uid})
+	executor, err := wasmee.Connect(e.net, e.addr, i.uid, logger)
+	if err != nil {
+		return err
+	}
+	i.executor = executor
+	i.status = instanceStarted
+	e.instances[i.uid] = i
+	return nil
+}
+
+func (e *wasmExecutionEngine) run(i *wasmInstance) {
+	for {
+		err := i.executor.Run()
+		e.logger.Tracef("Run result uid=%s err=%+v", i.uid, err)
+
+		e.lock.Lock()
+		delete(e.instances, i.uid)
+		if i.status != instanceOnline {
+			e.logger.Warnf("It's not correctly started status=%s err=%+v",
+				i.status, err)
+			e.lock.Unlock()
+			return
+		}
+		if len(e.instances) >= e.target {
+			e.logger.Tracef("End the instance uid=%s", i.uid)
+			e.lock.Unlock()
+			return
+		}
+		e.logger.Warnf("Instance uid=%s is stopped err=%+v", i.uid, err)
+		if err := e.start(i); err != nil {
+			e.logger.Errorf("Fail to start instance err=%+v", err)
+			e.lock.Unlock()
+			return
+		}
+		e.lock.Unlock()
+	}
+}
+
+func (e *wasmExecutionEngine) startNew() error {
+	i := new(wasmInstance)
+	if err := e.start(i); err != nil {
+		return err
+	}
+	go e.run(i)
+	return nil
+}
+
+func (e *wasmExecutionEngine) OnConnect(conn ipc.Connection, version uint16) error {
+	return common.ErrUnsupported
+}
+
+func (e *wasmExecutionEngine) OnClose(conn ipc.Connection) bool {
+	return false
+}
+
+func NewWasmEE(logger log.Logger) (Engine, error) {
+	return &wasmExecutionEngine{
+		instances: make(map[string]*wasmInstance),
+		logger:    logger.WithFields(log.Fields{log.FieldKeyModule: WasmEE}),
+	}, nil
+}
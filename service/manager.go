@@ -61,6 +61,13 @@ type manager struct {
 	log log.Logger
 
 	skipTxPatch atomic.Value
+	lastResult  atomic.Value
+}
+
+// finalizedResult is the result of the last finalized transition.
+type finalizedResult struct {
+	result []byte
+	height int64
 }
 
 func NewManager(chain module.Chain, nm module.NetworkManager,
@@ -119,6 +126,7 @@ func NewManager(chain module.Chain, nm module.NetworkManager,
 		dsm: dsm,
 		lm:  lm,
 	}
+	tm.SetTxValidator(mgr)
 	if nm != nil {
 		mgr.txReactor = NewTransactionReactor(nm, tm)
 	}
@@ -321,6 +329,10 @@ func (m *manager) Finalize(t module.Transition, opt int) error {
 				return err
 			}
 			m.tm.NotifyFinalized(tst.patchTransactions, tst.patchReceipts, tst.normalTransactions, tst.normalReceipts)
+			m.lastResult.Store(&finalizedResult{
+				result: tst.Result(),
+				height: tst.bi.Height(),
+			})
 			now := time.Now()
 			m.patchMetric.OnFinalize(tst.patchTransactions.Hash(), now)
 			m.normalMetric.OnFinalize(tst.normalTransactions.Hash(), now)
@@ -459,7 +471,50 @@ func (m *manager) preValidateTx(result []byte, height int64, tx transaction.Tran
 	if err != nil {
 		return err
 	}
-	return tx.PreValidate(&worldContextWrapper{wc, height}, false)
+	if err := tx.PreValidate(&worldContextWrapper{wc, height}, false); err != nil {
+		return err
+	}
+	if tx.Version() == module.TransactionVersion4 {
+		return m.validateByWallet(result, height, tx)
+	}
+	return nil
+}
+
+func (m *manager) validateByWallet(result []byte, height int64, tx transaction.Transaction) error {
+	wss, err := m.trc.GetWorldSnapshot(result, nil)
+	if err != nil {
+		return err
+	}
+	ws := state.NewReadOnlyWorldState(wss)
+	wc := state.NewWorldContext(ws, common.NewBlockInfo(height, tx.Timestamp()), nil, m.plt)
+	ctx := contract.NewContext(wc, m.cm, m.eem, m.chain, m.log, nil, eeproxy.ForQuery)
+	return transaction.ValidateByWallet(ctx, tx)
+}
+
+// ValidateTx drops the transaction rejected by the wallet of the sender
+// from the proposal, as validators reject the block including it.
+func (m *manager) ValidateTx(wc state.WorldContext, tx transaction.Transaction) error {
+	if tx.Version() != module.TransactionVersion4 {
+		return nil
+	}
+	qc := wc.WorldStateChanged(state.NewReadOnlyWorldState(wc.GetSnapshot()))
+	ctx := contract.NewContext(qc, m.cm, m.eem, m.chain, m.log, nil, eeproxy.ForQuery)
+	return transaction.ValidateByWallet(ctx, tx)
+}
+
+// ValidateNewTx checks the transaction from peers with the state of the last
+// finalized transition, so that the transaction rejected by the wallet
+// doesn't take a room of the pool. Before the first finalization, it's left
+// to the validation for the proposal.
+func (m *manager) ValidateNewTx(tx transaction.Transaction) error {
+	if tx.Version() != module.TransactionVersion4 {
+		return nil
+	}
+	last, ok := m.lastResult.Load().(*finalizedResult)
+	if !ok {
+		return nil
+	}
+	return m.validateByWallet(last.result, last.height+1, tx)
 }
 
 func (m *manager) SendTransaction(result []byte, height int64, txi interface{}) ([]byte, error) {
@@ -525,11 +580,15 @@ func (m *manager) ValidatorListFromHash(hash []byte) module.ValidatorList {
 }
 
 func (m *manager) getSystemByteStoreState(result []byte) (containerdb.BytesStoreState, error) {
+	return m.getByteStoreStateOf(result, state.SystemID)
+}
+
+func (m *manager) getByteStoreStateOf(result []byte, id []byte) (containerdb.BytesStoreState, error) {
 	wss, err := m.trc.GetWorldSnapshot(result, nil)
 	if err != nil {
 		return nil, err
 	}
-	ass := wss.GetAccountSnapshot(state.SystemID)
+	ass := wss.GetAccountSnapshot(id)
 	if ass == nil {
 		return containerdb.EmptyBytesStoreState, nil
 	}
@@ -553,6 +612,20 @@ func (m *manager) GetBalance(result []byte, addr module.Address) (*big.Int, erro
 	return ass.GetBalance(), nil
 }
 
+func (m *manager) GetTokenBalance(result []byte, id int64, addr module.Address) (*big.Int, error) {
+	as, err := m.getByteStoreStateOf(result, state.TokenID)
+	if err != nil {
+		return nil, err
+	}
+	tdb := contract.NewTokenDB(as)
+	if t, err := tdb.Get(id); err != nil {
+		return nil, err
+	} else if t == nil {
+		return nil, errors.NotFoundError.Errorf("NoToken(id=%d)", id)
+	}
+	return tdb.BalanceOf(id, addr), nil
+}
+
 func (m *manager) GetTotalSupply(result []byte) (*big.Int, error) {
 	as, err := m.getSystemByteStoreState(result)
 	if err != nil {
@@ -845,13 +918,17 @@ func (m *manager) WaitForTransaction(
 }
 
 func (m *manager) ExportResult(result []byte, vh []byte, d db.Database) error {
+	return ExportResult(m.db, m.plt, result, vh, d)
+}
+
+// ExportState exports entries of the world state of the result to the
+// database. Unlike ExportResult, receipts are not exported.
+func (m *manager) ExportState(result []byte, vh []byte, d db.Database) error {
 	r, err := newTransitionResultFromBytes(result)
 	if err != nil {
 		return err
 	}
 	e := merkle.PrepareCopyContext(m.db, d)
-	txresult.NewReceiptListWithBuilder(e.Builder(), r.NormalReceiptHash)
-	txresult.NewReceiptListWithBuilder(e.Builder(), r.PatchReceiptHash)
 	ess := m.plt.NewExtensionWithBuilder(e.Builder(), r.ExtensionData)
 	state.NewWorldSnapshotWithBuilder(e.Builder(), r.StateHash, vh, ess, r.BTPData)
 	return e.Run()
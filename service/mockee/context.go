@@ -0,0 +1,301 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mockee
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/intconv"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeipc"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+const (
+	dataTypeCall = "call"
+)
+
+// Context is the environment of an invocation given to the handler.
+type Context struct {
+	executor *eeipc.Executor
+	method   string
+	from     *common.Address
+	to       common.Address
+	value    *big.Int
+	readOnly bool
+	trace    bool
+	limit    int64
+	used     int64
+	info     map[string]interface{}
+}
+
+func newContext(e *eeipc.Executor, m *eeipc.InvokeMessage) (*Context, error) {
+	ctx := &Context{
+		executor: e,
+		method:   m.Method,
+		from:     m.From,
+		to:       m.To,
+		value:    new(big.Int).Set(&m.Value.Int),
+		readOnly: (m.Flag & eeipc.InvokeFlagReadOnly) != 0,
+		trace:    (m.Flag & eeipc.InvokeFlagTrace) != 0,
+	}
+	if m.Limit.IsInt64() {
+		ctx.limit = m.Limit.Int64()
+	} else {
+		ctx.limit = int64(^uint64(0) >> 1)
+	}
+	if info, err := common.DecodeAny(m.Info); err != nil {
+		return nil, err
+	} else if mi, ok := info.(map[string]interface{}); ok {
+		ctx.info = mi
+	} else {
+		ctx.info = make(map[string]interface{})
+	}
+	return ctx, nil
+}
+
+// Method returns the name of the method being invoked.
+func (ctx *Context) Method() string {
+	return ctx.method
+}
+
+// From returns the caller. It's nil for queries.
+func (ctx *Context) From() module.Address {
+	if ctx.from == nil {
+		return nil
+	}
+	return ctx.from
+}
+
+// Address returns the address of the contract.
+func (ctx *Context) Address() module.Address {
+	return &ctx.to
+}
+
+// Value returns the amount of coins transferred with the call.
+func (ctx *Context) Value() *big.Int {
+	return ctx.value
+}
+
+// ReadOnly returns whether the contract is invoked for a query.
+func (ctx *Context) ReadOnly() bool {
+	return ctx.readOnly
+}
+
+// Info returns information of the block and the transaction like
+// "B.height", "T.hash" and "StepCosts".
+func (ctx *Context) Info() map[string]interface{} {
+	return ctx.info
+}
+
+// StepLimit returns the limit of steps for the invocation.
+func (ctx *Context) StepLimit() int64 {
+	return ctx.limit
+}
+
+// StepUsed returns steps used by the invocation.
+func (ctx *Context) StepUsed() int64 {
+	return ctx.used
+}
+
+// UseSteps charges steps for the invocation. Steps are not charged for
+// storage access implicitly, so the handler uses it to simulate the cost.
+func (ctx *Context) UseSteps(steps int64) error {
+	if steps > ctx.limit-ctx.used {
+		ctx.used = ctx.limit
+		return scoreresult.ErrOutOfStep
+	}
+	ctx.used += steps
+	return nil
+}
+
+func (ctx *Context) checkWritable(name string) error {
+	if ctx.readOnly {
+		return scoreresult.AccessDeniedError.Errorf("%sInReadOnly", name)
+	}
+	return nil
+}
+
+// GetValue returns the value of the key in the storage of the contract.
+// It returns nil if there is no value.
+func (ctx *Context) GetValue(key []byte) ([]byte, error) {
+	var m eeipc.GetValueMessage
+	if err := ctx.executor.SendAndReceive(eeipc.MsgGETVALUE, key, &m); err != nil {
+		return nil, err
+	}
+	if !m.Success {
+		return nil, nil
+	}
+	return m.Value, nil
+}
+
+// SetValue sets the value of the key in the storage of the contract.
+func (ctx *Context) SetValue(key, value []byte) error {
+	if err := ctx.checkWritable("SetValue"); err != nil {
+		return err
+	}
+	m := &eeipc.SetValueMessage{
+		Key:   key,
+		Flag:  eeipc.FlagOLDVALUE,
+		Value: value,
+	}
+	var old eeipc.OldValueMessage
+	return ctx.executor.SendAndReceive(eeipc.MsgSETVALUE, m, &old)
+}
+
+// DeleteValue deletes the key from the storage of the contract.
+func (ctx *Context) DeleteValue(key []byte) error {
+	if err := ctx.checkWritable("DeleteValue"); err != nil {
+		return err
+	}
+	m := &eeipc.SetValueMessage{
+		Key:  key,
+		Flag: eeipc.FlagDELETE | eeipc.FlagOLDVALUE,
+	}
+	var old eeipc.OldValueMessage
+	return ctx.executor.SendAndReceive(eeipc.MsgSETVALUE, m, &old)
+}
+
+// bytesOf returns bytes of the value used for event logs.
+func bytesOf(v interface{}) ([]byte, error) {
+	switch o := v.(type) {
+	case nil:
+		return nil, nil
+	case *big.Int:
+		return intconv.BigIntToBytes(o), nil
+	case *common.HexInt:
+		return intconv.BigIntToBytes(&o.Int), nil
+	case int:
+		return intconv.Int64ToBytes(int64(o)), nil
+	case int64:
+		return intconv.Int64ToBytes(o), nil
+	case bool:
+		if o {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case string:
+		return []byte(o), nil
+	case []byte:
+		return o, nil
+	case module.Address:
+		return o.Bytes(), nil
+	default:
+		return nil, scoreresult.InvalidParameterError.Errorf("UnsupportedType(type=%T)", v)
+	}
+}
+
+// Emit emits the event with the signature. The first indexed values of
+// values are indexed.
+func (ctx *Context) Emit(sig string, indexed int, values ...interface{}) error {
+	if err := ctx.checkWritable("EmitEvent"); err != nil {
+		return err
+	}
+	if indexed < 0 || indexed > len(values) {
+		return scoreresult.InvalidParameterError.Errorf("InvalidIndexed(indexed=%d)", indexed)
+	}
+	var m eeipc.EventMessage
+	m.Indexed = [][]byte{[]byte(sig)}
+	for i, v := range values {
+		bs, err := bytesOf(v)
+		if err != nil {
+			return err
+		}
+		if i < indexed {
+			m.Indexed = append(m.Indexed, bs)
+		} else {
+			m.Data = append(m.Data, bs)
+		}
+	}
+	return ctx.executor.Send(eeipc.MsgEVENT, &m)
+}
+
+// Call calls the method of the contract at the address with the value.
+// Parameters are encoded with common.EncodeAny. If the call fails, it
+// returns the error with the status of the result.
+func (ctx *Context) Call(to module.Address, value *big.Int, method string, params ...interface{}) (interface{}, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	if value.Sign() < 0 {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidValue(value=%s)", value)
+	}
+	if value.Sign() > 0 {
+		if err := ctx.checkWritable("Transfer"); err != nil {
+			return nil, err
+		}
+	}
+	ps, err := common.EncodeAny(params)
+	if err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidParams")
+	}
+	m := &eeipc.CallMessage{
+		DataType: dataTypeCall,
+		Data: common.MustEncodeAny(map[string]*codec.TypedObj{
+			"method": common.MustEncodeAny(method),
+			"params": ps,
+		}),
+	}
+	m.To.Set(to)
+	m.Value.Set(value)
+	m.Limit.SetInt64(ctx.limit - ctx.used)
+	r, err := ctx.executor.Call(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.UseSteps(r.StepUsed.Int64()); err != nil {
+		return nil, err
+	}
+	if code := r.Status & eeipc.CodeMask; code != errors.Success {
+		return nil, code.New(common.DecodeAsString(r.Result, ""))
+	}
+	return common.DecodeAny(r.Result)
+}
+
+// GetBalance returns the balance of the address.
+func (ctx *Context) GetBalance(addr module.Address) (*big.Int, error) {
+	var balance common.HexInt
+	if err := ctx.executor.SendAndReceive(eeipc.MsgGETBALANCE, common.AddressToPtr(addr), &balance); err != nil {
+		return nil, err
+	}
+	return &balance.Int, nil
+}
+
+// SetFeeProportion sets the proportion of the fee paid by the contract.
+func (ctx *Context) SetFeeProportion(proportion int) error {
+	if err := ctx.checkWritable("SetFeeProportion"); err != nil {
+		return err
+	}
+	if proportion < 0 || proportion > 100 {
+		return scoreresult.InvalidParameterError.Errorf("InvalidProportion(proportion=%d)", proportion)
+	}
+	return ctx.executor.Send(eeipc.MsgSETFEEPCT, proportion)
+}
+
+// Logf sends the debug log to the service.
+func (ctx *Context) Logf(format string, args ...interface{}) {
+	m := &eeipc.LogMessage{Level: log.DebugLevel, Message: fmt.Sprintf(format, args...)}
+	if ctx.trace {
+		m.Flag |= eeipc.LogFlagTrace
+	}
+	_ = ctx.executor.Send(eeipc.MsgLOG, m)
+}
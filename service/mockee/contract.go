@@ -0,0 +1,70 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mockee
+
+import (
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// Handler implements a method of the contract. Parameters are decoded with
+// common.DecodeAny, so integers are *common.HexInt and addresses are
+// *common.Address. The result is encoded with common.EncodeAny.
+type Handler func(ctx *Context, params []interface{}) (interface{}, error)
+
+// Contract is a contract implemented in Go. Methods are the API of the
+// contract, and Handlers has the handler for each of them. Install and
+// update methods of the EEType are added to the API if they are missing,
+// and they do nothing without handlers.
+type Contract struct {
+	Methods  []*scoreapi.Method
+	Handlers map[string]Handler
+}
+
+func (c *Contract) apiFor(t state.EEType) *scoreapi.Info {
+	methods := append([]*scoreapi.Method{}, c.Methods...)
+	names := make(map[string]bool)
+	for _, m := range methods {
+		if !m.IsEvent() {
+			names[m.Name] = true
+		}
+	}
+	internals := make([]string, 0, 2)
+	if name, ok := t.InstallMethod(); ok {
+		internals = append(internals, name)
+	}
+	if name, ok := t.UpdateMethod(t); ok {
+		internals = append(internals, name)
+	}
+	for _, name := range internals {
+		if !names[name] {
+			methods = append(methods, &scoreapi.Method{
+				Type: scoreapi.Function,
+				Name: name,
+			})
+			names[name] = true
+		}
+	}
+	return scoreapi.NewInfo(methods)
+}
+
+// Revert returns the error reverting the call with the user code.
+func Revert(code int, msg string) error {
+	return scoreresult.New(module.StatusReverted+module.Status(code), msg)
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mockee implements an execution engine for tests, which runs
+// contracts implemented in Go in the process. It takes the place of the
+// engine for an EEType, and talks to eeproxy through the IPC connection
+// like other engines, so the service handles calls, transfers, events,
+// fees and reverts of the contracts as usual.
+//
+// A contract is registered to the engine with its name, and it's deployed
+// with the name as the content. For JavaEE, deploy the name with the
+// content type "application/java".
+package mockee
+
+import (
+	"sync"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/ipc"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/service/eeipc"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// codeFiles has the name of the file storing the code for each EEType.
+var codeFiles = map[state.EEType]string{
+	state.JavaEE: "code.jar",
+	state.WasmEE: "code.wasm",
+}
+
+type instance struct {
+	uid      string
+	executor *eeipc.Executor
+	online   bool
+}
+
+// Engine is the execution engine with contracts in Go.
+type Engine struct {
+	eeType   state.EEType
+	codeFile string
+	log      log.Logger
+
+	lock      sync.Mutex
+	contracts map[string]*Contract
+	target    int
+	instances map[string]*instance
+	net, addr string
+}
+
+// NewEngine returns the engine for the EEType. The content of the code is
+// used as the name of the contract.
+func NewEngine(t state.EEType, logger log.Logger) (*Engine, error) {
+	file, ok := codeFiles[t]
+	if !ok {
+		return nil, errors.IllegalArgumentError.Errorf("UnsupportedEEType(type=%s)", t)
+	}
+	return &Engine{
+		eeType:    t,
+		codeFile:  file,
+		log:       logger.WithFields(log.Fields{log.FieldKeyModule: "mockee"}),
+		contracts: make(map[string]*Contract),
+		instances: make(map[string]*instance),
+	}, nil
+}
+
+// Register registers the contract with the name. It replaces the contract
+// registered with the same name.
+func (e *Engine) Register(name string, c *Contract) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.contracts[name] = c
+}
+
+func (e *Engine) contract(name string) *Contract {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.contracts[name]
+}
+
+func (e *Engine) Type() string {
+	return string(e.eeType)
+}
+
+func (e *Engine) Init(net, addr string) error {
+	e.net = net
+	e.addr = addr
+	return nil
+}
+
+func (e *Engine) SetInstances(n int) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if n < 0 {
+		return errors.ErrIllegalArgument
+	}
+
+	e.target = n
+	for e.target > len(e.instances) {
+		if err := e.startNew(); err != nil {
+			e.log.Errorf("Fail to start execution engine err=%+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Engine) OnAttach(uid string) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if is, ok := e.instances[uid]; ok {
+		is.online = true
+		return true
+	}
+	return false
+}
+
+func (e *Engine) OnEnd(uid string) bool {
+	return true
+}
+
+func (e *Engine) Kill(uid string) (bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if is, ok := e.instances[uid]; ok {
+		// the handler may not return, so it starts new one without waiting
+		// for the end of the instance.
+		err := is.executor.Close()
+		delete(e.instances, uid)
+		if len(e.instances) < e.target {
+			if err := e.startNew(); err != nil {
+				return true, err
+			}
+		}
+		return true, err
+	} else {
+		return false, nil
+	}
+}
+
+func (e *Engine) start(i *instance) error {
+	i.uid = uuid.Must(uuid.NewV4()).String()
+	logger := e.log.WithFields(log.Fields{log.FieldKeyEID: i.uid})
+	executor, err := connect(e, i.uid, logger)
+	if err != nil {
+		return err
+	}
+	i.executor = executor
+	i.online = false
+	e.instances[i.uid] = i
+	return nil
+}
+
+func (e *Engine) run(i *instance) {
+	for {
+		err := i.executor.Run()
+		e.log.Tracef("Run result uid=%s err=%+v", i.uid, err)
+
+		e.lock.Lock()
+		delete(e.instances, i.uid)
+		if !i.online || len(e.instances) >= e.target {
+			e.lock.Unlock()
+			return
+		}
+		e.log.Warnf("Instance uid=%s is stopped err=%+v", i.uid, err)
+		if err := e.start(i); err != nil {
+			e.log.Errorf("Fail to start instance err=%+v", err)
+			e.lock.Unlock()
+			return
+		}
+		e.lock.Unlock()
+	}
+}
+
+func (e *Engine) startNew() error {
+	i := new(instance)
+	if err := e.start(i); err != nil {
+		return err
+	}
+	go e.run(i)
+	return nil
+}
+
+func (e *Engine) OnConnect(conn ipc.Connection, version uint16) error {
+	return common.ErrUnsupported
+}
+
+func (e *Engine) OnClose(conn ipc.Connection) bool {
+	return false
+}
+
+var _ eeproxy.Engine = (*Engine)(nil)
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mockee
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/service/eeipc"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+type contract struct {
+	name     string
+	contract *Contract
+	api      *scoreapi.Info
+}
+
+// runtime runs contracts registered to the engine for the executor.
+type runtime struct {
+	engine *Engine
+}
+
+func connect(e *Engine, uid string, l log.Logger) (*eeipc.Executor, error) {
+	return eeipc.Connect(e.net, e.addr, uid, string(e.eeType), &runtime{e}, l)
+}
+
+// load returns the contract for the code. The code file has the name of
+// the registered contract.
+func (r *runtime) load(path string) (*contract, error) {
+	bs, err := os.ReadFile(filepath.Join(path, r.engine.codeFile))
+	if err != nil {
+		return nil, errors.CriticalIOError.Wrapf(err, "FailToReadCode(path=%s)", path)
+	}
+	name := strings.TrimSpace(string(bs))
+	c := r.engine.contract(name)
+	if c == nil {
+		return nil, scoreresult.IllegalFormatError.Errorf("UnknownContract(name=%s)", name)
+	}
+	return &contract{
+		name:     name,
+		contract: c,
+		api:      c.apiFor(r.engine.eeType),
+	}, nil
+}
+
+func (r *runtime) GetAPI(code string) (*scoreapi.Info, error) {
+	c, err := r.load(code)
+	if err != nil {
+		return nil, err
+	}
+	return c.api, nil
+}
+
+func (r *runtime) Invoke(e *eeipc.Executor, m *eeipc.InvokeMessage) (*codec.TypedObj, int64, error) {
+	c, err := r.load(m.Code)
+	if err != nil {
+		return nil, 0, err
+	}
+	method := c.api.GetMethod(m.Method)
+	if method == nil || !method.IsCallable() {
+		return nil, 0, scoreresult.MethodNotFoundError.Errorf("MethodNotFound(name=%s)", m.Method)
+	}
+	handler, ok := c.contract.Handlers[m.Method]
+	if !ok {
+		// install and update methods are optional
+		if install, _ := r.engine.eeType.InstallMethod(); m.Method == install {
+			return codec.Nil, 0, nil
+		}
+		if update, _ := r.engine.eeType.UpdateMethod(r.engine.eeType); m.Method == update {
+			return codec.Nil, 0, nil
+		}
+		return nil, 0, scoreresult.MethodNotFoundError.Errorf("NoHandler(contract=%s,name=%s)", c.name, m.Method)
+	}
+
+	ctx, err := newContext(e, m)
+	if err != nil {
+		return nil, 0, err
+	}
+	var params []interface{}
+	if m.Params != nil {
+		if ps, err := common.DecodeAny(m.Params); err != nil {
+			return nil, 0, scoreresult.InvalidParameterError.Wrap(err, "InvalidParams")
+		} else if params, ok = ps.([]interface{}); !ok {
+			return nil, 0, scoreresult.InvalidParameterError.Errorf("InvalidParams(params=%v)", ps)
+		}
+	}
+	ret, err := call(handler, ctx, params)
+	if err != nil {
+		return nil, ctx.used, err
+	}
+	if len(method.Outputs) == 0 || ret == nil {
+		return codec.Nil, ctx.used, nil
+	}
+	result, err := common.EncodeAny(ret)
+	if err != nil {
+		return nil, ctx.used, scoreresult.UnknownFailureError.Wrap(err, "InvalidResult")
+	}
+	return result, ctx.used, nil
+}
+
+// call calls the handler. A panic in the handler fails the invocation
+// instead of the process.
+func call(h Handler, ctx *Context, params []interface{}) (ret interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = scoreresult.UnknownFailureError.Errorf("Panic(%v)", r)
+		}
+	}()
+	return h(ctx, params)
+}
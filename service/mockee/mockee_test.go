@@ -0,0 +1,265 @@
+package mockee_test
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/mockee"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+type result struct {
+	status error
+	steps  *big.Int
+	result *codec.TypedObj
+	info   *scoreapi.Info
+}
+
+type call struct {
+	to     module.Address
+	value  *big.Int
+	method string
+	params interface{}
+}
+
+type event struct {
+	indexed [][]byte
+	data    [][]byte
+}
+
+type testContext struct {
+	proxy  eeproxy.Proxy
+	store  map[string][]byte
+	events []event
+	calls  []call
+	result chan result
+}
+
+func (c *testContext) GetValue(key []byte) ([]byte, error) {
+	return c.store[string(key)], nil
+}
+
+func (c *testContext) SetValue(key []byte, value []byte) ([]byte, error) {
+	old := c.store[string(key)]
+	c.store[string(key)] = value
+	return old, nil
+}
+
+func (c *testContext) DeleteValue(key []byte) ([]byte, error) {
+	old := c.store[string(key)]
+	delete(c.store, string(key))
+	return old, nil
+}
+
+func (c *testContext) ArrayDBContains(prefix, value []byte, limit int64) (bool, int, int, error) {
+	return false, 0, 0, nil
+}
+
+func (c *testContext) GetInfo() *codec.TypedObj {
+	return common.MustEncodeAny(map[string]interface{}{
+		"B.height": 10,
+	})
+}
+
+func (c *testContext) GetBalance(addr module.Address) *big.Int {
+	return big.NewInt(1000)
+}
+
+func (c *testContext) OnEvent(addr module.Address, indexed, data [][]byte) error {
+	c.events = append(c.events, event{indexed, data})
+	return nil
+}
+
+func (c *testContext) OnResult(status error, flag int, steps *big.Int, r *codec.TypedObj) {
+	c.result <- result{status: status, steps: steps, result: r}
+}
+
+// OnCall records the call, then it returns the parameters as the result
+// with 50 steps.
+func (c *testContext) OnCall(from, to module.Address, value, limit *big.Int, dataType string, dataObj *codec.TypedObj) {
+	data := common.MustDecodeAny(dataObj).(map[string]interface{})
+	c.calls = append(c.calls, call{to, value, data["method"].(string), data["params"]})
+	_ = c.proxy.SendResult(c, nil, big.NewInt(50), common.MustEncodeAny(data["params"]), 0, 0)
+}
+
+func (c *testContext) OnAPI(status error, info *scoreapi.Info) {
+	c.result <- result{status: status, info: info}
+}
+
+func (c *testContext) OnSetFeeProportion(portion int) {
+}
+
+func (c *testContext) SetCode(code []byte) error {
+	return nil
+}
+
+func (c *testContext) GetObjGraph(bool) (int, []byte, []byte, error) {
+	return 0, nil, nil, nil
+}
+
+func (c *testContext) SetObjGraph(flags bool, nextHash int, objGraph []byte) error {
+	return nil
+}
+
+func (c *testContext) Logger() log.Logger {
+	return log.GlobalLogger()
+}
+
+var store = &mockee.Contract{
+	Methods: []*scoreapi.Method{
+		{
+			Type:   scoreapi.Function,
+			Name:   "set",
+			Flags:  scoreapi.FlagExternal,
+			Inputs: []scoreapi.Parameter{{Name: "v", Type: scoreapi.String}},
+		},
+		{
+			Type:    scoreapi.Function,
+			Name:    "get",
+			Flags:   scoreapi.FlagExternal | scoreapi.FlagReadOnly,
+			Outputs: []scoreapi.DataType{scoreapi.String},
+		},
+		{
+			Type:  scoreapi.Function,
+			Name:  "fail",
+			Flags: scoreapi.FlagExternal,
+		},
+		{
+			Type:    scoreapi.Function,
+			Name:    "relay",
+			Flags:   scoreapi.FlagExternal,
+			Inputs:  []scoreapi.Parameter{{Name: "to", Type: scoreapi.Address}},
+			Outputs: []scoreapi.DataType{scoreapi.List},
+		},
+		{
+			Type:    scoreapi.Event,
+			Name:    "Changed",
+			Indexed: 1,
+			Inputs: []scoreapi.Parameter{
+				{Name: "by", Type: scoreapi.Address},
+				{Name: "v", Type: scoreapi.String},
+			},
+		},
+	},
+	Handlers: map[string]mockee.Handler{
+		"set": func(ctx *mockee.Context, params []interface{}) (interface{}, error) {
+			v := params[0].(string)
+			if err := ctx.UseSteps(100); err != nil {
+				return nil, err
+			}
+			if err := ctx.SetValue([]byte("v"), []byte(v)); err != nil {
+				return nil, err
+			}
+			return nil, ctx.Emit("Changed(Address,str)", 1, ctx.From(), v)
+		},
+		"get": func(ctx *mockee.Context, params []interface{}) (interface{}, error) {
+			v, err := ctx.GetValue([]byte("v"))
+			return string(v), err
+		},
+		"fail": func(ctx *mockee.Context, params []interface{}) (interface{}, error) {
+			return nil, mockee.Revert(3, "oops")
+		},
+		"relay": func(ctx *mockee.Context, params []interface{}) (interface{}, error) {
+			return ctx.Call(params[0].(module.Address), big.NewInt(1), "echo", big.NewInt(7), "x")
+		},
+	},
+}
+
+func TestEngine_Invoke(t *testing.T) {
+	dir := t.TempDir()
+	code := filepath.Join(dir, "contract")
+	require.NoError(t, os.MkdirAll(code, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(code, "code.jar"), []byte("store"), 0644))
+
+	engine, err := mockee.NewEngine(state.JavaEE, log.GlobalLogger())
+	require.NoError(t, err)
+	engine.Register("store", store)
+	mgr, err := eeproxy.NewManager("unix", filepath.Join(dir, "ee.sock"), log.GlobalLogger(), engine)
+	require.NoError(t, err)
+	defer mgr.Close()
+	go mgr.Loop()
+	require.NoError(t, mgr.SetInstances(1, 1, 1))
+
+	ex := mgr.GetExecutor(eeproxy.ForTransaction)
+	defer ex.Release()
+	proxy := ex.Get(string(state.JavaEE))
+	require.NotNil(t, proxy)
+
+	ctx := &testContext{
+		proxy:  proxy,
+		store:  make(map[string][]byte),
+		result: make(chan result, 1),
+	}
+
+	assert.NoError(t, proxy.GetAPI(ctx, code))
+	r := <-ctx.result
+	assert.NoError(t, r.status)
+	if assert.NotNil(t, r.info) {
+		assert.NotNil(t, r.info.GetMethod("get"))
+		assert.NotNil(t, r.info.GetMethod("<init>"))
+	}
+
+	from := common.MustNewAddressFromString("hx0000000000000000000000000000000000000001")
+	to := common.MustNewAddressFromString("cx0000000000000000000000000000000000000001")
+	invoke := func(method string, readOnly bool, params ...interface{}) result {
+		err := proxy.Invoke(ctx, code, readOnly, from, to, new(big.Int),
+			big.NewInt(1000), method, common.MustEncodeAny(params), nil, 1, nil)
+		require.NoError(t, err)
+		return <-ctx.result
+	}
+
+	r = invoke("<init>", false)
+	assert.NoError(t, r.status)
+
+	r = invoke("set", false, "hello")
+	assert.NoError(t, r.status)
+	assert.EqualValues(t, 100, r.steps.Int64())
+	assert.Equal(t, []byte("hello"), ctx.store["v"])
+	if assert.Len(t, ctx.events, 1) {
+		assert.Equal(t, [][]byte{[]byte("Changed(Address,str)"), from.Bytes()}, ctx.events[0].indexed)
+		assert.Equal(t, [][]byte{[]byte("hello")}, ctx.events[0].data)
+	}
+
+	r = invoke("get", true)
+	assert.NoError(t, r.status)
+	assert.Equal(t, "hello", common.MustDecodeAny(r.result))
+
+	r = invoke("set", true, "world")
+	assert.True(t, scoreresult.AccessDeniedError.Equals(r.status), r.status)
+	assert.Equal(t, []byte("hello"), ctx.store["v"])
+
+	r = invoke("fail", false)
+	s, _ := scoreresult.StatusOf(r.status)
+	assert.Equal(t, module.StatusReverted+3, s)
+	assert.Equal(t, "oops", r.status.Error())
+
+	other := common.MustNewAddressFromString("cx0000000000000000000000000000000000000002")
+	r = invoke("relay", false, other)
+	assert.NoError(t, r.status)
+	assert.EqualValues(t, 50, r.steps.Int64())
+	if assert.Len(t, ctx.calls, 1) {
+		assert.True(t, other.Equal(ctx.calls[0].to))
+		assert.EqualValues(t, 1, ctx.calls[0].value.Int64())
+		assert.Equal(t, "echo", ctx.calls[0].method)
+	}
+	ps := common.MustDecodeAny(r.result).([]interface{})
+	if assert.Len(t, ps, 2) {
+		assert.EqualValues(t, 7, ps[0].(*common.HexInt).Int64())
+		assert.Equal(t, "x", ps[1])
+	}
+
+	r = invoke("unknown", false)
+	assert.True(t, scoreresult.MethodNotFoundError.Equals(r.status), r.status)
+}
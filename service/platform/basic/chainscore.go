@@ -24,6 +24,7 @@ import (
 	"strings"
 
 	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/intconv"
 	"github.com/icon-project/goloop/common/log"
@@ -576,6 +577,166 @@ var chainMethods = []*chainMethod{
 		},
 		nil,
 	}, Revision9, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "scheduleScoreUpdate",
+		scoreapi.FlagExternal, 3,
+		[]scoreapi.Parameter{
+			{"txHash", scoreapi.Bytes, nil, nil},
+			{"height", scoreapi.Integer, nil, nil},
+			{"stepLimit", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "cancelScoreUpdate",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"address", scoreapi.Address, nil, nil},
+		},
+		nil,
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "scheduleCall",
+		scoreapi.FlagExternal | scoreapi.FlagPayable, 4,
+		[]scoreapi.Parameter{
+			{"height", scoreapi.Integer, nil, nil},
+			{"to", scoreapi.Address, nil, nil},
+			{"method", scoreapi.String, nil, nil},
+			{"stepLimit", scoreapi.Integer, nil, nil},
+			{"params", scoreapi.String, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Integer,
+		},
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "cancelScheduledCall",
+		scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getScheduledCall",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "verifyTypedSignature",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 3,
+		[]scoreapi.Parameter{
+			{"data", scoreapi.String, nil, nil},
+			{"signature", scoreapi.Bytes, nil, nil},
+			{"signer", scoreapi.Address, nil, nil},
+			{"keyType", scoreapi.String, nil, nil},
+			{"publicKey", scoreapi.Bytes, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Bool,
+		},
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "createToken",
+		scoreapi.FlagExternal, 3,
+		[]scoreapi.Parameter{
+			{"name", scoreapi.String, nil, nil},
+			{"symbol", scoreapi.String, nil, nil},
+			{"decimals", scoreapi.Integer, nil, nil},
+			{"initialSupply", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Integer,
+		},
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "mintToken",
+		scoreapi.FlagExternal, 3,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+			{"to", scoreapi.Address, nil, nil},
+			{"amount", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "burnToken",
+		scoreapi.FlagExternal, 2,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+			{"amount", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "transferToken",
+		scoreapi.FlagExternal, 3,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+			{"to", scoreapi.Address, nil, nil},
+			{"amount", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "approveToken",
+		scoreapi.FlagExternal, 3,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+			{"spender", scoreapi.Address, nil, nil},
+			{"amount", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "transferTokenFrom",
+		scoreapi.FlagExternal, 4,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+			{"from", scoreapi.Address, nil, nil},
+			{"to", scoreapi.Address, nil, nil},
+			{"amount", scoreapi.Integer, nil, nil},
+		},
+		nil,
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getToken",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 1,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Dict,
+		},
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getTokenBalance",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 2,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+			{"owner", scoreapi.Address, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Integer,
+		},
+	}, Revision10, 0},
+	{scoreapi.Method{
+		scoreapi.Function, "getTokenAllowance",
+		scoreapi.FlagReadOnly | scoreapi.FlagExternal, 3,
+		[]scoreapi.Parameter{
+			{"id", scoreapi.Integer, nil, nil},
+			{"owner", scoreapi.Address, nil, nil},
+			{"spender", scoreapi.Address, nil, nil},
+		},
+		[]scoreapi.DataType{
+			scoreapi.Integer,
+		},
+	}, Revision10, 0},
 }
 
 func (s *ChainScore) GetAPI() *scoreapi.Info {
@@ -946,6 +1107,15 @@ func (s *ChainScore) Ex_acceptScore(txHash []byte) error {
 	}
 	auditTxHash := s.cc.TransactionID()
 
+	sysAs := s.cc.GetAccountState(state.SystemID)
+	h2a := scoredb.NewDictDB(sysAs, state.VarTxHashToAddress, 1)
+	if value := h2a.Get(txHash); value != nil {
+		// accepting the scheduled update activates it immediately.
+		if err := contract.NewUpgradeDB(sysAs).CancelTx(value.Address(), txHash); err != nil {
+			return err
+		}
+	}
+
 	ch := contract.NewCommonHandler(s.from, state.SystemAddress, big.NewInt(0), false, s.log)
 	ah := contract.NewAcceptHandler(ch, txHash, auditTxHash)
 	status, steps, _, _ := s.cc.Call(ah, s.cc.StepAvailable())
@@ -976,9 +1146,228 @@ func (s *ChainScore) Ex_rejectScore(txHash []byte) error {
 	if err := h2a.Delete(txHash); err != nil {
 		return err
 	}
+	if err := contract.NewUpgradeDB(sysAs).CancelTx(scoreAddr, txHash); err != nil {
+		return err
+	}
 	return scoreAs.RejectContract(txHash, auditTxHash)
 }
 
+func (s *ChainScore) Ex_scheduleScoreUpdate(txHash []byte, height int64, stepLimit *common.HexInt) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	if len(txHash) == 0 {
+		return scoreresult.ErrInvalidParameter
+	}
+	_, err := contract.ScheduleUpdate(s.cc, txHash, s.cc.TransactionID(), height, stepLimit.Value())
+	return err
+}
+
+func (s *ChainScore) Ex_cancelScoreUpdate(address module.Address) error {
+	if err := s.checkGovernance(true); err != nil {
+		return err
+	}
+	if address == nil || !address.IsContract() {
+		return scoreresult.ErrInvalidParameter
+	}
+	udb := contract.NewUpgradeDB(s.cc.GetAccountState(state.SystemID))
+	if u, err := udb.Cancel(address); err != nil {
+		return err
+	} else if u == nil {
+		return scoreresult.New(StatusNotFound, "NoScheduledUpdate")
+	}
+	return nil
+}
+
+// Ex_scheduleCall schedules the call from the caller, and the value is kept
+// as the prepaid for the fee of the call.
+func (s *ChainScore) Ex_scheduleCall(height int64, to module.Address, method string,
+	stepLimit *common.HexInt, params string) (int64, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return 0, err
+	}
+	var limit *big.Int
+	if stepLimit != nil {
+		limit = stepLimit.Value()
+	}
+	var paramBytes []byte
+	if len(params) > 0 {
+		paramBytes = []byte(params)
+	}
+	return contract.ScheduleCall(s.cc, s.from, to, method, paramBytes, height, limit, s.value)
+}
+
+func (s *ChainScore) Ex_cancelScheduledCall(id int64) error {
+	if err := s.tryChargeCall(); err != nil {
+		return err
+	}
+	if c, err := contract.CancelScheduledCall(s.cc, s.from, id); err != nil {
+		return err
+	} else if c == nil {
+		return scoreresult.New(StatusNotFound, "NoScheduledCall")
+	}
+	return nil
+}
+
+func (s *ChainScore) Ex_getScheduledCall(id int64) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return nil, err
+	}
+	sdb := contract.NewScheduledCallDB(s.cc.GetAccountState(state.SystemID))
+	if c, err := sdb.Get(id); err != nil {
+		return nil, err
+	} else if c == nil {
+		return nil, scoreresult.New(StatusNotFound, "NoScheduledCall")
+	} else {
+		return c.ToJSON(id), nil
+	}
+}
+
+// Ex_verifyTypedSignature returns whether the signature for the typed data
+// is made by the signer. The domain of the data should have the network ID
+// of the chain. It charges apiCall steps for each struct value hashed.
+// The key of the signer is secp256k1 by default, and it's recovered from the
+// signature. For ed25519, the public key of the signer should be given.
+func (s *ChainScore) Ex_verifyTypedSignature(data string, signature []byte, signer module.Address,
+	keyType string, publicKey []byte) (bool, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return false, err
+	}
+	if !s.cc.ApplySteps(state.StepTypeInput, len(data)) {
+		return false, scoreresult.ErrOutOfStep
+	}
+	if signer == nil {
+		return false, scoreresult.ErrInvalidParameter
+	}
+	td, err := crypto.ParseTypedData([]byte(data))
+	if err != nil {
+		return false, scoreresult.InvalidParameterError.Wrap(err, "InvalidTypedData")
+	}
+	digest, err := td.Digest()
+	if !s.cc.ApplySteps(state.StepTypeApiCall, td.HashedStructs()) {
+		return false, scoreresult.ErrOutOfStep
+	}
+	if err != nil {
+		return false, scoreresult.InvalidParameterError.Wrap(err, "InvalidTypedData")
+	}
+	nid, err := td.NID()
+	if err != nil {
+		return false, scoreresult.InvalidParameterError.Wrap(err, "InvalidTypedData")
+	}
+	as := s.cc.GetAccountState(state.SystemID)
+	if nid != scoredb.NewVarDB(as, state.VarNetwork).Int64() {
+		return false, nil
+	}
+	switch keyType {
+	case "", crypto.KeyTypeSecp256k1:
+		sig, err := crypto.ParseSignature(signature)
+		if err != nil {
+			return false, nil
+		}
+		pk, err := sig.RecoverPublicKey(digest)
+		if err != nil {
+			return false, nil
+		}
+		if publicKey != nil {
+			if ppk, err := crypto.ParsePublicKey(publicKey); err != nil || !ppk.Equal(pk) {
+				return false, nil
+			}
+		}
+		return common.NewAccountAddressFromPublicKey(pk).Equal(signer), nil
+	case crypto.KeyTypeEd25519:
+		addr, err := common.NewAccountAddressFromKey(keyType, publicKey)
+		if err != nil {
+			return false, scoreresult.InvalidParameterError.Wrap(err, "InvalidPublicKey")
+		}
+		if !addr.Equal(signer) {
+			return false, nil
+		}
+		return crypto.VerifyEd25519(publicKey, digest, signature), nil
+	default:
+		return false, scoreresult.InvalidParameterError.Errorf("UnsupportedKeyType(%s)", keyType)
+	}
+}
+
+// Ex_createToken creates a native token owned by the caller.
+func (s *ChainScore) Ex_createToken(name string, symbol string, decimals int64,
+	initialSupply *common.HexInt) (int64, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return 0, err
+	}
+	return contract.CreateToken(s.cc, s.from, name, symbol, decimals, initialSupply.Value())
+}
+
+func (s *ChainScore) Ex_mintToken(id int64, to module.Address, amount *common.HexInt) error {
+	if err := s.tryChargeCall(); err != nil {
+		return err
+	}
+	return contract.MintToken(s.cc, s.from, id, to, amount.Value())
+}
+
+func (s *ChainScore) Ex_burnToken(id int64, amount *common.HexInt) error {
+	if err := s.tryChargeCall(); err != nil {
+		return err
+	}
+	return contract.BurnToken(s.cc, s.from, id, amount.Value())
+}
+
+func (s *ChainScore) Ex_transferToken(id int64, to module.Address, amount *common.HexInt) error {
+	if err := s.tryChargeCall(); err != nil {
+		return err
+	}
+	return contract.TransferToken(s.cc, s.from, id, to, amount.Value())
+}
+
+func (s *ChainScore) Ex_approveToken(id int64, spender module.Address, amount *common.HexInt) error {
+	if err := s.tryChargeCall(); err != nil {
+		return err
+	}
+	return contract.ApproveToken(s.cc, s.from, id, spender, amount.Value())
+}
+
+func (s *ChainScore) Ex_transferTokenFrom(id int64, from module.Address, to module.Address, amount *common.HexInt) error {
+	if err := s.tryChargeCall(); err != nil {
+		return err
+	}
+	return contract.TransferTokenFrom(s.cc, s.from, id, from, to, amount.Value())
+}
+
+func (s *ChainScore) Ex_getToken(id int64) (map[string]interface{}, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return nil, err
+	}
+	tdb := contract.NewTokenDB(s.cc.GetAccountState(state.TokenID))
+	if t, err := tdb.Get(id); err != nil {
+		return nil, err
+	} else if t == nil {
+		return nil, scoreresult.New(StatusNotFound, "NoToken")
+	} else {
+		return t.ToJSON(id), nil
+	}
+}
+
+func (s *ChainScore) Ex_getTokenBalance(id int64, owner module.Address) (*big.Int, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		return nil, scoreresult.ErrInvalidParameter
+	}
+	tdb := contract.NewTokenDB(s.cc.GetAccountState(state.TokenID))
+	return tdb.BalanceOf(id, owner), nil
+}
+
+func (s *ChainScore) Ex_getTokenAllowance(id int64, owner module.Address, spender module.Address) (*big.Int, error) {
+	if err := s.tryChargeCall(); err != nil {
+		return nil, err
+	}
+	if owner == nil || spender == nil {
+		return nil, scoreresult.ErrInvalidParameter
+	}
+	tdb := contract.NewTokenDB(s.cc.GetAccountState(state.TokenID))
+	return tdb.Allowance(id, owner, spender), nil
+}
+
 // Governance score would check the verification of the address
 func (s *ChainScore) Ex_blockScore(address module.Address) error {
 	if err := s.tryChargeCall(); err != nil {
@@ -1323,6 +1712,35 @@ func (s *ChainScore) Ex_getScoreStatus(address module.Address) (map[string]inter
 		scoreStatus["next"] = nextContract
 	}
 
+	udb := contract.NewUpgradeDB(s.cc.GetAccountState(state.SystemID))
+	if u, height, err := udb.Scheduled(address); err != nil {
+		return nil, err
+	} else if u != nil {
+		scheduled := make(map[string]interface{})
+		scheduled["height"] = intconv.FormatInt(height)
+		scheduled["deployTxHash"] = fmt.Sprintf("%#x", u.TxHash)
+		scheduled["auditTxHash"] = fmt.Sprintf("%#x", u.AuditTxHash)
+		scheduled["stepLimit"] = intconv.FormatBigInt(u.StepLimit)
+		scoreStatus["scheduled"] = scheduled
+	}
+
+	if history, err := udb.History(address); err != nil {
+		return nil, err
+	} else if len(history) > 0 {
+		upgrades := make([]interface{}, len(history))
+		for i, r := range history {
+			upgrade := make(map[string]interface{})
+			upgrade["height"] = intconv.FormatInt(r.Height)
+			upgrade["deployTxHash"] = fmt.Sprintf("%#x", r.TxHash)
+			upgrade["auditTxHash"] = fmt.Sprintf("%#x", r.AuditTxHash)
+			upgrade["codeHash"] = fmt.Sprintf("%#x", r.CodeHash)
+			upgrade["status"] = intconv.FormatInt(int64(r.Status))
+			upgrade["stepUsed"] = intconv.FormatBigInt(r.StepUsed)
+			upgrades[i] = upgrade
+		}
+		scoreStatus["upgrades"] = upgrades
+	}
+
 	if di, err := as.GetDepositInfo(s.cc, module.JSONVersion3); err != nil {
 		return nil, scoreresult.New(module.StatusUnknownFailure, "FailOnDepositInfo")
 	} else if di != nil {
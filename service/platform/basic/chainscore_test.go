@@ -23,8 +23,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/scoredb"
 	"github.com/icon-project/goloop/service/state"
 )
 
@@ -50,6 +53,10 @@ func (cc *fakeCallContext) OnEvent(add module.Address, indexed [][]byte, data []
 	// do nothing
 }
 
+func (cc *fakeCallContext) ApplySteps(t state.StepType, n int) bool {
+	return true
+}
+
 func (cc *fakeCallContext) Revision() module.Revision {
 	return cc.revision
 }
@@ -105,4 +112,76 @@ func TestChainScore_GetAPI(t *testing.T) {
 			assert.NoError(t, err)
 		})
 	}
-}
\ No newline at end of file
+}
+
+const testTypedData = `{
+	"types": {
+		"Mail": [
+			{"name": "to", "type": "Address"},
+			{"name": "contents", "type": "str"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {"nid": "0x3"},
+	"message": {
+		"to": "hx0000000000000000000000000000000000000001",
+		"contents": "Hello"
+	}
+}`
+
+func TestChainScore_VerifyTypedSignature(t *testing.T) {
+	cc := newFakeCallContext()
+	as := cc.GetAccountState(state.SystemID)
+	assert.NoError(t, scoredb.NewVarDB(as, state.VarNetwork).Set(3))
+	score := &ChainScore{cc: cc, gov: true}
+
+	td, err := crypto.ParseTypedData([]byte(testTypedData))
+	assert.NoError(t, err)
+	digest, err := td.Digest()
+	assert.NoError(t, err)
+
+	sk, pk := crypto.GenerateKeyPair()
+	sig, err := crypto.NewSignature(digest, sk)
+	assert.NoError(t, err)
+	rsv, err := sig.SerializeRSV()
+	assert.NoError(t, err)
+	signer := common.NewAccountAddressFromPublicKey(pk)
+	_, pk2 := crypto.GenerateKeyPair()
+
+	seed := crypto.GenerateEd25519Key()
+	epk, err := crypto.Ed25519PublicKeyFromSeed(seed)
+	assert.NoError(t, err)
+	esig, err := crypto.SignEd25519(seed, digest)
+	assert.NoError(t, err)
+	esigner, err := common.NewAccountAddressFromKey(crypto.KeyTypeEd25519, epk)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		sig       []byte
+		signer    module.Address
+		keyType   string
+		publicKey []byte
+		ok        bool
+	}{
+		{"Secp256k1", rsv, signer, "", nil, true},
+		{"Secp256k1WithKey", rsv, signer, crypto.KeyTypeSecp256k1, pk.SerializeCompressed(), true},
+		{"Secp256k1WrongKey", rsv, signer, crypto.KeyTypeSecp256k1, pk2.SerializeCompressed(), false},
+		{"Secp256k1WrongSigner", rsv, esigner, "", nil, false},
+		{"Ed25519", esig, esigner, crypto.KeyTypeEd25519, epk, true},
+		{"Ed25519WrongSigner", esig, signer, crypto.KeyTypeEd25519, epk, false},
+		{"Ed25519WrongSignature", rsv, esigner, crypto.KeyTypeEd25519, epk, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ok, err := score.Ex_verifyTypedSignature(testTypedData, c.sig, c.signer, c.keyType, c.publicKey)
+			assert.NoError(t, err)
+			assert.Equal(t, c.ok, ok)
+		})
+	}
+
+	_, err = score.Ex_verifyTypedSignature(testTypedData, esig, esigner, crypto.KeyTypeEd25519, epk[1:])
+	assert.Error(t, err)
+	_, err = score.Ex_verifyTypedSignature(testTypedData, esig, esigner, "rsa", epk)
+	assert.Error(t, err)
+}
@@ -29,6 +29,7 @@ const (
 	Revision7
 	Revision8
 	Revision9
+	Revision10
 	RevisionReserved
 )
 
@@ -54,6 +55,7 @@ var toggleFlagsOnRevision = []struct {
 	{Revision7, module.UseChainID | module.UseMPTOnEvents},
 	{Revision8, module.UseCompactAPIInfo},
 	{Revision9, module.MultipleFeePayers | module.FixJCLSteps | module.ReportConfigureEvents},
+	{Revision10, module.ContractValidatedTx | module.BatchTransaction | module.AccountKeyTypes},
 }
 
 func init() {
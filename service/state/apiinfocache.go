@@ -23,6 +23,7 @@ import (
 	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/common/db"
 	"github.com/icon-project/goloop/common/errors"
+	tcache "github.com/icon-project/goloop/common/trie/cache"
 	"github.com/icon-project/goloop/service/scoreapi"
 )
 
@@ -39,12 +40,26 @@ type apiInfoCache struct {
 	cache *cache.LRUCache
 }
 
+// apiInfoEntryOverhead is approximated memory for keeping an API info in
+// the cache except its serialized bytes.
+const apiInfoEntryOverhead = 256
+
+// apiInfoEntry is an API info in the cache with bytes charged to the budget.
+type apiInfoEntry struct {
+	info  *scoreapi.Info
+	bytes int64
+}
+
+func apiInfoEntrySize(v interface{}) int64 {
+	return v.(*apiInfoEntry).bytes
+}
+
 func (a *apiInfoCache) Get(hash []byte) (*scoreapi.Info, error) {
 	obj, err := a.cache.Get(hash)
 	if err != nil {
 		return nil, err
 	} else {
-		return obj.(*scoreapi.Info), nil
+		return obj.(*apiInfoEntry).info, nil
 	}
 }
 
@@ -52,7 +67,10 @@ func (a *apiInfoCache) Set(hash, bytes []byte, info *scoreapi.Info) error {
 	if err := a.bk.Set(hash, bytes); err != nil {
 		return err
 	}
-	a.cache.Put(string(hash), info)
+	a.cache.Put(string(hash), &apiInfoEntry{
+		info:  info,
+		bytes: int64(len(hash)+len(bytes)) + apiInfoEntryOverhead,
+	})
 	return nil
 }
 
@@ -62,11 +80,15 @@ func GetAPIInfoFromBucket(bk db.Bucket, hash []byte) (*scoreapi.Info, error) {
 		return nil, errors.CriticalIOError.Wrapf(err, "FailToGetAPIInfo(hash=%x)", hash)
 
 	}
+	return decodeAPIInfo(hash, bs)
+}
+
+func decodeAPIInfo(hash, bs []byte) (*scoreapi.Info, error) {
 	if bs == nil {
 		return nil, nil
 	}
 	var info scoreapi.Info
-	_, err = codec.BC.UnmarshalFromBytes(bs, &info)
+	_, err := codec.BC.UnmarshalFromBytes(bs, &info)
 	if err != nil {
 		return nil, errors.CriticalFormatError.Wrapf(err, "InvalidAPIInfo(hash=%x)", hash)
 	}
@@ -83,15 +105,35 @@ func newAPIInfoCache(dbase db.Database, size int) (*apiInfoCache, error) {
 	if err != nil {
 		return nil, err
 	}
+	create := func(hash []byte) (interface{}, error) {
+		bs, err := bk.Get(hash)
+		if err != nil {
+			return nil, errors.CriticalIOError.Wrapf(err, "FailToGetAPIInfo(hash=%x)", hash)
+		}
+		info, err := decodeAPIInfo(hash, bs)
+		if err != nil {
+			return nil, err
+		}
+		return &apiInfoEntry{
+			info:  info,
+			bytes: int64(len(hash)+len(bs)) + apiInfoEntryOverhead,
+		}, nil
+	}
+	var c *cache.LRUCache
+	if usage := tcache.BudgetOf(dbase).NewUsage(); usage != nil {
+		c = cache.NewLRUCacheWithUsage(size, create, apiInfoEntrySize, usage)
+	} else {
+		c = cache.NewLRUCache(size, create)
+	}
 	return &apiInfoCache{
-		bk: bk,
-		cache: cache.NewLRUCache(size, func(hash []byte) (interface{}, error) {
-			info, err := GetAPIInfoFromBucket(bk, hash)
-			return info, err
-		}),
+		bk:    bk,
+		cache: c,
 	}, nil
 }
 
+// AttachAPIInfoCache attaches the cache of API info to the database. The
+// cache is charged to the memory budget of node caches if the database has
+// the cache manager.
 func AttachAPIInfoCache(dbase db.Database, size int) (db.Database, error) {
 	aic, err := newAPIInfoCache(dbase, size)
 	if err != nil {
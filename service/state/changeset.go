@@ -0,0 +1,103 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package state
+
+import (
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/trie"
+	"github.com/icon-project/goloop/common/trie/trie_manager"
+)
+
+// AccountChange is the change of an account. Key is the key of the account
+// in the world state, which is sha3 of the address ID, and Storage has keys
+// of the account storage changed.
+type AccountChange struct {
+	Key     []byte
+	Removed bool
+	Storage [][]byte
+}
+
+// ChangeSet is the set of accounts changed between two world states.
+type ChangeSet struct {
+	Accounts []*AccountChange
+}
+
+func (cs *ChangeSet) Bytes() []byte {
+	return codec.BC.MustMarshalToBytes(cs)
+}
+
+// Account returns the change of the account, or nil if it's not changed.
+func (cs *ChangeSet) Account(id []byte) *AccountChange {
+	key := string(addressIDToKey(id))
+	for _, ac := range cs.Accounts {
+		if string(ac.Key) == key {
+			return ac
+		}
+	}
+	return nil
+}
+
+func ChangeSetFromBytes(bs []byte) (*ChangeSet, error) {
+	cs := new(ChangeSet)
+	if _, err := codec.BC.UnmarshalFromBytes(bs, cs); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+func storeOf(dbase db.Database, obj trie.Object) trie.Immutable {
+	if ass, ok := obj.(*accountSnapshotImpl); ok {
+		if store := ass.Store(); store != nil {
+			return store
+		}
+	}
+	return trie_manager.NewImmutable(dbase, nil)
+}
+
+// NewChangeSet returns changes of accounts and their storages from the world
+// state of hash1 to the one of hash2. Nil hash means the empty world state.
+func NewChangeSet(dbase db.Database, hash1, hash2 []byte) (*ChangeSet, error) {
+	accounts1 := trie_manager.NewImmutableForObject(dbase, hash1, AccountType)
+	accounts2 := trie_manager.NewImmutableForObject(dbase, hash2, AccountType)
+
+	cs := new(ChangeSet)
+	var serr error
+	err := trie_manager.DiffImmutableForObject(accounts1, accounts2,
+		func(op int, key []byte, o1, o2 trie.Object) {
+			if serr != nil {
+				return
+			}
+			ac := &AccountChange{
+				Key:     key,
+				Removed: o2 == nil,
+			}
+			serr = trie_manager.DiffImmutable(
+				storeOf(dbase, o1), storeOf(dbase, o2),
+				func(op int, key, v1, v2 []byte) {
+					ac.Storage = append(ac.Storage, key)
+				})
+			cs.Accounts = append(cs.Accounts, ac)
+		})
+	if err != nil {
+		return nil, err
+	}
+	if serr != nil {
+		return nil, serr
+	}
+	return cs, nil
+}
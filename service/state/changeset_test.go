@@ -0,0 +1,95 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package state
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/db"
+)
+
+func TestNewChangeSet(t *testing.T) {
+	database := db.NewMapDB()
+	ws := NewWorldState(database, nil, nil, nil, nil)
+	for i := 0; i < 20; i++ {
+		as := ws.GetAccountState([]byte(fmt.Sprintf("account%d", i)))
+		as.SetBalance(big.NewInt(int64(i + 1)))
+		_, err := as.SetValue([]byte("key"), []byte("value"))
+		assert.NoError(t, err)
+	}
+	ss1 := ws.GetSnapshot()
+	assert.NoError(t, ss1.Flush())
+
+	// balance only
+	ws.GetAccountState([]byte("account1")).SetBalance(big.NewInt(100))
+	// storage
+	as2 := ws.GetAccountState([]byte("account2"))
+	_, err := as2.SetValue([]byte("key2"), []byte("value2"))
+	assert.NoError(t, err)
+	_, err = as2.DeleteValue([]byte("key"))
+	assert.NoError(t, err)
+	// removed
+	as3 := ws.GetAccountState([]byte("account3"))
+	as3.SetBalance(big.NewInt(0))
+	_, err = as3.DeleteValue([]byte("key"))
+	assert.NoError(t, err)
+	// added
+	ws.GetAccountState([]byte("new")).SetBalance(big.NewInt(1))
+
+	ss2 := ws.GetSnapshot()
+	assert.NoError(t, ss2.Flush())
+
+	cs, err := NewChangeSet(database, ss1.StateHash(), ss2.StateHash())
+	assert.NoError(t, err)
+	assert.Len(t, cs.Accounts, 4)
+
+	cs2, err := ChangeSetFromBytes(cs.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, cs, cs2)
+
+	ac := cs2.Account([]byte("account1"))
+	assert.NotNil(t, ac)
+	assert.False(t, ac.Removed)
+	assert.Empty(t, ac.Storage)
+
+	ac = cs2.Account([]byte("account2"))
+	assert.NotNil(t, ac)
+	assert.Equal(t, [][]byte{[]byte("key"), []byte("key2")}, ac.Storage)
+
+	ac = cs2.Account([]byte("account3"))
+	assert.NotNil(t, ac)
+	assert.True(t, ac.Removed)
+	assert.Equal(t, [][]byte{[]byte("key")}, ac.Storage)
+
+	ac = cs2.Account([]byte("new"))
+	assert.NotNil(t, ac)
+	assert.False(t, ac.Removed)
+
+	assert.Nil(t, cs2.Account([]byte("account4")))
+
+	// from the empty state
+	cs, err = NewChangeSet(database, nil, ss1.StateHash())
+	assert.NoError(t, err)
+	assert.Len(t, cs.Accounts, 20)
+	for _, ac := range cs.Accounts {
+		assert.Equal(t, [][]byte{[]byte("key")}, ac.Storage)
+	}
+}
@@ -42,6 +42,22 @@ const (
 	VarSystemDepositUsage = "system_deposit_usage"
 
 	VarDSRContextHistory = "dsr_context_history"
+
+	VarScheduledUpdates  = "scheduled_updates"
+	VarScheduledUpdateOf = "scheduled_update_of"
+	VarUpgradeHistory    = "upgrade_history"
+
+	VarScheduledCalls  = "scheduled_calls"
+	VarScheduledCall   = "scheduled_call"
+	VarScheduledCallID = "scheduled_call_id"
+)
+
+// Variables in the storage of the token account
+const (
+	VarTokens          = "tokens"
+	VarTokenID         = "token_id"
+	VarTokenBalances   = "token_balances"
+	VarTokenAllowances = "token_allowances"
 )
 
 const (
@@ -72,12 +88,17 @@ const (
 
 const (
 	SystemIDStr = "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"
+
+	// TokenIDStr is the identifier of the account keeping native tokens
+	// apart from the system storage.
+	TokenIDStr = "\x21\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"
 )
 
 var (
 	SystemID      = []byte(SystemIDStr)
 	SystemAddress = common.NewContractAddress(SystemID)
 	ZeroAddress   = common.NewAccountAddress(SystemID)
+	TokenID       = []byte(TokenIDStr)
 )
 
 var (
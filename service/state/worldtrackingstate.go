@@ -0,0 +1,198 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package state
+
+import (
+	"sync"
+)
+
+// WorldTrackingState records accounts accessed through it for optimistic
+// execution. Accounts in ReadSet are snapshots of the base at the first
+// access, so the execution is still valid if the latest state has the same
+// snapshots for them. Accessing validators, extension or BTP state can't be
+// tracked per account, so it's reported by WorldAccessed.
+type WorldTrackingState interface {
+	WorldState
+	ReadSet() map[string]AccountSnapshot
+	WriteSet() map[string]AccountSnapshot
+	WorldAccessed() bool
+}
+
+type worldTrackingState struct {
+	WorldState
+	lock   sync.Mutex
+	base   WorldSnapshot
+	reads  map[string]AccountSnapshot
+	states map[string]bool
+	world  bool
+}
+
+func (ws *worldTrackingState) onAccountAccess(id []byte, mutable bool) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	ids := string(id)
+	if _, ok := ws.reads[ids]; !ok {
+		ws.reads[ids] = ws.base.GetAccountSnapshot(id)
+	}
+	if mutable {
+		ws.states[ids] = true
+	}
+}
+
+func (ws *worldTrackingState) onWorldAccess() {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	ws.world = true
+}
+
+func (ws *worldTrackingState) GetAccountState(id []byte) AccountState {
+	ws.onAccountAccess(id, true)
+	return ws.WorldState.GetAccountState(id)
+}
+
+func (ws *worldTrackingState) GetAccountSnapshot(id []byte) AccountSnapshot {
+	ws.onAccountAccess(id, false)
+	return ws.WorldState.GetAccountSnapshot(id)
+}
+
+func (ws *worldTrackingState) GetValidatorState() ValidatorState {
+	ws.onWorldAccess()
+	return ws.WorldState.GetValidatorState()
+}
+
+func (ws *worldTrackingState) GetExtensionState() ExtensionState {
+	ws.onWorldAccess()
+	return ws.WorldState.GetExtensionState()
+}
+
+func (ws *worldTrackingState) GetBTPState() BTPState {
+	ws.onWorldAccess()
+	return ws.WorldState.GetBTPState()
+}
+
+func (ws *worldTrackingState) GetSnapshot() WorldSnapshot {
+	return &worldTrackingSnapshot{
+		WorldSnapshot: ws.WorldState.GetSnapshot(),
+		origin:        ws,
+	}
+}
+
+func (ws *worldTrackingState) Reset(snapshot WorldSnapshot) error {
+	if wtss, ok := snapshot.(*worldTrackingSnapshot); ok {
+		snapshot = wtss.WorldSnapshot
+	}
+	return ws.WorldState.Reset(snapshot)
+}
+
+func (ws *worldTrackingState) ReadSet() map[string]AccountSnapshot {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	reads := make(map[string]AccountSnapshot, len(ws.reads))
+	for id, ass := range ws.reads {
+		reads[id] = ass
+	}
+	return reads
+}
+
+// WriteSet returns the latest snapshots of accounts changed from the base.
+func (ws *worldTrackingState) WriteSet() map[string]AccountSnapshot {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	writes := make(map[string]AccountSnapshot)
+	for id := range ws.states {
+		ass := ws.WorldState.GetAccountSnapshot([]byte(id))
+		if !SameAccountSnapshot(ass, ws.reads[id]) {
+			writes[id] = ass
+		}
+	}
+	return writes
+}
+
+func (ws *worldTrackingState) WorldAccessed() bool {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	return ws.world
+}
+
+// NewWorldTrackingState returns a new WorldTrackingState on the world state
+// made from the base snapshot.
+func NewWorldTrackingState(base WorldSnapshot) (WorldTrackingState, error) {
+	ws, err := WorldStateFromSnapshot(base)
+	if err != nil {
+		return nil, err
+	}
+	return &worldTrackingState{
+		WorldState: ws,
+		base:       base,
+		reads:      make(map[string]AccountSnapshot),
+		states:     make(map[string]bool),
+	}, nil
+}
+
+type worldTrackingSnapshot struct {
+	WorldSnapshot
+	origin *worldTrackingState
+}
+
+func (wtss *worldTrackingSnapshot) GetAccountSnapshot(id []byte) AccountSnapshot {
+	wtss.origin.onAccountAccess(id, false)
+	return wtss.WorldSnapshot.GetAccountSnapshot(id)
+}
+
+func (wtss *worldTrackingSnapshot) GetValidatorSnapshot() ValidatorSnapshot {
+	wtss.origin.onWorldAccess()
+	return wtss.WorldSnapshot.GetValidatorSnapshot()
+}
+
+func (wtss *worldTrackingSnapshot) GetExtensionSnapshot() ExtensionSnapshot {
+	wtss.origin.onWorldAccess()
+	return wtss.WorldSnapshot.GetExtensionSnapshot()
+}
+
+func (wtss *worldTrackingSnapshot) GetBTPSnapshot() BTPSnapshot {
+	wtss.origin.onWorldAccess()
+	return wtss.WorldSnapshot.GetBTPSnapshot()
+}
+
+func (wtss *worldTrackingSnapshot) ExtensionData() []byte {
+	wtss.origin.onWorldAccess()
+	return wtss.WorldSnapshot.ExtensionData()
+}
+
+func (wtss *worldTrackingSnapshot) BTPData() []byte {
+	wtss.origin.onWorldAccess()
+	return wtss.WorldSnapshot.BTPData()
+}
+
+func (wtss *worldTrackingSnapshot) StateHash() []byte {
+	wtss.origin.onWorldAccess()
+	return wtss.WorldSnapshot.StateHash()
+}
+
+// SameAccountSnapshot returns whether both snapshots have the same account
+// data. Nil snapshot is regarded as an empty account.
+func SameAccountSnapshot(a, b AccountSnapshot) bool {
+	if a == nil || b == nil {
+		return (a == nil || a.IsEmpty()) && (b == nil || b.IsEmpty())
+	}
+	return a.Equal(b)
+}
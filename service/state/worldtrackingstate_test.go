@@ -0,0 +1,67 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common/db"
+)
+
+func TestWorldTrackingState_ReadWriteSet(t *testing.T) {
+	database := db.NewMapDB()
+	ws := NewWorldState(database, nil, nil, nil, nil)
+	ws.GetAccountState([]byte("a1")).SetBalance(big.NewInt(100))
+	ws.GetAccountState([]byte("a2")).SetBalance(big.NewInt(200))
+	base := ws.GetSnapshot()
+
+	wts, err := NewWorldTrackingState(base)
+	assert.NoError(t, err)
+
+	// read only
+	assert.Equal(t, int64(200), wts.GetAccountSnapshot([]byte("a2")).GetBalance().Int64())
+
+	// read through the snapshot
+	wss := wts.GetSnapshot()
+	assert.True(t, SameAccountSnapshot(wss.GetAccountSnapshot([]byte("a3")), nil))
+
+	// read and write
+	as1 := wts.GetAccountState([]byte("a1"))
+	as1.SetBalance(big.NewInt(150))
+
+	// mutable, but not changed
+	wts.GetAccountState([]byte("a4"))
+
+	reads := wts.ReadSet()
+	assert.Len(t, reads, 4)
+	assert.Equal(t, int64(100), reads["a1"].GetBalance().Int64())
+	assert.True(t, SameAccountSnapshot(reads["a3"], nil))
+
+	writes := wts.WriteSet()
+	assert.Len(t, writes, 1)
+	assert.Equal(t, int64(150), writes["a1"].GetBalance().Int64())
+	assert.False(t, wts.WorldAccessed())
+
+	wts.GetExtensionState()
+	assert.True(t, wts.WorldAccessed())
+
+	// reset with the snapshot made by itself
+	assert.NoError(t, wts.Reset(wss))
+	assert.Len(t, wts.WriteSet(), 0)
+}
+
+func TestSameAccountSnapshot(t *testing.T) {
+	database := db.NewMapDB()
+	ws := NewWorldState(database, nil, nil, nil, nil)
+	empty := ws.GetAccountSnapshot([]byte("a1"))
+	ws.GetAccountState([]byte("a2")).SetBalance(big.NewInt(100))
+	a2 := ws.GetAccountSnapshot([]byte("a2"))
+
+	assert.True(t, SameAccountSnapshot(nil, nil))
+	assert.True(t, SameAccountSnapshot(empty, nil))
+	assert.True(t, SameAccountSnapshot(nil, empty))
+	assert.False(t, SameAccountSnapshot(a2, nil))
+	assert.False(t, SameAccountSnapshot(empty, a2))
+	assert.True(t, SameAccountSnapshot(a2, ws.GetAccountSnapshot([]byte("a2"))))
+}
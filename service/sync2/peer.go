@@ -72,6 +72,13 @@ func (p *peer) RequestData(reqData []BucketIDAndBytes, handler DataHandler) erro
 	}
 }
 
+// report reports the violation of the peer if the sender supports it.
+func (p *peer) report(v module.PeerViolation, reason string) {
+	if pr, ok := p.sender.(module.PeerReporter); ok {
+		pr.ReportPeer(p.id, v, reason)
+	}
+}
+
 func (p *peer) OnData(reqID uint32, status errCode, data []BucketIDAndBytes) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
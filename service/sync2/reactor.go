@@ -34,6 +34,10 @@ type ReactorCommon struct {
 	sender    DataSender
 }
 
+func (r *ReactorCommon) ReportPeer(id module.PeerID, v module.PeerViolation, reason string) {
+	module.ReportPeer(r.ph, id, v, reason)
+}
+
 func (r *ReactorCommon) OnJoin(id module.PeerID) {
 	r.logger.Tracef("OnJoin() peer=%v, version=%d", id, r.version)
 	locker := common.LockForAutoCall(&r.mutex)
@@ -179,6 +183,7 @@ func (r *ReactorV1) requestNode(msg []byte, id module.PeerID) *nodeData {
 	req := new(requestNodeData)
 	if _, err := c.UnmarshalFromBytes(msg, &req); err != nil {
 		r.logger.Info("Failed to unmarshal len(msg)=%d, error=%+v", len(msg), err)
+		r.ReportPeer(id, module.ViolationInvalidMessage, "MalformedRequestNodeData")
 		return nil
 	}
 
@@ -221,6 +226,7 @@ func (r *ReactorV1) processMsg(msg []byte, id module.PeerID) (*nodeData, error)
 
 	if err != nil {
 		r.logger.Infof("Failed onReceive. receivedReqID=%d, err=%+v", data.ReqID, err)
+		r.ReportPeer(id, module.ViolationInvalidMessage, "MalformedNodeData")
 		return nil, errors.New("parse nodeData failed")
 	}
 
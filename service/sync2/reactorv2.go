@@ -62,6 +62,7 @@ func (r *ReactorV2) request(msg []byte, id module.PeerID) *responseData {
 	req := new(requestData)
 	if _, err := codec.UnmarshalFromBytes(msg, &req); err != nil {
 		r.logger.Infof("Failed to unmarshal error=%+v, len(msg)=%d", err, len(msg))
+		r.ReportPeer(id, module.ViolationInvalidMessage, "MalformedRequestData")
 		return nil
 	}
 
@@ -94,6 +95,7 @@ func (r *ReactorV2) processMsg(msg []byte, id module.PeerID) (*responseData, err
 
 	if err != nil {
 		r.logger.Infof("Failed onReceive. ReqID=%d, err=%v", data.ReqID, err)
+		r.ReportPeer(id, module.ViolationInvalidMessage, "MalformedResponseData")
 		return nil, errors.New("parse responseData failed")
 	}
 	return data, nil
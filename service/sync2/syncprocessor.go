@@ -9,6 +9,7 @@ import (
 	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/common/merkle"
+	"github.com/icon-project/goloop/module"
 )
 
 const (
@@ -384,6 +385,9 @@ func (s *syncProcessor) HandleData(reqID uint32, sender *peer, data []BucketIDAn
 				hasError = true
 				s.logger.Warnf("HandleData() failed builder.OnData err=%+v item=%v", err, item)
 			}
+			if err == merkle.ErrNoHasher {
+				sender.report(module.ViolationInvalidBlock, "InvalidBucketID")
+			}
 		}
 	}
 
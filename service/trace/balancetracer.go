@@ -29,6 +29,9 @@ var opTypeNames = []string{
 	"GHOST",
 	"REWARD",
 	"REG_PREP",
+	"TOKEN_MINT",
+	"TOKEN_BURN",
+	"TOKEN_TRANSFER",
 }
 
 func opTypeToString(o module.OpType) string {
@@ -41,6 +44,7 @@ type operation struct {
 	from   module.Address
 	to     module.Address
 	amount *common.HexInt
+	token  *common.HexInt64
 }
 
 func (o *operation) toJSON() map[string]interface{} {
@@ -54,6 +58,9 @@ func (o *operation) toJSON() map[string]interface{} {
 	if o.to != nil {
 		jso["to"] = o.to
 	}
+	if o.token != nil {
+		jso["token"] = o.token
+	}
 	return jso
 }
 
@@ -113,6 +120,11 @@ type BalanceTracer struct {
 }
 
 func (bt *BalanceTracer) add(opType module.OpType, from, to module.Address, amount *big.Int) error {
+	_, err := bt.addOp(opType, from, to, amount)
+	return err
+}
+
+func (bt *BalanceTracer) addOp(opType module.OpType, from, to module.Address, amount *big.Int) (*operation, error) {
 	curFrame := bt.curFrame
 	op := &operation{
 		depth:  curFrame.depth,
@@ -122,7 +134,7 @@ func (bt *BalanceTracer) add(opType module.OpType, from, to module.Address, amou
 		amount: &common.HexInt{Int: *amount},
 	}
 	curFrame.ops = append(curFrame.ops, op)
-	return nil
+	return op, nil
 }
 
 func (bt *BalanceTracer) getCurrentTx() (*transaction, error) {
@@ -216,6 +228,15 @@ func (bt *BalanceTracer) OnBalanceChange(opType module.OpType, from, to module.A
 	return bt.add(opType, from, to, amount)
 }
 
+func (bt *BalanceTracer) OnTokenBalanceChange(opType module.OpType, token int64, from, to module.Address, amount *big.Int) error {
+	op, err := bt.addOp(opType, from, to, amount)
+	if err != nil {
+		return err
+	}
+	op.token = &common.HexInt64{Value: token}
+	return nil
+}
+
 func (bt *BalanceTracer) ToJSON(height int64) interface{} {
 	jso := make([]interface{}, 0, len(bt.txs))
 	for _, tx := range bt.txs {
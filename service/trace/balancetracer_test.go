@@ -69,6 +69,26 @@ func TestNewBalanceTracer(t *testing.T) {
 	// fmt.Printf("%s\n", string(bs))
 }
 
+func TestBalanceTracer_OnTokenBalanceChange(t *testing.T) {
+	bt := NewBalanceTracer(10, nil)
+	txHash := newRandomHash(32)
+	assert.NoError(t, bt.OnTransactionStart(0, txHash, false))
+
+	from := common.MustNewAddressFromString("hx100")
+	to := common.MustNewAddressFromString("hx101")
+	assert.NoError(t, bt.OnBalanceChange(module.Fee, from, nil, big.NewInt(10)))
+	assert.NoError(t, bt.OnTokenBalanceChange(module.TokenTransfer, 3, from, to, big.NewInt(1000)))
+	assert.NoError(t, bt.OnTransactionEnd(0, txHash))
+
+	jso := bt.ToJSON(1).([]interface{})
+	ops := jso[0].(map[string]interface{})["ops"].([]map[string]interface{})
+	assert.Equal(t, 2, len(ops))
+	_, ok := ops[0]["token"]
+	assert.False(t, ok)
+	assert.Equal(t, "TOKEN_TRANSFER", ops[1]["opType"])
+	assert.Equal(t, &common.HexInt64{Value: 3}, ops[1]["token"])
+}
+
 func TestEmpyBalanceTracer_ErrorCase(t *testing.T) {
 	var err error
 	txIndex := 0
@@ -229,6 +249,9 @@ func TestOpTypeToString(t *testing.T) {
 		{module.Ghost, "GHOST"},
 		{module.Reward, "REWARD"},
 		{module.RegPRep, "REG_PREP"},
+		{module.TokenMint, "TOKEN_MINT"},
+		{module.TokenBurn, "TOKEN_BURN"},
+		{module.TokenTransfer, "TOKEN_TRANSFER"},
 	}
 
 	for _, item := range items {
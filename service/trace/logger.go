@@ -227,6 +227,31 @@ func (l *Logger) OnBalanceChange(opType module.OpType, from, to module.Address,
 	}
 }
 
+// OnTokenBalanceChange reports the change of the balance of the native token.
+// It's ignored if the callback doesn't trace native tokens.
+func (l *Logger) OnTokenBalanceChange(opType module.OpType, token int64, from, to module.Address, amount *big.Int) {
+	if l.TraceMode() == module.TraceModeNone {
+		return
+	}
+	if from == nil && to == nil {
+		l.Warnf("OnTokenBalanceChange() error: invalid addresses")
+		return
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		l.Infof("Invalid amount in OnTokenBalanceChange(): amount=%v", amount)
+		return
+	}
+
+	l.TSystemf("TOKENBALANCECHANGE opType=%d token=%d from=%s to=%s amount=%d",
+		opType, token, from, to, amount)
+	if cb, ok := l.cb.(module.TokenTraceCallback); ok {
+		if err := cb.OnTokenBalanceChange(opType, token, from, to, amount); err != nil {
+			l.Warnf("OnTokenBalanceChange() error: opType=%d token=%d from=%s to=%s amount=%d err=%#v",
+				opType, token, from, to, amount, err)
+		}
+	}
+}
+
 func NewLogger(l log.Logger, ti *module.TraceInfo) *Logger {
 	tlog := &Logger{
 		Logger: l,
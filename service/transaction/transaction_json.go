@@ -21,6 +21,11 @@ type transactionJSON struct {
 const (
 	Version2 = 2
 	Version3 = 3
+	Version4 = 4
+
+	// version4Proofless is for the hash of the transaction of version 4
+	// without the proof, which is given to the wallet with the proof.
+	version4Proofless = -Version4
 )
 
 var (
@@ -42,6 +47,17 @@ var (
 				"txHash":    true,
 			},
 		},
+		Version4: {
+			exclusion: map[string]bool{
+				"txHash": true,
+			},
+		},
+		version4Proofless: {
+			exclusion: map[string]bool{
+				"proof":  true,
+				"txHash": true,
+			},
+		},
 	}
 )
 
@@ -156,62 +156,79 @@ func (tx *transactionV3) Version() int {
 	return module.TransactionVersion3
 }
 
-func (tx *transactionV3) Verify() error {
+func verifyValueAndData(value, stepLimit *common.HexInt, dataType *string, data json.RawMessage) error {
 	// value >= 0
-	if tx.Value != nil && tx.Value.Sign() < 0 {
-		return InvalidTxValue.Errorf("InvalidTxValue(%s)", tx.Value.String())
+	if value != nil && value.Sign() < 0 {
+		return InvalidTxValue.Errorf("InvalidTxValue(%s)", value.String())
 	}
-	if tx.StepLimit.Sign() < 0 {
-		return InvalidTxValue.Errorf("InvalidTxStepLimit(%s)", tx.StepLimit.String())
+	if stepLimit.Sign() < 0 {
+		return InvalidTxValue.Errorf("InvalidTxStepLimit(%s)", stepLimit.String())
 	}
 
 	// character level size of data element <= 512KB
-	n, err := countBytesOfCompactJSON(tx.Data)
+	n, err := countBytesOfCompactJSON(data)
 	if err != nil {
-		return InvalidTxValue.Wrapf(err, "InvalidData(%x)", tx.Data)
+		return InvalidTxValue.Wrapf(err, "InvalidData(%x)", data)
 	} else if n > txMaxDataSize {
 		return InvalidTxValue.Errorf("InvalidDataSize(%d)", n)
 	}
 
 	// Checkups by data types
-	if tx.DataType != nil {
-		switch *tx.DataType {
+	if dataType != nil {
+		switch *dataType {
 		case contract.DataTypeCall:
 			// element check
-			if tx.Data == nil {
+			if data == nil {
 				return InvalidTxValue.Errorf("TxData for call is NIL")
 			}
-			if _, err := contract.ParseCallData(tx.Data); err != nil {
+			if _, err := contract.ParseCallData(data); err != nil {
 				return err
 			}
 		case contract.DataTypeDeploy:
 			// element check
-			if tx.Data == nil {
+			if data == nil {
 				return InvalidTxValue.New("TxData for deploy is NIL")
 			}
-			if _, err := contract.ParseDeployData(tx.Data); err != nil {
+			if _, err := contract.ParseDeployData(data); err != nil {
 				return InvalidTxValue.Wrap(err, "TxData is invalid")
 			}
-			if tx.Value != nil && tx.Value.Sign() != 0 {
-				return InvalidTxValue.Errorf("InvalidTxValue(%s)", tx.Value.String())
+			if value != nil && value.Sign() != 0 {
+				return InvalidTxValue.Errorf("InvalidTxValue(%s)", value.String())
 			}
 		case contract.DataTypePatch:
-			if tx.Data == nil {
+			if data == nil {
 				return InvalidTxValue.New("TxData for patch is NIL")
 			}
-			if _, err := contract.ParsePatchData(tx.Data); err != nil {
+			if _, err := contract.ParsePatchData(data); err != nil {
+				return InvalidTxValue.Wrap(err, "TxData is invalid")
+			}
+		case contract.DataTypeBatch:
+			if data == nil {
+				return InvalidTxValue.New("TxData for batch is NIL")
+			}
+			if _, err := contract.ParseBatchData(data); err != nil {
 				return InvalidTxValue.Wrap(err, "TxData is invalid")
 			}
+			if value != nil && value.Sign() != 0 {
+				return InvalidTxValue.Errorf("InvalidTxValue(%s)", value.String())
+			}
 		case contract.DataTypeDeposit:
-			if tx.Data == nil {
+			if data == nil {
 				return InvalidTxValue.New("TxData for deposit is NIL")
 			}
 			// Remove verification for IC2-315
-			// if _, err := contract.ParseDepositData(tx.Data); err != nil {
+			// if _, err := contract.ParseDepositData(data); err != nil {
 			// 	return InvalidTxValue.Wrap(err, "TxData is invalid")
 			// }
 		}
 	}
+	return nil
+}
+
+func (tx *transactionV3) Verify() error {
+	if err := verifyValueAndData(tx.Value, &tx.StepLimit, tx.DataType, tx.Data); err != nil {
+		return err
+	}
 
 	// signature verification
 	if err := tx.verifySignature(); err != nil {
@@ -229,27 +246,40 @@ func (tx *transactionV3) ValidateNetwork(nid int) bool {
 }
 
 func (tx *transactionV3) PreValidate(wc state.WorldContext, update bool) error {
-	if tx.DataType == nil || *tx.DataType != contract.DataTypePatch {
+	return preValidate(wc, tx.From(), tx.To(), tx.Value, &tx.StepLimit,
+		tx.DataType, tx.Data, 0, update)
+}
+
+func preValidate(wc state.WorldContext, from, to module.Address, value, stepLimit *common.HexInt, dataType *string, data json.RawMessage, extra int, update bool) error {
+	if dataType != nil && *dataType == contract.DataTypeBatch {
+		if !wc.Revision().Has(module.BatchTransaction) {
+			return InvalidTxValue.Errorf("NotSupportedDataType(%s)", *dataType)
+		}
+		if !to.Equal(state.SystemAddress) {
+			return InvalidTxValue.Errorf("InvalidBatchTarget(%s)", to)
+		}
+	}
+	if dataType == nil || *dataType != contract.DataTypePatch {
 		// stepLimit >= default step + input steps
-		cnt, err := MeasureBytesOfData(wc.Revision(), tx.Data)
+		cnt, err := MeasureBytesOfData(wc.Revision(), data)
 		if err != nil {
 			return err
 		}
-		minStep := big.NewInt(wc.StepsFor(state.StepTypeDefault, 1) + wc.StepsFor(state.StepTypeInput, cnt))
-		if tx.StepLimit.Cmp(minStep) < 0 {
-			return NotEnoughStepError.Errorf("NotEnoughStep(txStepLimit:%s, minStep:%s)", &tx.StepLimit.Int, minStep)
+		minStep := big.NewInt(wc.StepsFor(state.StepTypeDefault, 1) + wc.StepsFor(state.StepTypeInput, cnt+extra))
+		if stepLimit.Cmp(minStep) < 0 {
+			return NotEnoughStepError.Errorf("NotEnoughStep(txStepLimit:%s, minStep:%s)", &stepLimit.Int, minStep)
 		}
 	}
 
 	// balance >= (fee + value)
 	stepPrice := wc.StepPrice()
 
-	trans := new(big.Int).Mul(&tx.StepLimit.Int, stepPrice)
-	if tx.Value != nil {
-		trans.Add(trans, &tx.Value.Int)
+	trans := new(big.Int).Mul(&stepLimit.Int, stepPrice)
+	if value != nil {
+		trans.Add(trans, &value.Int)
 	}
 
-	as1 := wc.GetAccountState(tx.From().ID())
+	as1 := wc.GetAccountState(from.ID())
 	balance1 := as1.GetBalance()
 	if balance1.Cmp(trans) < 0 {
 		return NotEnoughBalanceError.Errorf("OutOfBalance(balance:%s, value:%s)", balance1, trans)
@@ -259,8 +289,8 @@ func (tx *transactionV3) PreValidate(wc state.WorldContext, update bool) error {
 		return AccessDeniedError.New("BlockedAccount")
 	}
 
-	as2 := wc.GetAccountState(tx.To().ID())
-	if contract.IsCallableDataType(tx.DataType) {
+	as2 := wc.GetAccountState(to.ID())
+	if contract.IsCallableDataType(dataType) {
 		if !as2.CanAcceptTx(wc) {
 			return ContractNotUsable.New("NotAcceptable")
 		}
@@ -269,9 +299,9 @@ func (tx *transactionV3) PreValidate(wc state.WorldContext, update bool) error {
 	// for cumulative balance check
 	if update {
 		as1.SetBalance(new(big.Int).Sub(balance1, trans))
-		if tx.Value != nil {
+		if value != nil {
 			balance2 := as2.GetBalance()
-			as2.SetBalance(new(big.Int).Add(balance2, &tx.Value.Int))
+			as2.SetBalance(new(big.Int).Add(balance2, &value.Int))
 		}
 	}
 	return nil
@@ -0,0 +1,277 @@
+package transaction
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// transactionV3WithKeyData is the transaction of version 3 signed with the
+// key other than secp256k1. As the public key can't be recovered from the
+// signature, the public key is included in the transaction with its type.
+// Both of them are a part of the hash.
+type transactionV3WithKeyData struct {
+	Version   common.HexUint16 `json:"version"`
+	From      common.Address   `json:"from"`
+	To        common.Address   `json:"to"`
+	Value     *common.HexInt   `json:"value,omitempty"`
+	StepLimit common.HexInt    `json:"stepLimit"`
+	TimeStamp common.HexInt64  `json:"timestamp"`
+	NID       *common.HexInt64 `json:"nid,omitempty"`
+	Nonce     *common.HexInt   `json:"nonce,omitempty"`
+	Signature []byte           `json:"signature"`
+	DataType  *string          `json:"dataType,omitempty"`
+	Data      json.RawMessage  `json:"data,omitempty"`
+	KeyType   string           `json:"keyType"`
+	PublicKey common.HexBytes  `json:"publicKey"`
+}
+
+func (tx *transactionV3WithKeyData) calcHash() ([]byte, error) {
+	bs, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	return calcHashOfTransactionJSON(bs, Version3)
+}
+
+type transactionV3WithKey struct {
+	transactionV3WithKeyData
+	txHash []byte
+	bytes  []byte
+}
+
+func (tx *transactionV3WithKey) Timestamp() int64 {
+	return tx.TimeStamp.Value
+}
+
+func (tx *transactionV3WithKey) TxHash() []byte {
+	if tx.txHash == nil {
+		h, err := tx.calcHash()
+		if err != nil {
+			tx.txHash = []byte{}
+		} else {
+			tx.txHash = h
+		}
+	}
+	return tx.txHash
+}
+
+func (tx *transactionV3WithKey) From() module.Address {
+	return &tx.transactionV3WithKeyData.From
+}
+
+func (tx *transactionV3WithKey) ID() []byte {
+	return tx.TxHash()
+}
+
+func (tx *transactionV3WithKey) Version() int {
+	return module.TransactionVersion3
+}
+
+func (tx *transactionV3WithKey) verifySignature() error {
+	if tx.KeyType != crypto.KeyTypeEd25519 {
+		return InvalidSignatureError.Errorf("UnsupportedKeyType(%s)", tx.KeyType)
+	}
+	addr, err := common.NewAccountAddressFromKey(tx.KeyType, tx.PublicKey)
+	if err != nil {
+		return InvalidSignatureError.Wrap(err, "invalid public key")
+	}
+	if !addr.Equal(tx.From()) {
+		return InvalidSignatureError.New("public key mismatch")
+	}
+	if !crypto.VerifyEd25519(tx.PublicKey, tx.TxHash(), tx.Signature) {
+		return InvalidSignatureError.New("fail to verify signature")
+	}
+	return nil
+}
+
+func (tx *transactionV3WithKey) Verify() error {
+	if tx.DataType != nil && *tx.DataType == contract.DataTypePatch {
+		return InvalidTxValue.Errorf("InvalidDataType(%s)", *tx.DataType)
+	}
+	if err := verifyValueAndData(tx.Value, &tx.StepLimit, tx.DataType, tx.Data); err != nil {
+		return err
+	}
+	if len(tx.TxHash()) == 0 {
+		return InvalidFormat.New("FailToCalculateHash")
+	}
+	return tx.verifySignature()
+}
+
+func (tx *transactionV3WithKey) ValidateNetwork(nid int) bool {
+	if tx.NID == nil {
+		return true
+	}
+	return int(tx.NID.Value) == nid
+}
+
+func (tx *transactionV3WithKey) PreValidate(wc state.WorldContext, update bool) error {
+	if !wc.Revision().Has(module.AccountKeyTypes) {
+		return InvalidTxValue.Errorf("NotSupportedKeyType(%s)", tx.KeyType)
+	}
+	return preValidate(wc, tx.From(), tx.To(), tx.Value, &tx.StepLimit,
+		tx.DataType, tx.Data, 0, update)
+}
+
+func (tx *transactionV3WithKey) GetHandler(cm contract.ContractManager) (Handler, error) {
+	var value *big.Int
+	if tx.Value != nil {
+		value = &tx.Value.Int
+	} else {
+		value = big.NewInt(0)
+	}
+	return NewHandler(cm,
+		tx.Group(),
+		tx.From(),
+		tx.To(),
+		value,
+		&tx.StepLimit.Int,
+		tx.DataType,
+		tx.Data)
+}
+
+func (tx *transactionV3WithKey) Group() module.TransactionGroup {
+	return module.TransactionGroupNormal
+}
+
+func (tx *transactionV3WithKey) Bytes() []byte {
+	if tx.bytes == nil {
+		if bs, err := codec.MarshalToBytes(&tx.transactionV3WithKeyData); err != nil {
+			log.Errorf("Fail to marshal transaction=%+v err=%+v", tx, err)
+			return nil
+		} else {
+			tx.bytes = bs
+		}
+	}
+	return tx.bytes
+}
+
+func (tx *transactionV3WithKey) SetBytes(bs []byte) error {
+	_, err := codec.UnmarshalFromBytes(bs, &tx.transactionV3WithKeyData)
+	if err != nil {
+		return InvalidFormat.Wrap(err, "fail to parse transaction bytes")
+	}
+	if tx.transactionV3WithKeyData.Version.Value != module.TransactionVersion3 {
+		return InvalidVersion.Errorf("NotTxVersion3(%d)", tx.transactionV3WithKeyData.Version.Value)
+	}
+	nbs := make([]byte, len(bs))
+	copy(nbs, bs)
+	tx.bytes = nbs
+	return nil
+}
+
+func (tx *transactionV3WithKey) Hash() []byte {
+	return crypto.SHA3Sum256(tx.Bytes())
+}
+
+func (tx *transactionV3WithKey) Nonce() *big.Int {
+	if nonce := tx.transactionV3WithKeyData.Nonce; nonce != nil {
+		return &nonce.Int
+	}
+	return nil
+}
+
+func (tx *transactionV3WithKey) To() module.Address {
+	return &tx.transactionV3WithKeyData.To
+}
+
+func (tx *transactionV3WithKey) ToJSON(version module.JSONVersion) (interface{}, error) {
+	jso := map[string]interface{}{
+		"version":   &tx.transactionV3WithKeyData.Version,
+		"from":      &tx.transactionV3WithKeyData.From,
+		"to":        &tx.transactionV3WithKeyData.To,
+		"stepLimit": &tx.transactionV3WithKeyData.StepLimit,
+		"timestamp": &tx.transactionV3WithKeyData.TimeStamp,
+		"signature": base64.StdEncoding.EncodeToString(tx.transactionV3WithKeyData.Signature),
+		"keyType":   tx.transactionV3WithKeyData.KeyType,
+		"publicKey": tx.transactionV3WithKeyData.PublicKey,
+	}
+	if tx.transactionV3WithKeyData.Value != nil {
+		jso["value"] = tx.transactionV3WithKeyData.Value
+	}
+	if tx.transactionV3WithKeyData.NID != nil {
+		jso["nid"] = tx.transactionV3WithKeyData.NID
+	}
+	if tx.transactionV3WithKeyData.Nonce != nil {
+		jso["nonce"] = tx.transactionV3WithKeyData.Nonce
+	}
+	if tx.transactionV3WithKeyData.DataType != nil {
+		jso["dataType"] = *tx.transactionV3WithKeyData.DataType
+	}
+	if tx.transactionV3WithKeyData.Data != nil {
+		jso["data"] = json.RawMessage(tx.transactionV3WithKeyData.Data)
+	}
+	jso["txHash"] = common.HexBytes(tx.ID())
+	return jso, nil
+}
+
+func (tx *transactionV3WithKey) MarshalJSON() ([]byte, error) {
+	if obj, err := tx.ToJSON(module.JSONVersionLast); err != nil {
+		return nil, scoreresult.WithStatus(err, module.StatusIllegalFormat)
+	} else {
+		return json.Marshal(obj)
+	}
+}
+
+func (tx *transactionV3WithKey) IsSkippable() bool {
+	return true
+}
+
+func checkV3WithKeyJSON(jso map[string]interface{}) bool {
+	if !checkV3JSON(jso) {
+		return false
+	}
+	_, ok := jso["keyType"]
+	return ok
+}
+
+// parseV3WithKeyJSON parses the transaction. Unknown fields are dropped, and
+// the hash is calculated only with the known fields, so that the hash doesn't
+// change on conversion to the binary form.
+func parseV3WithKeyJSON(js []byte, jsm map[string]any, raw bool) (Transaction, error) {
+	tx := new(transactionV3WithKey)
+	if err := json.Unmarshal(js, &tx.transactionV3WithKeyData); err != nil {
+		return nil, InvalidFormat.Wrapf(err, "Invalid json for transactionV3WithKey(%s)", string(js))
+	}
+	if tx.transactionV3WithKeyData.Version.Value != module.TransactionVersion3 {
+		return nil, InvalidVersion.Errorf("NotTxVersion3(%d)", tx.transactionV3WithKeyData.Version.Value)
+	}
+	return tx, nil
+}
+
+// checkV3WithKeyBinary checks the key type following the fields of the
+// transaction of version 3, which doesn't exist in others.
+func checkV3WithKeyBinary(bs []byte) bool {
+	var data transactionV3WithKeyData
+	if _, err := codec.UnmarshalFromBytes(bs, &data); err != nil {
+		return false
+	}
+	return data.Version.Value == module.TransactionVersion3 && len(data.KeyType) > 0
+}
+
+func parseV3WithKeyBinary(bs []byte) (Transaction, error) {
+	tx := new(transactionV3WithKey)
+	if err := tx.SetBytes(bs); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func init() {
+	RegisterFactory(&Factory{
+		Priority:    17,
+		CheckJSON:   checkV3WithKeyJSON,
+		ParseJSON:   parseV3WithKeyJSON,
+		CheckBinary: checkV3WithKeyBinary,
+		ParseBinary: parseV3WithKeyBinary,
+	})
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transaction
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/module"
+)
+
+const testV3WithKeyTxJSON = `{
+	"version": "0x3",
+	"from": "%s",
+	"to": "hx0000000000000000000000000000000000000002",
+	"value": "0x10",
+	"stepLimit": "0x100000",
+	"timestamp": "0x5f3b9c1d2e4a0",
+	"nid": "0x1",
+	"keyType": "ed25519",
+	"publicKey": "0x%x",
+	"signature": "%s",
+	"dataType": "message",
+	"data": "0x1234"
+}`
+
+func newTestV3WithKeyTx(t *testing.T, seed []byte, from module.Address) (Transaction, []byte) {
+	pk, err := crypto.Ed25519PublicKeyFromSeed(seed)
+	assert.NoError(t, err)
+	if from == nil {
+		from, err = common.NewAccountAddressFromKey(crypto.KeyTypeEd25519, pk)
+		assert.NoError(t, err)
+	}
+	tx, err := NewTransactionFromJSON([]byte(fmt.Sprintf(testV3WithKeyTxJSON, from, pk, "")))
+	assert.NoError(t, err)
+	sig, err := crypto.SignEd25519(seed, tx.ID())
+	assert.NoError(t, err)
+	js := fmt.Sprintf(testV3WithKeyTxJSON, from, pk, base64.StdEncoding.EncodeToString(sig))
+	tx, err = NewTransactionFromJSON([]byte(js))
+	assert.NoError(t, err)
+	return tx, []byte(js)
+}
+
+func TestTransactionV3WithKey_Verify(t *testing.T) {
+	seed := crypto.GenerateEd25519Key()
+	tx, js := newTestV3WithKeyTx(t, seed, nil)
+	assert.Equal(t, module.TransactionVersion3, tx.Version())
+	assert.NoError(t, tx.Verify())
+
+	// hash is same as the one calculated with the whole JSON
+	id, err := calcHashOfTransactionJSON(js, Version3)
+	assert.NoError(t, err)
+	assert.Equal(t, id, tx.ID())
+
+	// binary form keeps the hash
+	tx2, err := NewTransaction(tx.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, tx.ID(), tx2.ID())
+	assert.Equal(t, tx.Hash(), tx2.Hash())
+	assert.NoError(t, tx2.Verify())
+
+	// public key should match the sender
+	other := common.MustNewAddressFromString("hx0000000000000000000000000000000000000001")
+	tx3, _ := newTestV3WithKeyTx(t, seed, other)
+	assert.True(t, InvalidSignatureError.Equals(tx3.Verify()))
+
+	// signature should be made by the key
+	bs, err := hex.DecodeString("1234")
+	assert.NoError(t, err)
+	sig, err := crypto.SignEd25519(crypto.GenerateEd25519Key(), bs)
+	assert.NoError(t, err)
+	pk, _ := crypto.Ed25519PublicKeyFromSeed(seed)
+	tx4, err := NewTransactionFromJSON([]byte(fmt.Sprintf(testV3WithKeyTxJSON,
+		tx.From(), pk, base64.StdEncoding.EncodeToString(sig))))
+	assert.NoError(t, err)
+	assert.True(t, InvalidSignatureError.Equals(tx4.Verify()))
+}
+
+func TestTransactionV3WithKey_Binary(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	v3JSON := `{
+		"version": "0x3",
+		"from": "%s",
+		"to": "hx0000000000000000000000000000000000000002",
+		"stepLimit": "0x100000",
+		"timestamp": "0x5f3b9c1d2e4a0",
+		"nid": "0x1",
+		"signature": "%s"
+	}`
+	from := common.NewAccountAddressFromPublicKey(pk)
+	tx, err := NewTransactionFromJSON([]byte(fmt.Sprintf(v3JSON, from, "")))
+	assert.NoError(t, err)
+	sig, err := crypto.NewSignature(tx.ID(), sk)
+	assert.NoError(t, err)
+	bs, err := sig.SerializeRSV()
+	assert.NoError(t, err)
+	tx, err = NewTransactionFromJSON([]byte(fmt.Sprintf(v3JSON, from,
+		base64.StdEncoding.EncodeToString(bs))))
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Verify())
+
+	// transactions with secp256k1 keys stays as before
+	tx2, err := NewTransaction(tx.Bytes())
+	assert.NoError(t, err)
+	_, ok := Unwrap(tx2).(*transactionV3)
+	assert.True(t, ok)
+	assert.NoError(t, tx2.Verify())
+}
@@ -0,0 +1,319 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/state"
+)
+
+const (
+	txMaxProofSize = 8 * 1024 // 8kB
+)
+
+// transactionV4Data is the transaction sent by the contract. Instead of the
+// signature, it has the proof, which is verified by the contract with
+// contract.ValidationMethod.
+type transactionV4Data struct {
+	Version   common.HexUint16 `json:"version"`
+	From      common.Address   `json:"from"`
+	To        common.Address   `json:"to"`
+	Value     *common.HexInt   `json:"value,omitempty"`
+	StepLimit common.HexInt    `json:"stepLimit"`
+	TimeStamp common.HexInt64  `json:"timestamp"`
+	NID       *common.HexInt64 `json:"nid,omitempty"`
+	Nonce     *common.HexInt   `json:"nonce,omitempty"`
+	Proof     common.HexBytes  `json:"proof"`
+	DataType  *string          `json:"dataType,omitempty"`
+	Data      json.RawMessage  `json:"data,omitempty"`
+}
+
+func (tx *transactionV4Data) calcHash(version int) ([]byte, error) {
+	bs, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	return calcHashOfTransactionJSON(bs, version)
+}
+
+// transactionV4 is identified by the hash including the proof, so that
+// copies with other proofs don't affect the original one. The wallet
+// verifies the proof with the hash excluding the proof.
+type transactionV4 struct {
+	transactionV4Data
+	txHash         []byte
+	validationHash []byte
+	bytes          []byte
+}
+
+func (tx *transactionV4) Timestamp() int64 {
+	return tx.TimeStamp.Value
+}
+
+func (tx *transactionV4) TxHash() []byte {
+	if tx.txHash == nil {
+		h, err := tx.calcHash(Version4)
+		if err != nil {
+			tx.txHash = []byte{}
+		} else {
+			tx.txHash = h
+		}
+	}
+	return tx.txHash
+}
+
+// ValidationHash returns the hash of the transaction without the proof,
+// which is given to the validation method of the wallet.
+func (tx *transactionV4) ValidationHash() []byte {
+	if tx.validationHash == nil {
+		h, err := tx.calcHash(version4Proofless)
+		if err != nil {
+			tx.validationHash = []byte{}
+		} else {
+			tx.validationHash = h
+		}
+	}
+	return tx.validationHash
+}
+
+func (tx *transactionV4) From() module.Address {
+	return &tx.transactionV4Data.From
+}
+
+func (tx *transactionV4) ID() []byte {
+	return tx.TxHash()
+}
+
+func (tx *transactionV4) Version() int {
+	return module.TransactionVersion4
+}
+
+func (tx *transactionV4) Verify() error {
+	if !tx.transactionV4Data.From.IsContract() {
+		return InvalidTxValue.Errorf("InvalidFrom(%s)", &tx.transactionV4Data.From)
+	}
+	if len(tx.Proof) > txMaxProofSize {
+		return InvalidTxValue.Errorf("InvalidProofSize(%d)", len(tx.Proof))
+	}
+	if tx.DataType != nil && *tx.DataType == contract.DataTypePatch {
+		return InvalidTxValue.Errorf("InvalidDataType(%s)", *tx.DataType)
+	}
+	if err := verifyValueAndData(tx.Value, &tx.StepLimit, tx.DataType, tx.Data); err != nil {
+		return err
+	}
+	if len(tx.TxHash()) == 0 || len(tx.ValidationHash()) == 0 {
+		return InvalidFormat.New("FailToCalculateHash")
+	}
+	return nil
+}
+
+func (tx *transactionV4) ValidateNetwork(nid int) bool {
+	if tx.NID == nil {
+		return true
+	}
+	return int(tx.NID.Value) == nid
+}
+
+// PreValidate checks the transaction like the transaction of version 3.
+// The contract validating it is called on execution, and also on sending
+// it through the service manager.
+func (tx *transactionV4) PreValidate(wc state.WorldContext, update bool) error {
+	if !wc.Revision().Has(module.ContractValidatedTx) {
+		return InvalidVersion.Errorf("NotSupportedTxVersion(%d)", module.TransactionVersion4)
+	}
+	as := wc.GetAccountState(tx.From().ID())
+	if !as.IsContract() || as.ActiveContract() == nil {
+		return ContractNotUsable.Errorf("NotActiveWallet(%s)", tx.From())
+	}
+	return preValidate(wc, tx.From(), tx.To(), tx.Value, &tx.StepLimit,
+		tx.DataType, tx.Data, len(tx.Proof), update)
+}
+
+func (tx *transactionV4) GetHandler(cm contract.ContractManager) (Handler, error) {
+	var value *big.Int
+	if tx.Value != nil {
+		value = &tx.Value.Int
+	} else {
+		value = big.NewInt(0)
+	}
+	th, err := newHandler(cm,
+		tx.Group(),
+		tx.From(),
+		tx.To(),
+		value,
+		&tx.StepLimit.Int,
+		tx.DataType,
+		tx.Data)
+	if err != nil {
+		return nil, err
+	}
+	th.validation = &walletValidation{
+		txHash: tx.ValidationHash(),
+		proof:  tx.Proof,
+	}
+	return th, nil
+}
+
+func (tx *transactionV4) Group() module.TransactionGroup {
+	return module.TransactionGroupNormal
+}
+
+func (tx *transactionV4) Bytes() []byte {
+	if tx.bytes == nil {
+		if bs, err := codec.MarshalToBytes(&tx.transactionV4Data); err != nil {
+			log.Errorf("Fail to marshal transaction=%+v err=%+v", tx, err)
+			return nil
+		} else {
+			tx.bytes = bs
+		}
+	}
+	return tx.bytes
+}
+
+func (tx *transactionV4) SetBytes(bs []byte) error {
+	_, err := codec.UnmarshalFromBytes(bs, &tx.transactionV4Data)
+	if err != nil {
+		return InvalidFormat.Wrap(err, "fail to parse transaction bytes")
+	}
+	if tx.transactionV4Data.Version.Value != module.TransactionVersion4 {
+		return InvalidVersion.Errorf("NotTxVersion4(%d)", tx.transactionV4Data.Version.Value)
+	}
+	nbs := make([]byte, len(bs))
+	copy(nbs, bs)
+	tx.bytes = nbs
+	return nil
+}
+
+func (tx *transactionV4) Hash() []byte {
+	return crypto.SHA3Sum256(tx.Bytes())
+}
+
+func (tx *transactionV4) Nonce() *big.Int {
+	if nonce := tx.transactionV4Data.Nonce; nonce != nil {
+		return &nonce.Int
+	}
+	return nil
+}
+
+func (tx *transactionV4) To() module.Address {
+	return &tx.transactionV4Data.To
+}
+
+func (tx *transactionV4) ToJSON(version module.JSONVersion) (interface{}, error) {
+	jso := map[string]interface{}{
+		"version":   &tx.transactionV4Data.Version,
+		"from":      &tx.transactionV4Data.From,
+		"to":        &tx.transactionV4Data.To,
+		"stepLimit": &tx.transactionV4Data.StepLimit,
+		"timestamp": &tx.transactionV4Data.TimeStamp,
+		"proof":     tx.transactionV4Data.Proof,
+	}
+	if tx.transactionV4Data.Value != nil {
+		jso["value"] = tx.transactionV4Data.Value
+	}
+	if tx.transactionV4Data.NID != nil {
+		jso["nid"] = tx.transactionV4Data.NID
+	}
+	if tx.transactionV4Data.Nonce != nil {
+		jso["nonce"] = tx.transactionV4Data.Nonce
+	}
+	if tx.transactionV4Data.DataType != nil {
+		jso["dataType"] = *tx.transactionV4Data.DataType
+	}
+	if tx.transactionV4Data.Data != nil {
+		jso["data"] = json.RawMessage(tx.transactionV4Data.Data)
+	}
+	jso["txHash"] = common.HexBytes(tx.ID())
+	return jso, nil
+}
+
+func (tx *transactionV4) MarshalJSON() ([]byte, error) {
+	if obj, err := tx.ToJSON(module.JSONVersionLast); err != nil {
+		return nil, scoreresult.WithStatus(err, module.StatusIllegalFormat)
+	} else {
+		return json.Marshal(obj)
+	}
+}
+
+func (tx *transactionV4) IsSkippable() bool {
+	return true
+}
+
+func checkV4JSON(jso map[string]interface{}) bool {
+	if version, ok := jso["version"]; !ok || version != "0x4" {
+		return false
+	}
+	if _, ok := jso["from"]; !ok {
+		return false
+	}
+	return true
+}
+
+// parseV4JSON parses the transaction. Unknown fields are dropped, and the
+// hash is calculated only with the known fields, so that the hash doesn't
+// change on conversion to the binary form.
+func parseV4JSON(js []byte, jsm map[string]any, raw bool) (Transaction, error) {
+	tx := new(transactionV4)
+	if err := json.Unmarshal(js, &tx.transactionV4Data); err != nil {
+		return nil, InvalidFormat.Wrapf(err, "Invalid json for transactionV4(%s)", string(js))
+	}
+	if tx.transactionV4Data.Version.Value != module.TransactionVersion4 {
+		return nil, InvalidVersion.Errorf("NotTxVersion4(%d)", tx.transactionV4Data.Version.Value)
+	}
+	return tx, nil
+}
+
+type transactionV4Header struct {
+	Version common.HexUint16
+}
+
+func checkV4Binary(bs []byte) bool {
+	var header transactionV4Header
+	if _, err := codec.UnmarshalFromBytes(bs, &header); err != nil {
+		return false
+	}
+	return header.Version.Value == module.TransactionVersion4
+}
+
+func parseV4Binary(bs []byte) (Transaction, error) {
+	tx := new(transactionV4)
+	if err := tx.SetBytes(bs); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func init() {
+	RegisterFactory(&Factory{
+		Priority:    15,
+		CheckJSON:   checkV4JSON,
+		ParseJSON:   parseV4JSON,
+		CheckBinary: checkV4Binary,
+		ParseBinary: parseV4Binary,
+	})
+}
+
+// ValidateByWallet calls the validation method of the contract sending the
+// transaction. It's used for checking the transaction before accepting it
+// into the pool. It returns nil for other versions of transactions.
+func ValidateByWallet(ctx contract.Context, tx module.Transaction) error {
+	tx4, ok := Unwrap(tx).(*transactionV4)
+	if !ok {
+		return nil
+	}
+	limit := ctx.GetStepLimit(state.StepLimitTypeQuery)
+	if limit.Cmp(&tx4.StepLimit.Int) > 0 {
+		limit = &tx4.StepLimit.Int
+	}
+	cc := contract.NewCallContext(ctx, limit, true)
+	defer cc.Dispose()
+	_, err := contract.ValidateTransaction(cc, tx4.From(), tx4.ValidationHash(), tx4.Proof, limit)
+	return err
+}
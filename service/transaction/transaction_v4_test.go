@@ -0,0 +1,91 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transaction
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/module"
+)
+
+const testV4TxJSON = `{
+	"version": "0x4",
+	"from": "cx0000000000000000000000000000000000000001",
+	"to": "hx0000000000000000000000000000000000000002",
+	"value": "0x10",
+	"stepLimit": "0x100000",
+	"timestamp": "0x5f3b9c1d2e4a0",
+	"nid": "0x1",
+	"nonce": "0x1",
+	"proof": "%s",
+	"dataType": "message",
+	"data": "0x1234"
+}`
+
+func TestTransactionV4_Hash(t *testing.T) {
+	tx1, err := NewTransactionFromJSON([]byte(fmt.Sprintf(testV4TxJSON, "0x01")))
+	assert.NoError(t, err)
+	assert.Equal(t, module.TransactionVersion4, tx1.Version())
+	assert.NoError(t, tx1.Verify())
+
+	// the proof is a part of the ID, but not of the hash for the wallet
+	tx2, err := NewTransactionFromJSON([]byte(fmt.Sprintf(testV4TxJSON, "0x0203")))
+	assert.NoError(t, err)
+	assert.NotEqual(t, tx1.ID(), tx2.ID())
+	assert.NotEqual(t, tx1.Hash(), tx2.Hash())
+	v4tx1 := Unwrap(tx1).(*transactionV4)
+	v4tx2 := Unwrap(tx2).(*transactionV4)
+	assert.Equal(t, v4tx1.ValidationHash(), v4tx2.ValidationHash())
+	assert.NotEqual(t, tx1.ID(), v4tx1.ValidationHash())
+
+	id, err := calcHashOfTransactionJSON([]byte(fmt.Sprintf(testV4TxJSON, "0x01")), Version4)
+	assert.NoError(t, err)
+	assert.Equal(t, id, tx1.ID())
+	hash, err := calcHashOfTransactionJSON([]byte(fmt.Sprintf(testV4TxJSON, "0x")), version4Proofless)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, v4tx1.ValidationHash())
+
+	// binary form keeps the hash
+	tx3, err := NewTransaction(tx2.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, module.TransactionVersion4, tx3.Version())
+	assert.Equal(t, tx2.ID(), tx3.ID())
+	assert.Equal(t, tx2.Hash(), tx3.Hash())
+}
+
+func TestTransactionV4_Verify(t *testing.T) {
+	js := strings.Replace(fmt.Sprintf(testV4TxJSON, "0x"),
+		`"from": "cx`, `"from": "hx`, 1)
+	tx, err := NewTransactionFromJSON([]byte(js))
+	assert.NoError(t, err)
+	assert.True(t, InvalidTxValue.Equals(tx.Verify()))
+
+	proof := "0x" + strings.Repeat("00", txMaxProofSize+1)
+	tx, err = NewTransactionFromJSON([]byte(fmt.Sprintf(testV4TxJSON, proof)))
+	assert.NoError(t, err)
+	assert.True(t, InvalidTxValue.Equals(tx.Verify()))
+
+	js = strings.Replace(fmt.Sprintf(testV4TxJSON, "0x"),
+		`"dataType": "message"`, `"dataType": "patch"`, 1)
+	tx, err = NewTransactionFromJSON([]byte(js))
+	assert.NoError(t, err)
+	assert.True(t, InvalidTxValue.Equals(tx.Verify()))
+}
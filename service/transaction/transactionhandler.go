@@ -35,11 +35,23 @@ type transactionHandler struct {
 
 	chandler contract.ContractHandler
 
+	// Assigned for the transactions validated by the contract of the sender
+	validation *walletValidation
+
 	// Assigned at Execute()
 	cc contract.CallContext
 }
 
+type walletValidation struct {
+	txHash []byte
+	proof  []byte
+}
+
 func NewHandler(cm contract.ContractManager, group module.TransactionGroup, from, to module.Address, value, stepLimit *big.Int, dataType *string, data []byte) (Handler, error) {
+	return newHandler(cm, group, from, to, value, stepLimit, dataType, data)
+}
+
+func newHandler(cm contract.ContractManager, group module.TransactionGroup, from, to module.Address, value, stepLimit *big.Int, dataType *string, data []byte) (*transactionHandler, error) {
 	th := &transactionHandler{
 		group:     group,
 		from:      from,
@@ -64,6 +76,8 @@ func NewHandler(cm contract.ContractManager, group module.TransactionGroup, from
 			ctype = contract.CTypePatch
 		case contract.DataTypeDeposit:
 			ctype = contract.CTypeDeposit
+		case contract.DataTypeBatch:
+			ctype = contract.CTypeBatch
 		default:
 			return nil, InvalidFormat.Errorf("IllegalDataType(type=%s)", *dataType)
 		}
@@ -127,6 +141,40 @@ func (th *transactionHandler) checkBlocked(cc contract.CallContext) error {
 	return nil
 }
 
+// validateByWallet asks the contract of the sender to authorize the
+// transaction. Steps for the call are limited by the query step limit.
+// Blocks including the transaction rejected with the parent state are
+// invalid, so others can't drain the wallet with forged ones. Only the
+// transaction rejected for the changes made by preceding ones in the block
+// reaches here, and the wallet pays for the steps used as it authorized the
+// transaction once. While estimating steps, the rejection is ignored for the
+// proof may not be ready yet.
+func (th *transactionHandler) validateByWallet(cc contract.CallContext, estimate bool) (status error, err error) {
+	if !cc.ApplySteps(state.StepTypeInput, len(th.validation.proof)) {
+		return scoreresult.ErrOutOfStep, nil
+	}
+	limit := cc.GetStepLimit(state.StepLimitTypeQuery)
+	if avail := cc.StepAvailable(); avail.Cmp(limit) < 0 {
+		limit = avail
+	}
+	used, status := contract.ValidateTransaction(cc, th.from,
+		th.validation.txHash, th.validation.proof, limit)
+	if used != nil {
+		cc.DeductSteps(used)
+	}
+	if code := errors.CodeOf(status); code == errors.ExecutionFailError ||
+		errors.IsCriticalCode(code) {
+		return nil, status
+	}
+	if status != nil {
+		cc.FrameLogger().TSystemf("TRANSACTION rejected by wallet status=%v", status)
+		if estimate && used != nil {
+			return nil, nil
+		}
+	}
+	return status, nil
+}
+
 func (th *transactionHandler) DoExecute(cc contract.CallContext, estimate, isPatch bool) (
 	status error,
 	score module.Address,
@@ -151,6 +199,11 @@ func (th *transactionHandler) DoExecute(cc contract.CallContext, estimate, isPat
 		if err := th.checkBlocked(cc); err != nil {
 			return err, nil, nil
 		}
+		if th.validation != nil {
+			if status, err := th.validateByWallet(cc, estimate); status != nil || err != nil {
+				return status, nil, err
+			}
+		}
 	}
 
 	// Execute
@@ -279,6 +332,9 @@ func (th *transactionHandler) Execute(ctx contract.Context, wcs state.WorldSnaps
 		cc.GetEventLogs(receipt)
 		cc.GetBTPMessages(receipt)
 	}
+	if bh, ok := th.chandler.(*contract.BatchHandler); ok {
+		bh.GetResults(receipt)
+	}
 	if redeemed := cc.GetRedeemLogs(receipt); redeemed && stepToPay.Sign() != 0 {
 		receipt.AddPayment(th.from, stepToPay, stepToPay)
 	}
@@ -51,7 +51,7 @@ func (*mockTransaction) ToJSON(version module.JSONVersion) (interface{}, error)
 }
 
 func (*mockTransaction) PreValidate(wc state.WorldContext, update bool) error {
-	panic("implement me")
+	return nil
 }
 
 func (*mockTransaction) GetHandler(cm contract.ContractManager) (transaction.Handler, error) {
@@ -25,6 +25,7 @@ type TransactionManager struct {
 	tim          TXIDManager
 	patchTxPool  *TransactionPool
 	normalTxPool *TransactionPool
+	txv          TxValidator
 
 	callback func()
 
@@ -248,12 +249,32 @@ func (m *TransactionManager) SetPoolCapacityMonitor(pcm PoolCapacityMonitor) {
 	m.normalTxPool.SetPoolCapacityMonitor(pcm)
 }
 
+func (m *TransactionManager) SetTxValidator(txv TxValidator) {
+	m.lock.Lock()
+	m.txv = txv
+	m.lock.Unlock()
+	m.normalTxPool.SetTxValidator(txv)
+}
+
+// ValidateNewTx validates the transaction received from peers before adding
+// it. Transactions already in the pool are not validated again.
+func (m *TransactionManager) ValidateNewTx(tx transaction.Transaction) error {
+	if m.HasTx(tx.ID()) {
+		return nil
+	}
+	m.lock.Lock()
+	txv := m.txv
+	m.lock.Unlock()
+	return txv.ValidateNewTx(tx)
+}
+
 func NewTransactionManager(nid int, tsc *TxTimestampChecker, ptp *TransactionPool, ntp *TransactionPool, tim TXIDManager, logger log.Logger) *TransactionManager {
 	txm := &TransactionManager{
 		nid:          nid,
 		tsc:          tsc,
 		patchTxPool:  ptp,
 		normalTxPool: ntp,
+		txv:          dummyTxValidator{},
 		tim:          tim,
 		log:          logger,
 		txWaiters:    map[hashValue][]chan<- interface{}{},
@@ -61,6 +61,24 @@ func (m dummyPoolCapacityMonitor) OnPoolCapacityUpdated(group module.Transaction
 	// do nothing
 }
 
+// TxValidator validates the transaction with the world state for the
+// proposal in addition to PreValidate. ValidateNewTx validates the
+// transaction received from peers before adding it to the pool.
+type TxValidator interface {
+	ValidateTx(wc state.WorldContext, tx transaction.Transaction) error
+	ValidateNewTx(tx transaction.Transaction) error
+}
+
+type dummyTxValidator struct{}
+
+func (v dummyTxValidator) ValidateTx(wc state.WorldContext, tx transaction.Transaction) error {
+	return nil
+}
+
+func (v dummyTxValidator) ValidateNewTx(tx transaction.Transaction) error {
+	return nil
+}
+
 type TransactionPool struct {
 	group module.TransactionGroup
 
@@ -74,6 +92,7 @@ type TransactionPool struct {
 	txm     TxWaiterManager
 	monitor Monitor
 	pcm     PoolCapacityMonitor
+	txv     TxValidator
 	log     log.Logger
 }
 
@@ -86,6 +105,7 @@ func NewTransactionPool(group module.TransactionGroup, size int, tim TXIDManager
 		txm:     dummyTxWaiterManager{},
 		monitor: m,
 		pcm:     dummyPoolCapacityMonitor{},
+		txv:     dummyTxValidator{},
 		log:     log,
 	}
 	return pool
@@ -177,6 +197,16 @@ func (tp *TransactionPool) Candidate(wc state.WorldContext, maxBytes int, maxCou
 			}
 			continue
 		}
+		if err := tp.txv.ValidateTx(wc, tx); err != nil {
+			if e.err == nil {
+				e.err = err
+				tp.log.Debugf("VALIDATE FAIL: id=%#x from=%s reason=%v",
+					tx.ID(), tx.From().String(), err)
+			}
+			tp.tim.AddDroppedTX(tx.ID(), tx.Timestamp())
+			dropped = append(dropped, e)
+			continue
+		}
 		bs := tx.Bytes()
 		if txSize+len(bs) > maxBytes {
 			break
@@ -307,6 +337,13 @@ func (tp *TransactionPool) SetPoolCapacityMonitor(pcm PoolCapacityMonitor) {
 	go tp.pcm.OnPoolCapacityUpdated(tp.group, tp.size, tp.list.Len())
 }
 
+func (tp *TransactionPool) SetTxValidator(txv TxValidator) {
+	tp.mutex.Lock()
+	defer tp.mutex.Unlock()
+
+	tp.txv = txv
+}
+
 func (tp *TransactionPool) GetBloom() *TxBloom {
 	tp.mutex.Lock()
 	defer tp.mutex.Unlock()
@@ -8,9 +8,12 @@ import (
 
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/errors"
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/common/txlocator"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
 )
 
 type mockMonitor struct {
@@ -49,3 +52,76 @@ func TestTransactionPool_Add(t *testing.T) {
 		t.Error("Fail to add transaction with valid network ID")
 	}
 }
+
+type mockTxValidator struct {
+	reject []byte
+}
+
+func (v *mockTxValidator) ValidateTx(wc state.WorldContext, tx transaction.Transaction) error {
+	if string(tx.ID()) == string(v.reject) {
+		return errors.InvalidStateError.New("Rejected")
+	}
+	return nil
+}
+
+func (v *mockTxValidator) ValidateNewTx(tx transaction.Transaction) error {
+	return v.ValidateTx(nil, tx)
+}
+
+func TestTransactionPool_CandidateWithValidator(t *testing.T) {
+	dbase := db.NewMapDB()
+	tsc := NewTimestampChecker()
+	logger := log.New()
+	lm, err := txlocator.NewManager(dbase, logger)
+	assert.NoError(t, err)
+	tim, _ := NewTXIDManager(lm, tsc, nil)
+	pool := NewTransactionPool(module.TransactionGroupNormal, 5000, tim, &mockMonitor{}, logger)
+
+	ts := time.Now().UnixMicro()
+	addr := common.MustNewAddressFromString("hx1111111111111111111111111111111111111111")
+	tx1 := newMockTransaction([]byte("tx1"), addr, ts)
+	tx2 := newMockTransaction([]byte("tx2"), addr, ts+1)
+	assert.NoError(t, pool.Add(tx1, true))
+	assert.NoError(t, pool.Add(tx2, true))
+
+	ws := state.NewWorldState(dbase, nil, nil, nil, nil)
+	wc := state.NewWorldContext(ws, common.NewBlockInfo(1, ts), nil, &testPlatform{})
+
+	txs, _ := pool.Candidate(wc, 0, 0)
+	assert.Len(t, txs, 2)
+
+	pool.SetTxValidator(&mockTxValidator{reject: tx1.ID()})
+	txs, _ = pool.Candidate(wc, 0, 0)
+	assert.Len(t, txs, 1)
+	assert.Equal(t, tx2.ID(), txs[0].ID())
+	assert.Eventually(t, func() bool {
+		return !pool.HasTx(tx1.ID())
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, pool.HasTx(tx2.ID()))
+}
+
+func TestTransactionManager_ValidateNewTx(t *testing.T) {
+	dbase := db.NewMapDB()
+	tsc := NewTimestampChecker()
+	logger := log.New()
+	lm, err := txlocator.NewManager(dbase, logger)
+	assert.NoError(t, err)
+	tim, _ := NewTXIDManager(lm, tsc, nil)
+	ptp := NewTransactionPool(module.TransactionGroupPatch, 5000, tim, &mockMonitor{}, logger)
+	ntp := NewTransactionPool(module.TransactionGroupNormal, 5000, tim, &mockMonitor{}, logger)
+	tm := NewTransactionManager(1, tsc, ptp, ntp, tim, logger)
+
+	ts := time.Now().UnixMicro()
+	addr := common.MustNewAddressFromString("hx1111111111111111111111111111111111111111")
+	tx1 := newMockTransaction([]byte("tx1"), addr, ts)
+	tx2 := newMockTransaction([]byte("tx2"), addr, ts+1)
+	assert.NoError(t, tm.ValidateNewTx(tx1))
+
+	tm.SetTxValidator(&mockTxValidator{reject: tx1.ID()})
+	assert.Error(t, tm.ValidateNewTx(tx1))
+	assert.NoError(t, tm.ValidateNewTx(tx2))
+
+	// transactions in the pool are not validated again
+	assert.NoError(t, tm.Add(tx1, true, true))
+	assert.NoError(t, tm.ValidateNewTx(tx1))
+}
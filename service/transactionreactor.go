@@ -44,11 +44,22 @@ func (r *TransactionReactor) handleTransactionInBackground(buf []byte, peerId mo
 		if err != nil {
 			r.log.Warnf("InvalidPacket from=%s", peerId.String())
 			r.log.Debugf("Failed to unmarshal transaction. buf=%x, err=%+v", buf, err)
+			module.ReportPeer(r.membership, peerId, module.ViolationInvalidMessage, "MalformedTransaction")
 			onResult(false, err)
 			return
 		}
 
-		if err := r.tm.Add(tx, false, false); err != nil {
+		if err := r.tm.VerifyTx(tx); err != nil {
+			module.ReportPeer(r.membership, peerId, module.ViolationInvalidTransaction, "InvalidTransaction")
+			onResult(false, err)
+			return
+		}
+		if err := r.tm.ValidateNewTx(tx); err != nil {
+			r.log.Debugf("Fail to validate transaction id=%#x err=%v", tx.ID(), err)
+			onResult(false, err)
+			return
+		}
+		if err := r.tm.Add(tx, false, true); err != nil {
 			onResult(false, err)
 			return
 		}
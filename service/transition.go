@@ -751,6 +751,15 @@ func (t *transition) doExecute(alreadyValidated bool) {
 		t.reportExecution(err)
 		return
 	}
+	if err := contract.ApplyScheduledUpdates(ctx); err != nil {
+		t.reportExecution(err)
+		return
+	}
+	scheduledReceipts, err := contract.ApplyScheduledCalls(ctx)
+	if err != nil {
+		t.reportExecution(err)
+		return
+	}
 	patchReceipts := make([]txresult.Receipt, t.ptxCount)
 	if err := t.executeTxsSequential(t.patchTransactions, ctx, patchReceipts); err != nil {
 		t.reportExecution(err)
@@ -767,6 +776,12 @@ func (t *transition) doExecute(alreadyValidated bool) {
 	btpMsgs := list.New()
 
 	t.logsBloom.SetInt64(0)
+	for _, r := range scheduledReceipts {
+		t.logsBloom.Merge(r.LogsBloom())
+		if r.BTPMessages() != nil {
+			btpMsgs.PushBackList(r.BTPMessages())
+		}
+	}
 	fixLostFeeByDeposit := ctx.Revision().Has(module.FixLostFeeByDeposit)
 	for _, receipts := range [][]txresult.Receipt{patchReceipts, normalReceipts} {
 		for _, r := range receipts {
@@ -908,10 +923,29 @@ func (t *transition) validateTxs(l module.TransactionList, wc state.WorldContext
 		if err := tx.PreValidate(wc, true); err != nil {
 			return err
 		}
+		if tx.Version() == module.TransactionVersion4 {
+			if err := t.validateByWallet(wc, tx); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+// validateByWallet rejects the transaction if the wallet of the sender
+// doesn't authorize it with the parent state. The wallet pays for the
+// transaction included in the block, so forged ones shouldn't be included.
+func (t *transition) validateByWallet(wc state.WorldContext, tx transaction.Transaction) error {
+	qc := wc.WorldStateChanged(state.NewReadOnlyWorldState(wc.GetSnapshot()))
+	ctx := contract.NewContext(qc, t.cm, t.eem, t.chain, t.log, nil, eeproxy.ForQuery)
+	err := transaction.ValidateByWallet(ctx, tx)
+	if code := errors.CodeOf(err); err == nil || code == errors.ExecutionFailError ||
+		errors.IsCriticalCode(code) {
+		return err
+	}
+	return transaction.InvalidTxValue.Wrapf(err, "RejectedByWallet(id=%#x)", tx.ID())
+}
+
 func (t *transition) executeTxs(l module.TransactionList, ctx contract.Context, rctBuf []txresult.Receipt) error {
 	if l == nil {
 		return nil
@@ -921,6 +955,9 @@ func (t *transition) executeTxs(l module.TransactionList, ctx contract.Context,
 		return t.executeTxsSequential(l, ctx, rctBuf)
 	}
 	if cc := t.chain.ConcurrencyLevel(); cc > 1 {
+		if t.chain.OptimisticExecution() && t.ti == nil {
+			return t.executeTxsOptimistic(cc, l, ctx, rctBuf)
+		}
 		return t.executeTxsConcurrent(cc, l, ctx, rctBuf)
 	}
 	return t.executeTxsSequential(l, ctx, rctBuf)
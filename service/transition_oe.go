@@ -0,0 +1,199 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/server/metric"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
+	"github.com/icon-project/goloop/service/txresult"
+)
+
+const (
+	// hotAccountCount is the number of most conflicting accounts reported
+	// for a block.
+	hotAccountCount = 3
+)
+
+// speculation is the result of the optimistic execution of a transaction
+// on the state before the transactions.
+type speculation struct {
+	txo    transaction.Transaction
+	rct    txresult.Receipt
+	reads  map[string]state.AccountSnapshot
+	writes map[string]state.AccountSnapshot
+	world  bool
+	err    error
+}
+
+func (t *transition) speculateTx(ctx contract.Context, base state.WorldSnapshot, s *speculation, cnt int) {
+	ws, err := state.NewWorldTrackingState(base)
+	if err != nil {
+		s.err = err
+		return
+	}
+	if ctx.NodeCacheEnabled() {
+		ws.EnableNodeCache()
+	}
+	sctx := t.newContractContext(ctx.WorldStateChanged(ws))
+	sctx.SetProperty(contract.PropInitialSnapshot, ctx.GetProperty(contract.PropInitialSnapshot))
+	sctx.SetTransactionInfo(&state.TransactionInfo{
+		Group:     s.txo.Group(),
+		Index:     int32(cnt),
+		Timestamp: s.txo.Timestamp(),
+		Nonce:     s.txo.Nonce(),
+		Hash:      s.txo.ID(),
+		From:      s.txo.From(),
+	})
+	sctx.UpdateSystemInfo()
+
+	txh, err := s.txo.GetHandler(t.cm)
+	if err != nil {
+		s.err = err
+		return
+	}
+	rct, err := txh.Execute(sctx, sctx.GetSnapshot(), false)
+	txh.Dispose()
+	if err == nil {
+		err = t.plt.OnTransactionEnd(sctx, t.log, rct)
+	}
+	if err != nil {
+		s.err = err
+		return
+	}
+	s.rct = rct
+	s.reads = ws.ReadSet()
+	s.writes = ws.WriteSet()
+	s.world = ws.WorldAccessed()
+}
+
+// conflictsOf returns accounts read by the speculation, but changed by
+// previous transactions.
+func conflictsOf(ctx contract.Context, s *speculation) []string {
+	var ids []string
+	for id, ass := range s.reads {
+		if !state.SameAccountSnapshot(ctx.GetAccountSnapshot([]byte(id)), ass) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func applyWriteSet(ctx contract.Context, writes map[string]state.AccountSnapshot) error {
+	for id, ass := range writes {
+		if err := ctx.GetAccountState([]byte(id)).Reset(ass); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeTxsOptimistic executes transactions concurrently on the state before
+// them, then it validates accounts read by each transaction in order. Results
+// of valid transactions are applied as they are, and others are executed
+// again on the latest state. So it produces the same results as sequential
+// execution.
+func (t *transition) executeTxsOptimistic(level int, l module.TransactionList, ctx contract.Context, rctBuf []txresult.Receipt) error {
+	var specs []*speculation
+	for i := l.Iterator(); i.Has(); i.Next() {
+		txi, _, err := i.Get()
+		if err != nil {
+			t.log.Errorf("Fail to iterate transaction list err=%+v", err)
+			return err
+		}
+		specs = append(specs, &speculation{txo: txi.(transaction.Transaction)})
+	}
+
+	// system information is shared with contexts for speculation, so it
+	// should be updated before them.
+	ctx.UpdateSystemInfo()
+	base := ctx.GetSnapshot()
+
+	var wg sync.WaitGroup
+	next := int32(-1)
+	for w := 0; w < level; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !t.canceled() {
+				idx := int(atomic.AddInt32(&next, 1))
+				if idx >= len(specs) {
+					return
+				}
+				t.speculateTx(ctx, base, specs[idx], idx)
+			}
+		}()
+	}
+	wg.Wait()
+
+	conflicts := make(map[string]int)
+	var nConflicts, nReexecs int
+	for idx, s := range specs {
+		if t.canceled() {
+			return ErrTransitionInterrupted
+		}
+		if s.err == nil && !s.world {
+			ids := conflictsOf(ctx, s)
+			if len(ids) == 0 {
+				if err := applyWriteSet(ctx, s.writes); err != nil {
+					return err
+				}
+				rctBuf[idx] = s.rct
+				continue
+			}
+			nConflicts += 1
+			for _, id := range ids {
+				conflicts[id] += 1
+			}
+		}
+		nReexecs += 1
+		rct, err := t.executeTx(ctx, s.txo, idx)
+		if err != nil {
+			return err
+		}
+		rctBuf[idx] = rct
+	}
+	t.onOptimisticExecution(ctx, len(specs), nConflicts, nReexecs, conflicts)
+	return nil
+}
+
+func (t *transition) onOptimisticExecution(ctx contract.Context, txs, conflicts, reexecs int, accounts map[string]int) {
+	ids := make([]string, 0, len(accounts))
+	for id := range accounts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if accounts[ids[i]] != accounts[ids[j]] {
+			return accounts[ids[i]] > accounts[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	var top int
+	if len(ids) > 0 {
+		top = accounts[ids[0]]
+	}
+	if len(ids) > hotAccountCount {
+		ids = ids[:hotAccountCount]
+	}
+	hot := make(map[string]int, len(ids))
+	for _, id := range ids {
+		var addr module.Address
+		if ctx.GetAccountSnapshot([]byte(id)).IsContract() {
+			addr = common.NewContractAddress([]byte(id))
+		} else {
+			addr = common.NewAccountAddress([]byte(id))
+		}
+		hot[addr.String()] = accounts[id]
+	}
+	if reexecs > 0 {
+		t.log.Infof("OptimisticExecution: txs=%d conflicts=%d reexecutions=%d hot=%v",
+			txs, conflicts, reexecs, hot)
+	}
+	metric.NewExecutionMetric(t.chain.MetricContext()).OnOptimisticExecution(
+		txs, conflicts, reexecs, top)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/db"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/transaction"
+	"github.com/icon-project/goloop/service/txresult"
+)
+
+func (p *testPlatform) OnTransactionEnd(wc state.WorldContext, logger log.Logger, rct txresult.Receipt) error {
+	return nil
+}
+
+type testExecutionChain struct {
+	module.Chain
+}
+
+func (c *testExecutionChain) MetricContext() context.Context {
+	return context.Background()
+}
+
+func (c *testExecutionChain) TransactionTimeout() time.Duration {
+	return 5 * time.Second
+}
+
+const testTransferJSON = `{
+	"version": "0x3",
+	"from": "%s",
+	"to": "%s",
+	"value": "%#x",
+	"stepLimit": "0x100000",
+	"timestamp": "%#x",
+	"nid": "0x1",
+	"signature": ""
+}`
+
+func newTestTransfer(t *testing.T, from, to module.Address, value int64, ts int64) module.Transaction {
+	tx, err := transaction.NewTransactionFromJSON([]byte(
+		fmt.Sprintf(testTransferJSON, from, to, value, ts)))
+	assert.NoError(t, err)
+	return tx
+}
+
+func TestTransition_ExecuteTxsOptimistic(t *testing.T) {
+	dbase := db.NewMapDB()
+	logger := log.New()
+	plt := &testPlatform{}
+	cm, err := contract.NewContractManager(dbase, t.TempDir(), logger)
+	assert.NoError(t, err)
+	tr := &transition{
+		transitionContext: &transitionContext{
+			db:    dbase,
+			cm:    cm,
+			chain: &testExecutionChain{},
+			log:   logger,
+			plt:   plt,
+		},
+	}
+
+	addrs := make([]module.Address, 6)
+	ws := state.NewWorldState(dbase, nil, nil, nil, nil)
+	for i := range addrs {
+		addrs[i] = common.MustNewAddressFromString(fmt.Sprintf("hx%040x", i+1))
+		if i < 4 {
+			ws.GetAccountState(addrs[i].ID()).SetBalance(big.NewInt(1000))
+		}
+	}
+	as := ws.GetAccountState(state.SystemID)
+	assert.NoError(t, scoredb.NewVarDB(as, state.VarStepPrice).Set(1))
+	assert.NoError(t, scoredb.NewArrayDB(as, state.VarStepTypes).Put(state.StepTypeDefault))
+	assert.NoError(t, scoredb.NewDictDB(as, state.VarStepCosts, 1).Set(state.StepTypeDefault, 10))
+	base := ws.GetSnapshot()
+
+	// senders share receivers, and receivers spend what they received, so
+	// some of them conflict with preceding ones, and some fail.
+	var txs []module.Transaction
+	ts := time.Now().UnixMicro()
+	for i := 0; i < 24; i++ {
+		from := addrs[i%4]
+		if i%6 == 5 {
+			from = addrs[4]
+		}
+		to := addrs[4+i%2]
+		txs = append(txs, newTestTransfer(t, from, to, int64(100+i), ts+int64(i)))
+	}
+	l := transaction.NewTransactionListFromSlice(dbase, txs)
+
+	execute := func(optimistic bool) ([]txresult.Receipt, []byte) {
+		ws, err := state.WorldStateFromSnapshot(base)
+		assert.NoError(t, err)
+		wc := state.NewWorldContext(ws, common.NewBlockInfo(1, ts), nil, plt)
+		ctx := contract.NewContext(wc, cm, nil, tr.chain, logger, nil, eeproxy.ForTransaction)
+		rcts := make([]txresult.Receipt, len(txs))
+		if optimistic {
+			assert.NoError(t, tr.executeTxsOptimistic(4, l, ctx, rcts))
+		} else {
+			assert.NoError(t, tr.executeTxsSequential(l, ctx, rcts))
+		}
+		return rcts, ctx.GetSnapshot().StateHash()
+	}
+
+	rcts1, hash1 := execute(false)
+	rcts2, hash2 := execute(true)
+	assert.Equal(t, hash1, hash2)
+	failures := 0
+	for i := range rcts1 {
+		assert.Equal(t, rcts1[i].Bytes(), rcts2[i].Bytes(), "receipt %d", i)
+		if rcts1[i].Status() != module.StatusSuccess {
+			failures += 1
+		}
+	}
+	assert.NotZero(t, failures)
+}
@@ -35,57 +35,69 @@ func (t *transition) executeTxsSequential(l module.TransactionList, ctx contract
 			cnt++
 			continue
 		}
-		t.log.Tracef("START TX <0x%x>", txo.ID())
-		ts := time.Now()
-		txInfo := &state.TransactionInfo{
-			Group:     txo.Group(),
-			Index:     int32(cnt),
-			Timestamp: txo.Timestamp(),
-			Nonce:     txo.Nonce(),
-			Hash:      txo.ID(),
-			From:      txo.From(),
+		rct, err := t.executeTx(ctx, txo, cnt)
+		if err != nil {
+			return err
 		}
-		ctx.SetTransactionInfo(txInfo)
-		wcs := ctx.GetSnapshot()
-		traceLogger := ctx.GetTraceLogger(module.EPhaseTransaction)
-		traceLogger.OnTransactionStart(cnt, txo.ID())
+		rctBuf[cnt] = rct
+		cnt++
+	}
+	return nil
+}
 
-		for retry := 0; ; retry++ {
-			txh, err := txo.GetHandler(t.cm)
-			if err != nil {
-				t.log.Errorf("Fail to GetHandler err=%+v", err)
-				return err
-			}
-			ctx.UpdateSystemInfo()
-			rct, err := txh.Execute(ctx, wcs, false)
-			txh.Dispose()
-			if err == nil {
-				if err = t.plt.OnTransactionEnd(ctx, t.log, rct); err == nil {
-					rctBuf[cnt] = rct
-					break
-				}
-			}
-			if !errors.ExecutionFailError.Equals(err) && !errors.CriticalRerunError.Equals(err) {
-				t.log.Warnf("Fail to execute transaction err=%+v", err)
-				return err
-			}
-			if retry >= RetryCount {
-				t.log.Warnf("Fail to execute transaction retry=%d err=%+v", retry, err)
-				return err
-			}
-			t.log.Warnf("RETRY TX <%#x> for err=%+v", txo.ID(), err)
-			if err := ctx.Reset(wcs); err != nil {
-				t.log.Errorf("Fail to revert status on rerun err=%+v", err)
-				return errors.CriticalUnknownError.Wrapf(err, "FailToResetForRetry")
+// executeTx executes the transaction on the context with retries for
+// recoverable failures.
+func (t *transition) executeTx(ctx contract.Context, txo transaction.Transaction, cnt int) (txresult.Receipt, error) {
+	t.log.Tracef("START TX <0x%x>", txo.ID())
+	ts := time.Now()
+	txInfo := &state.TransactionInfo{
+		Group:     txo.Group(),
+		Index:     int32(cnt),
+		Timestamp: txo.Timestamp(),
+		Nonce:     txo.Nonce(),
+		Hash:      txo.ID(),
+		From:      txo.From(),
+	}
+	ctx.SetTransactionInfo(txInfo)
+	wcs := ctx.GetSnapshot()
+	traceLogger := ctx.GetTraceLogger(module.EPhaseTransaction)
+	traceLogger.OnTransactionStart(cnt, txo.ID())
+
+	var receipt txresult.Receipt
+	for retry := 0; ; retry++ {
+		txh, err := txo.GetHandler(t.cm)
+		if err != nil {
+			t.log.Errorf("Fail to GetHandler err=%+v", err)
+			return nil, err
+		}
+		ctx.UpdateSystemInfo()
+		rct, err := txh.Execute(ctx, wcs, false)
+		txh.Dispose()
+		if err == nil {
+			if err = t.plt.OnTransactionEnd(ctx, t.log, rct); err == nil {
+				receipt = rct
+				break
 			}
-			ts = time.Now()
-			traceLogger.OnTransactionReset()
 		}
-
-		traceLogger.OnTransactionEnd(cnt, txo.ID(), txInfo.From, ctx.Treasury(), ctx.Revision(), rctBuf[cnt])
-		duration := time.Since(ts)
-		t.log.Tracef("END   TX <0x%x> duration=%s", txo.ID(), duration)
-		cnt++
+		if !errors.ExecutionFailError.Equals(err) && !errors.CriticalRerunError.Equals(err) {
+			t.log.Warnf("Fail to execute transaction err=%+v", err)
+			return nil, err
+		}
+		if retry >= RetryCount {
+			t.log.Warnf("Fail to execute transaction retry=%d err=%+v", retry, err)
+			return nil, err
+		}
+		t.log.Warnf("RETRY TX <%#x> for err=%+v", txo.ID(), err)
+		if err := ctx.Reset(wcs); err != nil {
+			t.log.Errorf("Fail to revert status on rerun err=%+v", err)
+			return nil, errors.CriticalUnknownError.Wrapf(err, "FailToResetForRetry")
+		}
+		ts = time.Now()
+		traceLogger.OnTransactionReset()
 	}
-	return nil
+
+	traceLogger.OnTransactionEnd(cnt, txo.ID(), txInfo.From, ctx.Treasury(), ctx.Revision(), receipt)
+	duration := time.Since(ts)
+	t.log.Tracef("END   TX <0x%x> duration=%s", txo.ID(), duration)
+	return receipt, nil
 }
@@ -24,9 +24,11 @@ import (
 	"github.com/icon-project/goloop/common/containerdb"
 	"github.com/icon-project/goloop/common/db"
 	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/common/merkle"
 	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/service/scoredb"
 	"github.com/icon-project/goloop/service/state"
+	"github.com/icon-project/goloop/service/txresult"
 )
 
 type transitionResult struct {
@@ -128,6 +130,22 @@ func NewWorldSnapshot(database db.Database, plt base.Platform, result []byte, vl
 	return newWorldSnapshot(database, plt, result, vl)
 }
 
+// ExportResult exports entries of receipts and the world state of the result
+// from src to dst. It fails on a missing entry unless dst comes from the
+// CopyContext having the callback for it.
+func ExportResult(src db.Database, plt base.Platform, result []byte, vh []byte, dst db.Database) error {
+	r, err := newTransitionResultFromBytes(result)
+	if err != nil {
+		return err
+	}
+	e := merkle.PrepareCopyContext(src, dst)
+	txresult.NewReceiptListWithBuilder(e.Builder(), r.NormalReceiptHash)
+	txresult.NewReceiptListWithBuilder(e.Builder(), r.PatchReceiptHash)
+	ess := plt.NewExtensionWithBuilder(e.Builder(), r.ExtensionData)
+	state.NewWorldSnapshotWithBuilder(e.Builder(), r.StateHash, vh, ess, r.BTPData)
+	return e.Run()
+}
+
 func NewBTPContext(dbase db.Database, result []byte) (state.BTPContext, error) {
 	wss, err := NewWorldSnapshot(dbase, nil, result, nil)
 	if err != nil {
@@ -150,3 +168,17 @@ func BTPDigestHashFromResult(result []byte) ([]byte, error) {
 	}
 	return r.BTPData, nil
 }
+
+// NewChangeSet returns changes of the world state from the parent result to
+// the result. Empty parent means the empty world state.
+func NewChangeSet(dbase db.Database, parent, result []byte) (*state.ChangeSet, error) {
+	tr1, err := newTransitionResultFromBytes(parent)
+	if err != nil {
+		return nil, err
+	}
+	tr2, err := newTransitionResultFromBytes(result)
+	if err != nil {
+		return nil, err
+	}
+	return state.NewChangeSet(dbase, tr1.StateHash, tr2.StateHash)
+}
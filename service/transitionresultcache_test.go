@@ -24,6 +24,7 @@ import (
 	"github.com/icon-project/goloop/chain/base"
 	"github.com/icon-project/goloop/common/db"
 	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/service/state"
 )
 
@@ -35,6 +36,10 @@ func (p *testPlatform) NewExtensionSnapshot(dbase db.Database, raw []byte) state
 	return nil
 }
 
+func (p *testPlatform) ToRevision(value int) module.Revision {
+	return module.Revision(value)
+}
+
 func Test_transitionResultCache_GetWorldSnapshot(t *testing.T) {
 	mdb := db.NewMapDB()
 	logger := log.GlobalLogger()
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package txresult
+
+import (
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/module"
+)
+
+// callResult is the result of a call in the batch transaction.
+// Result is the encoded return value of the call.
+type callResult struct {
+	Status   module.Status
+	StepUsed common.HexInt
+	Result   []byte
+}
+
+type callResults []*callResult
+
+func (d *callResults) Add(status module.Status, used *big.Int, result *codec.TypedObj) {
+	r := new(callResult)
+	r.Status = status
+	r.StepUsed.Set(used)
+	if result != nil {
+		r.Result = codec.BC.MustMarshalToBytes(result)
+	}
+	*d = append(*d, r)
+}
+
+func (d callResults) Has() bool {
+	return len(d) > 0
+}
+
+func (d callResults) ToJSON(v module.JSONVersion) (interface{}, error) {
+	jso := make([]interface{}, 0, len(d))
+	for _, r := range d {
+		item := map[string]interface{}{
+			"stepUsed": &r.StepUsed,
+		}
+		if r.Status == module.StatusSuccess {
+			item["status"] = "0x1"
+			if r.Result != nil {
+				obj := new(codec.TypedObj)
+				if _, err := codec.BC.UnmarshalFromBytes(r.Result, obj); err != nil {
+					return nil, err
+				}
+				value, err := common.DecodeAnyForJSON(obj)
+				if err != nil {
+					return nil, err
+				}
+				item["result"] = value
+			}
+		} else {
+			item["status"] = "0x0"
+			item["failure"] = failureReasonByCode(r.Status)
+		}
+		jso = append(jso, item)
+	}
+	return jso, nil
+}
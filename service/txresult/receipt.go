@@ -172,6 +172,7 @@ const (
 const (
 	ExtensionFeeDetail = 1 << iota
 	ExtensionDisableLogsBloom
+	ExtensionCallResults
 )
 
 type receiptData struct {
@@ -185,6 +186,7 @@ type receiptData struct {
 	SCOREAddress       *common.Address
 	FeeDetail          feeDetail
 	DisableLogsBloom   bool
+	CallResults        callResults
 }
 
 func (r *receiptData) Equal(r2 *receiptData) bool {
@@ -196,7 +198,8 @@ func (r *receiptData) Equal(r2 *receiptData) bool {
 		r.LogsBloom.Equal(&r2.LogsBloom) &&
 		r.SCOREAddress.Equal(r2.SCOREAddress) &&
 		r.DisableLogsBloom == r2.DisableLogsBloom &&
-		reflect.DeepEqual(r.FeeDetail, r2.FeeDetail)
+		reflect.DeepEqual(r.FeeDetail, r2.FeeDetail) &&
+		reflect.DeepEqual(r.CallResults, r2.CallResults)
 }
 
 func (r *receiptData) Extension() int {
@@ -207,6 +210,9 @@ func (r *receiptData) Extension() int {
 	if r.DisableLogsBloom {
 		extension |= ExtensionDisableLogsBloom
 	}
+	if r.CallResults.Has() {
+		extension |= ExtensionCallResults
+	}
 	return extension
 }
 
@@ -318,6 +324,11 @@ func (r *receipt) RLPEncodeSelf(e codec.Encoder) error {
 				return err
 			}
 		}
+		if (extension & ExtensionCallResults) != 0 {
+			if err = e2.Encode(&r.data.CallResults); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 }
@@ -353,6 +364,11 @@ func (r *receipt) RLPDecodeSelf(d codec.Decoder) error {
 					return err
 				}
 			}
+			if (extension & ExtensionCallResults) != 0 {
+				if err := d2.Decode(&r.data.CallResults); err != nil {
+					return err
+				}
+			}
 		} else {
 			return codec.ErrInvalidFormat
 		}
@@ -431,6 +447,14 @@ func (r *receipt) AddPayment(addr module.Address, steps *big.Int, feeSteps *big.
 	}
 }
 
+// AddCallResult adds the result of a call in the batch transaction.
+func (r *receipt) AddCallResult(status module.Status, used *big.Int, result *codec.TypedObj) {
+	r.data.CallResults.Add(status, used, result)
+	if r.version < Version3 {
+		r.version = Version3
+	}
+}
+
 func (r *receipt) DisableLogsBloom() {
 	r.data.DisableLogsBloom = true
 	r.data.LogsBloom.SetBytes(nil)
@@ -512,6 +536,8 @@ type Receipt interface {
 	// ( steps - feeSteps ) is virtual steps paid by the payer.
 	// feeSteps can be nil if there is no steps for fee
 	AddPayment(addr module.Address, steps *big.Int, feeSteps *big.Int)
+	// AddCallResult adds the result of a call in the batch transaction.
+	AddCallResult(status module.Status, used *big.Int, result *codec.TypedObj)
 	// FeeByEOA returns a fee paid by EOA (not including deposit).
 	FeeByEOA() *big.Int
 	// Fee returns total fee (excluding virtual steps).
@@ -567,6 +593,14 @@ func (r *receipt) ToJSON(version module.JSONVersion) (interface{}, error) {
 		jso["stepUsedDetails"] = details
 	}
 
+	if r.data.CallResults.Has() {
+		results, err := r.data.CallResults.ToJSON(version)
+		if err != nil {
+			return nil, err
+		}
+		jso["callResults"] = results
+	}
+
 	if r.data.Status == module.StatusSuccess {
 		jso["status"] = "0x1"
 		if r.data.SCOREAddress != nil {
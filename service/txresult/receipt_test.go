@@ -136,6 +136,37 @@ func TestReceipt_DisableLogBloom(t *testing.T) {
 	}
 }
 
+func TestReceipt_CallResults(t *testing.T) {
+	dbase := db.NewMapDB()
+	to := common.MustNewAddressFromString("cx0000000000000000000000000000000000000000")
+	for _, rev := range []module.Revision{module.NoRevision, module.LatestRevision} {
+		t.Run(fmt.Sprint("Rev", rev), func(t *testing.T) {
+			rct := NewReceipt(dbase, rev, to)
+			rct.AddCallResult(module.StatusSuccess, big.NewInt(100), common.MustEncodeAny("0x10"))
+			rct.AddCallResult(module.StatusSuccess, big.NewInt(200), nil)
+			rct.AddCallResult(module.StatusInvalidParameter, big.NewInt(300), nil)
+			rct.SetResult(module.StatusInvalidParameter, big.NewInt(600), new(big.Int), nil)
+			assert.NoError(t, rct.Flush())
+
+			jso, err := rct.ToJSON(module.JSONVersionLast)
+			assert.NoError(t, err)
+			jb, err := json.Marshal(jso.(map[string]interface{})["callResults"])
+			assert.NoError(t, err)
+			assert.JSONEq(t, `[
+				{"status":"0x1","stepUsed":"0x64","result":"0x10"},
+				{"status":"0x1","stepUsed":"0xc8"},
+				{"status":"0x0","stepUsed":"0x12c","failure":{"code":"0x6","message":"InvalidParameter"}}
+			]`, string(jb))
+
+			bs := codec.BC.MustMarshalToBytes(rct)
+			rct2 := new(receipt)
+			assert.NoError(t, rct2.Reset(dbase, bs))
+			assert.NoError(t, rct.Check(rct2))
+			assert.EqualValues(t, rct.(*receipt).data.CallResults, rct2.data.CallResults)
+		})
+	}
+}
+
 func TestReceipt_Fee(t *testing.T) {
 	database := db.NewMapDB()
 	eoa1 := common.MustNewAddressFromString("hx9834234")
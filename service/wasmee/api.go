@@ -0,0 +1,246 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasmee
+
+import (
+	"encoding/json"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/wasm"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+const (
+	// APISection is the name of the custom section having the API of the
+	// contract in the format of icx_getScoreApi.
+	APISection = "icon.api"
+
+	InstallMethod = "on_install"
+	UpdateMethod  = "on_update"
+
+	// FallbackExport is the name of the exported function for the fallback.
+	FallbackExport = "fallback"
+)
+
+type apiParameter struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`
+	Default json.RawMessage `json:"default"`
+	Indexed string          `json:"indexed"`
+}
+
+type apiOutput struct {
+	Type string `json:"type"`
+}
+
+type apiMethod struct {
+	Type     string         `json:"type"`
+	Name     string         `json:"name"`
+	Inputs   []apiParameter `json:"inputs"`
+	Outputs  []apiOutput    `json:"outputs"`
+	ReadOnly string         `json:"readonly"`
+	Payable  string         `json:"payable"`
+	Isolated string         `json:"isolated"`
+}
+
+func exportOf(name string) string {
+	if name == scoreapi.FallbackMethodName {
+		return FallbackExport
+	}
+	return name
+}
+
+func isInternal(name string) bool {
+	return name == InstallMethod || name == UpdateMethod
+}
+
+func (am *apiMethod) toMethod(m *wasm.Module) (*scoreapi.Method, error) {
+	method := &scoreapi.Method{Name: am.Name}
+	switch am.Type {
+	case "function":
+		method.Type = scoreapi.Function
+		if !isInternal(am.Name) {
+			method.Flags |= scoreapi.FlagExternal
+		}
+	case "fallback":
+		method.Type = scoreapi.Fallback
+		method.Name = scoreapi.FallbackMethodName
+	case "eventlog":
+		method.Type = scoreapi.Event
+	default:
+		return nil, scoreresult.IllegalFormatError.Errorf(
+			"InvalidMethodType(name=%s,type=%s)", am.Name, am.Type)
+	}
+	if am.ReadOnly == "0x1" {
+		method.Flags |= scoreapi.FlagReadOnly
+	}
+	if am.Payable == "0x1" {
+		method.Flags |= scoreapi.FlagPayable
+	}
+	if am.Isolated == "0x1" {
+		method.Flags |= scoreapi.FlagIsolated
+	}
+
+	optional := false
+	for _, input := range am.Inputs {
+		t := scoreapi.DataTypeOf(input.Type)
+		p := scoreapi.Parameter{Name: input.Name, Type: t}
+		if method.IsEvent() {
+			if !t.UsableForEvent() {
+				return nil, scoreresult.IllegalFormatError.Errorf(
+					"InvalidEventType(name=%s,type=%s)", am.Name, input.Type)
+			}
+			if input.Indexed == "0x1" {
+				method.Indexed += 1
+			}
+		} else {
+			if !t.UsableForInput() {
+				return nil, scoreresult.IllegalFormatError.Errorf(
+					"InvalidInputType(name=%s,type=%s)", am.Name, input.Type)
+			}
+			if input.Default != nil {
+				optional = true
+				if bs, err := bytesOf(t, input.Default); err != nil {
+					return nil, scoreresult.IllegalFormatError.Wrapf(err,
+						"InvalidDefault(name=%s,input=%s)", am.Name, input.Name)
+				} else {
+					p.Default = bs
+				}
+			} else if optional {
+				return nil, scoreresult.IllegalFormatError.Errorf(
+					"MissingDefault(name=%s,input=%s)", am.Name, input.Name)
+			} else {
+				method.Indexed += 1
+			}
+		}
+		method.Inputs = append(method.Inputs, p)
+	}
+	for _, output := range am.Outputs {
+		t := scoreapi.DataTypeOf(output.Type)
+		if t == scoreapi.Unknown {
+			return nil, scoreresult.IllegalFormatError.Errorf(
+				"InvalidOutputType(name=%s,type=%s)", am.Name, output.Type)
+		}
+		method.Outputs = append(method.Outputs, t)
+	}
+	if len(method.Outputs) > 1 {
+		return nil, scoreresult.IllegalFormatError.Errorf(
+			"TooManyOutputs(name=%s)", am.Name)
+	}
+
+	if !method.IsEvent() {
+		name := exportOf(method.Name)
+		_, ft, ok := m.ExportedFunc(name)
+		if !ok {
+			if !isInternal(name) || len(method.Inputs) > 0 {
+				return nil, scoreresult.IllegalFormatError.Errorf(
+					"NoExportedFunction(name=%s)", name)
+			}
+		} else if len(ft.Params) != 0 || len(ft.Results) != 0 {
+			return nil, scoreresult.IllegalFormatError.Errorf(
+				"InvalidFunctionType(name=%s)", name)
+		}
+	}
+	return method, nil
+}
+
+// apiOf returns API of the module. Methods for install and update are added
+// if the module doesn't declare them.
+func apiOf(m *wasm.Module) (*scoreapi.Info, error) {
+	bs, ok := m.Customs[APISection]
+	if !ok {
+		return nil, scoreresult.IllegalFormatError.Errorf("NoAPISection(name=%s)", APISection)
+	}
+	var ams []apiMethod
+	if err := json.Unmarshal(bs, &ams); err != nil {
+		return nil, scoreresult.IllegalFormatError.Wrap(err, "InvalidAPI")
+	}
+	methods := make([]*scoreapi.Method, 0, len(ams)+2)
+	names := make(map[string]bool)
+	for i := range ams {
+		method, err := ams[i].toMethod(m)
+		if err != nil {
+			return nil, err
+		}
+		if !method.IsEvent() {
+			if names[method.Name] {
+				return nil, scoreresult.IllegalFormatError.Errorf(
+					"DuplicateMethod(name=%s)", method.Name)
+			}
+			names[method.Name] = true
+		}
+		methods = append(methods, method)
+	}
+	for _, name := range []string{InstallMethod, UpdateMethod} {
+		if !names[name] {
+			methods = append(methods, &scoreapi.Method{
+				Type: scoreapi.Function,
+				Name: name,
+			})
+		}
+	}
+	return scoreapi.NewInfo(methods), nil
+}
+
+// bytesOf returns bytes of the value in the form used by event logs and
+// default values.
+func bytesOf(t scoreapi.DataType, jso json.RawMessage) ([]byte, error) {
+	obj, err := t.ConvertJSONToTypedObj(jso, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	value, err := common.DecodeAny(obj)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case *common.HexInt:
+		return v.Bytes(), nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case bool:
+		if v {
+			return codec.TrueBytes, nil
+		}
+		return codec.FalseBytes, nil
+	case *common.Address:
+		return v.Bytes(), nil
+	default:
+		return nil, scoreresult.InvalidParameterError.Errorf(
+			"UnsupportedType(type=%s)", t)
+	}
+}
+
+// typedObjOf converts JSON value into typed object for the type.
+func typedObjOf(t scoreapi.DataType, jso json.RawMessage) (*codec.TypedObj, error) {
+	switch t.Tag() {
+	case scoreapi.TList, scoreapi.TDict:
+		var value interface{}
+		if err := json.Unmarshal(jso, &value); err != nil {
+			return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidJSON")
+		}
+		return common.EncodeAny(value)
+	default:
+		return t.ConvertJSONToTypedObj(jso, nil, true)
+	}
+}
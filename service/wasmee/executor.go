@@ -0,0 +1,179 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wasmee implements the execution environment for WebAssembly
+// contracts. It runs in the process of the node, but it talks to eeproxy
+// through the IPC connection like other execution environments, so the
+// service handles them in the same way.
+//
+// A contract is a module using the integer subset supported by common/wasm.
+// It exports the memory and a function without parameters and results for
+// each method declared in the custom section "icon.api", which has the API
+// in the format of icx_getScoreApi. The fallback is exported as "fallback".
+//
+// Host functions are imported from the module "env". Functions returning
+// data keep it in the return buffer and return its length (or -1 if there
+// is no data), then the contract reads it with read_return.
+//
+//	input_size() -> i32                 length of parameters in JSON array
+//	read_input(ptr)                     copy parameters to the memory
+//	set_return(ptr, len)                set the result in JSON
+//	read_return(ptr)                    copy the return buffer
+//	get_value(key, klen) -> i32         read the storage
+//	set_value(key, klen, value, vlen)   write the storage
+//	delete_value(key, klen)             delete from the storage
+//	emit_event(ptr, len)                {"indexed":[sig,...],"data":[...]}
+//	call(ptr, len) -> i32               {"to","value","method","params":[{"type","value"}]}
+//	                                    returns the status and the result or the message
+//	get_balance(addr, len) -> i32       balance of the address in hex string
+//	get_info() -> i32                   information of the block and the transaction
+//	revert(code, ptr, len)              revert with the user code and the message
+//	log(ptr, len)                       debug log
+//	set_fee_proportion(proportion)      proportion of the fee paid by the contract
+//	sha3_256(ptr, len) -> i32           hash of the data
+package wasmee
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/common/wasm"
+	"github.com/icon-project/goloop/service/eeipc"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+const (
+	// EEType is the type of the execution environment used in the version
+	// message. It's same as the EEType of the contract.
+	EEType = "wasm"
+
+	// CodeFile is the name of the file in the contract directory.
+	CodeFile = "code.wasm"
+
+	maxCachedModules = 64
+)
+
+type contract struct {
+	module *wasm.Module
+	api    *scoreapi.Info
+}
+
+// runtime runs contracts in WebAssembly for the executor. It keeps decoded
+// modules for the code.
+type runtime struct {
+	lock      sync.Mutex
+	contracts map[string]*contract
+}
+
+// Connect connects to eeproxy, and sends the version message with the uid.
+func Connect(net, addr, uid string, l log.Logger) (*eeipc.Executor, error) {
+	rt := &runtime{
+		contracts: make(map[string]*contract),
+	}
+	return eeipc.Connect(net, addr, uid, EEType, rt, l)
+}
+
+func (r *runtime) load(path string) (*contract, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if c, ok := r.contracts[path]; ok {
+		return c, nil
+	}
+	bs, err := os.ReadFile(filepath.Join(path, CodeFile))
+	if err != nil {
+		return nil, errors.CriticalIOError.Wrapf(err, "FailToReadCode(path=%s)", path)
+	}
+	m, err := wasm.Decode(bs)
+	if err != nil {
+		return nil, scoreresult.IllegalFormatError.Wrap(err, "InvalidModule")
+	}
+	api, err := apiOf(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.contracts) >= maxCachedModules {
+		r.contracts = make(map[string]*contract)
+	}
+	c := &contract{module: m, api: api}
+	r.contracts[path] = c
+	return c, nil
+}
+
+func (r *runtime) GetAPI(code string) (*scoreapi.Info, error) {
+	c, err := r.load(code)
+	if err != nil {
+		return nil, err
+	}
+	return c.api, nil
+}
+
+// Invoke runs the method. Running out of steps in the module is reported
+// as OutOfStep.
+func (r *runtime) Invoke(e *eeipc.Executor, m *eeipc.InvokeMessage) (*codec.TypedObj, int64, error) {
+	result, steps, err := r.execute(e, m)
+	if errors.Is(err, wasm.ErrOutOfSteps) {
+		err = scoreresult.OutOfStepError.Wrap(err, "OutOfSteps")
+	}
+	return result, steps, err
+}
+
+func (r *runtime) execute(e *eeipc.Executor, m *eeipc.InvokeMessage) (*codec.TypedObj, int64, error) {
+	c, err := r.load(m.Code)
+	if err != nil {
+		return nil, 0, err
+	}
+	method := c.api.GetMethod(m.Method)
+	if method == nil || !method.IsCallable() {
+		return nil, 0, scoreresult.MethodNotFoundError.Errorf("MethodNotFound(name=%s)", m.Method)
+	}
+	name := exportOf(m.Method)
+	if _, _, ok := c.module.ExportedFunc(name); !ok {
+		// install and update methods are optional
+		if isInternal(name) {
+			return codec.Nil, 0, nil
+		}
+		return nil, 0, scoreresult.MethodNotFoundError.Errorf("NoExportedFunction(name=%s)", name)
+	}
+
+	ctx, err := newContext(e, m)
+	if err != nil {
+		return nil, 0, err
+	}
+	in, err := wasm.NewInstance(c.module, ctx.imports(), ctx.limit)
+	if err != nil {
+		if errors.Is(err, wasm.ErrOutOfSteps) {
+			return nil, ctx.limit, err
+		}
+		return nil, 0, err
+	}
+	if _, err := in.Call(name); err != nil {
+		return nil, in.StepsUsed(), err
+	}
+	if len(method.Outputs) == 0 || ctx.output == nil {
+		return codec.Nil, in.StepsUsed(), nil
+	}
+	result, err := typedObjOf(method.Outputs[0], ctx.output)
+	if err != nil {
+		return nil, in.StepsUsed(), scoreresult.UnknownFailureError.Wrap(err, "InvalidResult")
+	}
+	return result, in.StepsUsed(), nil
+}
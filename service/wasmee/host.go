@@ -0,0 +1,498 @@
+/*
+ * Copyright 2024 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasmee
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/common/wasm"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeipc"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+)
+
+// HostModule is the name of the module for imported host functions.
+const HostModule = "env"
+
+const (
+	dataTypeCall = "call"
+	noValue      = uint64(^uint32(0))
+)
+
+const (
+	infoStepCosts = "StepCosts"
+)
+
+// stepCost has step costs given by the invoke message. Formulas are same as
+// the ones used by javaee.
+type stepCost map[string]int64
+
+func stepCostOf(info map[string]interface{}) stepCost {
+	sc := make(stepCost)
+	if costs, ok := info[infoStepCosts].(map[string]interface{}); ok {
+		for k, v := range costs {
+			if i, ok := v.(*common.HexInt); ok && i.IsInt64() {
+				sc[k] = i.Int64()
+			}
+		}
+	}
+	return sc
+}
+
+func (sc stepCost) replaceBase() int64 {
+	return (sc["setBase"] + sc["deleteBase"]) / 2
+}
+
+func (sc stepCost) getStorage(size int) int64 {
+	return sc["getBase"] + int64(size)*sc["get"]
+}
+
+func (sc stepCost) setStorageSet(size int) int64 {
+	return sc["setBase"] + int64(size)*sc["set"]
+}
+
+func (sc stepCost) setStorageDelete(size int) int64 {
+	return sc["deleteBase"] + int64(size)*sc["delete"]
+}
+
+func (sc stepCost) eventLog(size int) int64 {
+	return sc["logBase"] + int64(size)*sc["log"]
+}
+
+// context has the state of an invocation.
+type context struct {
+	executor *eeipc.Executor
+	log      log.Logger
+
+	readOnly bool
+	trace    bool
+	limit    int64
+	info     *codec.TypedObj
+	costs    stepCost
+
+	input  []byte
+	output []byte
+	ret    []byte
+}
+
+func newContext(e *eeipc.Executor, m *eeipc.InvokeMessage) (*context, error) {
+	ctx := &context{
+		executor: e,
+		log:      e.Log(),
+		readOnly: (m.Flag & eeipc.InvokeFlagReadOnly) != 0,
+		trace:    (m.Flag & eeipc.InvokeFlagTrace) != 0,
+		info:     m.Info,
+	}
+	if m.Limit.IsInt64() {
+		ctx.limit = m.Limit.Int64()
+	} else {
+		ctx.limit = int64(^uint64(0) >> 1)
+	}
+	if info, err := common.DecodeAny(m.Info); err != nil {
+		return nil, err
+	} else if mi, ok := info.(map[string]interface{}); ok {
+		ctx.costs = stepCostOf(mi)
+	} else {
+		ctx.costs = make(stepCost)
+	}
+	if m.Params != nil {
+		params, err := common.DecodeAnyForJSON(m.Params)
+		if err != nil {
+			return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidParams")
+		}
+		if ctx.input, err = json.Marshal(params); err != nil {
+			return nil, scoreresult.InvalidParameterError.Wrap(err, "InvalidParams")
+		}
+	} else {
+		ctx.input = []byte("[]")
+	}
+	return ctx, nil
+}
+
+func hostFunc(params, results []wasm.ValueType, f func(in *wasm.Instance, args []uint64) ([]uint64, error)) *wasm.HostFunc {
+	return &wasm.HostFunc{
+		Type: wasm.FuncType{Params: params, Results: results},
+		Func: f,
+	}
+}
+
+func (ctx *context) imports() wasm.Imports {
+	i32 := wasm.I32
+	return wasm.Imports{
+		HostModule: {
+			"input_size":         hostFunc(nil, []wasm.ValueType{i32}, ctx.inputSize),
+			"read_input":         hostFunc([]wasm.ValueType{i32}, nil, ctx.readInput),
+			"set_return":         hostFunc([]wasm.ValueType{i32, i32}, nil, ctx.setReturn),
+			"read_return":        hostFunc([]wasm.ValueType{i32}, nil, ctx.readReturn),
+			"get_value":          hostFunc([]wasm.ValueType{i32, i32}, []wasm.ValueType{i32}, ctx.getValue),
+			"set_value":          hostFunc([]wasm.ValueType{i32, i32, i32, i32}, nil, ctx.setValue),
+			"delete_value":       hostFunc([]wasm.ValueType{i32, i32}, nil, ctx.deleteValue),
+			"emit_event":         hostFunc([]wasm.ValueType{i32, i32}, nil, ctx.emitEvent),
+			"call":               hostFunc([]wasm.ValueType{i32, i32}, []wasm.ValueType{i32}, ctx.call),
+			"get_balance":        hostFunc([]wasm.ValueType{i32, i32}, []wasm.ValueType{i32}, ctx.getBalance),
+			"get_info":           hostFunc(nil, []wasm.ValueType{i32}, ctx.getInfo),
+			"revert":             hostFunc([]wasm.ValueType{i32, i32, i32}, nil, ctx.revert),
+			"log":                hostFunc([]wasm.ValueType{i32, i32}, nil, ctx.logMessage),
+			"set_fee_proportion": hostFunc([]wasm.ValueType{i32}, nil, ctx.setFeeProportion),
+			"sha3_256":           hostFunc([]wasm.ValueType{i32, i32}, []wasm.ValueType{i32}, ctx.sha3256),
+		},
+	}
+}
+
+func (ctx *context) returnData(bs []byte) []uint64 {
+	ctx.ret = bs
+	if bs == nil {
+		return []uint64{noValue}
+	}
+	return []uint64{uint64(len(bs))}
+}
+
+func (ctx *context) inputSize(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(len(ctx.input))}, nil
+}
+
+func (ctx *context) readInput(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, in.Write(uint32(args[0]), ctx.input)
+}
+
+func (ctx *context) setReturn(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	bs, err := in.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	ctx.output = bs
+	return nil, nil
+}
+
+func (ctx *context) readReturn(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	return nil, in.Write(uint32(args[0]), ctx.ret)
+}
+
+func (ctx *context) getValue(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	key, err := in.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	var m eeipc.GetValueMessage
+	if err := ctx.executor.SendAndReceive(eeipc.MsgGETVALUE, key, &m); err != nil {
+		return nil, err
+	}
+	if err := in.UseSteps(ctx.costs.getStorage(len(m.Value))); err != nil {
+		return nil, err
+	}
+	if !m.Success {
+		return ctx.returnData(nil), nil
+	}
+	if m.Value == nil {
+		m.Value = []byte{}
+	}
+	return ctx.returnData(m.Value), nil
+}
+
+func (ctx *context) checkWritable(name string) error {
+	if ctx.readOnly {
+		return scoreresult.AccessDeniedError.Errorf("%sInReadOnly", name)
+	}
+	return nil
+}
+
+func (ctx *context) setValue(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := ctx.checkWritable("SetValue"); err != nil {
+		return nil, err
+	}
+	key, err := in.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	value, err := in.Read(uint32(args[2]), uint32(args[3]))
+	if err != nil {
+		return nil, err
+	}
+	if err := in.UseSteps(ctx.costs.setStorageSet(len(value))); err != nil {
+		return nil, err
+	}
+	var old eeipc.OldValueMessage
+	m := &eeipc.SetValueMessage{Key: key, Flag: eeipc.FlagOLDVALUE, Value: value}
+	if err := ctx.executor.SendAndReceive(eeipc.MsgSETVALUE, m, &old); err != nil {
+		return nil, err
+	}
+	if old.HasOld {
+		steps := -ctx.costs["setBase"] + ctx.costs.replaceBase() +
+			int64(old.OldSize)*ctx.costs["delete"]
+		if err := in.UseSteps(steps); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (ctx *context) deleteValue(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := ctx.checkWritable("DeleteValue"); err != nil {
+		return nil, err
+	}
+	key, err := in.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	rb := ctx.costs.replaceBase()
+	if err := in.UseSteps(rb); err != nil {
+		return nil, err
+	}
+	var old eeipc.OldValueMessage
+	m := &eeipc.SetValueMessage{Key: key, Flag: eeipc.FlagDELETE | eeipc.FlagOLDVALUE}
+	if err := ctx.executor.SendAndReceive(eeipc.MsgSETVALUE, m, &old); err != nil {
+		return nil, err
+	}
+	if old.HasOld && old.OldSize > 0 {
+		if err := in.UseSteps(ctx.costs.setStorageDelete(old.OldSize) - rb); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+type eventJSON struct {
+	Indexed []json.RawMessage `json:"indexed"`
+	Data    []json.RawMessage `json:"data"`
+}
+
+// parseSignature returns types of parameters in the event signature.
+func parseSignature(sig string) ([]scoreapi.DataType, bool) {
+	open := strings.IndexByte(sig, '(')
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return nil, false
+	}
+	params := sig[open+1 : len(sig)-1]
+	if len(params) == 0 {
+		return nil, true
+	}
+	var types []scoreapi.DataType
+	for _, name := range strings.Split(params, ",") {
+		t := scoreapi.DataTypeOf(name)
+		if !t.UsableForEvent() {
+			return nil, false
+		}
+		types = append(types, t)
+	}
+	return types, true
+}
+
+func (ctx *context) emitEvent(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := ctx.checkWritable("EmitEvent"); err != nil {
+		return nil, err
+	}
+	bs, err := in.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	var ev eventJSON
+	var sig string
+	if err := json.Unmarshal(bs, &ev); err != nil || len(ev.Indexed) == 0 {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidEvent(json=%q)", bs)
+	}
+	if err := json.Unmarshal(ev.Indexed[0], &sig); err != nil {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidSignature(json=%q)", ev.Indexed[0])
+	}
+	types, ok := parseSignature(sig)
+	if !ok || len(types) != len(ev.Indexed)-1+len(ev.Data) {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidSignature(sig=%s)", sig)
+	}
+	var m eeipc.EventMessage
+	m.Indexed = [][]byte{[]byte(sig)}
+	size := len(sig)
+	for i, v := range append(ev.Indexed[1:], ev.Data...) {
+		b, err := bytesOf(types[i], v)
+		if err != nil {
+			return nil, err
+		}
+		if i < len(ev.Indexed)-1 {
+			m.Indexed = append(m.Indexed, b)
+		} else {
+			m.Data = append(m.Data, b)
+		}
+		size += len(b)
+	}
+	if err := in.UseSteps(ctx.costs.eventLog(size)); err != nil {
+		return nil, err
+	}
+	return nil, ctx.executor.Send(eeipc.MsgEVENT, &m)
+}
+
+type paramJSON struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type callJSON struct {
+	To     common.Address `json:"to"`
+	Value  common.HexInt  `json:"value"`
+	Method string         `json:"method"`
+	Params []paramJSON    `json:"params"`
+}
+
+// statusOfCode returns the status for the code in the result message.
+func statusOfCode(code errors.Code) module.Status {
+	if s, ok := scoreresult.StatusOf(code.New("")); ok {
+		return s
+	}
+	return module.StatusUnknownFailure
+}
+
+func (ctx *context) call(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	bs, err := in.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	var c callJSON
+	if err := json.Unmarshal(bs, &c); err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrapf(err, "InvalidCall(json=%q)", bs)
+	}
+	if c.Value.Sign() < 0 {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidValue(value=%s)", &c.Value)
+	}
+	if c.Value.Sign() > 0 {
+		if err := ctx.checkWritable("Transfer"); err != nil {
+			return nil, err
+		}
+	}
+	params := make([]*codec.TypedObj, len(c.Params))
+	for i, p := range c.Params {
+		t := scoreapi.DataTypeOf(p.Type)
+		if t == scoreapi.Unknown {
+			return nil, scoreresult.InvalidParameterError.Errorf("InvalidParamType(type=%s)", p.Type)
+		}
+		if params[i], err = typedObjOf(t, p.Value); err != nil {
+			return nil, err
+		}
+	}
+	m := &eeipc.CallMessage{
+		To:       c.To,
+		Value:    c.Value,
+		DataType: dataTypeCall,
+		Data: common.MustEncodeAny(map[string]*codec.TypedObj{
+			"method": common.MustEncodeAny(c.Method),
+			"params": common.MustEncodeAny(params),
+		}),
+	}
+	m.Limit.SetInt64(ctx.limit - in.StepsUsed())
+	r, err := ctx.executor.Call(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.UseSteps(r.StepUsed.Int64()); err != nil {
+		return nil, err
+	}
+	code := r.Status & eeipc.CodeMask
+	if code != errors.Success {
+		ctx.ret = []byte(common.DecodeAsString(r.Result, ""))
+		return []uint64{uint64(statusOfCode(code))}, nil
+	}
+	jso, err := common.DecodeAnyForJSON(r.Result)
+	if err != nil {
+		return nil, err
+	}
+	result, err := json.Marshal(jso)
+	if err != nil {
+		return nil, err
+	}
+	ctx.ret = result
+	return []uint64{0}, nil
+}
+
+func (ctx *context) getBalance(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	bs, err := in.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	var addr common.Address
+	if err := addr.SetStringStrict(string(bs)); err != nil {
+		return nil, scoreresult.InvalidParameterError.Wrapf(err, "InvalidAddress(addr=%q)", bs)
+	}
+	var balance common.HexInt
+	if err := ctx.executor.SendAndReceive(eeipc.MsgGETBALANCE, &addr, &balance); err != nil {
+		return nil, err
+	}
+	return ctx.returnData([]byte(balance.String())), nil
+}
+
+func (ctx *context) getInfo(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	jso, err := common.DecodeAnyForJSON(ctx.info)
+	if err != nil {
+		return nil, err
+	}
+	if mi, ok := jso.(map[string]interface{}); ok {
+		delete(mi, infoStepCosts)
+	}
+	bs, err := json.Marshal(jso)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.returnData(bs), nil
+}
+
+func (ctx *context) revert(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	code := module.Status(int32(args[0]))
+	if code < 0 || code > module.StatusLimit-module.StatusReverted {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidRevertCode(code=%d)", code)
+	}
+	msg, err := in.Read(uint32(args[1]), uint32(args[2]))
+	if err != nil {
+		return nil, err
+	}
+	return nil, scoreresult.New(module.StatusReverted+code, string(msg))
+}
+
+func (ctx *context) logMessage(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	msg, err := in.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	m := &eeipc.LogMessage{Level: log.DebugLevel, Message: string(msg)}
+	if ctx.trace {
+		m.Flag |= eeipc.LogFlagTrace
+	}
+	return nil, ctx.executor.Send(eeipc.MsgLOG, m)
+}
+
+func (ctx *context) setFeeProportion(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	if err := ctx.checkWritable("SetFeeProportion"); err != nil {
+		return nil, err
+	}
+	proportion := int(int32(args[0]))
+	if proportion < 0 || proportion > 100 {
+		return nil, scoreresult.InvalidParameterError.Errorf("InvalidProportion(%d)", proportion)
+	}
+	return nil, ctx.executor.Send(eeipc.MsgSETFEEPCT, proportion)
+}
+
+func (ctx *context) sha3256(in *wasm.Instance, args []uint64) ([]uint64, error) {
+	bs, err := in.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	if err := in.UseSteps(ctx.costs["apiCall"]); err != nil {
+		return nil, err
+	}
+	return ctx.returnData(crypto.SHA3Sum256(bs)), nil
+}
@@ -0,0 +1,299 @@
+package wasmee_test
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
+	"github.com/icon-project/goloop/service/scoreapi"
+	"github.com/icon-project/goloop/service/scoreresult"
+	"github.com/icon-project/goloop/service/wasmee"
+)
+
+func uleb(v int) []byte {
+	var bs []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(bs, b)
+		}
+		bs = append(bs, b|0x80)
+	}
+}
+
+func cat(items ...[]byte) []byte {
+	var bs []byte
+	for _, item := range items {
+		bs = append(bs, item...)
+	}
+	return bs
+}
+
+func vec(items ...[]byte) []byte {
+	return cat(uleb(len(items)), cat(items...))
+}
+
+func str(s string) []byte {
+	return cat(uleb(len(s)), []byte(s))
+}
+
+func section(id byte, content []byte) []byte {
+	return cat([]byte{id}, uleb(len(content)), content)
+}
+
+func funcType(params, results int) []byte {
+	i32 := []byte{0x7f}
+	ps := make([][]byte, params)
+	for i := range ps {
+		ps[i] = i32
+	}
+	rs := make([][]byte, results)
+	for i := range rs {
+		rs[i] = i32
+	}
+	return cat([]byte{0x60}, vec(ps...), vec(rs...))
+}
+
+func importFunc(name string, typ int) []byte {
+	return cat(str(wasmee.HostModule), str(name), []byte{0x00}, uleb(typ))
+}
+
+func body(code ...[]byte) []byte {
+	content := cat(vec(cat(uleb(1), []byte{0x7f})), cat(code...), []byte{0x0b})
+	return cat(uleb(len(content)), content)
+}
+
+func i32(v int) []byte {
+	var bs []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return cat([]byte{0x41}, bs, []byte{b})
+		}
+		bs = append(bs, b|0x80)
+	}
+}
+
+func call(idx int) []byte {
+	return cat([]byte{0x10}, uleb(idx))
+}
+
+var (
+	localGet = []byte{0x20, 0x00}
+	localSet = []byte{0x21, 0x00}
+)
+
+const testAPI = `[
+	{"type":"function","name":"set","inputs":[{"name":"v","type":"str"}],"outputs":[]},
+	{"type":"function","name":"get","inputs":[],"outputs":[{"type":"list"}],"readonly":"0x1"},
+	{"type":"function","name":"fail","inputs":[],"outputs":[]}
+]`
+
+// testContract stores parameters of set, returns them for get, and
+// fail reverts with code 3.
+func testContract() []byte {
+	types := section(1, vec(
+		funcType(0, 1), // 0: input_size
+		funcType(1, 0), // 1: read_input, read_return
+		funcType(2, 0), // 2: set_return
+		funcType(2, 1), // 3: get_value
+		funcType(4, 0), // 4: set_value
+		funcType(3, 0), // 5: revert
+		funcType(0, 0), // 6: methods
+	))
+	imports := section(2, vec(
+		importFunc("input_size", 0),
+		importFunc("read_input", 1),
+		importFunc("set_return", 2),
+		importFunc("get_value", 3),
+		importFunc("set_value", 4),
+		importFunc("read_return", 1),
+		importFunc("revert", 5),
+	))
+	funcs := section(3, vec(uleb(6), uleb(6), uleb(6)))
+	memory := section(5, vec([]byte{0x00, 0x01}))
+	exports := section(7, vec(
+		cat(str("memory"), []byte{0x02}, uleb(0)),
+		cat(str("set"), []byte{0x00}, uleb(7)),
+		cat(str("get"), []byte{0x00}, uleb(8)),
+		cat(str("fail"), []byte{0x00}, uleb(9)),
+	))
+	codes := section(10, vec(
+		body(
+			call(0), localSet,
+			i32(100), call(1),
+			i32(0), i32(1), i32(100), localGet, call(4),
+		),
+		body(
+			i32(0), i32(1), call(3), localSet,
+			i32(100), call(5),
+			i32(100), localGet, call(2),
+		),
+		body(i32(3), i32(16), i32(4), call(6)),
+	))
+	data := section(11, vec(
+		cat([]byte{0x00}, i32(0), []byte{0x0b}, str("k")),
+		cat([]byte{0x00}, i32(16), []byte{0x0b}, str("oops")),
+	))
+	custom := section(0, cat(str(wasmee.APISection), []byte(testAPI)))
+	return cat([]byte("\x00asm\x01\x00\x00\x00"),
+		types, imports, funcs, memory, exports, codes, data, custom)
+}
+
+type result struct {
+	status error
+	steps  *big.Int
+	result *codec.TypedObj
+	info   *scoreapi.Info
+}
+
+type testContext struct {
+	store  map[string][]byte
+	result chan result
+}
+
+func (c *testContext) GetValue(key []byte) ([]byte, error) {
+	return c.store[string(key)], nil
+}
+
+func (c *testContext) SetValue(key []byte, value []byte) ([]byte, error) {
+	old := c.store[string(key)]
+	c.store[string(key)] = value
+	return old, nil
+}
+
+func (c *testContext) DeleteValue(key []byte) ([]byte, error) {
+	old := c.store[string(key)]
+	delete(c.store, string(key))
+	return old, nil
+}
+
+func (c *testContext) ArrayDBContains(prefix, value []byte, limit int64) (bool, int, int, error) {
+	return false, 0, 0, nil
+}
+
+func (c *testContext) GetInfo() *codec.TypedObj {
+	return common.MustEncodeAny(map[string]interface{}{
+		"StepCosts": map[string]interface{}{
+			"getBase": 10,
+			"get":     1,
+			"setBase": 100,
+			"set":     2,
+		},
+	})
+}
+
+func (c *testContext) GetBalance(addr module.Address) *big.Int {
+	return new(big.Int)
+}
+
+func (c *testContext) OnEvent(addr module.Address, indexed, data [][]byte) error {
+	return nil
+}
+
+func (c *testContext) OnResult(status error, flag int, steps *big.Int, r *codec.TypedObj) {
+	c.result <- result{status: status, steps: steps, result: r}
+}
+
+func (c *testContext) OnCall(from, to module.Address, value, limit *big.Int, dataType string, dataObj *codec.TypedObj) {
+}
+
+func (c *testContext) OnAPI(status error, info *scoreapi.Info) {
+	c.result <- result{status: status, info: info}
+}
+
+func (c *testContext) OnSetFeeProportion(portion int) {
+}
+
+func (c *testContext) SetCode(code []byte) error {
+	return nil
+}
+
+func (c *testContext) GetObjGraph(bool) (int, []byte, []byte, error) {
+	return 0, nil, nil, nil
+}
+
+func (c *testContext) SetObjGraph(flags bool, nextHash int, objGraph []byte) error {
+	return nil
+}
+
+func (c *testContext) Logger() log.Logger {
+	return log.GlobalLogger()
+}
+
+func TestExecutor_Invoke(t *testing.T) {
+	dir := t.TempDir()
+	code := filepath.Join(dir, "contract")
+	require.NoError(t, os.MkdirAll(code, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(code, wasmee.CodeFile), testContract(), 0644))
+
+	engine, err := eeproxy.NewWasmEE(log.GlobalLogger())
+	require.NoError(t, err)
+	mgr, err := eeproxy.NewManager("unix", filepath.Join(dir, "ee.sock"), log.GlobalLogger(), engine)
+	require.NoError(t, err)
+	defer mgr.Close()
+	go mgr.Loop()
+	require.NoError(t, mgr.SetInstances(1, 1, 1))
+
+	ex := mgr.GetExecutor(eeproxy.ForTransaction)
+	defer ex.Release()
+	proxy := ex.Get(wasmee.EEType)
+	require.NotNil(t, proxy)
+
+	ctx := &testContext{
+		store:  make(map[string][]byte),
+		result: make(chan result, 1),
+	}
+
+	assert.NoError(t, proxy.GetAPI(ctx, code))
+	r := <-ctx.result
+	assert.NoError(t, r.status)
+	if assert.NotNil(t, r.info) {
+		assert.NotNil(t, r.info.GetMethod("get"))
+		assert.NotNil(t, r.info.GetMethod(wasmee.InstallMethod))
+	}
+
+	to := common.MustNewAddressFromString("cx0000000000000000000000000000000000000001")
+	invoke := func(method string, readOnly bool, params *codec.TypedObj) result {
+		err := proxy.Invoke(ctx, code, readOnly, nil, to, new(big.Int),
+			big.NewInt(100000), method, params, nil, 1, nil)
+		require.NoError(t, err)
+		return <-ctx.result
+	}
+
+	r = invoke("set", false, common.MustEncodeAny([]interface{}{"hello"}))
+	assert.NoError(t, r.status)
+	assert.Equal(t, []byte(`["hello"]`), ctx.store["k"])
+	// setBase + set * len(value) and instructions
+	assert.True(t, r.steps.Int64() > 100+2*9, r.steps)
+
+	r = invoke("get", true, nil)
+	assert.NoError(t, r.status)
+	assert.Equal(t, []interface{}{"hello"}, common.MustDecodeAny(r.result))
+
+	r = invoke("set", true, common.MustEncodeAny([]interface{}{"world"}))
+	assert.True(t, scoreresult.AccessDeniedError.Equals(r.status), r.status)
+	assert.Equal(t, []byte(`["hello"]`), ctx.store["k"])
+
+	r = invoke("fail", false, nil)
+	s, _ := scoreresult.StatusOf(r.status)
+	assert.Equal(t, module.StatusReverted+3, s)
+	assert.Equal(t, "oops", r.status.Error())
+
+	r = invoke("unknown", false, nil)
+	assert.True(t, scoreresult.MethodNotFoundError.Equals(r.status), r.status)
+
+	r = invoke(wasmee.InstallMethod, false, nil)
+	assert.NoError(t, r.status)
+}
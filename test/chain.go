@@ -81,6 +81,10 @@ func (c *Chain) ConcurrencyLevel() int {
 	return 1
 }
 
+func (c *Chain) OptimisticExecution() bool {
+	return false
+}
+
 func (c *Chain) NormalTxPoolSize() int {
 	return 5000
 }
@@ -186,7 +190,7 @@ func (c *Chain) Prune(gs string, dbt string, height int64) error {
 	panic("implement me")
 }
 
-func (c *Chain) Backup(file string, extra []string) error {
+func (c *Chain) Backup(file, base string, extra []string) error {
 	panic("implement me")
 }
 
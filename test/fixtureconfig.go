@@ -27,6 +27,7 @@ import (
 	"github.com/icon-project/goloop/common/db"
 	"github.com/icon-project/goloop/consensus"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
 	"github.com/icon-project/goloop/service/platform/basic"
 )
 
@@ -49,6 +50,7 @@ type FixtureConfig struct {
 	Wallet            module.Wallet
 	AddDefaultNode    *bool
 	WAL               func() consensus.WALManager
+	NewEngines        func(ctx *NodeContext) []eeproxy.Engine
 }
 
 func NewFixtureConfig(t T, o ...FixtureOption) *FixtureConfig {
@@ -151,5 +153,8 @@ func (cf *FixtureConfig) Override(cf2 *FixtureConfig) *FixtureConfig {
 	if cf2.WAL != nil {
 		res.WAL = cf2.WAL
 	}
+	if cf2.NewEngines != nil {
+		res.NewEngines = cf2.NewEngines
+	}
 	return &res
 }
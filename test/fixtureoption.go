@@ -22,6 +22,7 @@ import (
 	"github.com/icon-project/goloop/common/db"
 	"github.com/icon-project/goloop/consensus"
 	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/eeproxy"
 )
 
 type FixtureOption func(cf *FixtureConfig) *FixtureConfig
@@ -84,3 +85,13 @@ func SetTimeoutPropose(to time.Duration) FixtureOption {
 func UseSMFactory(f func(ctx *NodeContext) module.ServiceManager) FixtureOption {
 	return UseConfig(&FixtureConfig{NewSM: f})
 }
+
+// UseEngines option makes nodes use the engines instead of external ones.
+// Engines are shared by nodes, so it's for the fixture with single node.
+func UseEngines(engines ...eeproxy.Engine) FixtureOption {
+	return UseConfig(&FixtureConfig{
+		NewEngines: func(ctx *NodeContext) []eeproxy.Engine {
+			return engines
+		},
+	})
+}
@@ -91,15 +91,22 @@ func NewNode(t T, o ...FixtureOption) *Node {
 	ctx.Platform = plt
 	cm, err := plt.NewContractManager(c.Database(), path.Join(base, ContractPath), c.Logger())
 	assert.NoError(t, err)
-	ee, err := eeproxy.AllocEngines(c.Logger(), "python")
-	assert.NoError(t, err)
+	var ee []eeproxy.Engine
+	instances := 0
+	if cf.NewEngines != nil {
+		ee = cf.NewEngines(ctx)
+		instances = 1
+	} else {
+		ee, err = eeproxy.AllocEngines(c.Logger(), "python")
+		assert.NoError(t, err)
+	}
 	em, err := eeproxy.NewManager("unix", path.Join(base, EESocketPath), c.Logger(), ee...)
 	assert.NoError(t, err)
 
 	go func() {
 		_ = em.Loop()
 	}()
-	err = em.SetInstances(0, 0, 0)
+	err = em.SetInstances(instances, instances, instances)
 	assert.NoError(t, err)
 
 	ctx.CM = cm
@@ -49,6 +49,10 @@ func (r *regulatorImpl) SetBlockInterval(i time.Duration, d time.Duration) {
 	// do nothing
 }
 
+func (r *regulatorImpl) IdleTimeout(lastBlockTime time.Time) time.Duration {
+	return 0
+}
+
 func NewRegulator() module.Regulator {
 	return &regulatorImpl{}
 }